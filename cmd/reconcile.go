@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/spf13/cobra"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Check S3, the database and the RAG index for inconsistencies",
+	Long:  `List stored files, documents and indexed documents and report orphans and missing entries. Nothing is changed unless --repair is given`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repair, _ := cmd.Flags().GetBool("repair")
+
+		configs.Init()
+		databases.Init()
+		defer databases.Close()
+
+		ragServerService := services.NewRAGServerService()
+		versionRepo := repositories.NewDocumentVersionRepository()
+		blobRepo := repositories.NewBlobRepository()
+		documentRepo := repositories.NewDocumentRepository()
+		storage := services.NewS3Storage()
+		documentService := services.NewDocumentService(
+			documentRepo,
+			versionRepo,
+			repositories.NewDocumentFolderRepository(),
+			blobRepo,
+			repositories.NewSpaceRepository(),
+			repositories.NewUserRepository(),
+			repositories.NewDocumentTextRepository(),
+			ragServerService,
+			storage,
+			services.NewScanner(),
+		)
+		reconcilerService := services.NewReconcilerService(
+			documentRepo,
+			versionRepo,
+			blobRepo,
+			repositories.NewResumableUploadRepository(),
+			documentService,
+			ragServerService,
+			storage,
+		)
+
+		report, err := reconcilerService.Reconcile(repair)
+		if err != nil {
+			log.Fatalf("Reconciliation failed: %v", err)
+		}
+
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to print report: %v", err)
+		}
+		fmt.Println(string(output))
+
+		log.Printf("Orphaned objects: %d, missing objects: %d, orphaned index entries: %d, missing index entries: %d\n",
+			len(report.OrphanedObjects), len(report.MissingObjects),
+			len(report.OrphanedIndexEntries), len(report.MissingIndexEntries))
+		if !repair {
+			log.Println("Dry run, run again with --repair to fix orphans and missing index entries")
+		}
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().Bool("repair", false, "Delete orphans and re-index documents missing from the RAG server")
+}
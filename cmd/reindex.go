@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Re-index documents into the RAG server",
+	Long:  `Send stored documents to the RAG server again, e.g. after its embedding model changed or its vector store was wiped`,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		spaceID, _ := cmd.Flags().GetUint("space")
+
+		if all == (spaceID != 0) {
+			log.Fatal("Specify either --all or --space")
+		}
+
+		configs.Init()
+		databases.Init()
+		defer databases.Close()
+
+		documentRepo := repositories.NewDocumentRepository()
+		documentService := services.NewDocumentService(
+			documentRepo,
+			repositories.NewDocumentVersionRepository(),
+			repositories.NewDocumentFolderRepository(),
+			repositories.NewBlobRepository(),
+			repositories.NewSpaceRepository(),
+			repositories.NewUserRepository(),
+			repositories.NewDocumentTextRepository(),
+			services.NewRAGServerService(),
+			services.NewS3Storage(),
+			services.NewScanner(),
+		)
+		reindexService := services.NewReindexService(repositories.NewReindexJobRepository(), documentRepo, documentService)
+
+		var scope *uint
+		if spaceID != 0 {
+			scope = &spaceID
+		}
+
+		job, err := reindexService.CreateJob(scope, nil, nil)
+		if err != nil {
+			log.Fatalf("Failed to create reindex job: %v", err)
+		}
+
+		jobID := job.ID
+		log.Printf("Reindex job %d started\n", jobID)
+		job, err = reindexService.RunJob(jobID, func(job *entities.ReindexJob) {
+			log.Printf("Reindexed %d/%d documents (%d failed)\n", job.Processed, job.Total, job.Failed)
+		})
+		if err != nil {
+			log.Fatalf("Reindex job %d failed: %v", jobID, err)
+		}
+
+		log.Printf("Reindex job %d %s: %d documents, %d failed\n", job.ID, job.Status, job.Total, job.Failed)
+	},
+}
+
+func init() {
+	reindexCmd.Flags().Bool("all", false, "Re-index every document")
+	reindexCmd.Flags().Uint("space", 0, "Re-index the documents of a single space")
+}
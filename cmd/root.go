@@ -26,6 +26,10 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	rootCmd.AddCommand(seedCmd)
 	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(reindexCmd)
+	rootCmd.AddCommand(reconcileCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a space into an archive",
+	Long:  `Write the settings, roles, members, folders and documents of a space, and optionally its chat sessions, into a ZIP archive that import reads`,
+	Run: func(cmd *cobra.Command, args []string) {
+		spaceID, _ := cmd.Flags().GetUint("space")
+		output, _ := cmd.Flags().GetString("output")
+		sessions, _ := cmd.Flags().GetBool("sessions")
+
+		if spaceID == 0 {
+			log.Fatal("Specify the space to export with --space")
+		}
+		if output == "" {
+			output = fmt.Sprintf("space-%d.zip", spaceID)
+		}
+
+		configs.Init()
+		databases.Init()
+		defer databases.Close()
+
+		archiveService := newSpaceArchiveService()
+		manifest, err := archiveService.PrepareExport(spaceID, dtos.SpaceExportOptions{
+			IncludeMembers:  true,
+			IncludeSessions: sessions,
+		})
+		if err != nil {
+			log.Fatalf("Failed to export space %d: %v", spaceID, err)
+		}
+
+		file, err := os.Create(output)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", output, err)
+		}
+		defer file.Close()
+
+		if err := archiveService.WriteExport(manifest, file); err != nil {
+			log.Fatalf("Failed to export space %d: %v", spaceID, err)
+		}
+
+		log.Printf("Exported space %d with %d documents to %s\n", spaceID, len(manifest.Documents), output)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a space from an archive",
+	Long:  `Create a space from an archive written by export. Members with an account are added directly and documents are sent to the RAG server again`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("file")
+		ownerID, _ := cmd.Flags().GetUint("owner")
+		sessions, _ := cmd.Flags().GetBool("sessions")
+
+		if path == "" || ownerID == 0 {
+			log.Fatal("Specify the archive with --file and the owner of the space with --owner")
+		}
+
+		configs.Init()
+		databases.Init()
+		defer databases.Close()
+
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", path, err)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", path, err)
+		}
+
+		report, err := newSpaceArchiveService().ImportSpace(file, info.Size(), ownerID, dtos.SpaceImportOptions{
+			IncludeSessions: sessions,
+			Trusted:         true,
+		})
+		if err != nil {
+			log.Fatalf("Failed to import %s: %v", path, err)
+		}
+
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to print report: %v", err)
+		}
+		fmt.Println(string(output))
+
+		log.Printf("Imported space %d: %d members, %d sessions, %d skipped members, %d skipped documents\n",
+			report.Space.ID, report.AddedMembers, report.ImportedSessions,
+			len(report.SkippedMembers), len(report.SkippedDocuments))
+	},
+}
+
+func newSpaceArchiveService() services.SpaceArchiveService {
+	ragServerService := services.NewRAGServerService()
+	storage := services.NewS3Storage()
+	userRepo := repositories.NewUserRepository()
+	spaceRepo := repositories.NewSpaceRepository()
+	documentRepo := repositories.NewDocumentRepository()
+	folderRepo := repositories.NewDocumentFolderRepository()
+	documentService := services.NewDocumentService(
+		documentRepo,
+		repositories.NewDocumentVersionRepository(),
+		folderRepo,
+		repositories.NewBlobRepository(),
+		spaceRepo,
+		userRepo,
+		repositories.NewDocumentTextRepository(),
+		ragServerService,
+		storage,
+		services.NewScanner(),
+	)
+	spaceService := services.NewSpaceService(
+		repositories.NewSpaceInvitationLinkRepository(),
+		ragServerService,
+		userRepo,
+		repositories.NewSpaceInvitationRepository(),
+		documentRepo,
+		documentService,
+		services.NewMailer(),
+	)
+
+	return services.NewSpaceArchiveService(
+		spaceRepo,
+		repositories.NewSpaceRoleRepository(),
+		folderRepo,
+		documentRepo,
+		userRepo,
+		repositories.NewUserQuerySessionRepository(),
+		spaceService,
+		documentService,
+		storage,
+	)
+}
+
+func init() {
+	exportCmd.Flags().Uint("space", 0, "Space to export")
+	exportCmd.Flags().String("output", "", "Path of the archive, space-<id>.zip by default")
+	exportCmd.Flags().Bool("sessions", false, "Include the chat sessions of the space")
+
+	importCmd.Flags().String("file", "", "Path of the archive to import")
+	importCmd.Flags().Uint("owner", 0, "User that owns the imported space")
+	importCmd.Flags().Bool("sessions", false, "Restore the chat sessions of the archive")
+}
@@ -29,7 +29,8 @@ type OAuthConfig struct {
 }
 
 type AWSS3Config struct {
-	Bucket string `yaml:"bucket"`
+	Bucket               string `yaml:"bucket"`
+	PresignExpirySeconds int    `yaml:"presign_expiry_seconds"`
 }
 
 type AWSConfig struct {
@@ -43,17 +44,102 @@ type RAGServerConfig struct {
 	ChatURL           string `yaml:"chat_url"`
 	RemoveDocURL      string `yaml:"remove_doc_url"`
 	RemoveSpaceURL    string `yaml:"remove_space_url"`
+	UpdateDocMetaURL  string `yaml:"update_doc_meta_url"`
+	ListDocumentsURL  string `yaml:"list_documents_url"`
 }
+type BulkUploadConfig struct {
+	MaxArchiveSizeMb    int `yaml:"max_archive_size_mb"`
+	MaxEntries          int `yaml:"max_entries"`
+	MaxTotalSizeMb      int `yaml:"max_total_size_mb"`
+	MaxCompressionRatio int `yaml:"max_compression_ratio"`
+}
+
+type URLIngestConfig struct {
+	TimeoutSeconds      int    `yaml:"timeout_seconds"`
+	MaxRedirects        int    `yaml:"max_redirects"`
+	UserAgent           string `yaml:"user_agent"`
+	RefreshCheckMinutes int    `yaml:"refresh_check_minutes"`
+}
+
+type ResumableUploadConfig struct {
+	ExpiryHours            int `yaml:"expiry_hours"`
+	MaxChunkSizeMb         int `yaml:"max_chunk_size_mb"`
+	CleanupIntervalMinutes int `yaml:"cleanup_interval_minutes"`
+}
+
+type ScannerConfig struct {
+	// Type selects the scanner, "clamd" or empty for none
+	Type string `yaml:"type"`
+	// Address of clamd, e.g. unix:/var/run/clamav/clamd.ctl or tcp:localhost:3310
+	Address        string `yaml:"address"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+}
+
+type UploadValidationConfig struct {
+	RejectEncryptedPDFs  bool          `yaml:"reject_encrypted_pdfs"`
+	RejectMacroDocuments bool          `yaml:"reject_macro_documents"`
+	Scanner              ScannerConfig `yaml:"scanner"`
+}
+
+type TrashConfig struct {
+	RetentionDays        int `yaml:"retention_days"`
+	PurgeIntervalMinutes int `yaml:"purge_interval_minutes"`
+}
+
+type StorageConfig struct {
+	ReconcileIntervalMinutes int `yaml:"reconcile_interval_minutes"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type MailConfig struct {
+	// Type selects the mailer, "smtp", "file" or empty to only log the messages
+	Type string `yaml:"type"`
+	From string `yaml:"from"`
+	// Directory receives one .eml file per message with the file mailer
+	Directory string     `yaml:"directory"`
+	SMTP      SMTPConfig `yaml:"smtp"`
+}
+
+type InvitationConfig struct {
+	ExpiryDays int `yaml:"expiry_days"`
+}
+
+type JoinRequestConfig struct {
+	MaxPending             int `yaml:"max_pending"`
+	MaxPerDay              int `yaml:"max_per_day"`
+	RejectionCooldownHours int `yaml:"rejection_cooldown_hours"`
+}
+
+type SpaceArchiveConfig struct {
+	MaxImportSizeMb int `yaml:"max_import_size_mb"`
+}
+
 type Config struct {
-	Port         int              `yaml:"port"`
-	MasterDBs    []MasterDBConfig `yaml:"master_db"`
-	Redis        RedisConfig      `yaml:"redis"`
-	OAuth        OAuthConfig      `yaml:"oauth"`
-	JwtSecret    string           `yaml:"jwt_secret"`
-	WebClientURL string           `yaml:"web_client_url"`
-	AllowOrigins []string         `yaml:"allow_origins"`
-	AWS          AWSConfig        `yaml:"aws"`
-	RAGServer    RAGServerConfig  `yaml:"rag_server"`
+	Port             int                    `yaml:"port"`
+	MasterDBs        []MasterDBConfig       `yaml:"master_db"`
+	Redis            RedisConfig            `yaml:"redis"`
+	OAuth            OAuthConfig            `yaml:"oauth"`
+	JwtSecret        string                 `yaml:"jwt_secret"`
+	WebClientURL     string                 `yaml:"web_client_url"`
+	AllowOrigins     []string               `yaml:"allow_origins"`
+	AWS              AWSConfig              `yaml:"aws"`
+	RAGServer        RAGServerConfig        `yaml:"rag_server"`
+	BulkUpload       BulkUploadConfig       `yaml:"bulk_upload"`
+	URLIngest        URLIngestConfig        `yaml:"url_ingest"`
+	ResumableUpload  ResumableUploadConfig  `yaml:"resumable_upload"`
+	UploadValidation UploadValidationConfig `yaml:"upload_validation"`
+	Trash            TrashConfig            `yaml:"trash"`
+	Storage          StorageConfig          `yaml:"storage"`
+	Mail             MailConfig             `yaml:"mail"`
+	Invitation       InvitationConfig       `yaml:"invitation"`
+	JoinRequest      JoinRequestConfig      `yaml:"join_request"`
+	SpaceArchive     SpaceArchiveConfig     `yaml:"space_archive"`
 }
 
 var config Config
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	reconcilerService services.ReconcilerService
+}
+
+func NewAdminController(reconcilerService services.ReconcilerService) *AdminController {
+	return &AdminController{
+		reconcilerService: reconcilerService,
+	}
+}
+
+// Reconcile reports inconsistencies between S3, the database and the RAG
+// index. It only repairs them with ?repair=true.
+func (c *AdminController) Reconcile(ctx *gin.Context) {
+	repair := ctx.Query("repair") == "true"
+
+	report, err := c.reconcilerService.Reconcile(repair)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to reconcile storage", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Reconciliation finished", gin.H{"report": report})
+}
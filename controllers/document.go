@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models"
 	"github.com/BlenDMinh/dutgrad-server/models/dtos"
 	"github.com/BlenDMinh/dutgrad-server/services"
 	"github.com/gin-gonic/gin"
@@ -14,35 +18,236 @@ import (
 
 type DocumentController struct {
 	CrudController[entities.Document, uint]
-	service      services.DocumentService
-	spaceService services.SpaceService
+	service             services.DocumentService
+	spaceService        services.SpaceService
+	documentTextService services.DocumentTextService
 }
 
 func NewDocumentController(
 	service services.DocumentService,
 	spaceService services.SpaceService,
+	documentTextService services.DocumentTextService,
 ) *DocumentController {
 	crudController := NewCrudController(service)
 	return &DocumentController{
-		CrudController: *crudController,
-		service:        service,
-		spaceService:   spaceService,
+		CrudController:      *crudController,
+		service:             service,
+		spaceService:        spaceService,
+		documentTextService: documentTextService,
 	}
 }
 
+// Retrieve lists the documents of every space the caller is a member of.
+func (c *DocumentController) Retrieve(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page-size", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	documents, total, err := c.service.GetVisibleDocuments(userID, page, pageSize)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to retrieve documents", err)
+		return
+	}
+
+	pagination := dtos.PaginationResponse{
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalPages:  (total + int64(pageSize) - 1) / int64(pageSize),
+		TotalItems:  total,
+		HasNext:     int64(page*pageSize) < total,
+		HasPrev:     page > 1,
+	}
+
+	HandleSuccess(ctx, "Documents retrieved successfully", gin.H{
+		"data":       documents,
+		"pagination": pagination,
+	})
+}
+
+func (c *DocumentController) RetrieveOne(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	document, err := c.service.GetById(docID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document not found", err)
+		return
+	}
+
+	role, ok := c.readerRole(ctx, userID, document.SpaceID)
+	if !ok {
+		return
+	}
+
+	if !document.IsVisibleTo(role) {
+		HandleError(ctx, http.StatusNotFound, "Document not found", nil)
+		return
+	}
+
+	HandleSuccess(ctx, "Document retrieved successfully", document)
+}
+
+// readerRole returns the role of the caller in the space. Visitors of a public
+// space get a nil role, which grants what viewers can see.
+func (c *DocumentController) readerRole(ctx *gin.Context, userID uint, spaceID uint) (*entities.SpaceRole, bool) {
+	role, err := c.spaceService.GetUserRole(userID, spaceID)
+	if err == nil {
+		return role, true
+	}
+
+	space, spaceErr := c.spaceService.GetById(spaceID)
+	if spaceErr != nil {
+		HandleError(ctx, http.StatusNotFound, "Space not found", spaceErr)
+		return nil, false
+	}
+
+	if space.PrivacyStatus {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return nil, false
+	}
+
+	return nil, true
+}
+
+// authorizeDocumentReader loads the document of the id parameter for a reader
+// of its space, following the rules of readerRole. Documents hidden from the
+// reader are reported as not found.
+func (c *DocumentController) authorizeDocumentReader(ctx *gin.Context) (*entities.Document, bool) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return nil, false
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return nil, false
+	}
+
+	document, err := c.service.GetById(docID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document not found", err)
+		return nil, false
+	}
+
+	role, ok := c.readerRole(ctx, userID, document.SpaceID)
+	if !ok {
+		return nil, false
+	}
+
+	if !document.IsVisibleTo(role) {
+		HandleError(ctx, http.StatusNotFound, "Document not found", nil)
+		return nil, false
+	}
+
+	return document, true
+}
+
 func (c *DocumentController) GetBySpaceID(ctx *gin.Context) {
 	spaceID, ok := ExtractID(ctx, "id")
 	if !ok {
 		return
 	}
+	role := GetSpaceRole(ctx)
+
+	var query dtos.DocumentListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
 
-	documents, err := c.service.GetDocumentsBySpaceID(spaceID)
+	params := helpers.GetPaginationParams(ctx, repositories.DefaultPageSize)
+	result, err := c.service.ListDocuments(spaceID, role, query, params.Page, params.PageSize)
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to retrieve documents", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid folder_id") ||
+			strings.Contains(err.Error(), "folder not found") ||
+			strings.Contains(err.Error(), "invalid tag") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to retrieve documents", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Documents retrieved successfully", gin.H{
+		"documents":  result.Data,
+		"pagination": paginationMeta(result),
+	})
+}
+
+func paginationMeta(result *helpers.PaginationResult) gin.H {
+	return gin.H{
+		"current_page": result.Page,
+		"page_size":    result.PageSize,
+		"total_pages":  result.TotalPages,
+		"total_items":  result.TotalItems,
+		"has_next":     result.HasNext,
+		"has_prev":     result.HasPrev,
+	}
+}
+
+// SearchText finds the documents of a space whose extracted text contains
+// every word of the q parameter.
+func (c *DocumentController) SearchText(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+	role := GetSpaceRole(ctx)
+
+	params := helpers.GetPaginationParams(ctx, repositories.DefaultPageSize)
+	result, err := c.documentTextService.SearchSpace(spaceID, role, ctx.Query("q"), params.Page, params.PageSize)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid search query") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to search documents", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Documents searched successfully", gin.H{
+		"results":    result.Data,
+		"pagination": paginationMeta(result),
+	})
+}
+
+// GetDocumentText pages through the extracted text of a document, with the
+// words of the optional q parameter highlighted.
+func (c *DocumentController) GetDocumentText(ctx *gin.Context) {
+	document, ok := c.authorizeDocumentReader(ctx)
+	if !ok {
+		return
+	}
+
+	params := helpers.GetPaginationParams(ctx, repositories.DefaultPageSize)
+	result, err := c.documentTextService.GetDocumentText(document.ID, ctx.Query("q"), params.Page, params.PageSize)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to get document text", err)
 		return
 	}
 
-	HandleSuccess(ctx, "Documents retrieved successfully", gin.H{"documents": documents})
+	HandleSuccess(ctx, "Document text retrieved successfully", gin.H{
+		"document_id": document.ID,
+		"chunks":      result.Data,
+		"pagination":  paginationMeta(result),
+	})
 }
 
 func (c *DocumentController) UploadDocument(ctx *gin.Context) {
@@ -60,13 +265,19 @@ func (c *DocumentController) UploadDocument(ctx *gin.Context) {
 	}
 	req.SpaceID = uint(spaceID)
 	req.Description = ctx.Request.FormValue("description")
+	options, err := parseUploadOptions(ctx, req.Description)
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Invalid folder_id", err)
+		return
+	}
+
 	role, err := c.spaceService.GetUserRole(userID, req.SpaceID)
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to get user role", err)
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
 		return
 	}
 
-	if !role.IsOwner() && !role.IsEditor() {
+	if !role.HasPermission(entities.SpacePermissionUploadDocuments) {
 		HandleError(ctx, http.StatusForbidden, "You are not allowed to import documents to this space", nil)
 		return
 	}
@@ -78,13 +289,22 @@ func (c *DocumentController) UploadDocument(ctx *gin.Context) {
 	}
 	mimeType := ctx.Request.Header.Get("Mime-Type")
 
-	document, err := c.service.UploadDocument(file, req.SpaceID, mimeType, req.Description)
+	document, err := c.service.UploadDocument(file, req.SpaceID, userID, mimeType, options)
 	if err != nil {
+		if handleDuplicateDocument(ctx, err) || handleRejectedUpload(ctx, err) {
+			return
+		}
+
 		statusCode := http.StatusInternalServerError
 
 		if strings.Contains(err.Error(), "document limit reached") ||
-			strings.Contains(err.Error(), "file size exceeds the limit") {
+			strings.Contains(err.Error(), "file size exceeds the limit") ||
+			strings.Contains(err.Error(), "storage quota exceeded") {
 			statusCode = http.StatusTooManyRequests
+		} else if strings.Contains(err.Error(), "folder not found") ||
+			strings.Contains(err.Error(), "invalid tag") ||
+			strings.Contains(err.Error(), "invalid visibility") {
+			statusCode = http.StatusBadRequest
 		}
 
 		HandleError(ctx, statusCode, "Failed to upload document", err)
@@ -94,24 +314,83 @@ func (c *DocumentController) UploadDocument(ctx *gin.Context) {
 	HandleSuccess(ctx, "Document uploaded successfully", gin.H{"document": document})
 }
 
-func (c *DocumentController) GetUserDocumentCount(ctx *gin.Context) {
+func (c *DocumentController) UploadZipArchive(ctx *gin.Context) {
 	userID, ok := ExtractID(ctx, "user_id")
 	if !ok {
 		return
 	}
 
-	count, err := c.service.CountUserDocuments(userID)
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	file, err := ctx.FormFile("file")
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to count documents", err)
+		HandleError(ctx, http.StatusBadRequest, "Failed to get file", err)
 		return
 	}
 
-	ctx.Header("X-Document-Count", fmt.Sprintf("%d", count))
+	options, err := parseUploadOptions(ctx, ctx.Request.FormValue("description"))
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Invalid folder_id", err)
+		return
+	}
 
-	HandleSuccess(ctx, "Document count retrieved successfully", gin.H{"count": count})
+	report, err := c.service.UploadZipArchive(file, spaceID, userID, options)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+
+		if strings.Contains(err.Error(), "invalid zip archive") ||
+			strings.Contains(err.Error(), "zip archive contains") ||
+			strings.Contains(err.Error(), "archive size exceeds the limit") {
+			statusCode = http.StatusBadRequest
+		}
+
+		HandleError(ctx, statusCode, "Failed to import zip archive", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Zip archive processed", report)
 }
 
-func (c *DocumentController) DeleteDocument(ctx *gin.Context) {
+func (c *DocumentController) UploadFromURL(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.DocumentFromURLRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	options := dtos.DocumentUploadOptions{
+		Description: req.Description,
+		FolderID:    req.FolderID,
+		Tags:        req.Tags,
+		Replace:     req.Replace || ctx.Query("replace") == "true",
+		Visibility:  entities.DocumentVisibility(req.Visibility),
+	}
+
+	document, err := c.service.IngestFromURL(req.URL, spaceID, userID, options, req.RefreshIntervalHours)
+	if err != nil {
+		if handleDuplicateDocument(ctx, err) || handleRejectedUpload(ctx, err) {
+			return
+		}
+		HandleError(ctx, urlIngestStatusCode(err), "Failed to import document from URL", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document imported successfully", gin.H{"document": document})
+}
+
+func (c *DocumentController) RefetchDocument(ctx *gin.Context) {
 	userID, ok := ExtractID(ctx, "user_id")
 	if !ok {
 		return
@@ -127,22 +406,685 @@ func (c *DocumentController) DeleteDocument(ctx *gin.Context) {
 		HandleError(ctx, http.StatusNotFound, "Document not found", err)
 		return
 	}
+
 	role, err := c.spaceService.GetUserRole(userID, document.SpaceID)
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to get user role", err)
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
 		return
 	}
 
-	if !role.IsOwner() && !role.IsEditor() {
-		HandleError(ctx, http.StatusForbidden, "You are not allowed to delete this document", nil)
+	if !role.HasPermission(entities.SpacePermissionUploadDocuments) {
+		HandleError(ctx, http.StatusForbidden, "You are not allowed to update this document", nil)
 		return
 	}
 
-	err = c.service.DeleteDocument(docID)
+	document, err = c.service.RefetchDocument(docID, &userID)
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to delete document", err)
+		if handleDuplicateDocument(ctx, err) {
+			return
+		}
+		HandleError(ctx, urlIngestStatusCode(err), "Failed to refetch document", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document refetched successfully", gin.H{"document": document})
+}
+
+func urlIngestStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case errors.Is(err, helpers.ErrDestinationNotAllowed),
+		strings.Contains(message, "invalid URL"),
+		strings.Contains(message, "unsupported URL scheme"),
+		strings.Contains(message, "invalid refresh interval"),
+		strings.Contains(message, "folder not found"),
+		strings.Contains(message, "invalid tag"),
+		strings.Contains(message, "invalid visibility"),
+		strings.Contains(message, "not imported from a URL"):
+		return http.StatusBadRequest
+	case strings.Contains(message, "document limit reached"),
+		strings.Contains(message, "file size exceeds the limit"),
+		strings.Contains(message, "storage quota exceeded"),
+		strings.Contains(message, "remote content exceeds the limit"):
+		return http.StatusTooManyRequests
+	case strings.Contains(message, "failed to fetch URL"),
+		strings.Contains(message, "failed to read remote content"):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func (c *DocumentController) GetTagsBySpaceID(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	tags, err := c.service.GetTagsBySpaceID(spaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to retrieve tags", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Tags retrieved successfully", gin.H{"tags": tags})
+}
+
+func (c *DocumentController) MoveDocument(ctx *gin.Context) {
+	docID, ok := c.authorizeDocumentEditor(ctx)
+	if !ok {
+		return
+	}
+
+	var req dtos.MoveDocumentRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	document, err := c.service.MoveDocumentToFolder(docID, req.FolderID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "folder not found") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to move document", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document moved successfully", gin.H{"document": document})
+}
+
+// Moving a document takes it out of its space, so the caller also needs to be
+// allowed to delete documents there.
+const (
+	moveSourcePermission = entities.SpacePermissionUploadDocuments | entities.SpacePermissionDeleteDocuments
+	copySourcePermission = entities.SpacePermissionUploadDocuments
+)
+
+func (c *DocumentController) MoveDocumentToSpace(ctx *gin.Context) {
+	c.transferDocument(ctx, "moved", moveSourcePermission, c.moveToSpace)
+}
+
+func (c *DocumentController) CopyDocumentToSpace(ctx *gin.Context) {
+	c.transferDocument(ctx, "copied", copySourcePermission, c.service.CopyDocumentToSpace)
+}
+
+func (c *DocumentController) MoveDocuments(ctx *gin.Context) {
+	c.transferDocuments(ctx, "moved", moveSourcePermission, c.moveToSpace)
+}
+
+func (c *DocumentController) CopyDocuments(ctx *gin.Context) {
+	c.transferDocuments(ctx, "copied", copySourcePermission, c.service.CopyDocumentToSpace)
+}
+
+type documentTransfer func(documentID uint, targetSpaceID uint, folderID *uint, userID uint) (*entities.Document, error)
+
+func (c *DocumentController) moveToSpace(documentID uint, targetSpaceID uint, folderID *uint, userID uint) (*entities.Document, error) {
+	return c.service.MoveDocumentToSpace(documentID, targetSpaceID, folderID)
+}
+
+func (c *DocumentController) transferDocument(ctx *gin.Context, action string, sourcePermission entities.SpacePermission, transfer documentTransfer) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.TransferDocumentRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	if !c.authorizeTransferTarget(ctx, userID, req.TargetSpaceID) {
+		return
+	}
+
+	document, statusCode, err := c.transferOne(userID, docID, req.TargetSpaceID, req.FolderID, sourcePermission, transfer)
+	if err != nil {
+		if handleDuplicateDocument(ctx, err) || handleRejectedUpload(ctx, err) {
+			return
+		}
+		HandleError(ctx, statusCode, fmt.Sprintf("Document could not be %s", action), err)
+		return
+	}
+
+	HandleSuccess(ctx, fmt.Sprintf("Document %s successfully", action), gin.H{"document": document})
+}
+
+// transferDocuments handles the batch form. Every document is processed on its
+// own and the report lists which ones failed and why.
+func (c *DocumentController) transferDocuments(ctx *gin.Context, action string, sourcePermission entities.SpacePermission, transfer documentTransfer) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	var req dtos.BatchTransferDocumentsRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	if !c.authorizeTransferTarget(ctx, userID, req.TargetSpaceID) {
 		return
 	}
 
-	HandleSuccess(ctx, "Document deleted successfully", nil)
+	report := dtos.DocumentTransferReport{
+		Accepted: []dtos.DocumentTransferAcceptedEntry{},
+		Rejected: []dtos.DocumentTransferRejectedEntry{},
+	}
+	for _, docID := range req.DocumentIDs {
+		document, _, err := c.transferOne(userID, docID, req.TargetSpaceID, req.FolderID, sourcePermission, transfer)
+		if err != nil {
+			report.Rejected = append(report.Rejected, dtos.DocumentTransferRejectedEntry{DocumentID: docID, Reason: err.Error()})
+			continue
+		}
+		report.Accepted = append(report.Accepted, dtos.DocumentTransferAcceptedEntry{DocumentID: docID, Document: document})
+	}
+
+	HandleSuccess(ctx, fmt.Sprintf("Documents %s", action), report)
+}
+
+// authorizeTransferTarget requires the caller to be allowed to upload documents
+// to the space documents are moved or copied into.
+func (c *DocumentController) authorizeTransferTarget(ctx *gin.Context, userID uint, targetSpaceID uint) bool {
+	role, err := c.spaceService.GetUserRole(userID, targetSpaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return false
+	}
+
+	if !role.HasPermission(entities.SpacePermissionUploadDocuments) {
+		HandleError(ctx, http.StatusForbidden, "You are not allowed to add documents to the target space", nil)
+		return false
+	}
+	return true
+}
+
+// transferOne checks that the caller's role in the source space of the
+// document grants sourcePermission and returns the status code that matches a
+// failure.
+func (c *DocumentController) transferOne(userID uint, docID uint, targetSpaceID uint, folderID *uint, sourcePermission entities.SpacePermission, transfer documentTransfer) (*entities.Document, int, error) {
+	document, err := c.service.GetById(docID)
+	if err != nil {
+		return nil, http.StatusNotFound, errors.New("document not found")
+	}
+
+	role, err := c.spaceService.GetUserRole(userID, document.SpaceID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to get user role: %v", err)
+	}
+
+	if !role.HasPermission(sourcePermission) {
+		if !document.IsVisibleTo(role) {
+			return nil, http.StatusNotFound, errors.New("document not found")
+		}
+		return nil, http.StatusForbidden, errors.New("you are not allowed to transfer this document")
+	}
+
+	document, err = transfer(docID, targetSpaceID, folderID, userID)
+	if err != nil {
+		return nil, transferStatusCode(err), err
+	}
+	return document, http.StatusOK, nil
+}
+
+func transferStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "invalid target space"),
+		strings.Contains(message, "folder not found"):
+		return http.StatusBadRequest
+	case strings.Contains(message, "document limit reached"),
+		strings.Contains(message, "file size exceeds the limit"),
+		strings.Contains(message, "storage quota exceeded"):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+func (c *DocumentController) UpdateTags(ctx *gin.Context) {
+	docID, ok := c.authorizeDocumentEditor(ctx)
+	if !ok {
+		return
+	}
+
+	var req dtos.UpdateDocumentTagsRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	document, err := c.service.SetDocumentTags(docID, req.Tags)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid tag") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to update document tags", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document tags updated successfully", gin.H{"document": document})
+}
+
+func (c *DocumentController) UpdateVisibility(ctx *gin.Context) {
+	docID, ok := c.authorizeDocumentEditor(ctx)
+	if !ok {
+		return
+	}
+
+	var req dtos.UpdateDocumentVisibilityRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	document, err := c.service.SetDocumentVisibility(docID, entities.DocumentVisibility(req.Visibility))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid visibility") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to update document visibility", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document visibility updated successfully", gin.H{"document": document})
+}
+
+// Update and Patch only edit the name and the description, restricted to
+// members allowed to upload documents to the space of the document.
+func (c *DocumentController) Update(ctx *gin.Context) {
+	docID, ok := c.authorizeDocumentEditor(ctx)
+	if !ok {
+		return
+	}
+
+	var req dtos.UpdateDocumentRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	document, err := c.service.UpdateByID(docID, &entities.Document{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to update document", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document updated successfully", document)
+}
+
+func (c *DocumentController) Patch(ctx *gin.Context) {
+	docID, ok := c.authorizeDocumentEditor(ctx)
+	if !ok {
+		return
+	}
+
+	var req dtos.PatchDocumentRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	document, err := c.service.PatchByID(docID, &entities.Document{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to patch document", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document patched successfully", document)
+}
+
+func (c *DocumentController) authorizeDocumentEditor(ctx *gin.Context) (uint, bool) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return 0, false
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return 0, false
+	}
+
+	document, err := c.service.GetById(docID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document not found", err)
+		return 0, false
+	}
+
+	role, err := c.spaceService.GetUserRole(userID, document.SpaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return 0, false
+	}
+
+	if !role.HasPermission(entities.SpacePermissionUploadDocuments) {
+		HandleError(ctx, http.StatusForbidden, "You are not allowed to update this document", nil)
+		return 0, false
+	}
+
+	return docID, true
+}
+
+func (c *DocumentController) GetUserDocumentCount(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	count, err := c.service.CountUserDocuments(userID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to count documents", err)
+		return
+	}
+
+	ctx.Header("X-Document-Count", fmt.Sprintf("%d", count))
+
+	HandleSuccess(ctx, "Document count retrieved successfully", gin.H{"count": count})
+}
+
+func (c *DocumentController) DeleteDocument(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	document, err := c.service.GetById(docID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document not found", err)
+		return
+	}
+	role, err := c.spaceService.GetUserRole(userID, document.SpaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return
+	}
+
+	if !role.HasPermission(entities.SpacePermissionDeleteDocuments) {
+		HandleError(ctx, http.StatusForbidden, "You are not allowed to delete this document", nil)
+		return
+	}
+
+	err = c.service.DeleteDocument(docID, &userID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to delete document", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document moved to trash", nil)
+}
+
+func (c *DocumentController) DownloadDocument(ctx *gin.Context) {
+	c.serveDocument(ctx, false)
+}
+
+func (c *DocumentController) PreviewDocument(ctx *gin.Context) {
+	c.serveDocument(ctx, true)
+}
+
+func (c *DocumentController) serveDocument(ctx *gin.Context, inline bool) {
+	document, ok := c.authorizeDocumentReader(ctx)
+	if !ok {
+		return
+	}
+
+	c.sendDocument(ctx, document, inline)
+}
+
+func (c *DocumentController) sendDocument(ctx *gin.Context, document *entities.Document, inline bool) {
+	if ctx.Query("mode") != "stream" {
+		url, err := c.service.GetPresignedURL(document, inline)
+		if err != nil {
+			HandleError(ctx, http.StatusInternalServerError, "Failed to generate document URL", err)
+			return
+		}
+
+		ctx.Header("Cache-Control", "no-store")
+		ctx.Redirect(http.StatusTemporaryRedirect, url)
+		return
+	}
+
+	content, err := c.service.GetDocumentContent(document)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to read document", err)
+		return
+	}
+	defer content.Close()
+
+	contentLength := document.Size
+	if contentLength <= 0 {
+		contentLength = -1
+	}
+
+	// The sandbox keeps a previewed file from running scripts with the origin
+	// of the API
+	ctx.DataFromReader(http.StatusOK, contentLength, services.GetDocumentContentType(document), content, map[string]string{
+		"Content-Disposition":     services.GetDocumentContentDisposition(document, inline),
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "sandbox",
+		"Cache-Control":           "private, no-store",
+	})
+}
+
+func (c *DocumentController) ReplaceDocumentFile(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	document, err := c.service.GetById(docID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document not found", err)
+		return
+	}
+
+	role, err := c.spaceService.GetUserRole(userID, document.SpaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return
+	}
+
+	if !role.HasPermission(entities.SpacePermissionUploadDocuments) {
+		HandleError(ctx, http.StatusForbidden, "You are not allowed to update this document", nil)
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Failed to get file", err)
+		return
+	}
+	mimeType := ctx.Request.Header.Get("Mime-Type")
+
+	document, err = c.service.ReplaceDocumentFile(docID, helpers.NewUploadFileFromHeader(file), userID, mimeType)
+	if err != nil {
+		if handleDuplicateDocument(ctx, err) || handleRejectedUpload(ctx, err) {
+			return
+		}
+
+		statusCode := http.StatusInternalServerError
+
+		if strings.Contains(err.Error(), "file size exceeds the limit") ||
+			strings.Contains(err.Error(), "storage quota exceeded") {
+			statusCode = http.StatusTooManyRequests
+		}
+
+		HandleError(ctx, statusCode, "Failed to upload new document version", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document version uploaded successfully", gin.H{"document": document})
+}
+
+func (c *DocumentController) GetDocumentVersions(ctx *gin.Context) {
+	document, ok := c.authorizeDocumentReader(ctx)
+	if !ok {
+		return
+	}
+
+	versions, err := c.service.GetDocumentVersions(document.ID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to retrieve document versions", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document versions retrieved successfully", gin.H{
+		"current_version": document.CurrentVersion,
+		"versions":        versions,
+	})
+}
+
+func (c *DocumentController) DownloadDocumentVersion(ctx *gin.Context) {
+	document, ok := c.authorizeDocumentReader(ctx)
+	if !ok {
+		return
+	}
+
+	versionID, ok := ExtractID(ctx, "versionId")
+	if !ok {
+		return
+	}
+
+	version, err := c.service.GetDocumentVersion(document.ID, versionID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document version not found", err)
+		return
+	}
+
+	versionDocument := *document
+	versionDocument.Name = version.Name
+	versionDocument.MimeType = version.MimeType
+	versionDocument.Size = version.Size
+	versionDocument.S3URL = version.S3URL
+
+	c.sendDocument(ctx, &versionDocument, false)
+}
+
+func (c *DocumentController) RollbackDocumentVersion(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	versionID, ok := ExtractID(ctx, "versionId")
+	if !ok {
+		return
+	}
+
+	document, err := c.service.GetById(docID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document not found", err)
+		return
+	}
+
+	role, err := c.spaceService.GetUserRole(userID, document.SpaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return
+	}
+
+	if !role.HasPermission(entities.SpacePermissionUploadDocuments) {
+		HandleError(ctx, http.StatusForbidden, "You are not allowed to update this document", nil)
+		return
+	}
+
+	document, err = c.service.RollbackDocument(docID, versionID, userID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+
+		if strings.Contains(err.Error(), "version not found") {
+			statusCode = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "already the current version") {
+			statusCode = http.StatusConflict
+		} else if strings.Contains(err.Error(), "storage quota exceeded") {
+			statusCode = http.StatusTooManyRequests
+		}
+
+		HandleError(ctx, statusCode, "Failed to roll back document", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document rolled back successfully", gin.H{"document": document})
+}
+
+// parseUploadOptions reads the optional folder_id, tags and visibility form
+// fields and the replace query parameter. Tags may be sent as repeated fields or as a single
+// comma separated value.
+func parseUploadOptions(ctx *gin.Context, description string) (dtos.DocumentUploadOptions, error) {
+	options := dtos.DocumentUploadOptions{
+		Description: description,
+		Replace:     ctx.Query("replace") == "true",
+		Visibility:  entities.DocumentVisibility(ctx.Request.FormValue("visibility")),
+	}
+
+	if folderIDStr := ctx.Request.FormValue("folder_id"); folderIDStr != "" {
+		folderID, err := strconv.ParseUint(folderIDStr, 10, 32)
+		if err != nil {
+			return options, err
+		}
+		id := uint(folderID)
+		options.FolderID = &id
+	}
+
+	for _, value := range ctx.PostFormArray("tags") {
+		options.Tags = append(options.Tags, strings.Split(value, ",")...)
+	}
+
+	return options, nil
+}
+
+// handleRejectedUpload answers with the reasons a file failed validation.
+func handleRejectedUpload(ctx *gin.Context, err error) bool {
+	var rejected *services.UploadRejectedError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+
+	errMsg := err.Error()
+	ctx.JSON(http.StatusUnprocessableEntity, models.ResponseWrapper{
+		Status:  http.StatusUnprocessableEntity,
+		Message: "Upload rejected",
+		Error:   &errMsg,
+		Data:    gin.H{"reasons": rejected.Reasons},
+	})
+	return true
+}
+
+// handleDuplicateDocument answers with 409 and the id of the document holding
+// the same content, so clients can link to it or retry with replace=true.
+func handleDuplicateDocument(ctx *gin.Context, err error) bool {
+	var duplicate *services.DuplicateDocumentError
+	if !errors.As(err, &duplicate) {
+		return false
+	}
+
+	errMsg := err.Error()
+	ctx.JSON(http.StatusConflict, models.ResponseWrapper{
+		Status:  http.StatusConflict,
+		Message: "Document already exists",
+		Error:   &errMsg,
+		Data:    gin.H{"existing_document_id": duplicate.DocumentID},
+	})
+	return true
 }
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentFolderController struct {
+	CrudController[entities.DocumentFolder, uint]
+	service services.DocumentFolderService
+}
+
+func NewDocumentFolderController(
+	service services.DocumentFolderService,
+) *DocumentFolderController {
+	crudController := NewCrudController(service)
+	return &DocumentFolderController{
+		CrudController: *crudController,
+		service:        service,
+	}
+}
+
+func (c *DocumentFolderController) GetBySpaceID(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	folders, err := c.service.GetFoldersBySpaceID(spaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to retrieve folders", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Folders retrieved successfully", gin.H{"folders": folders})
+}
+
+func (c *DocumentFolderController) CreateFolder(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.CreateFolderRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	folder, err := c.service.CreateFolder(spaceID, req.ParentID, req.Name)
+	if err != nil {
+		HandleError(ctx, folderStatusCode(err), "Failed to create folder", err)
+		return
+	}
+
+	HandleCreated(ctx, "Folder created successfully", gin.H{"folder": folder})
+}
+
+func (c *DocumentFolderController) RenameFolder(ctx *gin.Context) {
+	folderID, ok := c.authorizeFolder(ctx)
+	if !ok {
+		return
+	}
+
+	var req dtos.RenameFolderRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	folder, err := c.service.RenameFolder(folderID, req.Name)
+	if err != nil {
+		HandleError(ctx, folderStatusCode(err), "Failed to rename folder", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Folder renamed successfully", gin.H{"folder": folder})
+}
+
+func (c *DocumentFolderController) MoveFolder(ctx *gin.Context) {
+	folderID, ok := c.authorizeFolder(ctx)
+	if !ok {
+		return
+	}
+
+	var req dtos.MoveFolderRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	folder, err := c.service.MoveFolder(folderID, req.ParentID)
+	if err != nil {
+		HandleError(ctx, folderStatusCode(err), "Failed to move folder", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Folder moved successfully", gin.H{"folder": folder})
+}
+
+func (c *DocumentFolderController) DeleteFolder(ctx *gin.Context) {
+	folderID, ok := c.authorizeFolder(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	recursive := ctx.Query("recursive") == "true"
+	if err := c.service.DeleteFolder(folderID, recursive, userID); err != nil {
+		HandleError(ctx, folderStatusCode(err), "Failed to delete folder", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Folder deleted successfully", nil)
+}
+
+// authorizeFolder returns the folderId parameter when the folder belongs to
+// the space of the request. The role is checked by the space middleware.
+func (c *DocumentFolderController) authorizeFolder(ctx *gin.Context) (uint, bool) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return 0, false
+	}
+
+	folderID, ok := ExtractID(ctx, "folderId")
+	if !ok {
+		return 0, false
+	}
+
+	folder, err := c.service.GetById(folderID)
+	if err != nil || folder.SpaceID != spaceID {
+		HandleError(ctx, http.StatusNotFound, "Folder not found", err)
+		return 0, false
+	}
+
+	return folderID, true
+}
+
+func folderStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "already exists"),
+		strings.Contains(message, "folder is not empty"):
+		return http.StatusConflict
+	case strings.Contains(message, "invalid folder name"),
+		strings.Contains(message, "invalid parent"),
+		strings.Contains(message, "folder not found"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/models"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ReindexController struct {
+	CrudController[entities.ReindexJob, uint]
+	service         services.ReindexService
+	documentService services.DocumentService
+	spaceService    services.SpaceService
+}
+
+func NewReindexController(
+	service services.ReindexService,
+	documentService services.DocumentService,
+	spaceService services.SpaceService,
+) *ReindexController {
+	crudController := NewCrudController(service)
+	return &ReindexController{
+		CrudController:  *crudController,
+		service:         service,
+		documentService: documentService,
+		spaceService:    spaceService,
+	}
+}
+
+func (c *ReindexController) ReindexSpace(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	job, err := c.service.StartJob(&spaceID, nil, &userID)
+	if err != nil {
+		HandleError(ctx, reindexStatusCode(err), "Failed to start reindex", err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.NewSuccessResponse(
+		http.StatusAccepted,
+		"Reindex started",
+		gin.H{"job": job},
+	))
+}
+
+func (c *ReindexController) ReindexDocument(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	document, err := c.documentService.GetById(docID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Document not found", err)
+		return
+	}
+
+	role, err := c.spaceService.GetUserRole(userID, document.SpaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return
+	}
+
+	if !role.HasPermission(entities.SpacePermissionUploadDocuments) {
+		HandleError(ctx, http.StatusForbidden, "You are not allowed to reindex this document", nil)
+		return
+	}
+
+	job, err := c.service.StartJob(&document.SpaceID, &document.ID, &userID)
+	if err != nil {
+		HandleError(ctx, reindexStatusCode(err), "Failed to start reindex", err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.NewSuccessResponse(
+		http.StatusAccepted,
+		"Reindex started",
+		gin.H{"job": job},
+	))
+}
+
+func (c *ReindexController) GetJob(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	jobID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	job, err := c.service.GetById(jobID)
+	if err != nil || job.SpaceID == nil {
+		HandleError(ctx, http.StatusNotFound, "Reindex job not found", err)
+		return
+	}
+
+	if _, err := c.spaceService.GetUserRole(userID, *job.SpaceID); err != nil {
+		HandleError(ctx, http.StatusNotFound, "Reindex job not found", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Reindex job retrieved successfully", gin.H{"job": job})
+}
+
+func reindexStatusCode(err error) int {
+	if strings.Contains(err.Error(), "reindex already in progress") {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ResumableUploadController implements the core tus protocol with the
+// creation, expiration and termination extensions.
+type ResumableUploadController struct {
+	CrudController[entities.ResumableUpload, uint]
+	service services.ResumableUploadService
+}
+
+func NewResumableUploadController(
+	service services.ResumableUploadService,
+) *ResumableUploadController {
+	crudController := NewCrudController(service)
+	return &ResumableUploadController{
+		CrudController: *crudController,
+		service:        service,
+	}
+}
+
+func (c *ResumableUploadController) Options(ctx *gin.Context) {
+	ctx.Header("Tus-Resumable", helpers.TusVersion)
+	ctx.Header("Tus-Version", helpers.TusVersion)
+	ctx.Header("Tus-Extension", helpers.TusExtensions)
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *ResumableUploadController) CreateUpload(ctx *gin.Context) {
+	if !checkTusResumable(ctx) {
+		return
+	}
+
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	length, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Invalid Upload-Length header", err)
+		return
+	}
+
+	metadata, err := helpers.ParseTusMetadata(ctx.GetHeader("Upload-Metadata"))
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Invalid Upload-Metadata header", err)
+		return
+	}
+
+	options, err := parseTusUploadOptions(metadata)
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Invalid folder_id", err)
+		return
+	}
+
+	upload, err := c.service.CreateUpload(spaceID, userID, length, metadata["filename"], metadata["filetype"], options)
+	if err != nil {
+		HandleError(ctx, uploadStatusCode(err), "Failed to create upload", err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/uploads/%d", upload.ID))
+	setUploadHeaders(ctx, upload)
+	HandleCreated(ctx, "Upload created successfully", gin.H{"upload": upload})
+}
+
+func (c *ResumableUploadController) GetUploadOffset(ctx *gin.Context) {
+	if !checkTusResumable(ctx) {
+		return
+	}
+
+	upload, ok := c.authorizeUpload(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	setUploadHeaders(ctx, upload)
+	ctx.Status(http.StatusOK)
+}
+
+func (c *ResumableUploadController) PatchUpload(ctx *gin.Context) {
+	if !checkTusResumable(ctx) {
+		return
+	}
+
+	upload, ok := c.authorizeUpload(ctx)
+	if !ok {
+		return
+	}
+
+	if ctx.ContentType() != "application/offset+octet-stream" {
+		HandleError(ctx, http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream", nil)
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Invalid Upload-Offset header", err)
+		return
+	}
+
+	upload, document, err := c.service.WriteChunk(upload.ID, offset, ctx.Request.Body)
+	if err != nil {
+		if upload != nil {
+			setUploadHeaders(ctx, upload)
+		}
+		if handleDuplicateDocument(ctx, err) || handleRejectedUpload(ctx, err) {
+			return
+		}
+		HandleError(ctx, uploadStatusCode(err), "Failed to write upload chunk", err)
+		return
+	}
+
+	if document != nil {
+		upload.DocumentID = &document.ID
+	}
+	setUploadHeaders(ctx, upload)
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *ResumableUploadController) DeleteUpload(ctx *gin.Context) {
+	if !checkTusResumable(ctx) {
+		return
+	}
+
+	upload, ok := c.authorizeUpload(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.TerminateUpload(upload.ID); err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to delete upload", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// authorizeUpload only lets the user who created an upload see or continue it.
+func (c *ResumableUploadController) authorizeUpload(ctx *gin.Context) (*entities.ResumableUpload, bool) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return nil, false
+	}
+
+	uploadID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return nil, false
+	}
+
+	upload, err := c.service.GetById(uploadID)
+	if err != nil || upload.UserID != userID {
+		HandleError(ctx, http.StatusNotFound, "Upload not found", err)
+		return nil, false
+	}
+
+	return upload, true
+}
+
+func checkTusResumable(ctx *gin.Context) bool {
+	ctx.Header("Tus-Resumable", helpers.TusVersion)
+	if version := ctx.GetHeader("Tus-Resumable"); version != "" && version != helpers.TusVersion {
+		ctx.Header("Tus-Version", helpers.TusVersion)
+		HandleError(ctx, http.StatusPreconditionFailed, "Unsupported tus protocol version", nil)
+		return false
+	}
+	return true
+}
+
+func setUploadHeaders(ctx *gin.Context, upload *entities.ResumableUpload) {
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	ctx.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	if upload.DocumentID != nil {
+		ctx.Header("Upload-Document-Id", strconv.FormatUint(uint64(*upload.DocumentID), 10))
+	}
+}
+
+// parseTusUploadOptions reads the same options as a form upload from the
+// Upload-Metadata pairs description, folder_id, tags, visibility and replace.
+func parseTusUploadOptions(metadata map[string]string) (dtos.DocumentUploadOptions, error) {
+	options := dtos.DocumentUploadOptions{
+		Description: metadata["description"],
+		Replace:     metadata["replace"] == "true",
+		Visibility:  entities.DocumentVisibility(metadata["visibility"]),
+	}
+
+	if folderIDStr := metadata["folder_id"]; folderIDStr != "" {
+		folderID, err := strconv.ParseUint(folderIDStr, 10, 32)
+		if err != nil {
+			return options, err
+		}
+		id := uint(folderID)
+		options.FolderID = &id
+	}
+
+	if tags := metadata["tags"]; tags != "" {
+		options.Tags = strings.Split(tags, ",")
+	}
+
+	return options, nil
+}
+
+func uploadStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "offset mismatch"),
+		strings.Contains(message, "upload is already complete"):
+		return http.StatusConflict
+	case strings.Contains(message, "upload has expired"):
+		return http.StatusGone
+	case strings.Contains(message, "not allowed to upload"):
+		return http.StatusForbidden
+	case strings.Contains(message, "upload exceeds the declared length"):
+		return http.StatusRequestEntityTooLarge
+	case strings.Contains(message, "document limit reached"),
+		strings.Contains(message, "file size exceeds the limit"),
+		strings.Contains(message, "storage quota exceeded"):
+		return http.StatusTooManyRequests
+	case strings.Contains(message, "invalid upload"),
+		strings.Contains(message, "folder not found"),
+		strings.Contains(message, "invalid tag"),
+		strings.Contains(message, "invalid visibility"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
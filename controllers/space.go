@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BlenDMinh/dutgrad-server/configs"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
@@ -17,16 +19,22 @@ import (
 
 type SpaceController struct {
 	CrudController[entities.Space, uint]
-	service services.SpaceService
+	service             services.SpaceService
+	documentTextService services.DocumentTextService
+	cloneService        services.SpaceCloneService
 }
 
 func NewSpaceController(
 	service services.SpaceService,
+	documentTextService services.DocumentTextService,
+	cloneService services.SpaceCloneService,
 ) *SpaceController {
 	crudController := NewCrudController(service)
 	return &SpaceController{
-		CrudController: *crudController,
-		service:        service,
+		CrudController:      *crudController,
+		service:             service,
+		documentTextService: documentTextService,
+		cloneService:        cloneService,
 	}
 }
 
@@ -51,6 +59,27 @@ func (c *SpaceController) GetPublicSpaces(ctx *gin.Context) {
 	})
 }
 
+func (c *SpaceController) GetDiscoverableSpaces(ctx *gin.Context) {
+	params := helpers.GetPaginationParams(ctx, repositories.DefaultPageSize)
+	result, err := c.service.GetDiscoverableSpaces(params.Page, params.PageSize)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to fetch discoverable spaces", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Discoverable spaces retrieved successfully", gin.H{
+		"spaces": result.Data,
+		"pagination": gin.H{
+			"current_page": result.Page,
+			"page_size":    result.PageSize,
+			"total_pages":  result.TotalPages,
+			"total_items":  result.TotalItems,
+			"has_next":     result.HasNext,
+			"has_prev":     result.HasPrev,
+		},
+	})
+}
+
 func (c *SpaceController) CreateSpace(ctx *gin.Context) {
 	model := c.getModel()
 	if !HandleBindJSON(ctx, model) {
@@ -62,6 +91,25 @@ func (c *SpaceController) CreateSpace(ctx *gin.Context) {
 		return
 	}
 
+	// The template provides the settings and documents, the body may still
+	// rename the space
+	if ctx.Query("template_id") != "" {
+		templateID, err := strconv.ParseUint(ctx.Query("template_id"), 10, 64)
+		if err != nil {
+			HandleError(ctx, http.StatusBadRequest, "Invalid template ID", err)
+			return
+		}
+
+		result, err := c.cloneService.CreateSpaceFromTemplate(uint(templateID), userID, model)
+		if err != nil {
+			HandleError(ctx, spaceTemplateStatusCode(err), "Failed to create space", err)
+			return
+		}
+
+		HandleCreated(ctx, "Space created successfully", result)
+		return
+	}
+
 	createdSpace, err := c.service.CreateSpace(model, userID)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
@@ -123,7 +171,7 @@ func (c *SpaceController) GetInvitations(ctx *gin.Context) {
 }
 
 func (c *SpaceController) GetInvitationLink(ctx *gin.Context) {
-	_, ok := ExtractID(ctx, "user_id")
+	userID, ok := ExtractID(ctx, "user_id")
 	if !ok {
 		return
 	}
@@ -141,29 +189,163 @@ func (c *SpaceController) GetInvitationLink(ctx *gin.Context) {
 	spaceRoleID := req.SpaceRoleID
 
 	service := c.service
-	invitationLink, err := service.GetOrCreateSpaceInvitationLink(spaceId, spaceRoleID)
+	invitationLink, err := service.GetOrCreateSpaceInvitationLink(spaceId, spaceRoleID, userID)
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to create invitation link", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid role") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to create invitation link", err)
 		return
 	}
 
-	link, _, err := helpers.GenerateTokenForPayload(
-		gin.H{
-			"space_id":      invitationLink.SpaceID,
-			"space_role_id": invitationLink.SpaceRoleID,
-		},
-		nil,
-	)
+	link, err := invitationLinkURL(invitationLink)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Invitation link created successfully", gin.H{"invitation_link": link})
+}
+
+func (c *SpaceController) GetInvitationLinks(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
 
+	links, err := c.service.GetInvitationLinks(spaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to get invitation links", err)
+		return
+	}
+
+	response := make([]dtos.SpaceInvitationLinkResponse, 0, len(links))
+	for _, link := range links {
+		item, err := newInvitationLinkResponse(&link)
+		if err != nil {
+			HandleError(ctx, http.StatusInternalServerError, "Failed to generate token", err)
+			return
+		}
+		response = append(response, item)
+	}
+
+	HandleSuccess(ctx, "Invitation links retrieved successfully", gin.H{"invitation_links": response})
+}
+
+func (c *SpaceController) CreateInvitationLink(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.CreateInvitationLinkRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	link, err := c.service.CreateInvitationLink(&entities.SpaceInvitationLink{
+		SpaceID:     spaceID,
+		SpaceRoleID: req.SpaceRoleID,
+		Name:        req.Name,
+		CreatedBy:   &userID,
+		ExpiresAt:   req.ExpiresAt,
+		MaxUses:     req.MaxUses,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid link") || strings.Contains(err.Error(), "invalid role") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to create invitation link", err)
+		return
+	}
+
+	response, err := newInvitationLinkResponse(link)
 	if err != nil {
 		HandleError(ctx, http.StatusInternalServerError, "Failed to generate token", err)
 		return
 	}
 
-	config := configs.GetEnv()
-	link = config.WebClientURL + "/invitation?token=" + link
+	HandleCreated(ctx, "Invitation link created successfully", response)
+}
 
-	HandleSuccess(ctx, "Invitation link created successfully", gin.H{"invitation_link": link})
+func (c *SpaceController) RevokeInvitationLink(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	linkID, ok := ExtractID(ctx, "linkId")
+	if !ok {
+		return
+	}
+
+	if err := c.service.RevokeInvitationLink(spaceID, linkID, userID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			statusCode = http.StatusNotFound
+		}
+		HandleError(ctx, statusCode, "Failed to revoke invitation link", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Invitation link revoked successfully", gin.H{})
+}
+
+func (c *SpaceController) GetInvitationLinkUses(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	linkID, ok := ExtractID(ctx, "linkId")
+	if !ok {
+		return
+	}
+
+	uses, err := c.service.GetInvitationLinkUses(spaceID, linkID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			statusCode = http.StatusNotFound
+		}
+		HandleError(ctx, statusCode, "Failed to get invitation link uses", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Invitation link uses retrieved successfully", gin.H{"uses": uses})
+}
+
+// invitationLinkURL builds the web client URL that joins through link.
+func invitationLinkURL(link *entities.SpaceInvitationLink) (string, error) {
+	token, err := helpers.GenerateInvitationLinkToken(link.ID, link.ExpiresAt)
+	if err != nil {
+		return "", err
+	}
+	return configs.GetEnv().WebClientURL + "/invitation?token=" + token, nil
+}
+
+func newInvitationLinkResponse(link *entities.SpaceInvitationLink) (dtos.SpaceInvitationLinkResponse, error) {
+	url, err := invitationLinkURL(link)
+	if err != nil {
+		return dtos.SpaceInvitationLinkResponse{}, err
+	}
+	return dtos.SpaceInvitationLinkResponse{
+		SpaceInvitationLink: *link,
+		InvitationLink:      url,
+		Active:              link.UnusableReason(time.Now()) == "",
+	}, nil
 }
 
 func (c *SpaceController) JoinSpace(ctx *gin.Context) {
@@ -183,12 +365,16 @@ func (c *SpaceController) JoinSpace(ctx *gin.Context) {
 		statusCode := http.StatusInternalServerError
 		message := "Failed to join space"
 
-		if err.Error() == "invalid token" {
+		switch {
+		case err.Error() == "invalid token":
 			statusCode = http.StatusUnauthorized
 			message = "Invalid token"
-		} else if err.Error() == "user is already a member of this space" {
+		case err.Error() == "user is already a member of this space":
 			statusCode = http.StatusConflict
 			message = "You are already a member of this space"
+		case strings.HasPrefix(err.Error(), "invitation link"):
+			statusCode = http.StatusGone
+			message = "This invitation link can no longer be used"
 		}
 
 		HandleError(ctx, statusCode, message, err)
@@ -237,51 +423,91 @@ func (c *SpaceController) InviteUserToSpace(ctx *gin.Context) {
 	}
 
 	invitation := entities.SpaceInvitation{
-		SpaceID:     spaceId,
-		SpaceRoleID: req.SpaceRoleID,
-		InviterID:   inviterId,
-		Status:      "pending",
-		Message:     req.Message,
+		SpaceID:       spaceId,
+		SpaceRoleID:   req.SpaceRoleID,
+		InviterID:     inviterId,
+		InvitedUserID: req.InvitedUserID,
+		Message:       req.Message,
+	}
+	if req.InvitedUserID == nil {
+		invitation.InvitedEmail = &req.InvitedUserEmail
 	}
 
-	if req.InvitedUserID != nil {
-		invitation.InvitedUserID = *req.InvitedUserID
-	} else {
-		userService := services.NewUserService()
-		user, err := userService.GetUserByEmail(req.InvitedUserEmail)
-		if err != nil {
-			HandleError(ctx, http.StatusInternalServerError, "Failed to find user by email", err)
-			return
+	created, err := c.service.CreateInvitation(&invitation)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		message := "Failed to create invitation"
+
+		switch {
+		case strings.Contains(err.Error(), "uniq_user_space_role"),
+			strings.Contains(err.Error(), "already a member"):
+			statusCode = http.StatusBadRequest
+			message = "User is already a member of this space"
+		case strings.Contains(err.Error(), "uniq_email_space_invitation"):
+			statusCode = http.StatusBadRequest
+			message = "This email address has already been invited"
+		case strings.Contains(err.Error(), "invalid role"),
+			strings.Contains(err.Error(), "invalid invitation"):
+			statusCode = http.StatusBadRequest
 		}
-		invitation.InvitedUserID = user.ID
+
+		HandleError(ctx, statusCode, message, err)
+		return
 	}
 
-	isMember, err := c.service.IsMemberOfSpace(invitation.InvitedUserID, spaceId)
-	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to check membership", err)
+	HandleSuccess(ctx, "Invitation sent successfully", gin.H{"invitation": created})
+}
+
+// ResendInvitation emails a pending invitation again and extends its expiry.
+func (c *SpaceController) ResendInvitation(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
 		return
 	}
 
-	if isMember {
-		HandleError(ctx, http.StatusBadRequest, "User is already a member of this space", nil)
+	invitationID, ok := ExtractID(ctx, "invitationId")
+	if !ok {
 		return
 	}
 
-	_, err = c.service.CreateInvitation(&invitation)
+	invitation, err := c.service.ResendInvitation(spaceID, invitationID)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		message := "Failed to create invitation"
+		HandleError(ctx, spaceInvitationStatusCode(err), "Failed to resend invitation", err)
+		return
+	}
 
-		if strings.Contains(err.Error(), "uniq_user_space_role") {
-			statusCode = http.StatusBadRequest
-			message = "User is already a member of this space"
-		}
+	HandleSuccess(ctx, "Invitation resent successfully", gin.H{"invitation": invitation})
+}
 
-		HandleError(ctx, statusCode, message, err)
+func (c *SpaceController) RevokeInvitation(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	invitationID, ok := ExtractID(ctx, "invitationId")
+	if !ok {
 		return
 	}
 
-	HandleSuccess(ctx, "Invitation sent successfully", gin.H{})
+	if err := c.service.RevokeInvitation(spaceID, invitationID); err != nil {
+		HandleError(ctx, spaceInvitationStatusCode(err), "Failed to revoke invitation", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Invitation revoked successfully", gin.H{})
+}
+
+func spaceInvitationStatusCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "invitation not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "no longer pending"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "failed to send"):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
 }
 
 func (c *SpaceController) GetSpaceRoles(ctx *gin.Context) {
@@ -294,6 +520,129 @@ func (c *SpaceController) GetSpaceRoles(ctx *gin.Context) {
 	HandleSuccess(ctx, "Roles retrieved successfully", gin.H{"roles": roles})
 }
 
+// GetRolesOfSpace lists the built-in roles and the custom roles of the space.
+func (c *SpaceController) GetRolesOfSpace(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	roles, err := c.service.GetRolesOfSpace(spaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to fetch roles", err)
+		return
+	}
+
+	response := make([]dtos.SpaceRoleResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, dtos.NewSpaceRoleResponse(role))
+	}
+	HandleSuccess(ctx, "Roles retrieved successfully", gin.H{"roles": response})
+}
+
+func (c *SpaceController) CreateRole(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.SpaceRoleRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+	if req.Name == nil {
+		HandleError(ctx, http.StatusBadRequest, "Role name is required", nil)
+		return
+	}
+	permission, ok := entities.ParseSpacePermissions(req.Permissions)
+	if !ok {
+		HandleError(ctx, http.StatusBadRequest, "Unknown permission", nil)
+		return
+	}
+
+	role, err := c.service.CreateCustomRole(spaceID, userID, *req.Name, permission)
+	if err != nil {
+		HandleError(ctx, spaceRoleStatusCode(err), "Failed to create role", err)
+		return
+	}
+
+	HandleCreated(ctx, "Role created successfully", dtos.NewSpaceRoleResponse(*role))
+}
+
+func (c *SpaceController) UpdateRole(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+	roleID, ok := ExtractID(ctx, "roleId")
+	if !ok {
+		return
+	}
+
+	var req dtos.SpaceRoleRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+	var permission *entities.SpacePermission
+	if req.Permissions != nil {
+		parsed, ok := entities.ParseSpacePermissions(req.Permissions)
+		if !ok {
+			HandleError(ctx, http.StatusBadRequest, "Unknown permission", nil)
+			return
+		}
+		permission = &parsed
+	}
+
+	role, err := c.service.UpdateCustomRole(spaceID, roleID, userID, req.Name, permission)
+	if err != nil {
+		HandleError(ctx, spaceRoleStatusCode(err), "Failed to update role", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Role updated successfully", dtos.NewSpaceRoleResponse(*role))
+}
+
+func (c *SpaceController) DeleteRole(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+	roleID, ok := ExtractID(ctx, "roleId")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteCustomRole(spaceID, roleID); err != nil {
+		HandleError(ctx, spaceRoleStatusCode(err), "Failed to delete role", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Role deleted successfully", gin.H{})
+}
+
+func spaceRoleStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "invalid role name"),
+		strings.Contains(message, "invalid permission"):
+		return http.StatusBadRequest
+	case strings.Contains(message, "role not found"):
+		return http.StatusNotFound
+	case strings.Contains(message, "already exists"),
+		strings.Contains(message, "still assigned"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
 func (c *SpaceController) JoinPublicSpace(ctx *gin.Context) {
 	userID, ok := ExtractID(ctx, "user_id")
 	if !ok {
@@ -392,9 +741,19 @@ func (c *SpaceController) Chat(ctx *gin.Context) {
 	}
 
 	ragService := services.NewRAGServerService()
-	answer, err := ragService.Chat(session.ID, session.SpaceID, req.Query)
+	options := services.ChatOptions{FolderID: req.FolderID}
+	answer, err := ragService.Chat(session.ID, session.SpaceID, req.Query, options)
+	degraded := false
+	if errors.Is(err, services.ErrRAGServerUnavailable) {
+		answer, err = c.documentTextService.KeywordAnswer(session.SpaceID, req.Query, options)
+		degraded = err == nil
+	}
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to get answer", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "folder not found") {
+			statusCode = http.StatusBadRequest
+		}
+		HandleError(ctx, statusCode, "Failed to get answer", err)
 		return
 	}
 
@@ -402,6 +761,7 @@ func (c *SpaceController) Chat(ctx *gin.Context) {
 		"session_id": session.ID,
 		"query":      req.Query,
 		"answer":     answer,
+		"degraded":   degraded,
 	})
 }
 
@@ -431,7 +791,18 @@ func (c *SpaceController) UpdateUserRole(ctx *gin.Context) {
 	service := c.service
 	err = service.UpdateMemberRole(spaceId, uint(memberId), req.RoleID, userID)
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to update user role", err)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "invalid role"):
+			statusCode = http.StatusBadRequest
+		case strings.Contains(err.Error(), "cannot change the role"):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, repositories.ErrLastSpaceOwner):
+			statusCode = http.StatusConflict
+		case strings.Contains(err.Error(), "member not found"):
+			statusCode = http.StatusNotFound
+		}
+		HandleError(ctx, statusCode, "Failed to update user role", err)
 		return
 	}
 
@@ -462,12 +833,14 @@ func (c *SpaceController) RemoveMember(ctx *gin.Context) {
 		statusCode := http.StatusInternalServerError
 		message := err.Error()
 
-		if strings.Contains(message, "only space owners can remove members") ||
+		if strings.Contains(message, "can remove members") ||
 			strings.Contains(message, "cannot remove a space owner") ||
 			strings.Contains(message, "you cannot remove yourself") {
 			statusCode = http.StatusForbidden
 		} else if strings.Contains(message, "not a member of this space") {
 			statusCode = http.StatusNotFound
+		} else if errors.Is(err, repositories.ErrLastSpaceOwner) {
+			statusCode = http.StatusConflict
 		}
 
 		HandleError(ctx, statusCode, message, nil)
@@ -476,3 +849,52 @@ func (c *SpaceController) RemoveMember(ctx *gin.Context) {
 
 	HandleSuccess(ctx, "Member removed successfully", gin.H{})
 }
+
+func (c *SpaceController) LeaveSpace(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.LeaveSpace(spaceID, userID); err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repositories.ErrLastSpaceOwner):
+			statusCode = http.StatusConflict
+		case strings.Contains(err.Error(), "member not found"):
+			statusCode = http.StatusNotFound
+		}
+		HandleError(ctx, statusCode, "Failed to leave space", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Left the space successfully", gin.H{})
+}
+
+func (c *SpaceController) DeleteSpace(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteSpace(spaceID, userID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "only the owner") {
+			statusCode = http.StatusForbidden
+		}
+		HandleError(ctx, statusCode, "Failed to delete space", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Space moved to trash", nil)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SpaceArchiveController struct {
+	service      services.SpaceArchiveService
+	spaceService services.SpaceService
+}
+
+func NewSpaceArchiveController(
+	service services.SpaceArchiveService,
+	spaceService services.SpaceService,
+) *SpaceArchiveController {
+	return &SpaceArchiveController{
+		service:      service,
+		spaceService: spaceService,
+	}
+}
+
+// ExportSpace streams the archive of a space. Members are only exported for
+// callers who may manage them, and chat sessions, which are private, only
+// for owners.
+func (c *SpaceArchiveController) ExportSpace(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	role, err := c.spaceService.GetUserRole(userID, spaceID)
+	if err != nil || role == nil {
+		HandleError(ctx, http.StatusForbidden, "You are not a member of this space", err)
+		return
+	}
+
+	options := dtos.SpaceExportOptions{
+		IncludeMembers:  role.HasPermission(entities.SpacePermissionManageMembers),
+		IncludeSessions: ctx.Query("include_sessions") == "true",
+	}
+	if options.IncludeSessions && !role.IsOwner() {
+		HandleError(ctx, http.StatusForbidden, "Failed to export space", errors.New("only owners can export chat sessions"))
+		return
+	}
+
+	manifest, err := c.service.PrepareExport(spaceID, options)
+	if err != nil {
+		HandleError(ctx, spaceArchiveStatusCode(err), "Failed to export space", err)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"space-%d.zip\"", spaceID))
+	ctx.Header("Cache-Control", "private, no-store")
+	ctx.Status(http.StatusOK)
+
+	// The status is sent already, a failure can only cut the archive short
+	if err := c.service.WriteExport(manifest, ctx.Writer); err != nil {
+		log.Printf("Failed to export space %d: %v", spaceID, err)
+	}
+}
+
+func (c *SpaceArchiveController) ImportSpace(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Failed to get file", err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		HandleError(ctx, http.StatusBadRequest, "Failed to read file", err)
+		return
+	}
+	defer file.Close()
+
+	report, err := c.service.ImportSpace(file, fileHeader.Size, userID, dtos.SpaceImportOptions{
+		IncludeSessions: ctx.Query("include_sessions") == "true",
+	})
+	if err != nil {
+		HandleError(ctx, spaceArchiveStatusCode(err), "Failed to import space", err)
+		return
+	}
+
+	HandleCreated(ctx, "Space imported successfully", report)
+}
+
+func spaceArchiveStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "space limit reached"):
+		return http.StatusTooManyRequests
+	case strings.Contains(message, "invalid archive"),
+		strings.Contains(message, "invalid role name"),
+		strings.Contains(message, "invalid permission"),
+		strings.Contains(message, "role name already exists"):
+		return http.StatusBadRequest
+	case strings.Contains(message, "unsupported archive version"):
+		return http.StatusUnprocessableEntity
+	case strings.Contains(message, "space not found"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
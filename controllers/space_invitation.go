@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/services"
@@ -23,6 +24,55 @@ func NewSpaceInvitationController(
 	}
 }
 
+// RetrieveOne shows an invitation to the invited user, the inviter and the
+// members allowed to manage the members of the space.
+func (c *SpaceInvitationController) RetrieveOne(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	invitationID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	invitation, err := c.service.GetInvitationForUser(invitationID, userID)
+	if err != nil {
+		HandleError(ctx, http.StatusNotFound, "Invitation not found", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Invitation retrieved successfully", invitation)
+}
+
+// Delete withdraws an invitation on behalf of the inviter or a member allowed
+// to manage the members of the space.
+func (c *SpaceInvitationController) Delete(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	invitationID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteInvitation(invitationID, userID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invitation not found") {
+			statusCode = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "not allowed") {
+			statusCode = http.StatusForbidden
+		}
+		HandleError(ctx, statusCode, "Failed to cancel invitation", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Invitation cancelled successfully", nil)
+}
+
 func (c *SpaceInvitationController) AcceptInvitation(ctx *gin.Context) {
 	userId, ok := ExtractID(ctx, "user_id")
 	if !ok {
@@ -36,6 +86,10 @@ func (c *SpaceInvitationController) AcceptInvitation(ctx *gin.Context) {
 
 	spaceId, err := c.service.AcceptInvitation(invitationId, userId)
 	if err != nil {
+		if strings.Contains(err.Error(), "invitation has expired") {
+			HandleError(ctx, http.StatusGone, "This invitation has expired", err)
+			return
+		}
 		HandleError(ctx, http.StatusInternalServerError, "Failed to accept invitation", err)
 		return
 	}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SpaceJoinRequestController struct {
+	service services.SpaceJoinRequestService
+}
+
+func NewSpaceJoinRequestController(
+	service services.SpaceJoinRequestService,
+) *SpaceJoinRequestController {
+	return &SpaceJoinRequestController{
+		service: service,
+	}
+}
+
+func (c *SpaceJoinRequestController) SubmitRequest(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.JoinRequestRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	request, err := c.service.SubmitRequest(spaceID, userID, req.Message)
+	if err != nil {
+		HandleError(ctx, joinRequestStatusCode(err), "Failed to submit join request", err)
+		return
+	}
+
+	HandleCreated(ctx, "Join request submitted successfully", request)
+}
+
+func (c *SpaceJoinRequestController) GetSpaceRequests(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	requests, err := c.service.GetSpaceRequests(spaceID, ctx.Query("status"))
+	if err != nil {
+		HandleError(ctx, joinRequestStatusCode(err), "Failed to get join requests", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Join requests retrieved successfully", gin.H{"join_requests": requests})
+}
+
+func (c *SpaceJoinRequestController) GetMyRequests(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	requests, err := c.service.GetMyRequests(userID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to get join requests", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Join requests retrieved successfully", gin.H{"join_requests": requests})
+}
+
+func (c *SpaceJoinRequestController) ApproveRequest(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	requestID, ok := ExtractID(ctx, "requestId")
+	if !ok {
+		return
+	}
+
+	var req dtos.ApproveJoinRequestRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	request, err := c.service.ApproveRequest(spaceID, requestID, userID, req.SpaceRoleID)
+	if err != nil {
+		HandleError(ctx, joinRequestStatusCode(err), "Failed to approve join request", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Join request approved successfully", request)
+}
+
+func (c *SpaceJoinRequestController) RejectRequest(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	requestID, ok := ExtractID(ctx, "requestId")
+	if !ok {
+		return
+	}
+
+	if err := c.service.RejectRequest(spaceID, requestID, userID); err != nil {
+		HandleError(ctx, joinRequestStatusCode(err), "Failed to reject join request", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Join request rejected successfully", gin.H{})
+}
+
+func (c *SpaceJoinRequestController) CancelRequest(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	requestID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.CancelRequest(requestID, userID); err != nil {
+		HandleError(ctx, joinRequestStatusCode(err), "Failed to cancel join request", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Join request cancelled successfully", gin.H{})
+}
+
+func joinRequestStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "too many"):
+		return http.StatusTooManyRequests
+	case strings.Contains(message, "invalid request"),
+		strings.Contains(message, "invalid role"),
+		strings.Contains(message, "space is public"):
+		return http.StatusBadRequest
+	case strings.Contains(message, "does not accept join requests"):
+		return http.StatusForbidden
+	case strings.Contains(message, "space not found"),
+		strings.Contains(message, "join request not found"):
+		return http.StatusNotFound
+	case strings.Contains(message, "already a member"),
+		strings.Contains(message, "already pending"),
+		strings.Contains(message, "no longer pending"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
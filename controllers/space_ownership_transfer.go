@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SpaceOwnershipTransferController struct {
+	service services.SpaceOwnershipTransferService
+}
+
+func NewSpaceOwnershipTransferController(
+	service services.SpaceOwnershipTransferService,
+) *SpaceOwnershipTransferController {
+	return &SpaceOwnershipTransferController{
+		service: service,
+	}
+}
+
+func (c *SpaceOwnershipTransferController) ProposeTransfer(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.OwnershipTransferRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	transfer, err := c.service.ProposeTransfer(spaceID, userID, req.ToUserID, req.KeepOwnership)
+	if err != nil {
+		HandleError(ctx, ownershipTransferStatusCode(err), "Failed to propose ownership transfer", err)
+		return
+	}
+
+	HandleCreated(ctx, "Ownership transfer proposed successfully", transfer)
+}
+
+func (c *SpaceOwnershipTransferController) GetSpaceTransfers(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	transfers, err := c.service.GetPendingTransfers(spaceID, userID)
+	if err != nil {
+		HandleError(ctx, ownershipTransferStatusCode(err), "Failed to get ownership transfers", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Ownership transfers retrieved successfully", gin.H{"transfers": transfers})
+}
+
+func (c *SpaceOwnershipTransferController) GetMyTransfers(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	transfers, err := c.service.GetMyTransfers(userID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to get ownership transfers", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Ownership transfers retrieved successfully", gin.H{"transfers": transfers})
+}
+
+func (c *SpaceOwnershipTransferController) AcceptTransfer(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	transferID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	transfer, err := c.service.AcceptTransfer(transferID, userID)
+	if err != nil {
+		HandleError(ctx, ownershipTransferStatusCode(err), "Failed to accept ownership transfer", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Ownership transfer accepted successfully", transfer)
+}
+
+func (c *SpaceOwnershipTransferController) DeclineTransfer(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	transferID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeclineTransfer(transferID, userID); err != nil {
+		HandleError(ctx, ownershipTransferStatusCode(err), "Failed to decline ownership transfer", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Ownership transfer declined successfully", gin.H{})
+}
+
+func (c *SpaceOwnershipTransferController) CancelTransfer(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	transferID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.CancelTransfer(transferID, userID); err != nil {
+		HandleError(ctx, ownershipTransferStatusCode(err), "Failed to cancel ownership transfer", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Ownership transfer cancelled successfully", gin.H{})
+}
+
+func ownershipTransferStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "invalid target"):
+		return http.StatusBadRequest
+	case strings.Contains(message, "only owners"),
+		strings.Contains(message, "not a member"),
+		strings.Contains(message, "no longer an owner"):
+		return http.StatusForbidden
+	case strings.Contains(message, "transfer not found"):
+		return http.StatusNotFound
+	case strings.Contains(message, "already pending"),
+		strings.Contains(message, "no longer pending"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SpaceTemplateController struct {
+	service services.SpaceCloneService
+}
+
+func NewSpaceTemplateController(
+	service services.SpaceCloneService,
+) *SpaceTemplateController {
+	return &SpaceTemplateController{
+		service: service,
+	}
+}
+
+func (c *SpaceTemplateController) CloneSpace(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.CloneSpaceRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	result, err := c.service.CloneSpace(spaceID, userID, &req)
+	if err != nil {
+		HandleError(ctx, spaceTemplateStatusCode(err), "Failed to clone space", err)
+		return
+	}
+
+	HandleCreated(ctx, "Space cloned successfully", result)
+}
+
+func (c *SpaceTemplateController) SaveTemplate(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req dtos.SaveSpaceTemplateRequest
+	if !HandleBindJSON(ctx, &req) {
+		return
+	}
+
+	template, skipped, err := c.service.SaveTemplate(spaceID, userID, &req)
+	if err != nil {
+		HandleError(ctx, spaceTemplateStatusCode(err), "Failed to save template", err)
+		return
+	}
+
+	HandleCreated(ctx, "Template saved successfully", gin.H{
+		"template":          template,
+		"skipped_documents": skipped,
+	})
+}
+
+func (c *SpaceTemplateController) GetMyTemplates(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	templates, err := c.service.GetTemplates(userID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to get templates", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Templates retrieved successfully", gin.H{"templates": templates})
+}
+
+func (c *SpaceTemplateController) GetTemplate(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	templateID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	template, err := c.service.GetTemplate(templateID, userID)
+	if err != nil {
+		HandleError(ctx, spaceTemplateStatusCode(err), "Failed to get template", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Template retrieved successfully", template)
+}
+
+func (c *SpaceTemplateController) DeleteTemplate(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	templateID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteTemplate(templateID, userID); err != nil {
+		HandleError(ctx, spaceTemplateStatusCode(err), "Failed to delete template", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Template deleted successfully", gin.H{})
+}
+
+func spaceTemplateStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "space limit reached"):
+		return http.StatusTooManyRequests
+	case strings.Contains(message, "invalid template"):
+		return http.StatusBadRequest
+	case strings.Contains(message, "not allowed"):
+		return http.StatusForbidden
+	case strings.Contains(message, "space not found"),
+		strings.Contains(message, "template not found"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
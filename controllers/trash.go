@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TrashController struct {
+	service services.TrashService
+}
+
+func NewTrashController(
+	service services.TrashService,
+) *TrashController {
+	return &TrashController{
+		service: service,
+	}
+}
+
+func (c *TrashController) GetMyTrash(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	trash, err := c.service.GetUserTrash(userID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to get trash", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Trash retrieved successfully", trash)
+}
+
+func (c *TrashController) GetSpaceTrash(ctx *gin.Context) {
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	documents, err := c.service.GetSpaceTrash(spaceID)
+	if err != nil {
+		HandleError(ctx, http.StatusInternalServerError, "Failed to get trash", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Trash retrieved successfully", gin.H{"documents": documents})
+}
+
+func (c *TrashController) RestoreDocument(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	docID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	document, err := c.service.RestoreDocument(docID, userID)
+	if err != nil {
+		if handleDuplicateDocument(ctx, err) {
+			return
+		}
+		HandleError(ctx, restoreStatusCode(err), "Failed to restore document", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Document restored successfully", document)
+}
+
+func (c *TrashController) RestoreSpace(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	spaceID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	space, job, err := c.service.RestoreSpace(spaceID, userID)
+	if err != nil && space == nil {
+		HandleError(ctx, restoreStatusCode(err), "Failed to restore space", err)
+		return
+	}
+
+	data := gin.H{"space": space, "job": job}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	HandleSuccess(ctx, "Space restored successfully", data)
+}
+
+func (c *TrashController) RestoreSession(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	sessionID, ok := ExtractID(ctx, "id")
+	if !ok {
+		return
+	}
+
+	session, err := c.service.RestoreSession(sessionID, userID)
+	if err != nil {
+		HandleError(ctx, restoreStatusCode(err), "Failed to restore session", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Session restored successfully", session)
+}
+
+func restoreStatusCode(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "not found in trash"):
+		return http.StatusNotFound
+	case strings.Contains(message, "not allowed"):
+		return http.StatusForbidden
+	case strings.Contains(message, "document limit reached"),
+		strings.Contains(message, "file size exceeds the limit"),
+		strings.Contains(message, "storage quota exceeded"):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/models/dtos"
@@ -174,3 +175,23 @@ func (c *UserController) GetUserAuthMethod(ctx *gin.Context) {
 
 	HandleSuccess(ctx, "Auth methods retrieved successfully", authMethods)
 }
+
+// DeleteAccount deletes the account of the current user, unless they are the
+// last owner of a space.
+func (c *UserController) DeleteAccount(ctx *gin.Context) {
+	userID, ok := ExtractID(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.Delete(userID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "last owner") {
+			statusCode = http.StatusConflict
+		}
+		HandleError(ctx, statusCode, "Failed to delete account", err)
+		return
+	}
+
+	HandleSuccess(ctx, "Account deleted successfully", nil)
+}
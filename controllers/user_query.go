@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/models/dtos"
@@ -11,16 +13,19 @@ import (
 
 type UserQueryController struct {
 	CrudController[entities.UserQuery, uint]
-	service services.UserQueryService
+	service             services.UserQueryService
+	documentTextService services.DocumentTextService
 }
 
 func NewUserQueryController(
 	service services.UserQueryService,
+	documentTextService services.DocumentTextService,
 ) *UserQueryController {
 	crudController := NewCrudController(service)
 	return &UserQueryController{
-		CrudController: *crudController,
-		service:        service,
+		CrudController:      *crudController,
+		service:             service,
+		documentTextService: documentTextService,
 	}
 }
 
@@ -58,9 +63,22 @@ func (c *UserQueryController) Ask(ctx *gin.Context) {
 		return
 	}
 	ragService := services.NewRAGServerService()
-	answer, err := ragService.Chat(req.QuerySessionID, session.SpaceID, req.Query)
+	options := services.ChatOptions{FolderID: req.FolderID, UserID: &userID}
+	answer, err := ragService.Chat(req.QuerySessionID, session.SpaceID, req.Query, options)
+	degraded := false
+	if errors.Is(err, services.ErrRAGServerUnavailable) {
+		// Answer from the keyword index instead of failing the chat
+		answer, err = c.documentTextService.KeywordAnswer(session.SpaceID, req.Query, options)
+		degraded = err == nil
+	}
 	if err != nil {
-		HandleError(ctx, http.StatusInternalServerError, "Failed to get answer", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "folder not found") {
+			statusCode = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "not a member") || strings.Contains(err.Error(), "not allowed to chat") {
+			statusCode = http.StatusForbidden
+		}
+		HandleError(ctx, statusCode, "Failed to get answer", err)
 		return
 	}
 
@@ -78,7 +96,8 @@ func (c *UserQueryController) Ask(ctx *gin.Context) {
 	}
 
 	HandleSuccess(ctx, "Answer retrieved successfully", gin.H{
-		"answer": answer,
-		"query":  query,
+		"answer":   answer,
+		"query":    query,
+		"degraded": degraded,
 	})
 }
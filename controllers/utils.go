@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/models"
 	"github.com/gin-gonic/gin"
 )
@@ -36,6 +37,14 @@ func ExtractID(ctx *gin.Context, paramName string) (uint, bool) {
 	return uint(id), true
 }
 
+// GetSpaceRole returns the role RequireSpacePermission or RequireSpaceReader
+// resolved for the request, nil for visitors of a public space.
+func GetSpaceRole(ctx *gin.Context) *entities.SpaceRole {
+	role, _ := ctx.Get(models.SpaceRoleKey)
+	spaceRole, _ := role.(*entities.SpaceRole)
+	return spaceRole
+}
+
 func HandleBindJSON[T any](ctx *gin.Context, req *T) bool {
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		errMsg := err.Error()
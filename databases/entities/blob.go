@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// Blob is a stored file shared by every document version with the same
+// content. RefCount is the number of document versions referencing it.
+type Blob struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ContentHash string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"content_hash"`
+	S3URL       string    `gorm:"not null" json:"-"`
+	Size        int64     `gorm:"not null" json:"size"`
+	RefCount    int       `gorm:"not null;default:0" json:"ref_count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (b Blob) GetIdType() string {
+	return "uint"
+}
@@ -1,22 +1,160 @@
 package entities
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Document struct {
-	ID               uint      `gorm:"primaryKey" json:"id"`
-	SpaceID          uint      `gorm:"not null;index" json:"space_id"`
-	Name             string    `gorm:"type:varchar(255)" json:"name"`
-	Description      string    `gorm:"type:varchar(8192)" json:"description"`
-	MimeType         string    `gorm:"column:mime_type;type:varchar(255)" json:"mime_type"`
-	Size             int64     `gorm:"column:size;not null" json:"size"`
-	ProcessingStatus int       `gorm:"default:0" json:"processing_status"`
-	S3URL            string    `gorm:"not null" json:"s3_url"`
-	PrivacyStatus    bool      `gorm:"default:true" json:"privacy_status"`
-	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
-	Space            *Space    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"space"`
+	ID                   uint               `gorm:"primaryKey" json:"id"`
+	SpaceID              uint               `gorm:"not null;index" json:"space_id"`
+	Name                 string             `gorm:"type:varchar(255)" json:"name"`
+	Description          string             `gorm:"type:varchar(8192)" json:"description"`
+	MimeType             string             `gorm:"column:mime_type;type:varchar(255)" json:"mime_type"`
+	Size                 int64              `gorm:"column:size;not null" json:"size"`
+	ProcessingStatus     int                `gorm:"default:0" json:"processing_status"`
+	S3URL                string             `gorm:"not null" json:"-"`
+	ContentHash          string             `gorm:"type:varchar(64);index" json:"content_hash,omitempty"`
+	PrivacyStatus        DocumentVisibility `gorm:"type:varchar(20);not null;default:'members'" json:"privacy_status"`
+	FolderID             *uint              `gorm:"index" json:"folder_id"`
+	Tags                 DocumentTags       `gorm:"type:jsonb" json:"tags"`
+	Metadata             DocumentMetadata   `gorm:"type:jsonb" json:"metadata"`
+	CurrentVersion       int                `gorm:"not null;default:1" json:"current_version"`
+	SourceURL            string             `gorm:"type:text" json:"source_url,omitempty"`
+	RefreshIntervalHours int                `gorm:"not null;default:0" json:"refresh_interval_hours"`
+	LastFetchedAt        *time.Time         `json:"last_fetched_at,omitempty"`
+	UploadedBy           *uint              `gorm:"index" json:"uploaded_by"`
+	CreatedAt            time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt            gorm.DeletedAt     `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedBy            *uint              `json:"deleted_by,omitempty"`
+	Space                *Space             `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"space"`
+	Folder               *DocumentFolder    `gorm:"foreignKey:FolderID;constraint:OnDelete:SET NULL;" json:"-"`
+	Uploader             *User              `gorm:"foreignKey:UploadedBy;constraint:OnDelete:SET NULL;" json:"uploader,omitempty"`
+	DocumentScan
 }
 
 func (s Document) GetIdType() string {
 	return "uint"
 }
+
+// DocumentVisibility decides which members of a space can see a document and
+// whether the chat may retrieve from it.
+type DocumentVisibility string
+
+const (
+	// DocumentVisibilityMembers documents are visible to everyone in the space
+	DocumentVisibilityMembers DocumentVisibility = "members"
+	// DocumentVisibilityEditors documents are hidden from viewers
+	DocumentVisibilityEditors DocumentVisibility = "editors"
+	// DocumentVisibilityReferenceOnly documents can be read by every member but
+	// are never used to answer chat questions
+	DocumentVisibilityReferenceOnly DocumentVisibility = "reference_only"
+)
+
+func (v DocumentVisibility) IsValid() bool {
+	switch v {
+	case DocumentVisibilityMembers, DocumentVisibilityEditors, DocumentVisibilityReferenceOnly:
+		return true
+	}
+	return false
+}
+
+// VisibleDocumentVisibilities returns the visibilities a member with role can
+// list and download. A nil role stands for a visitor of a public space. The
+// editors visibility follows the permission to upload documents.
+func VisibleDocumentVisibilities(role *SpaceRole) []DocumentVisibility {
+	visibilities := []DocumentVisibility{DocumentVisibilityMembers, DocumentVisibilityReferenceOnly}
+	if role != nil && role.HasPermission(SpacePermissionUploadDocuments) {
+		visibilities = append(visibilities, DocumentVisibilityEditors)
+	}
+	return visibilities
+}
+
+// RetrievableDocumentVisibilities returns the visibilities the chat may
+// retrieve from on behalf of a member with role.
+func RetrievableDocumentVisibilities(role *SpaceRole) []DocumentVisibility {
+	visibilities := []DocumentVisibility{DocumentVisibilityMembers}
+	if role != nil && role.HasPermission(SpacePermissionUploadDocuments) {
+		visibilities = append(visibilities, DocumentVisibilityEditors)
+	}
+	return visibilities
+}
+
+func (d Document) IsVisibleTo(role *SpaceRole) bool {
+	return slices.Contains(VisibleDocumentVisibilities(role), d.PrivacyStatus)
+}
+
+// DocumentScanStatus records the malware scan of the current file. Infected
+// files are rejected, so a stored document is either clean or not scanned.
+type DocumentScanStatus string
+
+const (
+	DocumentScanNotScanned DocumentScanStatus = "not_scanned"
+	DocumentScanClean      DocumentScanStatus = "clean"
+)
+
+// DocumentScan is stored with every version and copied to the document when
+// the version becomes current.
+type DocumentScan struct {
+	ScanStatus DocumentScanStatus `gorm:"type:varchar(20);not null;default:'not_scanned'" json:"scan_status"`
+	ScanEngine string             `gorm:"type:varchar(64)" json:"scan_engine,omitempty"`
+	ScannedAt  *time.Time         `json:"scanned_at,omitempty"`
+}
+
+type DocumentTags []string
+
+func (t DocumentTags) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *DocumentTags) Scan(value interface{}) error {
+	if value == nil {
+		*t = DocumentTags{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal document tags")
+	}
+
+	return json.Unmarshal(bytes, t)
+}
+
+type DocumentMetadata struct {
+	Title      string `json:"title,omitempty"`
+	Author     string `json:"author,omitempty"`
+	PageCount  int    `json:"page_count,omitempty"`
+	SlideCount int    `json:"slide_count,omitempty"`
+	SheetCount int    `json:"sheet_count,omitempty"`
+	RowCount   int    `json:"row_count,omitempty"`
+	WordCount  int    `json:"word_count,omitempty"`
+	Language   string `json:"language,omitempty"`
+}
+
+func (m DocumentMetadata) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *DocumentMetadata) Scan(value interface{}) error {
+	if value == nil {
+		*m = DocumentMetadata{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal document metadata")
+	}
+
+	return json.Unmarshal(bytes, m)
+}
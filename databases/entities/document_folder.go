@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+type DocumentFolder struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	SpaceID   uint            `gorm:"not null;index" json:"space_id"`
+	ParentID  *uint           `gorm:"index" json:"parent_id"`
+	Name      string          `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	Space     *Space          `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Parent    *DocumentFolder `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+func (f DocumentFolder) GetIdType() string {
+	return "uint"
+}
@@ -0,0 +1,16 @@
+package entities
+
+// DocumentTextChunk is a piece of the plain text extracted from the current
+// version of a document. The table has a generated full-text search vector
+// over Content.
+type DocumentTextChunk struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DocumentID uint      `gorm:"not null;index" json:"document_id"`
+	ChunkIndex int       `gorm:"not null" json:"chunk_index"`
+	Content    string    `gorm:"type:text;not null" json:"content"`
+	Document   *Document `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (c DocumentTextChunk) GetIdType() string {
+	return "uint"
+}
@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+type DocumentVersion struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	DocumentID  uint             `gorm:"not null;index" json:"document_id"`
+	Version     int              `gorm:"not null" json:"version"`
+	Name        string           `gorm:"type:varchar(255)" json:"name"`
+	MimeType    string           `gorm:"column:mime_type;type:varchar(255)" json:"mime_type"`
+	Size        int64            `gorm:"column:size;not null" json:"size"`
+	S3URL       string           `gorm:"not null" json:"-"`
+	ContentHash string           `gorm:"type:varchar(64);index" json:"content_hash,omitempty"`
+	Metadata    DocumentMetadata `gorm:"type:jsonb" json:"metadata"`
+	UploadedBy  *uint            `gorm:"index" json:"uploaded_by"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	Uploader    *User            `gorm:"foreignKey:UploadedBy;constraint:OnDelete:SET NULL;" json:"uploader,omitempty"`
+	Document    *Document        `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	DocumentScan
+}
+
+func (v DocumentVersion) GetIdType() string {
+	return "uint"
+}
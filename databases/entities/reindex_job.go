@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+const (
+	ReindexJobPending   = "pending"
+	ReindexJobRunning   = "running"
+	ReindexJobCompleted = "completed"
+	ReindexJobFailed    = "failed"
+)
+
+// ReindexJob pushes stored documents to the RAG server again. A job covers a
+// single document, a space or, without either, every document.
+type ReindexJob struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	SpaceID    *uint      `gorm:"index" json:"space_id"`
+	DocumentID *uint      `gorm:"index" json:"document_id"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Total      int        `gorm:"not null;default:0" json:"total"`
+	Processed  int        `gorm:"not null;default:0" json:"processed"`
+	Failed     int        `gorm:"not null;default:0" json:"failed"`
+	LastError  string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedBy  *uint      `json:"created_by"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (j ReindexJob) GetIdType() string {
+	return "uint"
+}
+
+func (j ReindexJob) IsActive() bool {
+	return j.Status == ReindexJobPending || j.Status == ReindexJobRunning
+}
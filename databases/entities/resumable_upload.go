@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// ResumableUpload tracks a chunked upload. The received bytes are stored as
+// numbered chunk objects until the upload completes and becomes a document.
+type ResumableUpload struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	SpaceID     uint               `gorm:"not null;index" json:"space_id"`
+	UserID      uint               `gorm:"not null;index" json:"user_id"`
+	Filename    string             `gorm:"type:varchar(255);not null" json:"filename"`
+	MimeType    string             `gorm:"type:varchar(255)" json:"mime_type"`
+	Description string             `gorm:"type:text" json:"description"`
+	FolderID    *uint              `json:"folder_id"`
+	Tags        DocumentTags       `gorm:"type:jsonb;not null;default:'[]'" json:"tags"`
+	Replace     bool               `gorm:"not null;default:false" json:"replace"`
+	Visibility  DocumentVisibility `gorm:"type:varchar(20)" json:"visibility"`
+	Length      int64              `gorm:"not null" json:"length"`
+	Offset      int64              `gorm:"not null;default:0" json:"offset"`
+	ChunkCount  int                `gorm:"not null;default:0" json:"chunk_count"`
+	DocumentID  *uint              `json:"document_id"`
+	ExpiresAt   time.Time          `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time          `gorm:"autoUpdateTime" json:"updated_at"`
+	Space       *Space             `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (u ResumableUpload) GetIdType() string {
+	return "uint"
+}
@@ -1,23 +1,61 @@
 package entities
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Space struct {
-	ID              uint               `gorm:"primaryKey" json:"id"`
-	Name            string             `gorm:"type:varchar(255);not null" json:"name"`
-	Description     string             `gorm:"type:text" json:"description"`
-	PrivacyStatus   bool               `json:"privacy_status"`
-	SystemPrompt    string             `gorm:"type:varchar(1024);default:'You are an AI assistant for answering questions about documents in this space. Provide helpful, accurate, and concise information based on the content available.'" json:"system_prompt"`
-	DocumentLimit   int                `json:"document_limit" gorm:"default:10"`
-	FileSizeLimitKb int                `json:"file_size_limit_kb" gorm:"default:5120"`
-	ApiCallLimit    int                `json:"api_call_limit" gorm:"default:100"`
-	CreatedAt       time.Time          `json:"created_at"`
-	UpdatedAt       time.Time          `json:"updated_at"`
-	Documents       []Document         `gorm:"foreignKey:SpaceID" json:"documents"`
-	Sessions        []UserQuerySession `gorm:"foreignKey:SpaceID" json:"sessions"`
-	UserCount       int                `json:"user_count" gorm:"-"`
+	ID                uint               `gorm:"primaryKey" json:"id"`
+	Name              string             `gorm:"type:varchar(255);not null" json:"name"`
+	Description       string             `gorm:"type:text" json:"description"`
+	PrivacyStatus     bool               `json:"privacy_status"`
+	AllowJoinRequests bool               `json:"allow_join_requests" gorm:"not null;default:false"`
+	SystemPrompt      string             `gorm:"type:varchar(1024);default:'You are an AI assistant for answering questions about documents in this space. Provide helpful, accurate, and concise information based on the content available.'" json:"system_prompt"`
+	DocumentLimit     int                `json:"document_limit" gorm:"default:10"`
+	FileSizeLimitKb   int                `json:"file_size_limit_kb" gorm:"default:5120"`
+	ApiCallLimit      int                `json:"api_call_limit" gorm:"default:100"`
+	AllowedFileTypes  FileTypeList       `gorm:"type:jsonb" json:"allowed_file_types"`
+	StorageLimitBytes int64              `json:"storage_limit_bytes" gorm:"not null;default:0"`
+	StorageUsedBytes  int64              `json:"storage_used_bytes" gorm:"->"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt     `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedBy         *uint              `json:"deleted_by,omitempty"`
+	Documents         []Document         `gorm:"foreignKey:SpaceID" json:"documents"`
+	Sessions          []UserQuerySession `gorm:"foreignKey:SpaceID" json:"sessions"`
+	UserCount         int                `json:"user_count" gorm:"-"`
 }
 
 func (s Space) GetIdType() string {
 	return "uint"
 }
+
+// FileTypeList holds the extensions that can be uploaded to a space, an empty
+// list allows every type.
+type FileTypeList []string
+
+func (l FileTypeList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+func (l *FileTypeList) Scan(value interface{}) error {
+	if value == nil {
+		*l = FileTypeList{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal file types")
+	}
+
+	return json.Unmarshal(bytes, l)
+}
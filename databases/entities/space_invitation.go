@@ -8,18 +8,25 @@ const (
 	InvitationStatusDeclined = "declined"
 )
 
+// SpaceInvitation invites a user to a space. People without an account are
+// invited by email address, the invitation is handed to their user once they
+// register with that address.
 type SpaceInvitation struct {
 	ID            uint       `json:"id"`
 	SpaceID       uint       `json:"space_id"`
 	Space         *Space     `json:"space" gorm:"foreignKey:SpaceID"`
 	SpaceRoleID   uint       `json:"space_role_id"`
 	SpaceRole     *SpaceRole `json:"space_role" gorm:"foreignKey:SpaceRoleID"`
-	InvitedUserID uint       `json:"invited_user_id"`
+	InvitedUserID *uint      `json:"invited_user_id"`
 	InvitedUser   *User      `json:"invited_user" gorm:"foreignKey:InvitedUserID"`
+	InvitedEmail  *string    `json:"invited_email"`
 	InviterID     uint       `json:"inviter_id"`
 	Inviter       *User      `json:"inviter" gorm:"foreignKey:InviterID"`
 	Status        string     `json:"status"` // e.g., "pending", "accepted", "declined"
 	Message       string     `json:"message"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	LastSentAt    *time.Time `json:"last_sent_at"`
+	SendCount     int        `json:"send_count"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 }
@@ -27,3 +34,16 @@ type SpaceInvitation struct {
 func (s SpaceInvitation) GetIdType() string {
 	return "uint"
 }
+
+// IsForEmail reports whether the invitee has no account yet.
+func (s SpaceInvitation) IsForEmail() bool {
+	return s.InvitedUserID == nil
+}
+
+func (s SpaceInvitation) IsInvited(userID uint) bool {
+	return s.InvitedUserID != nil && *s.InvitedUserID == userID
+}
+
+func (s SpaceInvitation) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
@@ -2,12 +2,22 @@ package entities
 
 import "time"
 
+// SpaceInvitationLink is a shareable link to join a space with a role. The
+// link token only names the row, joining checks the row so that revoking,
+// expiring or using up a link takes effect immediately.
 type SpaceInvitationLink struct {
 	ID          uint       `json:"id" gorm:"primaryKey"`
 	SpaceID     uint       `json:"space_id"`
 	Space       *Space     `json:"space" gorm:"foreignKey:SpaceID"`
 	SpaceRoleID uint       `json:"space_role_id"`
 	SpaceRole   *SpaceRole `json:"space_role" gorm:"foreignKey:SpaceRoleID"`
+	Name        string     `json:"name"`
+	CreatedBy   *uint      `json:"created_by"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxUses     *int       `json:"max_uses"`
+	UseCount    int        `json:"use_count"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	RevokedBy   *uint      `json:"revoked_by"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -15,3 +25,35 @@ type SpaceInvitationLink struct {
 func (s SpaceInvitationLink) GetIdType() string {
 	return "uint"
 }
+
+// UnusableReason returns why the link cannot be used to join at now, or an
+// empty string when it can.
+func (s SpaceInvitationLink) UnusableReason(now time.Time) string {
+	switch {
+	case s.RevokedAt != nil:
+		return "invitation link has been revoked"
+	case s.ExpiresAt != nil && !now.Before(*s.ExpiresAt):
+		return "invitation link has expired"
+	case s.MaxUses != nil && s.UseCount >= *s.MaxUses:
+		return "invitation link has reached its maximum number of uses"
+	}
+	return ""
+}
+
+// SpaceInvitationLinkUse records who joined a space through which link.
+type SpaceInvitationLinkUse struct {
+	ID                    uint      `json:"id" gorm:"primaryKey"`
+	SpaceInvitationLinkID uint      `json:"space_invitation_link_id"`
+	SpaceID               uint      `json:"space_id"`
+	UserID                uint      `json:"user_id"`
+	User                  *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+func (s SpaceInvitationLinkUse) GetIdType() string {
+	return "uint"
+}
+
+// DefaultInvitationLinkName names the link kept by the single link endpoint
+// that predates named links.
+const DefaultInvitationLinkName = "Default link"
@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+const (
+	JoinRequestPending   = "pending"
+	JoinRequestApproved  = "approved"
+	JoinRequestRejected  = "rejected"
+	JoinRequestCancelled = "cancelled"
+)
+
+// SpaceJoinRequest is a user's request to join a private space that accepts
+// requests. SpaceRoleID is the role granted when the request is approved.
+type SpaceJoinRequest struct {
+	ID          uint       `json:"id"`
+	SpaceID     uint       `json:"space_id"`
+	Space       *Space     `json:"space,omitempty" gorm:"foreignKey:SpaceID"`
+	UserID      uint       `json:"user_id"`
+	User        *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Message     string     `json:"message"`
+	Status      string     `json:"status"`
+	SpaceRoleID *uint      `json:"space_role_id"`
+	SpaceRole   *SpaceRole `json:"space_role,omitempty" gorm:"foreignKey:SpaceRoleID"`
+	ReviewedBy  *uint      `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (r SpaceJoinRequest) GetIdType() string {
+	return "uint"
+}
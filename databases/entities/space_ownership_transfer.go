@@ -0,0 +1,31 @@
+package entities
+
+import "time"
+
+const (
+	OwnershipTransferPending   = "pending"
+	OwnershipTransferAccepted  = "accepted"
+	OwnershipTransferDeclined  = "declined"
+	OwnershipTransferCancelled = "cancelled"
+)
+
+// SpaceOwnershipTransfer is an owner's proposal to make another member an
+// owner of the space. The proposer stays a co-owner when KeepOwnership is set
+// and becomes an editor otherwise, once the target accepts.
+type SpaceOwnershipTransfer struct {
+	ID            uint      `json:"id"`
+	SpaceID       uint      `json:"space_id"`
+	Space         *Space    `json:"space,omitempty" gorm:"foreignKey:SpaceID"`
+	FromUserID    uint      `json:"from_user_id"`
+	FromUser      *User     `json:"from_user,omitempty" gorm:"foreignKey:FromUserID"`
+	ToUserID      uint      `json:"to_user_id"`
+	ToUser        *User     `json:"to_user,omitempty" gorm:"foreignKey:ToUserID"`
+	KeepOwnership bool      `json:"keep_ownership"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (t SpaceOwnershipTransfer) GetIdType() string {
+	return "uint"
+}
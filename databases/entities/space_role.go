@@ -2,12 +2,19 @@ package entities
 
 import "time"
 
+// SpaceRole is either one of the built-in roles shared by every space, with a
+// nil SpaceID, or a custom role created by the owners of a single space.
 type SpaceRole struct {
-	ID         uint      `json:"id"`
-	Name       string    `json:"name"`
-	Permission uint      `json:"permission"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         uint            `json:"id"`
+	SpaceID    *uint           `json:"space_id"`
+	Name       string          `json:"name"`
+	Permission SpacePermission `json:"permission"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func (r SpaceRole) GetIdType() string {
+	return "uint"
 }
 
 func (r SpaceRole) IsOwner() bool {
@@ -22,8 +29,91 @@ func (r SpaceRole) IsViewer() bool {
 	return r.ID == SpaceRoleViewer
 }
 
+// IsCustom reports whether r belongs to a single space.
+func (r SpaceRole) IsCustom() bool {
+	return r.SpaceID != nil
+}
+
+// HasPermission reports whether r grants every bit of permission.
+func (r SpaceRole) HasPermission(permission SpacePermission) bool {
+	return r.Permission&permission == permission
+}
+
 const (
 	SpaceRoleOwner  = 1
 	SpaceRoleEditor = 2
 	SpaceRoleViewer = 3
 )
+
+// SpacePermission is a bitmask of the actions a space role allows.
+type SpacePermission uint
+
+const (
+	SpacePermissionManageMembers SpacePermission = 1 << iota
+	SpacePermissionManageSettings
+	SpacePermissionUploadDocuments
+	SpacePermissionDeleteDocuments
+	SpacePermissionManageAPIKeys
+	SpacePermissionChat
+	SpacePermissionViewAnalytics
+	SpacePermissionExport
+
+	SpacePermissionNone SpacePermission = 0
+	SpacePermissionAll                  = SpacePermissionManageMembers | SpacePermissionManageSettings |
+		SpacePermissionUploadDocuments | SpacePermissionDeleteDocuments | SpacePermissionManageAPIKeys |
+		SpacePermissionChat | SpacePermissionViewAnalytics | SpacePermissionExport
+)
+
+// Permissions of the built-in roles, the migration that introduced the bits
+// stores the same values.
+const (
+	SpacePermissionsOwner  = SpacePermissionAll
+	SpacePermissionsEditor = SpacePermissionUploadDocuments | SpacePermissionDeleteDocuments | SpacePermissionChat
+	SpacePermissionsViewer = SpacePermissionChat
+)
+
+var spacePermissionNames = map[string]SpacePermission{
+	"manage_members":   SpacePermissionManageMembers,
+	"manage_settings":  SpacePermissionManageSettings,
+	"upload_documents": SpacePermissionUploadDocuments,
+	"delete_documents": SpacePermissionDeleteDocuments,
+	"manage_api_keys":  SpacePermissionManageAPIKeys,
+	"chat":             SpacePermissionChat,
+	"view_analytics":   SpacePermissionViewAnalytics,
+	"export":           SpacePermissionExport,
+}
+
+// ParseSpacePermissions combines named permissions into a bitmask and reports
+// whether every name is known.
+func ParseSpacePermissions(names []string) (SpacePermission, bool) {
+	var permission SpacePermission
+	for _, name := range names {
+		bit, ok := spacePermissionNames[name]
+		if !ok {
+			return SpacePermissionNone, false
+		}
+		permission |= bit
+	}
+	return permission, true
+}
+
+// Names returns the names of the bits set in p, in bit order.
+func (p SpacePermission) Names() []string {
+	names := []string{}
+	for bit := SpacePermissionManageMembers; bit <= SpacePermissionExport; bit <<= 1 {
+		if p&bit == 0 {
+			continue
+		}
+		for name, value := range spacePermissionNames {
+			if value == bit {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// IsValid reports whether p only contains known bits.
+func (p SpacePermission) IsValid() bool {
+	return p&^SpacePermissionAll == 0
+}
@@ -0,0 +1,86 @@
+package entities
+
+import "time"
+
+// SpaceTemplate holds the settings and baseline documents of a space, saved
+// by a user to create similar spaces later, e.g. every semester.
+type SpaceTemplate struct {
+	ID                uint                    `gorm:"primaryKey" json:"id"`
+	UserID            uint                    `gorm:"not null;index" json:"user_id"`
+	Name              string                  `gorm:"type:varchar(255);not null" json:"name"`
+	Description       string                  `gorm:"type:text" json:"description"`
+	SpaceName         string                  `gorm:"type:varchar(255);not null" json:"space_name"`
+	SpaceDescription  string                  `gorm:"type:text" json:"space_description"`
+	PrivacyStatus     bool                    `json:"privacy_status"`
+	AllowJoinRequests bool                    `json:"allow_join_requests"`
+	SystemPrompt      string                  `gorm:"type:varchar(1024)" json:"system_prompt"`
+	DocumentLimit     int                     `json:"document_limit"`
+	FileSizeLimitKb   int                     `json:"file_size_limit_kb"`
+	ApiCallLimit      int                     `json:"api_call_limit"`
+	AllowedFileTypes  FileTypeList            `gorm:"type:jsonb" json:"allowed_file_types"`
+	StorageLimitBytes int64                   `gorm:"not null;default:0" json:"storage_limit_bytes"`
+	SourceSpaceID     *uint                   `json:"source_space_id"`
+	Documents         []SpaceTemplateDocument `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE;" json:"documents"`
+	CreatedAt         time.Time               `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time               `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (t SpaceTemplate) GetIdType() string {
+	return "uint"
+}
+
+// NewSpace returns a space with the settings of the template.
+func (t SpaceTemplate) NewSpace() *Space {
+	return &Space{
+		Name:              t.SpaceName,
+		Description:       t.SpaceDescription,
+		PrivacyStatus:     t.PrivacyStatus,
+		AllowJoinRequests: t.AllowJoinRequests,
+		SystemPrompt:      t.SystemPrompt,
+		DocumentLimit:     t.DocumentLimit,
+		FileSizeLimitKb:   t.FileSizeLimitKb,
+		ApiCallLimit:      t.ApiCallLimit,
+		AllowedFileTypes:  t.AllowedFileTypes,
+		StorageLimitBytes: t.StorageLimitBytes,
+	}
+}
+
+// SpaceTemplateDocument is a baseline document of a template. It holds a
+// reference on the blob of the file, so that the file outlives the document
+// it was saved from.
+type SpaceTemplateDocument struct {
+	ID            uint               `gorm:"primaryKey" json:"id"`
+	TemplateID    uint               `gorm:"not null;index" json:"template_id"`
+	Name          string             `gorm:"type:varchar(255)" json:"name"`
+	Description   string             `gorm:"type:varchar(8192)" json:"description"`
+	MimeType      string             `gorm:"column:mime_type;type:varchar(255)" json:"mime_type"`
+	Size          int64              `gorm:"not null" json:"size"`
+	S3URL         string             `gorm:"not null" json:"-"`
+	ContentHash   string             `gorm:"type:varchar(64);not null" json:"content_hash"`
+	PrivacyStatus DocumentVisibility `gorm:"type:varchar(20);not null;default:'members'" json:"privacy_status"`
+	Tags          DocumentTags       `gorm:"type:jsonb" json:"tags"`
+	Metadata      DocumentMetadata   `gorm:"type:jsonb" json:"metadata"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (d SpaceTemplateDocument) GetIdType() string {
+	return "uint"
+}
+
+// NewDocument returns a document of the space with the file of the template
+// document.
+func (d SpaceTemplateDocument) NewDocument(spaceID uint, uploadedBy uint) *Document {
+	return &Document{
+		SpaceID:       spaceID,
+		Name:          d.Name,
+		Description:   d.Description,
+		MimeType:      d.MimeType,
+		Size:          d.Size,
+		S3URL:         d.S3URL,
+		ContentHash:   d.ContentHash,
+		PrivacyStatus: d.PrivacyStatus,
+		Tags:          d.Tags,
+		Metadata:      d.Metadata,
+		UploadedBy:    &uploadedBy,
+	}
+}
@@ -9,6 +9,7 @@ type Tier struct {
 	QueryHistoryLimit int       `json:"query_history_limit" gorm:"not null"`
 	QueryLimit        int       `json:"query_limit" gorm:"not null"`
 	FileSizeLimitKb   int       `json:"file_size_limit_kb" gorm:"default:5120"`
+	StorageLimitBytes int64     `json:"storage_limit_bytes" gorm:"not null;default:0"`
 	ApiCallLimit      int       `json:"api_call_limit" gorm:"default:100"`
 	CostMonth         float64   `json:"cost_month" gorm:"type:decimal(10,2);not null"`
 	Discount          float64   `json:"discount" gorm:"type:decimal(5,2);default:0"`
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE document_versions (
+    id SERIAL PRIMARY KEY,
+    document_id INT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    name VARCHAR(255),
+    mime_type VARCHAR(255),
+    size BIGINT NOT NULL DEFAULT 0,
+    s3_url TEXT NOT NULL,
+    uploaded_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uniq_document_version UNIQUE (document_id, version)
+);
+
+CREATE INDEX idx_document_versions_document_id ON document_versions(document_id);
+CREATE INDEX idx_document_versions_uploaded_by ON document_versions(uploaded_by);
+
+ALTER TABLE documents ADD COLUMN current_version INT NOT NULL DEFAULT 1;
+
+-- Every existing document becomes version 1 of itself
+INSERT INTO document_versions (document_id, version, name, mime_type, size, s3_url, created_at)
+SELECT id, 1, name, mime_type, size, s3_url, created_at FROM documents;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE documents DROP COLUMN current_version;
+DROP TABLE document_versions;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN source_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE documents ADD COLUMN refresh_interval_hours INT NOT NULL DEFAULT 0;
+ALTER TABLE documents ADD COLUMN last_fetched_at TIMESTAMP;
+
+CREATE INDEX idx_documents_refresh ON documents(last_fetched_at) WHERE refresh_interval_hours > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_refresh;
+ALTER TABLE documents DROP COLUMN last_fetched_at;
+ALTER TABLE documents DROP COLUMN refresh_interval_hours;
+ALTER TABLE documents DROP COLUMN source_url;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE document_folders (
+    id SERIAL PRIMARY KEY,
+    space_id INT NOT NULL REFERENCES spaces(id) ON DELETE CASCADE,
+    parent_id INT REFERENCES document_folders(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_document_folders_space_id ON document_folders(space_id);
+CREATE INDEX idx_document_folders_parent_id ON document_folders(parent_id);
+-- Sibling folders must have distinct names, root folders included
+CREATE UNIQUE INDEX uniq_document_folders_sibling_name
+    ON document_folders(space_id, COALESCE(parent_id, 0), LOWER(name));
+
+ALTER TABLE documents ADD COLUMN folder_id INT REFERENCES document_folders(id) ON DELETE SET NULL;
+ALTER TABLE documents ADD COLUMN tags JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX idx_documents_folder_id ON documents(folder_id);
+CREATE INDEX idx_documents_tags ON documents USING GIN (tags);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_tags;
+DROP INDEX IF EXISTS idx_documents_folder_id;
+ALTER TABLE documents DROP COLUMN tags;
+ALTER TABLE documents DROP COLUMN folder_id;
+DROP TABLE document_folders;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE document_versions ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_documents_metadata ON documents USING GIN (metadata);
+CREATE INDEX idx_documents_metadata_language ON documents ((metadata->>'language'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_metadata_language;
+DROP INDEX IF EXISTS idx_documents_metadata;
+ALTER TABLE document_versions DROP COLUMN metadata;
+ALTER TABLE documents DROP COLUMN metadata;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE blobs (
+    id SERIAL PRIMARY KEY,
+    content_hash VARCHAR(64) NOT NULL UNIQUE,
+    s3_url TEXT NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    ref_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Files stored before deduplication keep an empty hash and their own object
+ALTER TABLE documents ADD COLUMN content_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE document_versions ADD COLUMN content_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_documents_space_content_hash ON documents(space_id, content_hash) WHERE content_hash <> '';
+CREATE INDEX idx_document_versions_content_hash ON document_versions(content_hash) WHERE content_hash <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_document_versions_content_hash;
+DROP INDEX IF EXISTS idx_documents_space_content_hash;
+ALTER TABLE document_versions DROP COLUMN content_hash;
+ALTER TABLE documents DROP COLUMN content_hash;
+DROP TABLE blobs;
+-- +goose StatementEnd
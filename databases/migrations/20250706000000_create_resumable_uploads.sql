@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE resumable_uploads (
+    id SERIAL PRIMARY KEY,
+    space_id INT NOT NULL REFERENCES spaces(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(255),
+    description TEXT,
+    folder_id INT,
+    tags JSONB NOT NULL DEFAULT '[]',
+    replace BOOLEAN NOT NULL DEFAULT FALSE,
+    length BIGINT NOT NULL,
+    "offset" BIGINT NOT NULL DEFAULT 0,
+    chunk_count INT NOT NULL DEFAULT 0,
+    document_id INT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_resumable_uploads_space_id ON resumable_uploads(space_id);
+CREATE INDEX idx_resumable_uploads_user_id ON resumable_uploads(user_id);
+CREATE INDEX idx_resumable_uploads_expires_at ON resumable_uploads(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE resumable_uploads;
+-- +goose StatementEnd
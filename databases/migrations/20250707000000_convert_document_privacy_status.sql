@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The flag was never enforced, so every document keeps being visible to all members
+ALTER TABLE documents ALTER COLUMN privacy_status DROP DEFAULT;
+ALTER TABLE documents ALTER COLUMN privacy_status TYPE VARCHAR(20) USING 'members';
+ALTER TABLE documents ALTER COLUMN privacy_status SET DEFAULT 'members';
+ALTER TABLE documents ALTER COLUMN privacy_status SET NOT NULL;
+
+CREATE INDEX idx_documents_space_privacy_status ON documents(space_id, privacy_status);
+
+ALTER TABLE resumable_uploads ADD COLUMN visibility VARCHAR(20);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE resumable_uploads DROP COLUMN visibility;
+DROP INDEX IF EXISTS idx_documents_space_privacy_status;
+ALTER TABLE documents ALTER COLUMN privacy_status DROP NOT NULL;
+ALTER TABLE documents ALTER COLUMN privacy_status DROP DEFAULT;
+ALTER TABLE documents ALTER COLUMN privacy_status TYPE BOOLEAN USING privacy_status <> 'members';
+ALTER TABLE documents ALTER COLUMN privacy_status SET DEFAULT TRUE;
+-- +goose StatementEnd
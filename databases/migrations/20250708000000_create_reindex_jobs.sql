@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reindex_jobs (
+    id SERIAL PRIMARY KEY,
+    space_id INT REFERENCES spaces(id) ON DELETE CASCADE,
+    document_id INT REFERENCES documents(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reindex_jobs_space_id ON reindex_jobs(space_id);
+CREATE INDEX idx_reindex_jobs_document_id ON reindex_jobs(document_id);
+CREATE INDEX idx_reindex_jobs_status ON reindex_jobs(status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reindex_jobs;
+-- +goose StatementEnd
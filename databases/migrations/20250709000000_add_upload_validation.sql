@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE spaces ADD COLUMN allowed_file_types JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE documents ADD COLUMN scan_status VARCHAR(20) NOT NULL DEFAULT 'not_scanned';
+ALTER TABLE documents ADD COLUMN scan_engine VARCHAR(64);
+ALTER TABLE documents ADD COLUMN scanned_at TIMESTAMP;
+
+ALTER TABLE document_versions ADD COLUMN scan_status VARCHAR(20) NOT NULL DEFAULT 'not_scanned';
+ALTER TABLE document_versions ADD COLUMN scan_engine VARCHAR(64);
+ALTER TABLE document_versions ADD COLUMN scanned_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE document_versions DROP COLUMN scanned_at;
+ALTER TABLE document_versions DROP COLUMN scan_engine;
+ALTER TABLE document_versions DROP COLUMN scan_status;
+
+ALTER TABLE documents DROP COLUMN scanned_at;
+ALTER TABLE documents DROP COLUMN scan_engine;
+ALTER TABLE documents DROP COLUMN scan_status;
+
+ALTER TABLE spaces DROP COLUMN allowed_file_types;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE documents ADD COLUMN deleted_by INT REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_documents_deleted_at ON documents(deleted_at);
+
+ALTER TABLE spaces ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE spaces ADD COLUMN deleted_by INT REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_spaces_deleted_at ON spaces(deleted_at);
+
+ALTER TABLE user_query_sessions ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX idx_user_query_sessions_deleted_at ON user_query_sessions(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_query_sessions_deleted_at;
+ALTER TABLE user_query_sessions DROP COLUMN deleted_at;
+
+DROP INDEX idx_spaces_deleted_at;
+ALTER TABLE spaces DROP COLUMN deleted_by;
+ALTER TABLE spaces DROP COLUMN deleted_at;
+
+DROP INDEX idx_documents_deleted_at;
+ALTER TABLE documents DROP COLUMN deleted_by;
+ALTER TABLE documents DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE documents ADD COLUMN uploaded_by INT REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_documents_uploaded_by ON documents(uploaded_by);
+
+-- Documents uploaded so far are attributed to the uploader of their first version
+UPDATE documents SET uploaded_by = document_versions.uploaded_by
+FROM document_versions
+WHERE document_versions.document_id = documents.id AND document_versions.version = 1;
+
+CREATE INDEX idx_documents_space_id_created_at ON documents(space_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_documents_space_id_created_at;
+
+DROP INDEX idx_documents_uploaded_by;
+ALTER TABLE documents DROP COLUMN uploaded_by;
+-- +goose StatementEnd
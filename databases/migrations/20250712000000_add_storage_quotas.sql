@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tiers ADD COLUMN storage_limit_bytes BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE spaces ADD COLUMN storage_limit_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE spaces ADD COLUMN storage_used_bytes BIGINT NOT NULL DEFAULT 0;
+
+UPDATE spaces SET storage_used_bytes = usage.total
+FROM (
+    SELECT space_id, SUM(size) AS total
+    FROM documents
+    WHERE deleted_at IS NULL
+    GROUP BY space_id
+) AS usage
+WHERE usage.space_id = spaces.id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE spaces DROP COLUMN storage_used_bytes;
+ALTER TABLE spaces DROP COLUMN storage_limit_bytes;
+
+ALTER TABLE tiers DROP COLUMN storage_limit_bytes;
+-- +goose StatementEnd
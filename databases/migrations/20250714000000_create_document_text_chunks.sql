@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE document_text_chunks (
+    id SERIAL PRIMARY KEY,
+    document_id INT NOT NULL REFERENCES documents(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    chunk_index INT NOT NULL,
+    content TEXT NOT NULL,
+    -- The simple configuration does not stem, which keeps Vietnamese and
+    -- English text searchable with the same index
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED,
+    UNIQUE (document_id, chunk_index)
+);
+
+CREATE INDEX idx_document_text_chunks_search_vector ON document_text_chunks USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE document_text_chunks;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE space_roles ADD COLUMN space_id INT REFERENCES spaces(id) ON DELETE CASCADE;
+ALTER TABLE space_roles DROP CONSTRAINT IF EXISTS space_roles_name_key;
+CREATE UNIQUE INDEX idx_space_roles_space_name ON space_roles (COALESCE(space_id, 0), name);
+
+-- Built-in roles keep what they allowed before the permission bits existed:
+-- owner everything, editor uploads, deletes and chats, viewer only chats
+UPDATE space_roles SET permission = 255 WHERE id = 1;
+UPDATE space_roles SET permission = 44 WHERE id = 2;
+UPDATE space_roles SET permission = 32 WHERE id = 3;
+
+-- The built-in roles are inserted with explicit ids, custom roles must not collide
+SELECT setval(pg_get_serial_sequence('space_roles', 'id'), GREATEST((SELECT MAX(id) FROM space_roles), 3));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM space_roles WHERE space_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_space_roles_space_name;
+ALTER TABLE space_roles DROP COLUMN space_id;
+ALTER TABLE space_roles ADD CONSTRAINT space_roles_name_key UNIQUE (name);
+UPDATE space_roles SET permission = 0;
+-- +goose StatementEnd
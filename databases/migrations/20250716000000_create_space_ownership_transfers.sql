@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE space_ownership_transfers (
+    id SERIAL PRIMARY KEY,
+    space_id INT NOT NULL REFERENCES spaces(id) ON DELETE CASCADE,
+    from_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    keep_ownership BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A member has at most one open proposal per space
+CREATE UNIQUE INDEX idx_space_ownership_transfers_pending
+    ON space_ownership_transfers (space_id, to_user_id)
+    WHERE status = 'pending';
+CREATE INDEX idx_space_ownership_transfers_to_user ON space_ownership_transfers (to_user_id, status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE space_ownership_transfers;
+-- +goose StatementEnd
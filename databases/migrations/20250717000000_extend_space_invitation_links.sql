@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE space_invitation_links
+    ADD COLUMN name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN expires_at TIMESTAMP,
+    ADD COLUMN max_uses INT CHECK (max_uses > 0),
+    ADD COLUMN use_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN revoked_at TIMESTAMP,
+    ADD COLUMN revoked_by INT REFERENCES users(id) ON DELETE SET NULL;
+
+-- Tokens issued before links were checked against the database carry no link
+-- id and are rejected, the existing rows stay listed as the default links
+UPDATE space_invitation_links SET name = 'Default link' WHERE name = '';
+
+CREATE INDEX idx_space_invitation_links_space ON space_invitation_links (space_id);
+
+CREATE TABLE space_invitation_link_uses (
+    id SERIAL PRIMARY KEY,
+    space_invitation_link_id INT NOT NULL REFERENCES space_invitation_links(id) ON DELETE CASCADE,
+    space_id INT NOT NULL REFERENCES spaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_space_invitation_link_uses_link ON space_invitation_link_uses (space_invitation_link_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE space_invitation_link_uses;
+DROP INDEX IF EXISTS idx_space_invitation_links_space;
+ALTER TABLE space_invitation_links
+    DROP COLUMN name,
+    DROP COLUMN created_by,
+    DROP COLUMN expires_at,
+    DROP COLUMN max_uses,
+    DROP COLUMN use_count,
+    DROP COLUMN revoked_at,
+    DROP COLUMN revoked_by;
+-- +goose StatementEnd
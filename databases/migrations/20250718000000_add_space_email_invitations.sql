@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE space_invitations
+    ADD COLUMN invited_email VARCHAR(255),
+    ADD COLUMN expires_at TIMESTAMP,
+    ADD COLUMN last_sent_at TIMESTAMP,
+    ADD COLUMN send_count INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT space_invitations_invitee_check CHECK (invited_user_id IS NOT NULL OR invited_email IS NOT NULL);
+
+-- Invitations for people without an account are keyed by their address until
+-- they register, the existing invitations never expire
+CREATE UNIQUE INDEX uniq_email_space_invitation ON space_invitations (space_id, LOWER(invited_email)) WHERE invited_user_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM space_invitations WHERE invited_user_id IS NULL;
+DROP INDEX IF EXISTS uniq_email_space_invitation;
+ALTER TABLE space_invitations
+    DROP CONSTRAINT space_invitations_invitee_check,
+    DROP COLUMN invited_email,
+    DROP COLUMN expires_at,
+    DROP COLUMN last_sent_at,
+    DROP COLUMN send_count;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE spaces ADD COLUMN allow_join_requests BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE space_join_requests (
+    id SERIAL PRIMARY KEY,
+    space_id INT NOT NULL REFERENCES spaces(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    space_role_id INT REFERENCES space_roles(id) ON DELETE SET NULL,
+    reviewed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A user has at most one open request per space
+CREATE UNIQUE INDEX idx_space_join_requests_pending
+    ON space_join_requests (space_id, user_id)
+    WHERE status = 'pending';
+CREATE INDEX idx_space_join_requests_user ON space_join_requests (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE space_join_requests;
+ALTER TABLE spaces DROP COLUMN allow_join_requests;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE space_templates (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    space_name VARCHAR(255) NOT NULL,
+    space_description TEXT NOT NULL DEFAULT '',
+    privacy_status BOOLEAN NOT NULL DEFAULT FALSE,
+    allow_join_requests BOOLEAN NOT NULL DEFAULT FALSE,
+    system_prompt VARCHAR(1024) NOT NULL DEFAULT '',
+    document_limit INT NOT NULL DEFAULT 10,
+    file_size_limit_kb INT NOT NULL DEFAULT 5120,
+    api_call_limit INT NOT NULL DEFAULT 100,
+    allowed_file_types JSONB NOT NULL DEFAULT '[]',
+    storage_limit_bytes BIGINT NOT NULL DEFAULT 0,
+    source_space_id INT REFERENCES spaces(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_space_templates_user ON space_templates (user_id);
+
+-- Template documents hold a reference on their blob like document versions
+CREATE TABLE space_template_documents (
+    id SERIAL PRIMARY KEY,
+    template_id INT NOT NULL REFERENCES space_templates(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    description VARCHAR(8192) NOT NULL DEFAULT '',
+    mime_type VARCHAR(255) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL,
+    s3_url TEXT NOT NULL,
+    content_hash VARCHAR(64) NOT NULL,
+    privacy_status VARCHAR(20) NOT NULL DEFAULT 'members',
+    tags JSONB,
+    metadata JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_space_template_documents_template ON space_template_documents (template_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE space_template_documents;
+DROP TABLE space_templates;
+-- +goose StatementEnd
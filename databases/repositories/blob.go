@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlobRepository interface {
+	ICrudRepository[entities.Blob, uint]
+	GetByContentHash(contentHash string) (*entities.Blob, error)
+	Acquire(contentHash string, s3URL string, size int64) (*entities.Blob, error)
+	Release(contentHash string, onUnreferenced func(blob *entities.Blob) error) error
+	GetAllS3URLs() ([]string, error)
+}
+
+type blobRepositoryImpl struct {
+	*CrudRepository[entities.Blob, uint]
+}
+
+func NewBlobRepository() BlobRepository {
+	return &blobRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.Blob, uint](),
+	}
+}
+
+func (r *blobRepositoryImpl) GetByContentHash(contentHash string) (*entities.Blob, error) {
+	var blob entities.Blob
+	db := databases.GetDB()
+	if err := db.Where("content_hash = ?", contentHash).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// Acquire adds a reference to the blob with the given hash, creating it when it
+// does not exist yet. A returned RefCount of 1 means the caller holds the only
+// reference and must make sure the object is stored.
+func (r *blobRepositoryImpl) Acquire(contentHash string, s3URL string, size int64) (*entities.Blob, error) {
+	blob := entities.Blob{
+		ContentHash: contentHash,
+		S3URL:       s3URL,
+		Size:        size,
+		RefCount:    1,
+	}
+
+	db := databases.GetDB()
+	err := db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "content_hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"ref_count":  gorm.Expr("blobs.ref_count + 1"),
+				"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+			}),
+		},
+		clause.Returning{},
+	).Create(&blob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// Release drops one reference. When none are left, onUnreferenced runs while
+// the row is still locked so a concurrent Acquire cannot reuse a blob whose
+// object is being deleted; the row is only removed if it succeeds.
+func (r *blobRepositoryImpl) Release(contentHash string, onUnreferenced func(blob *entities.Blob) error) error {
+	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var blob entities.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("content_hash = ?", contentHash).
+			First(&blob).Error
+		if err != nil {
+			return err
+		}
+
+		blob.RefCount--
+		if blob.RefCount > 0 {
+			return tx.Model(&blob).UpdateColumn("ref_count", blob.RefCount).Error
+		}
+
+		if err := onUnreferenced(&blob); err != nil {
+			return err
+		}
+		return tx.Delete(&blob).Error
+	})
+}
+
+func (r *blobRepositoryImpl) GetAllS3URLs() ([]string, error) {
+	urls := []string{}
+	db := databases.GetDB()
+	err := db.Model(&entities.Blob{}).Pluck("s3_url", &urls).Error
+	return urls, err
+}
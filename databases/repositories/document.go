@@ -1,26 +1,179 @@
 package repositories
 
 import (
+	"encoding/json"
+	"strings"
+	"time"
+
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DocumentFilter narrows down the documents of a space. Zero values are ignored.
+type DocumentFilter struct {
+	SpaceID   uint
+	FolderIDs []uint
+	Unfiled   bool
+	Tags      []string
+	Title     string
+	Author    string
+	Language  string
+	MinPages  int
+	MaxPages  int
+	// Visibilities restricts the result to documents the caller may see
+	Visibilities []entities.DocumentVisibility
+	// Query is matched against the name and the description
+	Query string
+	// MimeType matches exactly, or a whole family when it ends in /*
+	MimeType         string
+	UploadedBy       *uint
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time
+	MinSize          int64
+	MaxSize          int64
+	ProcessingStatus *int
+	// SortBy is one of name, size, created_at and updated_at, name by default
+	SortBy   string
+	SortDesc bool
+}
+
+var documentSortColumns = map[string]string{
+	"name":       "name",
+	"size":       "size",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 type DocumentRepository interface {
 	ICrudRepository[entities.Document, uint]
+	ITrashRepository[entities.Document, uint]
+	SoftDelete(documentID uint, deletedBy *uint) error
+	GetDeleted(spaceID *uint, deletedBy *uint) ([]entities.Document, error)
+	GetAllIDsBySpaceID(spaceID uint) ([]uint, error)
 	GetBySpaceID(spaceID uint) ([]entities.Document, error)
+	GetByFilter(filter DocumentFilter) ([]entities.Document, error)
+	GetPageByFilter(filter DocumentFilter, page int, pageSize int) ([]entities.Document, int64, error)
+	GetBySpaceAndContentHash(spaceID uint, contentHash string) (*entities.Document, error)
+	GetVisibleToUser(userID uint, page int, pageSize int) ([]entities.Document, int64, error)
+	GetIDsByVisibility(spaceID uint, visibilities []entities.DocumentVisibility) ([]uint, error)
+	GetIDs(spaceID *uint) ([]uint, error)
+	UpdateVisibility(documentID uint, visibility entities.DocumentVisibility) error
+	GetTagsBySpaceID(spaceID uint) ([]string, error)
+	UpdateFolder(documentID uint, folderID *uint) error
+	UpdateSpace(documentID uint, spaceID uint, folderID *uint) error
+	UpdateTags(documentID uint, tags entities.DocumentTags) error
 	CountUserDocuments(userID uint) (int64, error)
+	CountBySpaceID(spaceID uint) (int64, error)
+	CreateWithVersion(document *entities.Document, version *entities.DocumentVersion) (*entities.Document, error)
+	AddVersion(documentID uint, version *entities.DocumentVersion) (*entities.Document, error)
+	GetDueForRefresh(now time.Time) ([]entities.Document, error)
+	GetIndexable() ([]entities.Document, error)
+	UpdateLastFetchedAt(documentID uint, fetchedAt time.Time) error
 }
 
 type documentRepositoryImpl struct {
 	*CrudRepository[entities.Document, uint]
+	*TrashRepository[entities.Document, uint]
 }
 
 func NewDocumentRepository() DocumentRepository {
 	return &documentRepositoryImpl{
-		CrudRepository: NewCrudRepository[entities.Document, uint](),
+		CrudRepository:  NewCrudRepository[entities.Document, uint](),
+		TrashRepository: &TrashRepository[entities.Document, uint]{},
 	}
 }
 
+// SoftDelete moves a document to the trash. Documents in the trash no longer
+// count towards the storage used by their space.
+func (r *documentRepositoryImpl) SoftDelete(documentID uint, deletedBy *uint) error {
+	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var document entities.Document
+		if err := tx.First(&document, documentID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&document).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&document).Error; err != nil {
+			return err
+		}
+		return addStorageUsage(tx, document.SpaceID, -document.Size)
+	})
+}
+
+func (r *documentRepositoryImpl) Restore(documentID uint) error {
+	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var document entities.Document
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&document, documentID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Model(&entities.Document{}).Where("id = ?", documentID).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return addStorageUsage(tx, document.SpaceID, document.Size)
+	})
+}
+
+// Purge deletes the document with its versions for good.
+func (r *documentRepositoryImpl) Purge(documentID uint) error {
+	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var document entities.Document
+		if err := tx.Unscoped().First(&document, documentID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Delete(&document).Error; err != nil {
+			return err
+		}
+		if document.DeletedAt.Valid {
+			return nil
+		}
+		return addStorageUsage(tx, document.SpaceID, -document.Size)
+	})
+}
+
+// GetDeleted lists the trash of a space, of a user or both. Documents of
+// spaces that are themselves in the trash are left out, they come back with
+// their space.
+func (r *documentRepositoryImpl) GetDeleted(spaceID *uint, deletedBy *uint) ([]entities.Document, error) {
+	db := databases.GetDB()
+	query := db.Unscoped().Model(&entities.Document{}).
+		Joins("JOIN spaces ON spaces.id = documents.space_id AND spaces.deleted_at IS NULL").
+		Where("documents.deleted_at IS NOT NULL")
+	if spaceID != nil {
+		query = query.Where("documents.space_id = ?", *spaceID)
+	}
+	if deletedBy != nil {
+		query = query.Where("documents.deleted_by = ?", *deletedBy)
+	}
+
+	documents := []entities.Document{}
+	err := query.Order("documents.deleted_at DESC").Find(&documents).Error
+	return documents, err
+}
+
+// GetAllIDsBySpaceID includes documents in the trash.
+func (r *documentRepositoryImpl) GetAllIDsBySpaceID(spaceID uint) ([]uint, error) {
+	ids := []uint{}
+	db := databases.GetDB()
+	err := db.Unscoped().Model(&entities.Document{}).
+		Where("space_id = ?", spaceID).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
 func (r *documentRepositoryImpl) GetBySpaceID(spaceID uint) ([]entities.Document, error) {
 	db := databases.GetDB()
 	documents := []entities.Document{}
@@ -31,15 +184,346 @@ func (r *documentRepositoryImpl) GetBySpaceID(spaceID uint) ([]entities.Document
 	return documents, nil
 }
 
+func (r *documentRepositoryImpl) GetByFilter(filter DocumentFilter) ([]entities.Document, error) {
+	query, err := filterDocuments(databases.GetDB(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := []entities.Document{}
+	if err := query.Order(documentOrder(filter)).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (r *documentRepositoryImpl) GetPageByFilter(filter DocumentFilter, page int, pageSize int) ([]entities.Document, int64, error) {
+	query, err := filterDocuments(databases.GetDB().Model(&entities.Document{}), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	pagination := NewPagination(page, pageSize, r.DefaultPageSize)
+	documents := []entities.Document{}
+	err = pagination.ApplyPagination(query).Order(documentOrder(filter)).Find(&documents).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return documents, total, nil
+}
+
+// documentOrder sorts by the requested column, ties are broken by ID so that
+// pages do not overlap.
+func documentOrder(filter DocumentFilter) string {
+	column, ok := documentSortColumns[filter.SortBy]
+	if !ok {
+		column = "name"
+	}
+
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+	return column + " " + direction + ", id " + direction
+}
+
+func filterDocuments(db *gorm.DB, filter DocumentFilter) (*gorm.DB, error) {
+	query := db.Where("space_id = ?", filter.SpaceID)
+
+	if filter.Unfiled {
+		query = query.Where("folder_id IS NULL")
+	} else if len(filter.FolderIDs) > 0 {
+		query = query.Where("folder_id IN ?", filter.FolderIDs)
+	}
+
+	if len(filter.Tags) > 0 {
+		tags, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("tags @> ?::jsonb", string(tags))
+	}
+
+	if filter.Title != "" {
+		query = query.Where("(metadata->>'title' ILIKE ? OR name ILIKE ?)", "%"+filter.Title+"%", "%"+filter.Title+"%")
+	}
+	if filter.Author != "" {
+		query = query.Where("metadata->>'author' ILIKE ?", "%"+filter.Author+"%")
+	}
+	if filter.Language != "" {
+		query = query.Where("metadata->>'language' = ?", filter.Language)
+	}
+
+	if len(filter.Visibilities) > 0 {
+		query = query.Where("privacy_status IN ?", filter.Visibilities)
+	}
+
+	// Slides count as pages so presentations can be filtered by length too
+	pages := "COALESCE((metadata->>'page_count')::int, (metadata->>'slide_count')::int, 0)"
+	if filter.MinPages > 0 {
+		query = query.Where(pages+" >= ?", filter.MinPages)
+	}
+	if filter.MaxPages > 0 {
+		query = query.Where(pages+" <= ?", filter.MaxPages)
+	}
+
+	if filter.Query != "" {
+		query = query.Where("(name ILIKE ? OR description ILIKE ?)", "%"+filter.Query+"%", "%"+filter.Query+"%")
+	}
+	if family, ok := strings.CutSuffix(filter.MimeType, "/*"); ok {
+		query = query.Where("mime_type LIKE ?", family+"/%")
+	} else if filter.MimeType != "" {
+		query = query.Where("mime_type = ?", filter.MimeType)
+	}
+	if filter.UploadedBy != nil {
+		query = query.Where("uploaded_by = ?", *filter.UploadedBy)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+	if filter.MinSize > 0 {
+		query = query.Where("size >= ?", filter.MinSize)
+	}
+	if filter.MaxSize > 0 {
+		query = query.Where("size <= ?", filter.MaxSize)
+	}
+	if filter.ProcessingStatus != nil {
+		query = query.Where("processing_status = ?", *filter.ProcessingStatus)
+	}
+
+	return query, nil
+}
+
+func (r *documentRepositoryImpl) GetBySpaceAndContentHash(spaceID uint, contentHash string) (*entities.Document, error) {
+	var document entities.Document
+	db := databases.GetDB()
+	err := db.Where("space_id = ? AND content_hash = ?", spaceID, contentHash).First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// GetVisibleToUser returns the documents of all spaces the user is a member
+// of, leaving out editor only documents where the role of the user cannot
+// upload documents.
+func (r *documentRepositoryImpl) GetVisibleToUser(userID uint, page int, pageSize int) ([]entities.Document, int64, error) {
+	db := databases.GetDB()
+	query := db.Model(&entities.Document{}).
+		Joins("JOIN spaces ON spaces.id = documents.space_id AND spaces.deleted_at IS NULL").
+		Joins("JOIN space_users ON space_users.space_id = documents.space_id AND space_users.user_id = ?", userID).
+		Joins("LEFT JOIN space_roles ON space_roles.id = space_users.space_role_id").
+		Where("documents.privacy_status <> ? OR (space_roles.permission & ?) <> 0",
+			entities.DocumentVisibilityEditors,
+			entities.SpacePermissionUploadDocuments)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	pagination := NewPagination(page, pageSize, r.DefaultPageSize)
+	documents := []entities.Document{}
+	err := pagination.ApplyPagination(query).
+		Order("documents.id ASC").
+		Find(&documents).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return documents, total, nil
+}
+
+func (r *documentRepositoryImpl) GetIDsByVisibility(spaceID uint, visibilities []entities.DocumentVisibility) ([]uint, error) {
+	ids := []uint{}
+	db := databases.GetDB()
+	err := db.Model(&entities.Document{}).
+		Where("space_id = ? AND privacy_status IN ?", spaceID, visibilities).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetIDs returns the ids of the documents in a space, or of all documents in
+// spaces outside the trash when spaceID is nil.
+func (r *documentRepositoryImpl) GetIDs(spaceID *uint) ([]uint, error) {
+	ids := []uint{}
+	db := databases.GetDB()
+	query := db.Model(&entities.Document{}).
+		Joins("JOIN spaces ON spaces.id = documents.space_id AND spaces.deleted_at IS NULL")
+	if spaceID != nil {
+		query = query.Where("documents.space_id = ?", *spaceID)
+	}
+	err := query.Order("documents.id ASC").Pluck("documents.id", &ids).Error
+	return ids, err
+}
+
+func (r *documentRepositoryImpl) UpdateVisibility(documentID uint, visibility entities.DocumentVisibility) error {
+	db := databases.GetDB()
+	return db.Model(&entities.Document{}).Where("id = ?", documentID).Update("privacy_status", visibility).Error
+}
+
+func (r *documentRepositoryImpl) GetTagsBySpaceID(spaceID uint) ([]string, error) {
+	tags := []string{}
+	db := databases.GetDB()
+	err := db.Raw(`
+		SELECT DISTINCT tag FROM documents, jsonb_array_elements_text(documents.tags) AS tag
+		WHERE documents.space_id = ?
+		ORDER BY tag`, spaceID).Scan(&tags).Error
+	return tags, err
+}
+
+func (r *documentRepositoryImpl) UpdateFolder(documentID uint, folderID *uint) error {
+	db := databases.GetDB()
+	return db.Model(&entities.Document{}).Where("id = ?", documentID).Update("folder_id", folderID).Error
+}
+
+func (r *documentRepositoryImpl) UpdateSpace(documentID uint, spaceID uint, folderID *uint) error {
+	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var document entities.Document
+		if err := tx.First(&document, documentID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&document).Updates(map[string]interface{}{
+			"space_id":  spaceID,
+			"folder_id": folderID,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := addStorageUsage(tx, document.SpaceID, -document.Size); err != nil {
+			return err
+		}
+		return addStorageUsage(tx, spaceID, document.Size)
+	})
+}
+
+func (r *documentRepositoryImpl) UpdateTags(documentID uint, tags entities.DocumentTags) error {
+	db := databases.GetDB()
+	return db.Model(&entities.Document{}).Where("id = ?", documentID).Update("tags", tags).Error
+}
+
 func (s *documentRepositoryImpl) CountUserDocuments(userID uint) (int64, error) {
 	var count int64
 	db := databases.GetDB()
 
 	err := db.Model(&entities.Document{}).
-		Joins("JOIN spaces ON documents.space_id = spaces.id").
+		Joins("JOIN spaces ON documents.space_id = spaces.id AND spaces.deleted_at IS NULL").
 		Joins("JOIN space_users ON spaces.id = space_users.space_id").
 		Where("space_users.user_id = ?", userID).
 		Count(&count).Error
 
 	return count, err
 }
+
+func (r *documentRepositoryImpl) CountBySpaceID(spaceID uint) (int64, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.Document{}).Where("space_id = ?", spaceID).Count(&count).Error
+	return count, err
+}
+
+func (r *documentRepositoryImpl) CreateWithVersion(document *entities.Document, version *entities.DocumentVersion) (*entities.Document, error) {
+	db := databases.GetDB()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		document.CurrentVersion = 1
+		if err := tx.Create(document).Error; err != nil {
+			return err
+		}
+
+		version.DocumentID = document.ID
+		version.Version = document.CurrentVersion
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		return addStorageUsage(tx, document.SpaceID, document.Size)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func (r *documentRepositoryImpl) AddVersion(documentID uint, version *entities.DocumentVersion) (*entities.Document, error) {
+	var document entities.Document
+	db := databases.GetDB()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&document, documentID).Error; err != nil {
+			return err
+		}
+
+		var latest int
+		if err := tx.Model(&entities.DocumentVersion{}).
+			Where("document_id = ?", documentID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		version.DocumentID = documentID
+		version.Version = latest + 1
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+
+		// Only the current version counts towards the storage of the space
+		if err := addStorageUsage(tx, document.SpaceID, version.Size-document.Size); err != nil {
+			return err
+		}
+
+		document.Name = version.Name
+		document.MimeType = version.MimeType
+		document.Size = version.Size
+		document.S3URL = version.S3URL
+		document.ContentHash = version.ContentHash
+		document.Metadata = version.Metadata
+		document.DocumentScan = version.DocumentScan
+		document.CurrentVersion = version.Version
+		return tx.Save(&document).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *documentRepositoryImpl) GetDueForRefresh(now time.Time) ([]entities.Document, error) {
+	db := databases.GetDB()
+	documents := []entities.Document{}
+	err := db.Joins("JOIN spaces ON spaces.id = documents.space_id AND spaces.deleted_at IS NULL").
+		Where("documents.source_url <> '' AND documents.refresh_interval_hours > 0").
+		Where("documents.last_fetched_at IS NULL OR documents.last_fetched_at + make_interval(hours => documents.refresh_interval_hours) <= ?", now).
+		Find(&documents).Error
+	if err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (r *documentRepositoryImpl) UpdateLastFetchedAt(documentID uint, fetchedAt time.Time) error {
+	db := databases.GetDB()
+	return db.Model(&entities.Document{}).
+		Where("id = ?", documentID).
+		UpdateColumn("last_fetched_at", fetchedAt).Error
+}
+
+// GetIndexable lists the documents that should have an entry in the RAG
+// index: those that are neither in the trash nor in a space in the trash.
+func (r *documentRepositoryImpl) GetIndexable() ([]entities.Document, error) {
+	db := databases.GetDB()
+	documents := []entities.Document{}
+	err := db.Joins("JOIN spaces ON spaces.id = documents.space_id AND spaces.deleted_at IS NULL").
+		Order("documents.id ASC").
+		Find(&documents).Error
+	return documents, err
+}
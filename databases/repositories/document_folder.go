@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+)
+
+type DocumentFolderRepository interface {
+	ICrudRepository[entities.DocumentFolder, uint]
+	GetBySpaceID(spaceID uint) ([]entities.DocumentFolder, error)
+	GetDescendantIDs(folderID uint) ([]uint, error)
+	GetPath(folderID uint) (string, error)
+	ExistsByName(spaceID uint, parentID *uint, name string, excludeID uint) (bool, error)
+	CountChildren(folderID uint) (int64, error)
+}
+
+type documentFolderRepositoryImpl struct {
+	*CrudRepository[entities.DocumentFolder, uint]
+}
+
+func NewDocumentFolderRepository() DocumentFolderRepository {
+	return &documentFolderRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.DocumentFolder, uint](),
+	}
+}
+
+func (r *documentFolderRepositoryImpl) GetBySpaceID(spaceID uint) ([]entities.DocumentFolder, error) {
+	folders := []entities.DocumentFolder{}
+	db := databases.GetDB()
+	err := db.Where("space_id = ?", spaceID).Order("name ASC").Find(&folders).Error
+	return folders, err
+}
+
+// GetDescendantIDs returns the folder itself followed by all of its subfolders.
+func (r *documentFolderRepositoryImpl) GetDescendantIDs(folderID uint) ([]uint, error) {
+	var ids []uint
+	db := databases.GetDB()
+	err := db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM document_folders WHERE id = ?
+			UNION ALL
+			SELECT f.id FROM document_folders f JOIN tree t ON f.parent_id = t.id
+		)
+		SELECT id FROM tree`, folderID).Scan(&ids).Error
+	return ids, err
+}
+
+// GetPath returns the slash separated path of a folder, e.g. "/Lectures/Week 1".
+func (r *documentFolderRepositoryImpl) GetPath(folderID uint) (string, error) {
+	var names []string
+	db := databases.GetDB()
+	err := db.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, name, 0 AS depth FROM document_folders WHERE id = ?
+			UNION ALL
+			SELECT f.id, f.parent_id, f.name, a.depth + 1
+			FROM document_folders f JOIN ancestors a ON f.id = a.parent_id
+		)
+		SELECT name FROM ancestors ORDER BY depth DESC`, folderID).Scan(&names).Error
+	if err != nil {
+		return "", err
+	}
+	return "/" + strings.Join(names, "/"), nil
+}
+
+func (r *documentFolderRepositoryImpl) ExistsByName(spaceID uint, parentID *uint, name string, excludeID uint) (bool, error) {
+	var count int64
+	db := databases.GetDB()
+	query := db.Model(&entities.DocumentFolder{}).
+		Where("space_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", spaceID, name, excludeID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *documentFolderRepositoryImpl) CountChildren(folderID uint) (int64, error) {
+	var folders, documents int64
+	db := databases.GetDB()
+	if err := db.Model(&entities.DocumentFolder{}).Where("parent_id = ?", folderID).Count(&folders).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Model(&entities.Document{}).Where("folder_id = ?", folderID).Count(&documents).Error; err != nil {
+		return 0, err
+	}
+	return folders + documents, nil
+}
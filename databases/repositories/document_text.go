@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+)
+
+type DocumentTextRepository interface {
+	ICrudRepository[entities.DocumentTextChunk, uint]
+	ReplaceForDocument(documentID uint, chunks []string) error
+	GetPageByDocumentID(documentID uint, page int, pageSize int) ([]entities.DocumentTextChunk, int64, error)
+	SearchSpace(spaceID uint, terms []string, visibilities []entities.DocumentVisibility, page int, pageSize int) ([]DocumentTextMatch, int64, error)
+	SearchDocuments(documentIDs []uint, terms []string, limit int) ([]DocumentTextMatch, error)
+}
+
+// DocumentTextMatch is a chunk matching a full-text query together with the
+// document it belongs to.
+type DocumentTextMatch struct {
+	DocumentID   uint
+	DocumentName string
+	MimeType     string
+	ChunkIndex   int
+	Content      string
+	Rank         float64
+}
+
+type documentTextRepositoryImpl struct {
+	*CrudRepository[entities.DocumentTextChunk, uint]
+}
+
+func NewDocumentTextRepository() DocumentTextRepository {
+	return &documentTextRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.DocumentTextChunk, uint](),
+	}
+}
+
+// ReplaceForDocument swaps the indexed text of a document in one transaction,
+// so searches never see a mix of two versions.
+func (r *documentTextRepositoryImpl) ReplaceForDocument(documentID uint, chunks []string) error {
+	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", documentID).Delete(&entities.DocumentTextChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		rows := make([]entities.DocumentTextChunk, len(chunks))
+		for i, chunk := range chunks {
+			rows[i] = entities.DocumentTextChunk{
+				DocumentID: documentID,
+				ChunkIndex: i,
+				Content:    chunk,
+			}
+		}
+		return tx.CreateInBatches(rows, 100).Error
+	})
+}
+
+func (r *documentTextRepositoryImpl) GetPageByDocumentID(documentID uint, page int, pageSize int) ([]entities.DocumentTextChunk, int64, error) {
+	db := databases.GetDB()
+	query := db.Model(&entities.DocumentTextChunk{}).Where("document_id = ?", documentID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	pagination := NewPagination(page, pageSize, r.DefaultPageSize)
+	chunks := []entities.DocumentTextChunk{}
+	err := pagination.ApplyPagination(query).
+		Order("chunk_index ASC").
+		Find(&chunks).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return chunks, total, nil
+}
+
+// SearchSpace returns the best matching chunk of every document in the space
+// containing all terms, ordered by rank. Trashed documents are left out.
+func (r *documentTextRepositoryImpl) SearchSpace(spaceID uint, terms []string, visibilities []entities.DocumentVisibility, page int, pageSize int) ([]DocumentTextMatch, int64, error) {
+	if len(terms) == 0 {
+		return []DocumentTextMatch{}, 0, nil
+	}
+
+	db := databases.GetDB()
+	tsquery := strings.Join(terms, " & ")
+	best := matchingChunks(db, tsquery).
+		Select("DISTINCT ON (c.document_id) c.document_id, d.name AS document_name, d.mime_type, c.chunk_index, c.content, ts_rank(c.search_vector, to_tsquery('simple', ?)) AS rank", tsquery).
+		Where("d.space_id = ? AND d.privacy_status IN ?", spaceID, visibilities).
+		Order("c.document_id, rank DESC")
+
+	query := db.Table("(?) AS matches", best)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	pagination := NewPagination(page, pageSize, r.DefaultPageSize)
+	matches := []DocumentTextMatch{}
+	err := pagination.ApplyPagination(query).
+		Order("rank DESC, document_id ASC").
+		Scan(&matches).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return matches, total, nil
+}
+
+// SearchDocuments returns the chunks of the given documents containing any of
+// the terms, best first.
+func (r *documentTextRepositoryImpl) SearchDocuments(documentIDs []uint, terms []string, limit int) ([]DocumentTextMatch, error) {
+	matches := []DocumentTextMatch{}
+	if len(terms) == 0 || len(documentIDs) == 0 {
+		return matches, nil
+	}
+
+	db := databases.GetDB()
+	tsquery := strings.Join(terms, " | ")
+	err := matchingChunks(db, tsquery).
+		Select("c.document_id, d.name AS document_name, d.mime_type, c.chunk_index, c.content, ts_rank(c.search_vector, to_tsquery('simple', ?)) AS rank", tsquery).
+		Where("c.document_id IN ?", documentIDs).
+		Order("rank DESC, c.document_id ASC, c.chunk_index ASC").
+		Limit(limit).
+		Scan(&matches).Error
+	return matches, err
+}
+
+// matchingChunks selects from the chunks matching tsquery whose document is
+// not in the trash. Terms are letters and digits only, so they are safe to
+// combine into tsquery syntax.
+func matchingChunks(db *gorm.DB, tsquery string) *gorm.DB {
+	return db.Table("document_text_chunks AS c").
+		Joins("JOIN documents d ON d.id = c.document_id AND d.deleted_at IS NULL").
+		Where("c.search_vector @@ to_tsquery('simple', ?)", tsquery)
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+)
+
+type DocumentVersionRepository interface {
+	ICrudRepository[entities.DocumentVersion, uint]
+	GetByDocumentID(documentID uint) ([]entities.DocumentVersion, error)
+	GetByDocumentAndVersionID(documentID uint, versionID uint) (*entities.DocumentVersion, error)
+	GetS3URLsByDocumentID(documentID uint) ([]string, error)
+	GetAllFileReferences() ([]entities.DocumentVersion, error)
+}
+
+type documentVersionRepositoryImpl struct {
+	*CrudRepository[entities.DocumentVersion, uint]
+}
+
+func NewDocumentVersionRepository() DocumentVersionRepository {
+	return &documentVersionRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.DocumentVersion, uint](),
+	}
+}
+
+func (r *documentVersionRepositoryImpl) GetByDocumentID(documentID uint) ([]entities.DocumentVersion, error) {
+	var versions []entities.DocumentVersion
+	db := databases.GetDB()
+	err := db.Preload("Uploader").
+		Where("document_id = ?", documentID).
+		Order("version DESC").
+		Find(&versions).Error
+	return versions, err
+}
+
+func (r *documentVersionRepositoryImpl) GetByDocumentAndVersionID(documentID uint, versionID uint) (*entities.DocumentVersion, error) {
+	var version entities.DocumentVersion
+	db := databases.GetDB()
+	if err := db.Where("id = ? AND document_id = ?", versionID, documentID).First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *documentVersionRepositoryImpl) GetS3URLsByDocumentID(documentID uint) ([]string, error) {
+	var urls []string
+	db := databases.GetDB()
+	err := db.Model(&entities.DocumentVersion{}).
+		Where("document_id = ?", documentID).
+		Distinct().
+		Pluck("s3_url", &urls).Error
+	return urls, err
+}
+
+// GetAllFileReferences returns the stored file of every version, including
+// the versions of documents in the trash. Only the IDs and URLs are loaded.
+func (r *documentVersionRepositoryImpl) GetAllFileReferences() ([]entities.DocumentVersion, error) {
+	versions := []entities.DocumentVersion{}
+	db := databases.GetDB()
+	err := db.Select("id", "document_id", "version", "s3_url").Order("id ASC").Find(&versions).Error
+	return versions, err
+}
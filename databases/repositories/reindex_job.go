@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+)
+
+type ReindexJobRepository interface {
+	ICrudRepository[entities.ReindexJob, uint]
+	GetActive(spaceID *uint, documentID *uint) (*entities.ReindexJob, error)
+	UpdateFields(jobID uint, fields map[string]interface{}) error
+	FailActiveJobs(reason string) (int64, error)
+}
+
+type reindexJobRepositoryImpl struct {
+	*CrudRepository[entities.ReindexJob, uint]
+}
+
+func NewReindexJobRepository() ReindexJobRepository {
+	return &reindexJobRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.ReindexJob, uint](),
+	}
+}
+
+// GetActive returns a pending or running job with exactly the same scope.
+func (r *reindexJobRepositoryImpl) GetActive(spaceID *uint, documentID *uint) (*entities.ReindexJob, error) {
+	db := databases.GetDB()
+	query := db.Where("status IN ?", []string{entities.ReindexJobPending, entities.ReindexJobRunning})
+
+	if spaceID != nil {
+		query = query.Where("space_id = ?", *spaceID)
+	} else {
+		query = query.Where("space_id IS NULL")
+	}
+	if documentID != nil {
+		query = query.Where("document_id = ?", *documentID)
+	} else {
+		query = query.Where("document_id IS NULL")
+	}
+
+	var job entities.ReindexJob
+	if err := query.First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *reindexJobRepositoryImpl) UpdateFields(jobID uint, fields map[string]interface{}) error {
+	db := databases.GetDB()
+	return db.Model(&entities.ReindexJob{}).Where("id = ?", jobID).Updates(fields).Error
+}
+
+// FailActiveJobs marks jobs that were left unfinished, e.g. by a restart, as
+// failed so that they can be started again.
+func (r *reindexJobRepositoryImpl) FailActiveJobs(reason string) (int64, error) {
+	db := databases.GetDB()
+	result := db.Model(&entities.ReindexJob{}).
+		Where("status IN ?", []string{entities.ReindexJobPending, entities.ReindexJobRunning}).
+		Updates(map[string]interface{}{
+			"status":      entities.ReindexJobFailed,
+			"last_error":  reason,
+			"finished_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+)
+
+type ResumableUploadRepository interface {
+	ICrudRepository[entities.ResumableUpload, uint]
+	GetExpired(now time.Time) ([]entities.ResumableUpload, error)
+	AdvanceOffset(uploadID uint, offset int64, size int64, expiresAt time.Time) error
+	SetDocumentID(uploadID uint, documentID uint) error
+	GetAllIDs() ([]uint, error)
+}
+
+type resumableUploadRepositoryImpl struct {
+	*CrudRepository[entities.ResumableUpload, uint]
+}
+
+func NewResumableUploadRepository() ResumableUploadRepository {
+	return &resumableUploadRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.ResumableUpload, uint](),
+	}
+}
+
+func (r *resumableUploadRepositoryImpl) GetExpired(now time.Time) ([]entities.ResumableUpload, error) {
+	uploads := []entities.ResumableUpload{}
+	db := databases.GetDB()
+	err := db.Where("expires_at <= ?", now).Find(&uploads).Error
+	return uploads, err
+}
+
+// AdvanceOffset records a stored chunk of size bytes that was written at
+// offset. It fails if another request moved the upload in the meantime.
+func (r *resumableUploadRepositoryImpl) AdvanceOffset(uploadID uint, offset int64, size int64, expiresAt time.Time) error {
+	db := databases.GetDB()
+	result := db.Model(&entities.ResumableUpload{}).
+		Where("id = ? AND \"offset\" = ?", uploadID, offset).
+		Updates(map[string]interface{}{
+			"offset":      gorm.Expr("\"offset\" + ?", size),
+			"chunk_count": gorm.Expr("chunk_count + 1"),
+			"expires_at":  expiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *resumableUploadRepositoryImpl) SetDocumentID(uploadID uint, documentID uint) error {
+	db := databases.GetDB()
+	return db.Model(&entities.ResumableUpload{}).
+		Where("id = ?", uploadID).
+		Update("document_id", documentID).Error
+}
+
+func (r *resumableUploadRepositoryImpl) GetAllIDs() ([]uint, error) {
+	ids := []uint{}
+	db := databases.GetDB()
+	err := db.Model(&entities.ResumableUpload{}).Pluck("id", &ids).Error
+	return ids, err
+}
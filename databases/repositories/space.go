@@ -7,18 +7,34 @@ import (
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrLastSpaceOwner is returned by changes that would leave a space without
+// an owner.
+var ErrLastSpaceOwner = errors.New("a space must keep at least one owner")
+
 type SpaceRepository interface {
 	ICrudRepository[entities.Space, uint]
+	ITrashRepository[entities.Space, uint]
+	SoftDelete(spaceID uint, deletedBy *uint) error
+	GetDeletedOwnedBy(userID uint) ([]entities.Space, error)
+	IsOwner(userID uint, spaceID uint) (bool, error)
+	GetOwnerID(spaceID uint) (uint, error)
+	GetSpacesWhereLastOwner(userID uint) ([]entities.Space, error)
+	ReconcileStorageUsage() (int64, error)
 	FindPublicSpaces(page int, pageSize int) ([]*entities.Space, error)
 	CountPublicSpaces() (int64, error)
+	FindDiscoverableSpaces(page int, pageSize int) ([]*entities.Space, error)
+	CountDiscoverableSpaces() (int64, error)
 	GetMembers(spaceId uint) ([]entities.SpaceUser, error)
 	GetInvitations(spaceId uint) ([]entities.SpaceInvitation, error)
 	GetUserRole(userID, spaceID uint) (*entities.SpaceRole, error)
 	CreateInvitation(invitation *entities.SpaceInvitation) (*entities.SpaceInvitation, error)
 	GetAllRoles() ([]entities.SpaceRole, error)
 	JoinPublicSpace(spaceID uint, userID uint) error
+	AddMembers(members []entities.SpaceUser) error
 	IsMemberOfSpace(userID uint, spaceID uint) (bool, error)
 	CountSpacesByUserID(userID uint) (int64, error)
 	CountOwnedSpacesByUserID(userID uint) (int64, error)
@@ -30,12 +46,91 @@ type SpaceRepository interface {
 
 type spaceRepositoryImpl struct {
 	*CrudRepository[entities.Space, uint]
+	*TrashRepository[entities.Space, uint]
 }
 
 func NewSpaceRepository() SpaceRepository {
 	return &spaceRepositoryImpl{
-		CrudRepository: NewCrudRepository[entities.Space, uint](),
+		CrudRepository:  NewCrudRepository[entities.Space, uint](),
+		TrashRepository: &TrashRepository[entities.Space, uint]{},
+	}
+}
+
+func (r *spaceRepositoryImpl) SoftDelete(spaceID uint, deletedBy *uint) error {
+	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.Space{}).Where("id = ?", spaceID).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entities.Space{}, spaceID).Error
+	})
+}
+
+func (r *spaceRepositoryImpl) Restore(spaceID uint) error {
+	db := databases.GetDB()
+	return db.Unscoped().Model(&entities.Space{}).Where("id = ?", spaceID).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}).Error
+}
+
+func (r *spaceRepositoryImpl) GetDeletedOwnedBy(userID uint) ([]entities.Space, error) {
+	spaces := []entities.Space{}
+	db := databases.GetDB()
+	err := db.Unscoped().
+		Joins("JOIN space_users ON space_users.space_id = spaces.id").
+		Where("spaces.deleted_at IS NOT NULL").
+		Where("space_users.user_id = ? AND space_users.space_role_id = ?", userID, entities.SpaceRoleOwner).
+		Order("spaces.deleted_at DESC").
+		Find(&spaces).Error
+	return spaces, err
+}
+
+// IsOwner also answers for spaces in the trash, unlike GetUserRole.
+func (r *spaceRepositoryImpl) IsOwner(userID uint, spaceID uint) (bool, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.SpaceUser{}).
+		Where("user_id = ? AND space_id = ? AND space_role_id = ?", userID, spaceID, entities.SpaceRoleOwner).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *spaceRepositoryImpl) GetOwnerID(spaceID uint) (uint, error) {
+	var spaceUser entities.SpaceUser
+	db := databases.GetDB()
+	err := db.Where("space_id = ? AND space_role_id = ?", spaceID, entities.SpaceRoleOwner).
+		Order("id ASC").
+		First(&spaceUser).Error
+	if err != nil {
+		return 0, err
 	}
+	return spaceUser.UserID, nil
+}
+
+// addStorageUsage adjusts the bytes stored in a space in the transaction that
+// creates, moves or removes a document, so usage never has to be summed up.
+func addStorageUsage(tx *gorm.DB, spaceID uint, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	return tx.Exec("UPDATE spaces SET storage_used_bytes = GREATEST(storage_used_bytes + ?, 0) WHERE id = ?", delta, spaceID).Error
+}
+
+// ReconcileStorageUsage recomputes the storage used by every space from its
+// documents and fixes the spaces that drifted. It returns how many were fixed.
+func (r *spaceRepositoryImpl) ReconcileStorageUsage() (int64, error) {
+	db := databases.GetDB()
+	result := db.Exec(`
+		UPDATE spaces SET storage_used_bytes = usage.total
+		FROM (
+			SELECT spaces.id, COALESCE(SUM(documents.size), 0) AS total
+			FROM spaces
+			LEFT JOIN documents ON documents.space_id = spaces.id AND documents.deleted_at IS NULL
+			GROUP BY spaces.id
+		) AS usage
+		WHERE usage.id = spaces.id AND spaces.storage_used_bytes <> usage.total`)
+	return result.RowsAffected, result.Error
 }
 
 func (r *spaceRepositoryImpl) FindPublicSpaces(page int, pageSize int) ([]*entities.Space, error) {
@@ -64,6 +159,36 @@ func (r *spaceRepositoryImpl) CountPublicSpaces() (int64, error) {
 	return count, err
 }
 
+// discoverableSpaceColumns are the columns shown to people outside a private
+// space that accepts join requests.
+var discoverableSpaceColumns = []string{"id", "name", "description", "privacy_status", "allow_join_requests", "created_at", "updated_at"}
+
+func (r *spaceRepositoryImpl) FindDiscoverableSpaces(page int, pageSize int) ([]*entities.Space, error) {
+	var spaces []*entities.Space
+	db := databases.GetDB()
+
+	pagination := NewPagination(page, pageSize, DefaultPageSize)
+
+	err := pagination.ApplyPagination(db).
+		Select(discoverableSpaceColumns).
+		Where("privacy_status = ? AND allow_join_requests = ?", true, true).
+		Find(&spaces).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.aggregateUserCount(spaces)
+}
+
+func (r *spaceRepositoryImpl) CountDiscoverableSpaces() (int64, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.Space{}).
+		Where("privacy_status = ? AND allow_join_requests = ?", true, true).
+		Count(&count).Error
+	return count, err
+}
+
 func (r *spaceRepositoryImpl) GetMembers(spaceId uint) ([]entities.SpaceUser, error) {
 	var members []entities.SpaceUser
 	db := databases.GetDB()
@@ -82,7 +207,8 @@ func (r *spaceRepositoryImpl) GetUserRole(userID, spaceID uint) (*entities.Space
 	db := databases.GetDB()
 	var spaceUser entities.SpaceUser
 
-	err := db.Where("user_id = ? AND space_id = ?", userID, spaceID).
+	err := db.Joins(activeSpaceJoin).
+		Where("space_users.user_id = ? AND space_users.space_id = ?", userID, spaceID).
 		Preload("SpaceRole").
 		First(&spaceUser).Error
 
@@ -108,7 +234,7 @@ func (s *spaceRepositoryImpl) CreateInvitation(invitation *entities.SpaceInvitat
 func (r *spaceRepositoryImpl) GetAllRoles() ([]entities.SpaceRole, error) {
 	var roles []entities.SpaceRole
 	db := databases.GetDB()
-	if err := db.Find(&roles).Error; err != nil {
+	if err := db.Where("space_id IS NULL").Find(&roles).Error; err != nil {
 		return nil, err
 	}
 	return roles, nil
@@ -141,11 +267,20 @@ func (s *spaceRepositoryImpl) JoinPublicSpace(spaceID uint, userID uint) error {
 	return nil
 }
 
+func (s *spaceRepositoryImpl) AddMembers(members []entities.SpaceUser) error {
+	if len(members) == 0 {
+		return nil
+	}
+	db := databases.GetDB()
+	return db.Create(&members).Error
+}
+
 func (s *spaceRepositoryImpl) IsMemberOfSpace(userID uint, spaceID uint) (bool, error) {
 	var count int64
 	db := databases.GetDB()
 	err := db.Model(&entities.SpaceUser{}).
-		Where("user_id = ? AND space_id = ?", userID, spaceID).
+		Joins(activeSpaceJoin).
+		Where("space_users.user_id = ? AND space_users.space_id = ?", userID, spaceID).
 		Count(&count).Error
 
 	if err != nil {
@@ -159,6 +294,7 @@ func (s *spaceRepositoryImpl) CountSpacesByUserID(userID uint) (int64, error) {
 	var count int64
 	err := databases.GetDB().
 		Table("space_users").
+		Joins(activeSpaceJoin).
 		Where("space_users.user_id = ?", userID).
 		Count(&count).Error
 	return count, err
@@ -168,6 +304,7 @@ func (s *spaceRepositoryImpl) CountOwnedSpacesByUserID(userID uint) (int64, erro
 	var count int64
 	err := databases.GetDB().
 		Table("space_users").
+		Joins(activeSpaceJoin).
 		Where("space_users.user_id = ? AND space_users.space_role_id = ?", userID, entities.SpaceRoleOwner).
 		Count(&count).Error
 	return count, err
@@ -201,35 +338,86 @@ func (r *spaceRepositoryImpl) GetPopularSpaces(order string) ([]*entities.Space,
 	return []*entities.Space{}, nil
 }
 
+// UpdateMemberRole refuses to demote the last owner of the space.
 func (r *spaceRepositoryImpl) UpdateMemberRole(spaceID, memberID, roleID, updatedBy uint) error {
 	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var spaceUser entities.SpaceUser
+		if err := tx.Where("space_id = ? AND user_id = ?", spaceID, memberID).First(&spaceUser).Error; err != nil {
+			return errors.New("member not found in the space")
+		}
 
-	var spaceUser entities.SpaceUser
-	if err := db.Where("space_id = ? AND user_id = ?", spaceID, memberID).First(&spaceUser).Error; err != nil {
-		return errors.New("member not found in the space")
-	}
-
-	spaceUser.SpaceRoleID = &roleID
-	if err := db.Save(&spaceUser).Error; err != nil {
-		return errors.New("failed to update member role")
-	}
+		if isOwnerMembership(spaceUser) && roleID != entities.SpaceRoleOwner {
+			if err := ensureAnotherOwner(tx, spaceID, memberID); err != nil {
+				return err
+			}
+		}
 
-	return nil
+		spaceUser.SpaceRoleID = &roleID
+		if err := tx.Save(&spaceUser).Error; err != nil {
+			return errors.New("failed to update member role")
+		}
+		return nil
+	})
 }
 
+// RemoveMember refuses to remove the last owner of the space.
 func (r *spaceRepositoryImpl) RemoveMember(spaceID, memberID uint) error {
 	db := databases.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var spaceUser entities.SpaceUser
+		if err := tx.Where("space_id = ? AND user_id = ?", spaceID, memberID).First(&spaceUser).Error; err != nil {
+			return errors.New("member not found in the space")
+		}
 
-	var spaceUser entities.SpaceUser
-	if err := db.Where("space_id = ? AND user_id = ?", spaceID, memberID).First(&spaceUser).Error; err != nil {
-		return errors.New("member not found in the space")
-	}
+		if isOwnerMembership(spaceUser) {
+			if err := ensureAnotherOwner(tx, spaceID, memberID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(&spaceUser).Error; err != nil {
+			return errors.New("failed to remove member from the space")
+		}
+		return nil
+	})
+}
+
+// GetSpacesWhereLastOwner returns the spaces that would be left without an
+// owner if the user went away. Spaces in the trash are not included.
+func (r *spaceRepositoryImpl) GetSpacesWhereLastOwner(userID uint) ([]entities.Space, error) {
+	spaces := []entities.Space{}
+	db := databases.GetDB()
+	err := db.Joins("JOIN space_users ON space_users.space_id = spaces.id").
+		Where("space_users.user_id = ? AND space_users.space_role_id = ?", userID, entities.SpaceRoleOwner).
+		Where("NOT EXISTS (SELECT 1 FROM space_users others WHERE others.space_id = spaces.id AND others.space_role_id = ? AND others.user_id <> ?)",
+			entities.SpaceRoleOwner, userID).
+		Order("spaces.id").
+		Find(&spaces).Error
+	return spaces, err
+}
+
+func isOwnerMembership(spaceUser entities.SpaceUser) bool {
+	return spaceUser.SpaceRoleID != nil && *spaceUser.SpaceRoleID == entities.SpaceRoleOwner
+}
 
-	if err := db.Delete(&spaceUser).Error; err != nil {
-		return errors.New("failed to remove member from the space")
+// ensureAnotherOwner locks the owners of the space, so that concurrent
+// changes cannot demote or remove all of them, and fails unless an owner
+// other than userID remains.
+func ensureAnotherOwner(tx *gorm.DB, spaceID, userID uint) error {
+	var owners []entities.SpaceUser
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("space_id = ? AND space_role_id = ?", spaceID, entities.SpaceRoleOwner).
+		Find(&owners).Error; err != nil {
+		return err
 	}
 
-	return nil
+	for _, owner := range owners {
+		if owner.UserID != userID {
+			return nil
+		}
+	}
+	return ErrLastSpaceOwner
 }
 
 func (r *spaceRepositoryImpl) GetSpaceUsage(spaceID uint) (*dtos.SpaceUsage, error) {
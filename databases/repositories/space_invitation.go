@@ -1,8 +1,12 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
 )
 
 type SpaceInvitationRepository interface {
@@ -11,6 +15,7 @@ type SpaceInvitationRepository interface {
 	RejectInvitation(invitationId uint, userId uint) error
 	CancelInvitation(spaceID uint, invitedUserID uint) error
 	CountInvitationByUserID(userID uint) (int64, error)
+	MarkSent(invitationID uint, sentAt time.Time, expiresAt *time.Time) error
 }
 
 type spaceInvitationRepositoryImpl struct {
@@ -29,6 +34,9 @@ func (r *spaceInvitationRepositoryImpl) AcceptInvitation(invitationId uint, user
 	if err := db.First(&invitation, "id = ? AND invited_user_id = ?", invitationId, userId).Error; err != nil {
 		return 0, err
 	}
+	if invitation.IsExpired(time.Now()) {
+		return 0, errors.New("invitation has expired")
+	}
 
 	member := entities.SpaceUser{
 		UserID:      userId,
@@ -70,6 +78,20 @@ func (s *spaceInvitationRepositoryImpl) CountInvitationByUserID(userID uint) (in
 	err := databases.GetDB().
 		Model(&entities.SpaceInvitation{}).
 		Where("invited_user_id = ? AND status = ?", userID, entities.InvitationStatusPending).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
 		Count(&count).Error
 	return count, err
 }
+
+// MarkSent records that the invitation email went out and moves the expiry.
+func (r *spaceInvitationRepositoryImpl) MarkSent(invitationID uint, sentAt time.Time, expiresAt *time.Time) error {
+	return databases.GetDB().
+		Model(&entities.SpaceInvitation{}).
+		Where("id = ?", invitationID).
+		Updates(map[string]interface{}{
+			"last_sent_at": sentAt,
+			"expires_at":   expiresAt,
+			"send_count":   gorm.Expr("send_count + 1"),
+			"updated_at":   sentAt,
+		}).Error
+}
@@ -1,13 +1,22 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SpaceInvitationLinkRepository interface {
 	ICrudRepository[entities.SpaceInvitationLink, uint]
-	GetBySpaceID(spaceID uint) (*entities.SpaceInvitationLink, error)
+	GetBySpaceID(spaceID uint) ([]entities.SpaceInvitationLink, error)
+	GetDefaultBySpaceID(spaceID uint) (*entities.SpaceInvitationLink, error)
+	Revoke(linkID uint, revokedBy uint) error
+	Redeem(linkID uint, userID uint) (*entities.SpaceInvitationLink, error)
+	GetUses(linkID uint) ([]entities.SpaceInvitationLinkUse, error)
 }
 
 type spaceInvitationLinkRepositoryImpl struct {
@@ -20,11 +29,103 @@ func NewSpaceInvitationLinkRepository() SpaceInvitationLinkRepository {
 	}
 }
 
-func (s *spaceInvitationLinkRepositoryImpl) GetBySpaceID(spaceID uint) (*entities.SpaceInvitationLink, error) {
+func (s *spaceInvitationLinkRepositoryImpl) GetBySpaceID(spaceID uint) ([]entities.SpaceInvitationLink, error) {
+	links := []entities.SpaceInvitationLink{}
+	db := databases.GetDB()
+	err := db.Preload("SpaceRole").
+		Where("space_id = ?", spaceID).
+		Order("created_at DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// GetDefaultBySpaceID returns the newest default link of the space that was
+// not revoked.
+func (s *spaceInvitationLinkRepositoryImpl) GetDefaultBySpaceID(spaceID uint) (*entities.SpaceInvitationLink, error) {
 	var invitationLink entities.SpaceInvitationLink
 	db := databases.GetDB()
-	if err := db.Where("space_id = ?", spaceID).First(&invitationLink).Error; err != nil {
+	if err := db.Where("space_id = ? AND name = ? AND revoked_at IS NULL", spaceID, entities.DefaultInvitationLinkName).
+		Order("id DESC").
+		First(&invitationLink).Error; err != nil {
 		return nil, err
 	}
 	return &invitationLink, nil
 }
+
+func (s *spaceInvitationLinkRepositoryImpl) Revoke(linkID uint, revokedBy uint) error {
+	db := databases.GetDB()
+	return db.Model(&entities.SpaceInvitationLink{}).
+		Where("id = ? AND revoked_at IS NULL", linkID).
+		Updates(map[string]interface{}{
+			"revoked_at": time.Now(),
+			"revoked_by": revokedBy,
+		}).Error
+}
+
+// Redeem adds the user to the space of the link with the role of the link,
+// counts the use and records it. The link row is locked so that concurrent
+// joins cannot exceed its maximum number of uses.
+func (s *spaceInvitationLinkRepositoryImpl) Redeem(linkID uint, userID uint) (*entities.SpaceInvitationLink, error) {
+	var link entities.SpaceInvitationLink
+	db := databases.GetDB()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&link, linkID).Error; err != nil {
+			return errors.New("invalid token")
+		}
+		if reason := link.UnusableReason(time.Now()); reason != "" {
+			return errors.New(reason)
+		}
+		if link.SpaceRoleID == 0 {
+			return errors.New("invitation link is no longer valid")
+		}
+
+		var space entities.Space
+		if err := tx.First(&space, link.SpaceID).Error; err != nil {
+			return errors.New("invitation link is no longer valid")
+		}
+
+		var count int64
+		if err := tx.Model(&entities.SpaceUser{}).
+			Where("user_id = ? AND space_id = ?", userID, link.SpaceID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("user is already a member of this space")
+		}
+
+		roleID := link.SpaceRoleID
+		if err := tx.Create(&entities.SpaceUser{
+			UserID:      userID,
+			SpaceID:     link.SpaceID,
+			SpaceRoleID: &roleID,
+		}).Error; err != nil {
+			return err
+		}
+
+		link.UseCount++
+		if err := tx.Model(&link).Update("use_count", link.UseCount).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&entities.SpaceInvitationLinkUse{
+			SpaceInvitationLinkID: link.ID,
+			SpaceID:               link.SpaceID,
+			UserID:                userID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (s *spaceInvitationLinkRepositoryImpl) GetUses(linkID uint) ([]entities.SpaceInvitationLinkUse, error) {
+	uses := []entities.SpaceInvitationLinkUse{}
+	db := databases.GetDB()
+	err := db.Preload("User").
+		Where("space_invitation_link_id = ?", linkID).
+		Order("created_at DESC").
+		Find(&uses).Error
+	return uses, err
+}
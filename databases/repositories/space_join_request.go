@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SpaceJoinRequestRepository interface {
+	ICrudRepository[entities.SpaceJoinRequest, uint]
+	GetBySpaceID(spaceID uint, status string) ([]entities.SpaceJoinRequest, error)
+	GetByUserID(userID uint) ([]entities.SpaceJoinRequest, error)
+	HasPending(spaceID, userID uint) (bool, error)
+	CountPendingByUserID(userID uint) (int64, error)
+	CountCreatedSince(userID uint, since time.Time) (int64, error)
+	GetLastRejectedAt(spaceID, userID uint) (*time.Time, error)
+	Approve(requestID, roleID, reviewedBy uint) (*entities.SpaceJoinRequest, error)
+	Close(requestID uint, status string, reviewedBy uint) error
+}
+
+type spaceJoinRequestRepositoryImpl struct {
+	*CrudRepository[entities.SpaceJoinRequest, uint]
+}
+
+func NewSpaceJoinRequestRepository() SpaceJoinRequestRepository {
+	return &spaceJoinRequestRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.SpaceJoinRequest, uint](),
+	}
+}
+
+// GetBySpaceID lists the requests of a space, only those with the status when
+// one is given.
+func (r *spaceJoinRequestRepositoryImpl) GetBySpaceID(spaceID uint, status string) ([]entities.SpaceJoinRequest, error) {
+	requests := []entities.SpaceJoinRequest{}
+	query := databases.GetDB().Preload("User").Preload("SpaceRole").Where("space_id = ?", spaceID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Find(&requests).Error
+	return requests, err
+}
+
+func (r *spaceJoinRequestRepositoryImpl) GetByUserID(userID uint) ([]entities.SpaceJoinRequest, error) {
+	requests := []entities.SpaceJoinRequest{}
+	db := databases.GetDB()
+	err := db.Preload("Space", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name", "description", "privacy_status")
+	}).Preload("SpaceRole").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&requests).Error
+	return requests, err
+}
+
+func (r *spaceJoinRequestRepositoryImpl) HasPending(spaceID, userID uint) (bool, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.SpaceJoinRequest{}).
+		Where("space_id = ? AND user_id = ? AND status = ?", spaceID, userID, entities.JoinRequestPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *spaceJoinRequestRepositoryImpl) CountPendingByUserID(userID uint) (int64, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.SpaceJoinRequest{}).
+		Where("user_id = ? AND status = ?", userID, entities.JoinRequestPending).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *spaceJoinRequestRepositoryImpl) CountCreatedSince(userID uint, since time.Time) (int64, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.SpaceJoinRequest{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// GetLastRejectedAt returns when a request of the user to the space was last
+// rejected, nil when none was.
+func (r *spaceJoinRequestRepositoryImpl) GetLastRejectedAt(spaceID, userID uint) (*time.Time, error) {
+	var request entities.SpaceJoinRequest
+	db := databases.GetDB()
+	err := db.Where("space_id = ? AND user_id = ? AND status = ?", spaceID, userID, entities.JoinRequestRejected).
+		Order("reviewed_at DESC").
+		First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return request.ReviewedAt, nil
+}
+
+// Approve adds the requester to the space with the role and closes the
+// request in one transaction.
+func (r *spaceJoinRequestRepositoryImpl) Approve(requestID, roleID, reviewedBy uint) (*entities.SpaceJoinRequest, error) {
+	var request entities.SpaceJoinRequest
+	db := databases.GetDB()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestID).Error; err != nil {
+			return errors.New("join request not found")
+		}
+		if request.Status != entities.JoinRequestPending {
+			return errors.New("join request is no longer pending")
+		}
+
+		var count int64
+		if err := tx.Model(&entities.SpaceUser{}).
+			Where("space_id = ? AND user_id = ?", request.SpaceID, request.UserID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			member := entities.SpaceUser{
+				UserID:      request.UserID,
+				SpaceID:     request.SpaceID,
+				SpaceRoleID: &roleID,
+			}
+			if err := tx.Create(&member).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		request.Status = entities.JoinRequestApproved
+		request.SpaceRoleID = &roleID
+		request.ReviewedBy = &reviewedBy
+		request.ReviewedAt = &now
+		return tx.Model(&request).Updates(map[string]interface{}{
+			"status":        request.Status,
+			"space_role_id": roleID,
+			"reviewed_by":   reviewedBy,
+			"reviewed_at":   now,
+			"updated_at":    now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Close rejects or cancels a pending request, a request that was already
+// answered is left alone.
+func (r *spaceJoinRequestRepositoryImpl) Close(requestID uint, status string, reviewedBy uint) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     status,
+		"updated_at": now,
+	}
+	if status == entities.JoinRequestRejected {
+		updates["reviewed_by"] = reviewedBy
+		updates["reviewed_at"] = now
+	}
+
+	db := databases.GetDB()
+	result := db.Model(&entities.SpaceJoinRequest{}).
+		Where("id = ? AND status = ?", requestID, entities.JoinRequestPending).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("join request is no longer pending")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SpaceOwnershipTransferRepository interface {
+	ICrudRepository[entities.SpaceOwnershipTransfer, uint]
+	GetPendingBySpaceID(spaceID uint) ([]entities.SpaceOwnershipTransfer, error)
+	GetPendingForUser(userID uint) ([]entities.SpaceOwnershipTransfer, error)
+	HasPending(spaceID, toUserID uint) (bool, error)
+	Accept(transferID uint) (*entities.SpaceOwnershipTransfer, error)
+	SetStatus(transferID uint, status string) error
+}
+
+type spaceOwnershipTransferRepositoryImpl struct {
+	*CrudRepository[entities.SpaceOwnershipTransfer, uint]
+}
+
+func NewSpaceOwnershipTransferRepository() SpaceOwnershipTransferRepository {
+	return &spaceOwnershipTransferRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.SpaceOwnershipTransfer, uint](),
+	}
+}
+
+func (r *spaceOwnershipTransferRepositoryImpl) GetPendingBySpaceID(spaceID uint) ([]entities.SpaceOwnershipTransfer, error) {
+	transfers := []entities.SpaceOwnershipTransfer{}
+	db := databases.GetDB()
+	err := db.Preload("FromUser").Preload("ToUser").
+		Where("space_id = ? AND status = ?", spaceID, entities.OwnershipTransferPending).
+		Order("created_at DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *spaceOwnershipTransferRepositoryImpl) GetPendingForUser(userID uint) ([]entities.SpaceOwnershipTransfer, error) {
+	transfers := []entities.SpaceOwnershipTransfer{}
+	db := databases.GetDB()
+	err := db.Preload("Space").Preload("FromUser").
+		Where("to_user_id = ? AND status = ?", userID, entities.OwnershipTransferPending).
+		Order("created_at DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *spaceOwnershipTransferRepositoryImpl) HasPending(spaceID, toUserID uint) (bool, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.SpaceOwnershipTransfer{}).
+		Where("space_id = ? AND to_user_id = ? AND status = ?", spaceID, toUserID, entities.OwnershipTransferPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Accept makes the target an owner and, unless the proposer keeps ownership,
+// demotes the proposer to editor, all in one transaction. The proposal is
+// void when either side left the space or the proposer is no longer an owner.
+func (r *spaceOwnershipTransferRepositoryImpl) Accept(transferID uint) (*entities.SpaceOwnershipTransfer, error) {
+	var transfer entities.SpaceOwnershipTransfer
+	db := databases.GetDB()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, transferID).Error; err != nil {
+			return errors.New("transfer not found")
+		}
+		if transfer.Status != entities.OwnershipTransferPending {
+			return errors.New("transfer is no longer pending")
+		}
+
+		var from, to entities.SpaceUser
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("space_id = ? AND user_id = ?", transfer.SpaceID, transfer.FromUserID).
+			First(&from).Error; err != nil || !isOwnerMembership(from) {
+			return errors.New("the proposer is no longer an owner of the space")
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("space_id = ? AND user_id = ?", transfer.SpaceID, transfer.ToUserID).
+			First(&to).Error; err != nil {
+			return errors.New("you are not a member of this space")
+		}
+
+		ownerRoleID := uint(entities.SpaceRoleOwner)
+		if err := tx.Model(&to).Update("space_role_id", ownerRoleID).Error; err != nil {
+			return err
+		}
+		if !transfer.KeepOwnership {
+			editorRoleID := uint(entities.SpaceRoleEditor)
+			if err := tx.Model(&from).Update("space_role_id", editorRoleID).Error; err != nil {
+				return err
+			}
+		}
+
+		transfer.Status = entities.OwnershipTransferAccepted
+		return tx.Model(&transfer).Update("status", transfer.Status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// SetStatus closes a pending transfer, a transfer that was already answered
+// is left alone.
+func (r *spaceOwnershipTransferRepositoryImpl) SetStatus(transferID uint, status string) error {
+	db := databases.GetDB()
+	result := db.Model(&entities.SpaceOwnershipTransfer{}).
+		Where("id = ? AND status = ?", transferID, entities.OwnershipTransferPending).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("transfer is no longer pending")
+	}
+	return nil
+}
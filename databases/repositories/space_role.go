@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
+)
+
+type SpaceRoleRepository interface {
+	ICrudRepository[entities.SpaceRole, uint]
+	GetBySpaceID(spaceID uint) ([]entities.SpaceRole, error)
+	NameExists(spaceID uint, name string, excludeID uint) (bool, error)
+	IsInUse(roleID uint) (bool, error)
+}
+
+type spaceRoleRepositoryImpl struct {
+	*CrudRepository[entities.SpaceRole, uint]
+}
+
+func NewSpaceRoleRepository() SpaceRoleRepository {
+	return &spaceRoleRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.SpaceRole, uint](),
+	}
+}
+
+// GetBySpaceID returns the built-in roles followed by the custom roles of the
+// space.
+func (r *spaceRoleRepositoryImpl) GetBySpaceID(spaceID uint) ([]entities.SpaceRole, error) {
+	var roles []entities.SpaceRole
+	db := databases.GetDB()
+	err := db.Where("space_id IS NULL OR space_id = ?", spaceID).
+		Order("space_id NULLS FIRST, id").
+		Find(&roles).Error
+	return roles, err
+}
+
+// NameExists compares names case-insensitively against the built-in roles and
+// the custom roles of the space.
+func (r *spaceRoleRepositoryImpl) NameExists(spaceID uint, name string, excludeID uint) (bool, error) {
+	var count int64
+	db := databases.GetDB()
+	err := db.Model(&entities.SpaceRole{}).
+		Where("(space_id IS NULL OR space_id = ?) AND LOWER(name) = LOWER(?) AND id <> ?", spaceID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// IsInUse reports whether a member, a pending invitation or an invitation link
+// that was not revoked still grants the role.
+func (r *spaceRoleRepositoryImpl) IsInUse(roleID uint) (bool, error) {
+	db := databases.GetDB()
+	queries := []*gorm.DB{
+		db.Model(&entities.SpaceUser{}).Where("space_role_id = ?", roleID),
+		db.Model(&entities.SpaceInvitation{}).Where("space_role_id = ? AND status = ?", roleID, entities.InvitationStatusPending),
+		db.Model(&entities.SpaceInvitationLink{}).Where("space_role_id = ? AND revoked_at IS NULL", roleID),
+	}
+	for _, query := range queries {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
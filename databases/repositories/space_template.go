@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+)
+
+type SpaceTemplateRepository interface {
+	ICrudRepository[entities.SpaceTemplate, uint]
+	GetByUserID(userID uint) ([]entities.SpaceTemplate, error)
+	GetWithDocuments(templateID uint) (*entities.SpaceTemplate, error)
+}
+
+type spaceTemplateRepositoryImpl struct {
+	*CrudRepository[entities.SpaceTemplate, uint]
+}
+
+func NewSpaceTemplateRepository() SpaceTemplateRepository {
+	return &spaceTemplateRepositoryImpl{
+		CrudRepository: NewCrudRepository[entities.SpaceTemplate, uint](),
+	}
+}
+
+func (r *spaceTemplateRepositoryImpl) GetByUserID(userID uint) ([]entities.SpaceTemplate, error) {
+	templates := []entities.SpaceTemplate{}
+	db := databases.GetDB()
+	err := db.Preload("Documents").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&templates).Error
+	return templates, err
+}
+
+func (r *spaceTemplateRepositoryImpl) GetWithDocuments(templateID uint) (*entities.SpaceTemplate, error) {
+	var template entities.SpaceTemplate
+	db := databases.GetDB()
+	if err := db.Preload("Documents").First(&template, templateID).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+)
+
+// activeSpaceJoin limits a space_users query to spaces that are not in the trash.
+const activeSpaceJoin = "JOIN spaces ON spaces.id = space_users.space_id AND spaces.deleted_at IS NULL"
+
+// ITrashRepository is implemented by repositories of soft deleted entities.
+// Delete of ICrudRepository moves such entities to the trash.
+type ITrashRepository[T entities.Entity, ID any] interface {
+	GetDeletedById(id ID) (*T, error)
+	GetIDsDeletedBefore(cutoff time.Time) ([]ID, error)
+	Restore(id ID) error
+	Purge(id ID) error
+}
+
+type TrashRepository[T entities.Entity, ID any] struct{}
+
+func (r *TrashRepository[T, ID]) GetDeletedById(id ID) (*T, error) {
+	db := databases.GetDB()
+	entity := new(T)
+	err := db.Unscoped().Where("deleted_at IS NOT NULL").First(entity, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *TrashRepository[T, ID]) GetIDsDeletedBefore(cutoff time.Time) ([]ID, error) {
+	ids := []ID{}
+	db := databases.GetDB()
+	err := db.Unscoped().Model(new(T)).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *TrashRepository[T, ID]) Restore(id ID) error {
+	db := databases.GetDB()
+	return db.Unscoped().Model(new(T)).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// Purge deletes the row for good, whether it is in the trash or not.
+func (r *TrashRepository[T, ID]) Purge(id ID) error {
+	db := databases.GetDB()
+	return db.Unscoped().Delete(new(T), id).Error
+}
@@ -19,6 +19,7 @@ type UserRepository interface {
 	SearchUsers(query string) ([]entities.User, error)
 	GetUserTier(userID uint) (*entities.Tier, error)
 	GetUserTierUsage(userID uint) (*dtos.TierUsageResponse, error)
+	GetStorageUsage(userID uint) (int64, error)
 }
 
 type userRepositoryImpl struct {
@@ -37,7 +38,8 @@ func (r *userRepositoryImpl) GetSpacesByUserId(userId uint) ([]dtos.UserSpaceDTO
 	db := databases.GetDB()
 	var spaceUsers []*entities.SpaceUser
 	err := db.Preload("Space").Preload("SpaceRole").
-		Where("user_id = ?", userId).
+		Joins(activeSpaceJoin).
+		Where("space_users.user_id = ?", userId).
 		Find(&spaceUsers).Error
 
 	if err != nil {
@@ -52,17 +54,19 @@ func (r *userRepositoryImpl) GetSpacesByUserId(userId uint) ([]dtos.UserSpaceDTO
 	var userSpaces []dtos.UserSpaceDTO
 	for _, su := range spaceUsers {
 		userSpace := dtos.UserSpaceDTO{
-			ID:              su.Space.ID,
-			Name:            su.Space.Name,
-			Description:     su.Space.Description,
-			PrivacyStatus:   su.Space.PrivacyStatus,
-			DocumentLimit:   su.Space.DocumentLimit,
-			FileSizeLimitKb: su.Space.FileSizeLimitKb,
-			ApiCallLimit:    su.Space.ApiCallLimit,
-			CreatedAt:       su.Space.CreatedAt,
-			UpdatedAt:       su.Space.UpdatedAt,
-			Role:            su.SpaceRole,
-			UserCount:       su.Space.UserCount,
+			ID:                su.Space.ID,
+			Name:              su.Space.Name,
+			Description:       su.Space.Description,
+			PrivacyStatus:     su.Space.PrivacyStatus,
+			DocumentLimit:     su.Space.DocumentLimit,
+			FileSizeLimitKb:   su.Space.FileSizeLimitKb,
+			ApiCallLimit:      su.Space.ApiCallLimit,
+			StorageLimitBytes: su.Space.StorageLimitBytes,
+			StorageUsedBytes:  su.Space.StorageUsedBytes,
+			CreatedAt:         su.Space.CreatedAt,
+			UpdatedAt:         su.Space.UpdatedAt,
+			Role:              su.SpaceRole,
+			UserCount:         su.Space.UserCount,
 		}
 		userSpaces = append(userSpaces, userSpace)
 	}
@@ -106,7 +110,9 @@ func (r *userRepositoryImpl) GetInvitationsByUserId(InvitedUserId uint) ([]entit
 	var invitations []entities.SpaceInvitation
 	db := databases.GetDB()
 	err := db.Preload("Space").Preload("Inviter").
-		Where("invited_user_id = ?", InvitedUserId).Find(&invitations).Error
+		Where("invited_user_id = ?", InvitedUserId).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Find(&invitations).Error
 	if err != nil {
 		return nil, err
 	}
@@ -159,19 +165,27 @@ func (s *userRepositoryImpl) GetUserTierUsage(userID uint) (*dtos.TierUsageRespo
 	response.Usage = &dtos.TierUsage{}
 
 	err = db.Model(&entities.SpaceUser{}).
-		Where("user_id = ? AND space_role_id = ?", userID, entities.SpaceRoleOwner).
+		Joins(activeSpaceJoin).
+		Where("space_users.user_id = ? AND space_users.space_role_id = ?", userID, entities.SpaceRoleOwner).
 		Count(&response.Usage.SpaceCount).Error
 	if err != nil {
 		return nil, err
 	}
 
 	err = db.Model(&entities.Document{}).
+		Joins("JOIN spaces ON spaces.id = documents.space_id AND spaces.deleted_at IS NULL").
 		Joins("JOIN space_users ON space_users.space_id = documents.space_id").
 		Where("space_users.user_id = ? AND space_users.space_role_id = ?", userID, entities.SpaceRoleOwner).
 		Count(&response.Usage.DocumentCount).Error
 	if err != nil {
 		return nil, err
 	}
+
+	response.Usage.StorageUsedBytes, err = s.GetStorageUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+
 	err = db.Model(&entities.ChatHistory{}).
 		Joins("JOIN user_query_sessions ON user_query_sessions.id = chat_histories.session_id").
 		Where("user_query_sessions.user_id = ?", userID).
@@ -203,6 +217,18 @@ func (s *userRepositoryImpl) GetUserTierUsage(userID uint) (*dtos.TierUsageRespo
 	return &response, nil
 }
 
+// GetStorageUsage adds up the storage used by the spaces a user owns.
+func (s *userRepositoryImpl) GetStorageUsage(userID uint) (int64, error) {
+	var total int64
+	db := databases.GetDB()
+	err := db.Model(&entities.SpaceUser{}).
+		Joins(activeSpaceJoin).
+		Where("space_users.user_id = ? AND space_users.space_role_id = ?", userID, entities.SpaceRoleOwner).
+		Select("COALESCE(SUM(spaces.storage_used_bytes), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
 func (r *userRepositoryImpl) aggregateUserCount(spaces []*entities.SpaceUser) ([]*entities.SpaceUser, error) {
 	type SpaceWithUserCount struct {
 		SpaceID   uint
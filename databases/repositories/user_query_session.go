@@ -6,24 +6,29 @@ import (
 
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"gorm.io/gorm"
 )
 
 type UserQuerySessionRepository interface {
 	ICrudRepository[entities.UserQuerySession, uint]
+	ITrashRepository[entities.UserQuerySession, uint]
+	GetDeletedByUserID(userID uint) ([]entities.UserQuerySession, error)
 	CountByUserID(userID uint) (int64, error)
 	GetByUserID(userID uint) ([]entities.UserQuerySession, error)
 	GetTempMessageByID(id uint) (*string, error)
 	GetChatHistoryBySessionID(sessionID uint) ([]map[string]interface{}, error)
-	ClearChatHistory(sessionID uint) error
+	GetBySpaceIDWithHistory(spaceID uint) ([]entities.UserQuerySession, error)
 }
 
 type userQuerySessionRepositoryImpl struct {
 	*CrudRepository[entities.UserQuerySession, uint]
+	*TrashRepository[entities.UserQuerySession, uint]
 }
 
 func NewUserQuerySessionRepository() UserQuerySessionRepository {
 	return &userQuerySessionRepositoryImpl{
-		CrudRepository: NewCrudRepository[entities.UserQuerySession, uint](),
+		CrudRepository:  NewCrudRepository[entities.UserQuerySession, uint](),
+		TrashRepository: &TrashRepository[entities.UserQuerySession, uint]{},
 	}
 }
 
@@ -38,6 +43,7 @@ func (s *userQuerySessionRepositoryImpl) GetByUserID(userID uint) ([]entities.Us
 	var sessions []entities.UserQuerySession
 	db := databases.GetDB()
 	err := db.Where("user_query_sessions.user_id = ?", userID).
+		Joins("INNER JOIN spaces ON spaces.id = user_query_sessions.space_id AND spaces.deleted_at IS NULL").
 		Joins("INNER JOIN space_users ON space_users.space_id = user_query_sessions.space_id AND space_users.user_id = ?", userID).
 		Joins("LEFT JOIN chat_histories ON chat_histories.session_id = user_query_sessions.id").
 		Group("user_query_sessions.id").
@@ -95,7 +101,34 @@ func (s *userQuerySessionRepositoryImpl) GetChatHistoryBySessionID(sessionID uin
 	return result, nil
 }
 
-func (s *userQuerySessionRepositoryImpl) ClearChatHistory(sessionID uint) error {
+// GetBySpaceIDWithHistory returns the sessions of a space with their users
+// and chat history in order.
+func (s *userQuerySessionRepositoryImpl) GetBySpaceIDWithHistory(spaceID uint) ([]entities.UserQuerySession, error) {
+	sessions := []entities.UserQuerySession{}
+	db := databases.GetDB()
+	err := db.Preload("User").
+		Preload("ChatHistories", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Where("space_id = ?", spaceID).
+		Order("created_at ASC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (s *userQuerySessionRepositoryImpl) GetDeletedByUserID(userID uint) ([]entities.UserQuerySession, error) {
+	sessions := []entities.UserQuerySession{}
+	db := databases.GetDB()
+	err := db.Unscoped().
+		Joins("JOIN spaces ON spaces.id = user_query_sessions.space_id AND spaces.deleted_at IS NULL").
+		Where("user_query_sessions.user_id = ? AND user_query_sessions.deleted_at IS NOT NULL", userID).
+		Order("user_query_sessions.deleted_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Purge deletes the session together with its chat history.
+func (s *userQuerySessionRepositoryImpl) Purge(sessionID uint) error {
 	db := databases.GetDB()
 
 	err := db.Where("session_id = ?", sessionID).Delete(&entities.ChatHistory{}).Error
@@ -108,7 +141,7 @@ func (s *userQuerySessionRepositoryImpl) ClearChatHistory(sessionID uint) error
 		return fmt.Errorf("failed to delete user queries: %v", err)
 	}
 
-	err = db.Where("id = ?", sessionID).Delete(&entities.UserQuerySession{}).Error
+	err = db.Unscoped().Where("id = ?", sessionID).Delete(&entities.UserQuerySession{}).Error
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %v", err)
 	}
@@ -0,0 +1,189 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+type ZipLimits struct {
+	MaxEntries          int
+	MaxEntrySize        int64
+	MaxTotalSize        int64
+	MaxCompressionRatio uint64
+}
+
+// ZipEntry is a file read from an archive. Reason is set when the entry was
+// rejected during extraction, in which case Data is nil.
+type ZipEntry struct {
+	Path   string
+	Name   string
+	Data   []byte
+	Reason string
+}
+
+var nestedArchiveExtensions = map[string]bool{
+	".zip": true, ".rar": true, ".7z": true, ".tar": true, ".gz": true,
+	".tgz": true, ".bz2": true, ".xz": true, ".jar": true,
+}
+
+var zipContainerExtensions = map[string]bool{
+	".docx": true, ".xlsx": true, ".pptx": true,
+}
+
+var archiveSignatures = [][]byte{
+	[]byte("PK\x03\x04"),
+	[]byte("Rar!\x1a\x07"),
+	[]byte("7z\xbc\xaf\x27\x1c"),
+	[]byte("\x1f\x8b"),
+	[]byte("BZh"),
+	[]byte("\xfd7zXZ\x00"),
+}
+
+// ReadZipEntries walks the files of a ZIP archive and calls fn for each of them,
+// guarding against path traversal, nested archives and decompression bombs.
+// Entries are read one at a time so only a single file is held in memory.
+func ReadZipEntries(r io.ReaderAt, size int64, limits ZipLimits, fn func(entry ZipEntry)) error {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %v", err)
+	}
+
+	files := []*zip.File{}
+	for _, file := range reader.File {
+		if !file.FileInfo().IsDir() {
+			files = append(files, file)
+		}
+	}
+
+	if limits.MaxEntries > 0 && len(files) > limits.MaxEntries {
+		return fmt.Errorf("zip archive contains %d files, the maximum is %d", len(files), limits.MaxEntries)
+	}
+
+	var total int64
+	for _, file := range files {
+		entry := ZipEntry{Path: file.Name, Name: path.Base(strings.ReplaceAll(file.Name, "\\", "/"))}
+
+		if limits.MaxTotalSize > 0 && total > limits.MaxTotalSize {
+			entry.Reason = "archive exceeds the total uncompressed size limit"
+			fn(entry)
+			continue
+		}
+
+		entry.Reason = checkZipFile(file, limits)
+		if entry.Reason == "" {
+			entry.Data, entry.Reason = readZipFile(file, limits)
+			total += int64(len(entry.Data))
+			if entry.Reason == "" && limits.MaxTotalSize > 0 && total > limits.MaxTotalSize {
+				entry.Data = nil
+				entry.Reason = "archive exceeds the total uncompressed size limit"
+			}
+		}
+
+		fn(entry)
+	}
+
+	return nil
+}
+
+// ReadZipFile reads the file with the exact name from an archive, failing
+// when it is missing or larger than maxSize.
+func ReadZipFile(reader *zip.Reader, name string, maxSize int64) ([]byte, error) {
+	file, err := reader.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is missing from the archive", name)
+	}
+	defer file.Close()
+
+	// The declared size in the header cannot be trusted
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%s exceeds the maximum allowed size", name)
+	}
+	return data, nil
+}
+
+func checkZipFile(file *zip.File, limits ZipLimits) string {
+	name := strings.ReplaceAll(file.Name, "\\", "/")
+	if strings.HasPrefix(name, "/") || strings.Contains(name, ":") {
+		return "absolute paths are not allowed"
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "path traversal is not allowed"
+		}
+	}
+
+	base := path.Base(name)
+	if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+		return "hidden or system file"
+	}
+
+	if file.Mode().Type() != 0 {
+		return "links and special files are not allowed"
+	}
+
+	if file.Flags&0x1 != 0 {
+		return "encrypted entries are not supported"
+	}
+
+	if nestedArchiveExtensions[strings.ToLower(path.Ext(base))] {
+		return "nested archives are not allowed"
+	}
+
+	if limits.MaxEntrySize > 0 && file.UncompressedSize64 > uint64(limits.MaxEntrySize) {
+		return "file exceeds the maximum allowed size"
+	}
+
+	if limits.MaxCompressionRatio > 0 {
+		compressed := file.CompressedSize64
+		if compressed == 0 {
+			compressed = 1
+		}
+		if file.UncompressedSize64/compressed > limits.MaxCompressionRatio {
+			return "compression ratio is suspiciously high"
+		}
+	}
+
+	return ""
+}
+
+func readZipFile(file *zip.File, limits ZipLimits) ([]byte, string) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Sprintf("failed to open entry: %v", err)
+	}
+	defer rc.Close()
+
+	var reader io.Reader = rc
+	if limits.MaxEntrySize > 0 {
+		// The declared size in the header cannot be trusted
+		reader = io.LimitReader(rc, limits.MaxEntrySize+1)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Sprintf("failed to read entry: %v", err)
+	}
+
+	if limits.MaxEntrySize > 0 && int64(len(data)) > limits.MaxEntrySize {
+		return nil, "file exceeds the maximum allowed size"
+	}
+
+	ext := strings.ToLower(path.Ext(file.Name))
+	if !zipContainerExtensions[ext] {
+		for _, signature := range archiveSignatures {
+			if bytes.HasPrefix(data, signature) {
+				return nil, "nested archives are not allowed"
+			}
+		}
+	}
+
+	return data, ""
+}
@@ -0,0 +1,200 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type FetchLimits struct {
+	MaxSize      int64
+	Timeout      time.Duration
+	MaxRedirects int
+	UserAgent    string
+}
+
+type FetchedContent struct {
+	URL         string
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+var ErrDestinationNotAllowed = errors.New("destination is not allowed")
+
+var blockedNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",
+		"100.64.0.0/10",
+		"192.0.0.0/24",
+		"192.0.2.0/24",
+		"198.18.0.0/15",
+		"198.51.100.0/24",
+		"203.0.113.0/24",
+		"240.0.0.0/4",
+		"64:ff9b::/96",
+		"2001:db8::/32",
+	}
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// IsPublicIP reports whether ip is a globally routable unicast address, which
+// excludes loopback, private, link-local (cloud metadata), CGNAT and reserved ranges.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateFetchURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme: %s", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("invalid URL: missing host")
+	}
+	if u.User != nil {
+		return errors.New("invalid URL: credentials are not allowed")
+	}
+	return nil
+}
+
+func newSafeHTTPClient(limits FetchLimits) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: limits.Timeout,
+		// Checked on the resolved address so DNS rebinding cannot reach internal hosts
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicIP(net.ParseIP(host)) {
+				return ErrDestinationNotAllowed
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   limits.Timeout,
+		ResponseHeaderTimeout: limits.Timeout,
+		MaxIdleConns:          1,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   limits.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > limits.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", limits.MaxRedirects)
+			}
+			return validateFetchURL(req.URL)
+		},
+	}
+}
+
+// FetchURL downloads a public web resource while enforcing the given limits.
+func FetchURL(rawURL string, limits FetchLimits) (*FetchedContent, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %v", err)
+	}
+	if err := validateFetchURL(u); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), limits.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if limits.UserAgent != "" {
+		req.Header.Set("User-Agent", limits.UserAgent)
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain,text/markdown,application/pdf,*/*;q=0.8")
+
+	resp, err := newSafeHTTPClient(limits).Do(req)
+	if err != nil {
+		if errors.Is(err, ErrDestinationNotAllowed) {
+			return nil, ErrDestinationNotAllowed
+		}
+		return nil, fmt.Errorf("failed to fetch URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch URL, status: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > limits.MaxSize {
+		return nil, fmt.Errorf("remote content exceeds the limit of %d KB", limits.MaxSize/1024)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limits.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote content: %v", err)
+	}
+	if int64(len(data)) > limits.MaxSize {
+		return nil, fmt.Errorf("remote content exceeds the limit of %d KB", limits.MaxSize/1024)
+	}
+
+	finalURL := resp.Request.URL
+	filename := path.Base(finalURL.Path)
+	if filename == "/" || filename == "." {
+		filename = finalURL.Hostname()
+	}
+
+	contentType, err := DetectMimeType(filename, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Sniffing cannot tell markup fragments from plain text, the server can
+	declared, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/plain") && (declared == "text/html" || declared == "application/xhtml+xml") {
+		contentType = declared
+	}
+
+	return &FetchedContent{
+		URL:         finalURL.String(),
+		Filename:    filename,
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
@@ -1,19 +1,90 @@
 package helpers
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
+type UploadFile struct {
+	Filename string
+	Size     int64
+	open     func() (multipart.File, error)
+}
+
+type bytesFile struct {
+	*bytes.Reader
+}
+
+func (f bytesFile) Close() error {
+	return nil
+}
+
+func NewUploadFileFromHeader(fileHeader *multipart.FileHeader) *UploadFile {
+	return &UploadFile{
+		Filename: fileHeader.Filename,
+		Size:     fileHeader.Size,
+		open:     fileHeader.Open,
+	}
+}
+
+func NewUploadFileFromBytes(filename string, data []byte) *UploadFile {
+	return &UploadFile{
+		Filename: filename,
+		Size:     int64(len(data)),
+		open: func() (multipart.File, error) {
+			return bytesFile{bytes.NewReader(data)}, nil
+		},
+	}
+}
+
+// NewUploadFileFromPath reads the file from disk on every Open, e.g. an upload
+// assembled from chunks in a temporary file.
+func NewUploadFileFromPath(filename string, path string) (*UploadFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadFile{
+		Filename: filename,
+		Size:     info.Size(),
+		open: func() (multipart.File, error) {
+			return os.Open(path)
+		},
+	}, nil
+}
+
+func (f *UploadFile) Open() (multipart.File, error) {
+	return f.open()
+}
+
+// ContentHash streams the file through SHA-256 and returns the hex digest.
+func (f *UploadFile) ContentHash() (string, error) {
+	file, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
 func GetUniqueFileKey(filename string) string {
 	now := time.Now()
 
@@ -45,19 +116,30 @@ func UploadToS3(bucket string, key string, file multipart.File) (string, error)
 		return "", fmt.Errorf("unable to upload file to S3, %v", err)
 	}
 
-	fileUrl := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, key)
-	return fileUrl, nil
+	return GetS3URL(bucket, key), nil
+}
+
+func GetS3URL(bucket string, key string) string {
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, key)
 }
 
 func GetMimeType(fileHeader *multipart.FileHeader) (string, error) {
-	file, err := fileHeader.Open()
+	return GetUploadFileMimeType(NewUploadFileFromHeader(fileHeader))
+}
+
+func GetUploadFileMimeType(uploadFile *UploadFile) (string, error) {
+	file, err := uploadFile.Open()
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
+	return DetectMimeType(uploadFile.Filename, file)
+}
+
+func DetectMimeType(filename string, file io.ReadSeeker) (string, error) {
 	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
+	_, err := file.Read(buffer)
 	if err != nil && err != io.EOF {
 		return "", err
 	}
@@ -67,7 +149,7 @@ func GetMimeType(fileHeader *multipart.FileHeader) (string, error) {
 	contentType := http.DetectContentType(buffer)
 
 	if contentType == "application/octet-stream" || contentType == "application/zip" || contentType == "application/x-zip-compressed" {
-		ext := filepath.Ext(fileHeader.Filename)
+		ext := filepath.Ext(filename)
 		switch ext {
 		case ".pdf":
 			return "application/pdf", nil
@@ -95,11 +177,115 @@ func GetMimeType(fileHeader *multipart.FileHeader) (string, error) {
 	return contentType, nil
 }
 
+func GetS3Key(s3URL string) string {
+	return filepath.Base(s3URL)
+}
+
+func GetS3Object(bucket string, key string) (io.ReadCloser, error) {
+	sess := ConnectAWS()
+	s3Client := s3.New(sess)
+
+	output, err := s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get file from S3, %v", err)
+	}
+
+	return output.Body, nil
+}
+
+type S3Object struct {
+	Key          string
+	LastModified time.Time
+}
+
+func ListS3Objects(bucket string) ([]S3Object, error) {
+	sess := ConnectAWS()
+	s3Client := s3.New(sess)
+
+	objects := []S3Object{}
+	err := s3Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			objects = append(objects, S3Object{
+				Key:          aws.StringValue(object.Key),
+				LastModified: aws.TimeValue(object.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list files in S3, %v", err)
+	}
+
+	return objects, nil
+}
+
+func S3ObjectExists(bucket string, key string) (bool, error) {
+	sess := ConnectAWS()
+	s3Client := s3.New(sess)
+
+	_, err := s3Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to check file in S3, %v", err)
+	}
+
+	return true, nil
+}
+
+func DownloadFromS3(bucket string, key string) ([]byte, error) {
+	body, err := GetS3Object(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file from S3, %v", err)
+	}
+
+	return data, nil
+}
+
+func GetPresignedS3URL(bucket string, key string, expiry time.Duration, contentType string, contentDisposition string) (string, error) {
+	sess := ConnectAWS()
+	s3Client := s3.New(sess)
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ResponseContentType = aws.String(contentType)
+	}
+	if contentDisposition != "" {
+		input.ResponseContentDisposition = aws.String(contentDisposition)
+	}
+
+	req, _ := s3Client.GetObjectRequest(input)
+	url, err := req.Presign(expiry)
+	if err != nil {
+		return "", fmt.Errorf("unable to presign S3 URL, %v", err)
+	}
+
+	return url, nil
+}
+
 func DeleteFromS3(bucket string, s3URL string) error {
 	sess := ConnectAWS()
 	s3Client := s3.New(sess)
 
-	key := filepath.Base(s3URL)
+	key := GetS3Key(s3URL)
 
 	deleteParams := &s3.DeleteObjectInput{
 		Bucket: aws.String(bucket),
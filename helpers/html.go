@@ -0,0 +1,268 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var skippedHTMLElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+	atom.Button: true, atom.Svg: true, atom.Iframe: true, atom.Object: true,
+	atom.Canvas: true, atom.Select: true, atom.Head: true,
+}
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+var spacesPattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+type markdownWriter struct {
+	buf      bytes.Buffer
+	base     *url.URL
+	listDeep int
+	inPre    bool
+}
+
+// HTMLToMarkdown converts an HTML page into readable Markdown and returns it
+// together with the page title. Navigation, scripts and other chrome are dropped.
+func HTMLToMarkdown(data []byte, pageURL string) (string, string, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	base, _ := url.Parse(pageURL)
+	title := strings.TrimSpace(findHTMLTitle(doc))
+
+	root := findHTMLElement(doc, atom.Main)
+	if root == nil {
+		root = findHTMLElement(doc, atom.Article)
+	}
+	if root == nil {
+		root = findHTMLElement(doc, atom.Body)
+	}
+	if root == nil {
+		root = doc
+	}
+
+	w := &markdownWriter{base: base}
+	w.writeChildren(root)
+
+	markdown := blankLinesPattern.ReplaceAllString(w.buf.String(), "\n\n")
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	markdown = strings.TrimSpace(strings.Join(lines, "\n"))
+
+	if title != "" && !strings.HasPrefix(markdown, "# ") {
+		markdown = "# " + title + "\n\n" + markdown
+	}
+
+	return title, markdown + "\n", nil
+}
+
+func findHTMLTitle(n *html.Node) string {
+	if title := findHTMLElement(n, atom.Title); title != nil {
+		return textContent(title)
+	}
+	if h1 := findHTMLElement(n, atom.H1); h1 != nil {
+		return textContent(h1)
+	}
+	return ""
+}
+
+func findHTMLElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findHTMLElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return spacesPattern.ReplaceAllString(sb.String(), " ")
+}
+
+func (w *markdownWriter) writeChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.writeNode(c)
+	}
+}
+
+func (w *markdownWriter) block(prefix string) {
+	w.buf.WriteString("\n\n")
+	w.buf.WriteString(prefix)
+}
+
+func (w *markdownWriter) writeNode(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.inPre {
+			w.buf.WriteString(n.Data)
+			return
+		}
+		text := spacesPattern.ReplaceAllString(n.Data, " ")
+		if text == " " && (w.buf.Len() == 0 || strings.HasSuffix(w.buf.String(), "\n")) {
+			return
+		}
+		w.buf.WriteString(text)
+		return
+	case html.ElementNode:
+	default:
+		w.writeChildren(n)
+		return
+	}
+
+	if skippedHTMLElements[n.DataAtom] || hasHTMLAttr(n, "hidden") || getHTMLAttr(n, "aria-hidden") == "true" {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		w.block(strings.Repeat("#", level) + " ")
+		w.buf.WriteString(strings.TrimSpace(textContent(n)))
+		w.buf.WriteString("\n\n")
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Table, atom.Figure:
+		w.block("")
+		w.writeChildren(n)
+		w.buf.WriteString("\n\n")
+	case atom.Br:
+		w.buf.WriteString("  \n")
+	case atom.Hr:
+		w.block("---\n\n")
+	case atom.Ul, atom.Ol:
+		w.listDeep++
+		w.buf.WriteString("\n")
+		index := 1
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+				continue
+			}
+			marker := "- "
+			if n.DataAtom == atom.Ol {
+				marker = fmt.Sprintf("%d. ", index)
+				index++
+			}
+			w.buf.WriteString("\n" + strings.Repeat("  ", w.listDeep-1) + marker)
+			w.writeChildren(c)
+		}
+		w.listDeep--
+		w.buf.WriteString("\n\n")
+	case atom.Tr:
+		w.buf.WriteString("\n|")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th) {
+				w.buf.WriteString(" " + strings.TrimSpace(textContent(c)) + " |")
+			}
+		}
+	case atom.Pre:
+		w.block("```\n")
+		w.inPre = true
+		w.buf.WriteString(strings.Trim(textContentRaw(n), "\n"))
+		w.inPre = false
+		w.buf.WriteString("\n```\n\n")
+	case atom.Code:
+		w.buf.WriteString("`" + textContent(n) + "`")
+	case atom.Strong, atom.B:
+		w.wrapInline(n, "**")
+	case atom.Em, atom.I:
+		w.wrapInline(n, "_")
+	case atom.Blockquote:
+		w.block("> ")
+		w.buf.WriteString(strings.TrimSpace(textContent(n)))
+		w.buf.WriteString("\n\n")
+	case atom.A:
+		text := strings.TrimSpace(textContent(n))
+		href := w.resolveURL(getHTMLAttr(n, "href"))
+		if href == "" || text == "" || strings.HasPrefix(href, "javascript:") {
+			w.buf.WriteString(text)
+			return
+		}
+		w.buf.WriteString("[" + text + "](" + href + ")")
+	case atom.Img:
+		alt := strings.TrimSpace(getHTMLAttr(n, "alt"))
+		if alt != "" {
+			w.buf.WriteString("[Image: " + alt + "]")
+		}
+	default:
+		w.writeChildren(n)
+	}
+}
+
+func (w *markdownWriter) wrapInline(n *html.Node, marker string) {
+	text := strings.TrimSpace(textContent(n))
+	if text == "" {
+		return
+	}
+	w.buf.WriteString(marker + text + marker)
+}
+
+func (w *markdownWriter) resolveURL(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if w.base == nil {
+		return ref.String()
+	}
+	return w.base.ResolveReference(ref).String()
+}
+
+func textContentRaw(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func getHTMLAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func hasHTMLAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
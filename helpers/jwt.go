@@ -37,6 +37,27 @@ func VerifyJWTToken(tokenString string) (uint, error) {
 	return uint(userIDFloat), nil
 }
 
+// GenerateInvitationLinkToken signs a token naming an invitation link. The
+// token expires with the link, if the link expires at all.
+func GenerateInvitationLinkToken(linkID uint, exp *time.Time) (string, error) {
+	token, _, err := GenerateTokenForPayload(map[string]interface{}{"link_id": linkID}, exp)
+	return token, err
+}
+
+func VerifyInvitationLinkToken(tokenString string) (uint, error) {
+	payload, err := VerifyTokenForPayload(tokenString)
+	if err != nil {
+		return 0, err
+	}
+
+	linkIDFloat, ok := (*payload)["link_id"].(float64)
+	if !ok {
+		return 0, errors.New("invalid link ID in token")
+	}
+
+	return uint(linkIDFloat), nil
+}
+
 func GenerateTokenForPayload(payload map[string]interface{}, exp *time.Time) (string, *time.Time, error) {
 	config := configs.GetEnv()
 	jwtSecret := config.JwtSecret
@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FileMetadata is the information extracted from a document file. Counts that
+// do not apply to a file type are left at zero.
+type FileMetadata struct {
+	Title      string `json:"title,omitempty"`
+	Author     string `json:"author,omitempty"`
+	PageCount  int    `json:"page_count,omitempty"`
+	SlideCount int    `json:"slide_count,omitempty"`
+	SheetCount int    `json:"sheet_count,omitempty"`
+	RowCount   int    `json:"row_count,omitempty"`
+	WordCount  int    `json:"word_count,omitempty"`
+	Language   string `json:"language,omitempty"`
+}
+
+const maxLanguageSampleSize = 64 * 1024
+
+// ExtractFileMetadata inspects the file content based on its MIME type. It is
+// best effort: unsupported or malformed files yield whatever could be read.
+func ExtractFileMetadata(filename string, mimeType string, data []byte) (FileMetadata, error) {
+	mediaType := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+
+	metadata, text, supported, err := extractFileContent(mediaType, data)
+	if !supported {
+		return metadata, nil
+	}
+
+	if text != "" && utf8.ValidString(text) {
+		if metadata.WordCount == 0 {
+			metadata.WordCount = CountWords(text)
+		}
+		metadata.Language = GuessLanguage(text)
+	}
+
+	if metadata.Title == "" && mediaType != "text/csv" {
+		metadata.Title = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+
+	return metadata, err
+}
+
+// ExtractFileText returns the plain text of the file. Like the metadata it is
+// best effort: unsupported types and scanned PDFs without a text layer yield
+// an empty string.
+func ExtractFileText(mimeType string, data []byte) (string, error) {
+	mediaType := strings.TrimSpace(strings.Split(mimeType, ";")[0])
+
+	_, text, _, err := extractFileContent(mediaType, data)
+
+	// Postgres text columns reject NUL bytes and invalid UTF-8
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\x00", "")
+	return text, err
+}
+
+func extractFileContent(mediaType string, data []byte) (FileMetadata, string, bool, error) {
+	var metadata FileMetadata
+	var text string
+	var err error
+
+	switch mediaType {
+	case "application/pdf":
+		metadata, text, err = extractPDFMetadata(data)
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		metadata, text, err = extractDOCXMetadata(data)
+	case "application/vnd.openxmlformats-officedocument.presentationml.presentation":
+		metadata, text, err = extractPPTXMetadata(data)
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		metadata, text, err = extractXLSXMetadata(data)
+	case "text/csv":
+		metadata, text, err = extractCSVMetadata(data)
+	case "text/markdown":
+		text = string(data)
+		metadata.Title = markdownTitle(text)
+	case "text/plain":
+		text = string(data)
+	default:
+		return metadata, "", false, nil
+	}
+
+	return metadata, text, true, err
+}
+
+func CountWords(text string) int {
+	count := 0
+	inWord := false
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if !inWord {
+				count++
+				inWord = true
+			}
+			continue
+		}
+		if r != '\'' && r != '-' {
+			inWord = false
+		}
+	}
+	return count
+}
+
+func markdownTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return ""
+}
+
+func extractCSVMetadata(data []byte) (FileMetadata, string, error) {
+	var metadata FileMetadata
+	var text strings.Builder
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return metadata, text.String(), err
+		}
+		metadata.RowCount++
+		text.WriteString(strings.Join(record, " "))
+		text.WriteString("\n")
+	}
+
+	metadata.SheetCount = 1
+	metadata.WordCount = CountWords(text.String())
+	return metadata, text.String(), nil
+}
+
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "it", "with", "as", "are", "this", "on", "be"},
+	"vi": {"và", "của", "là", "các", "có", "được", "trong", "cho", "không", "những", "một", "này", "với", "để", "người"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "dans", "que", "pour", "pas", "sur", "qui", "du", "au"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "sich", "des", "auf", "für"},
+	"es": {"el", "la", "de", "que", "y", "los", "en", "las", "por", "un", "una", "para", "con", "es", "del"},
+}
+
+// GuessLanguage returns the ISO 639-1 code of the most likely language of text
+// based on stopword frequency, or an empty string when there is too little
+// evidence.
+func GuessLanguage(text string) string {
+	if len(text) > maxLanguageSampleSize {
+		text = text[:maxLanguageSampleSize]
+		for !utf8.ValidString(text) && len(text) > 0 {
+			text = text[:len(text)-1]
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) < 20 {
+		return ""
+	}
+
+	stopwords := map[string]map[string]bool{}
+	for language, list := range languageStopwords {
+		stopwords[language] = map[string]bool{}
+		for _, word := range list {
+			stopwords[language][word] = true
+		}
+	}
+
+	scores := map[string]int{}
+	for _, word := range words {
+		for language, set := range stopwords {
+			if set[word] {
+				scores[language]++
+			}
+		}
+	}
+
+	best, bestScore := "", 0
+	for language, score := range scores {
+		if score > bestScore || (score == bestScore && language < best) {
+			best, bestScore = language, score
+		}
+	}
+
+	if bestScore*20 < len(words) {
+		return ""
+	}
+	return best
+}
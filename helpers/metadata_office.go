@@ -0,0 +1,201 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Office parts are small XML files, anything larger is not worth reading
+const maxOfficePartSize = 32 * 1024 * 1024
+
+var slidePartPattern = regexp.MustCompile(`^ppt/slides/slide\d+\.xml$`)
+
+type officeCoreProperties struct {
+	Title   string `xml:"title"`
+	Creator string `xml:"creator"`
+}
+
+type officeAppProperties struct {
+	Pages  int `xml:"Pages"`
+	Slides int `xml:"Slides"`
+	Words  int `xml:"Words"`
+}
+
+type officePackage struct {
+	files map[string]*zip.File
+}
+
+func openOfficePackage(data []byte) (*officePackage, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid office document: %v", err)
+	}
+
+	pkg := &officePackage{files: map[string]*zip.File{}}
+	for _, file := range reader.File {
+		pkg.files[path.Clean(file.Name)] = file
+	}
+	return pkg, nil
+}
+
+func (p *officePackage) read(name string) ([]byte, bool) {
+	file, ok := p.files[name]
+	if !ok || file.UncompressedSize64 > maxOfficePartSize {
+		return nil, false
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, false
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxOfficePartSize))
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+func (p *officePackage) properties() (FileMetadata, officeAppProperties) {
+	var metadata FileMetadata
+	var app officeAppProperties
+
+	if content, ok := p.read("docProps/core.xml"); ok {
+		var core officeCoreProperties
+		if xml.Unmarshal(content, &core) == nil {
+			metadata.Title = strings.TrimSpace(core.Title)
+			metadata.Author = strings.TrimSpace(core.Creator)
+		}
+	}
+
+	if content, ok := p.read("docProps/app.xml"); ok {
+		_ = xml.Unmarshal(content, &app)
+	}
+
+	return metadata, app
+}
+
+// text concatenates the character data of every element with the given local
+// name, e.g. "t" for runs of text in all three OOXML formats.
+func (p *officePackage) text(name string, element string) string {
+	content, ok := p.read(name)
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == element {
+				depth++
+			}
+		case xml.EndElement:
+			if t.Name.Local == element && depth > 0 {
+				depth--
+				sb.WriteString(" ")
+			} else if t.Name.Local == "p" {
+				sb.WriteString("\n")
+			}
+		case xml.CharData:
+			if depth > 0 {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func extractDOCXMetadata(data []byte) (FileMetadata, string, error) {
+	pkg, err := openOfficePackage(data)
+	if err != nil {
+		return FileMetadata{}, "", err
+	}
+
+	metadata, app := pkg.properties()
+	metadata.PageCount = app.Pages
+
+	text := pkg.text("word/document.xml", "t")
+	metadata.WordCount = CountWords(text)
+	return metadata, text, nil
+}
+
+func extractPPTXMetadata(data []byte) (FileMetadata, string, error) {
+	pkg, err := openOfficePackage(data)
+	if err != nil {
+		return FileMetadata{}, "", err
+	}
+
+	metadata, app := pkg.properties()
+
+	slides := []string{}
+	for name := range pkg.files {
+		if slidePartPattern.MatchString(name) {
+			slides = append(slides, name)
+		}
+	}
+	sort.Slice(slides, func(i, j int) bool {
+		return slideNumber(slides[i]) < slideNumber(slides[j])
+	})
+
+	metadata.SlideCount = len(slides)
+	if metadata.SlideCount == 0 {
+		metadata.SlideCount = app.Slides
+	}
+
+	var sb strings.Builder
+	for _, slide := range slides {
+		sb.WriteString(pkg.text(slide, "t"))
+		sb.WriteString("\n")
+	}
+
+	text := sb.String()
+	metadata.WordCount = CountWords(text)
+	return metadata, text, nil
+}
+
+func slideNumber(name string) int {
+	number, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "ppt/slides/slide"), ".xml"))
+	return number
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+func extractXLSXMetadata(data []byte) (FileMetadata, string, error) {
+	pkg, err := openOfficePackage(data)
+	if err != nil {
+		return FileMetadata{}, "", err
+	}
+
+	metadata, _ := pkg.properties()
+
+	if content, ok := pkg.read("xl/workbook.xml"); ok {
+		var workbook xlsxWorkbook
+		if xml.Unmarshal(content, &workbook) == nil {
+			metadata.SheetCount = len(workbook.Sheets)
+		}
+	}
+
+	text := pkg.text("xl/sharedStrings.xml", "t")
+	metadata.WordCount = CountWords(text)
+	return metadata, text, nil
+}
@@ -0,0 +1,253 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	maxPDFStreamSize   = 16 * 1024 * 1024
+	maxPDFInflatedSize = 64 * 1024 * 1024
+)
+
+var (
+	pdfPagesCountPattern = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfPagePattern       = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfStreamPattern     = regexp.MustCompile(`(?s)<<(.{0,1000}?)>>\s*stream\r?\n`)
+	pdfTextBlockPattern  = regexp.MustCompile(`(?s)\bBT\b(.*?)\bET\b`)
+)
+
+// extractPDFMetadata reads the document information dictionary, the page tree
+// and the literal text of content streams. Text in hex strings or custom font
+// encodings is not decoded, so the word count is a lower bound.
+func extractPDFMetadata(data []byte) (FileMetadata, string, error) {
+	var metadata FileMetadata
+
+	encrypted := bytes.Contains(data, []byte("/Encrypt"))
+	var streams [][]byte
+	if !encrypted {
+		streams = decodePDFStreams(data)
+	}
+
+	var text strings.Builder
+	for index, body := range append([][]byte{data}, streams...) {
+		for _, match := range pdfPagesCountPattern.FindAllSubmatch(body, -1) {
+			count := match[1]
+			if len(count) == 0 {
+				count = match[2]
+			}
+			if n, err := strconv.Atoi(string(count)); err == nil && n > metadata.PageCount {
+				metadata.PageCount = n
+			}
+		}
+
+		if encrypted {
+			continue
+		}
+
+		if metadata.Title == "" {
+			metadata.Title = pdfInfoString(body, "/Title")
+		}
+		if metadata.Author == "" {
+			metadata.Author = pdfInfoString(body, "/Author")
+		}
+
+		// The file itself is only searched for dictionaries, text lives in streams
+		if index == 0 {
+			continue
+		}
+
+		for _, block := range pdfTextBlockPattern.FindAllSubmatch(body, -1) {
+			for _, literal := range pdfLiteralStrings(block[1]) {
+				text.WriteString(decodePDFString(literal))
+				text.WriteString(" ")
+			}
+			text.WriteString("\n")
+		}
+	}
+
+	if metadata.PageCount == 0 {
+		metadata.PageCount = len(pdfPagePattern.FindAll(data, -1))
+	}
+
+	return metadata, text.String(), nil
+}
+
+// decodePDFStreams returns the content of unfiltered and Flate encoded
+// streams. Streams with other filters (images, fonts) are skipped.
+func decodePDFStreams(data []byte) [][]byte {
+	var streams [][]byte
+	total := 0
+
+	for _, loc := range pdfStreamPattern.FindAllSubmatchIndex(data, -1) {
+		dict := data[loc[2]:loc[3]]
+
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+
+		if !bytes.Contains(dict, []byte("/Filter")) {
+			total += end
+			if total > maxPDFInflatedSize {
+				break
+			}
+			streams = append(streams, data[start:start+end])
+			continue
+		}
+		if !bytes.Contains(dict, []byte("/FlateDecode")) {
+			continue
+		}
+
+		reader, err := zlib.NewReader(bytes.NewReader(data[start : start+end]))
+		if err != nil {
+			continue
+		}
+		inflated, _ := io.ReadAll(io.LimitReader(reader, maxPDFStreamSize))
+		reader.Close()
+
+		total += len(inflated)
+		if total > maxPDFInflatedSize {
+			break
+		}
+		streams = append(streams, inflated)
+	}
+
+	return streams
+}
+
+// pdfInfoString returns the decoded string value following key, e.g. /Title.
+func pdfInfoString(body []byte, key string) string {
+	offset := 0
+	for {
+		index := bytes.Index(body[offset:], []byte(key))
+		if index < 0 {
+			return ""
+		}
+		offset += index + len(key)
+
+		rest := bytes.TrimLeft(body[offset:], " \t\r\n")
+		if len(rest) == 0 {
+			return ""
+		}
+
+		switch rest[0] {
+		case '(':
+			if literals := pdfLiteralStrings(rest); len(literals) > 0 {
+				return strings.TrimSpace(decodePDFString(literals[0]))
+			}
+		case '<':
+			if len(rest) > 1 && rest[1] == '<' {
+				continue
+			}
+			end := bytes.IndexByte(rest, '>')
+			if end < 0 {
+				return ""
+			}
+			return strings.TrimSpace(decodePDFString(decodePDFHex(rest[1:end])))
+		}
+	}
+}
+
+// pdfLiteralStrings returns the unescaped content of every (...) string in
+// data, honouring nested parentheses and backslash escapes.
+func pdfLiteralStrings(data []byte) [][]byte {
+	var result [][]byte
+	for i := 0; i < len(data); i++ {
+		if data[i] != '(' {
+			continue
+		}
+
+		var literal []byte
+		depth := 1
+		i++
+		for ; i < len(data) && depth > 0; i++ {
+			c := data[i]
+			switch c {
+			case '\\':
+				i++
+				if i >= len(data) {
+					break
+				}
+				switch data[i] {
+				case 'n':
+					literal = append(literal, '\n')
+				case 'r':
+					literal = append(literal, '\r')
+				case 't':
+					literal = append(literal, '\t')
+				case 'b', 'f':
+				case '\r', '\n':
+				default:
+					if data[i] >= '0' && data[i] <= '7' {
+						end := i + 1
+						for end < len(data) && end < i+3 && data[end] >= '0' && data[end] <= '7' {
+							end++
+						}
+						value, _ := strconv.ParseUint(string(data[i:end]), 8, 8)
+						literal = append(literal, byte(value))
+						i = end - 1
+					} else {
+						literal = append(literal, data[i])
+					}
+				}
+			case '(':
+				depth++
+				literal = append(literal, c)
+			case ')':
+				depth--
+				if depth > 0 {
+					literal = append(literal, c)
+				}
+			default:
+				literal = append(literal, c)
+			}
+		}
+		i--
+		result = append(result, literal)
+	}
+	return result
+}
+
+func decodePDFHex(data []byte) []byte {
+	hex := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return r
+		}
+		return -1
+	}, string(data))
+	if len(hex)%2 == 1 {
+		hex += "0"
+	}
+
+	result := make([]byte, 0, len(hex)/2)
+	for i := 0; i < len(hex); i += 2 {
+		value, _ := strconv.ParseUint(hex[i:i+2], 16, 8)
+		result = append(result, byte(value))
+	}
+	return result
+}
+
+// decodePDFString handles UTF-16BE strings with a byte order mark and treats
+// everything else as Latin-1, which matches PDFDocEncoding for text.
+func decodePDFString(data []byte) string {
+	if len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF {
+		units := make([]uint16, 0, (len(data)-2)/2)
+		for i := 2; i+1 < len(data); i += 2 {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
@@ -0,0 +1,171 @@
+package helpers
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+// ChunkText splits text into chunks of at most size runes, cutting at
+// whitespace where possible so that words are not split across chunks.
+func ChunkText(text string, size int) []string {
+	if size <= 0 {
+		return nil
+	}
+
+	chunks := []string{}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		end := len(runes)
+		if end > size {
+			end = size
+			// Only back off to whitespace in the second half of the chunk, a
+			// single very long token is cut instead
+			for i := size; i > size/2; i-- {
+				if unicode.IsSpace(runes[i]) {
+					end = i
+					break
+				}
+			}
+		}
+
+		if chunk := strings.TrimSpace(string(runes[:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		runes = runes[end:]
+	}
+	return chunks
+}
+
+// SearchTerms returns the distinct lowercase words of query, split the same
+// way full-text search splits document text.
+func SearchTerms(query string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), isNotWordRune) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// HighlightMatches HTML escapes text and wraps every word matching one of the
+// terms in a <mark> element.
+func HighlightMatches(text string, terms []string) string {
+	matches := map[string]bool{}
+	for _, term := range terms {
+		matches[strings.ToLower(term)] = true
+	}
+
+	var sb strings.Builder
+	for len(text) > 0 {
+		start := strings.IndexFunc(text, isWordRune)
+		if start < 0 {
+			sb.WriteString(html.EscapeString(text))
+			break
+		}
+		sb.WriteString(html.EscapeString(text[:start]))
+		text = text[start:]
+
+		end := strings.IndexFunc(text, isNotWordRune)
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		if matches[strings.ToLower(word)] {
+			sb.WriteString(highlightStart + html.EscapeString(word) + highlightEnd)
+		} else {
+			sb.WriteString(html.EscapeString(word))
+		}
+		text = text[end:]
+	}
+	return sb.String()
+}
+
+// Snippet returns up to radius runes of context on each side of the first
+// match of terms in text, with whitespace collapsed. Without a match the
+// beginning of the text is used.
+func Snippet(text string, terms []string, radius int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+
+	center := -1
+	lower := strings.ToLower(text)
+	for _, term := range terms {
+		if index := indexWord(lower, strings.ToLower(term)); index >= 0 {
+			position := utf8.RuneCountInString(lower[:index])
+			if center < 0 || position < center {
+				center = position
+			}
+		}
+	}
+
+	var start, end int
+	if center < 0 {
+		center = 0
+		end = min(2*radius, len(runes))
+	} else {
+		start = max(center-radius, 0)
+		end = min(center+radius, len(runes))
+	}
+
+	// Do not cut words at the edges of the snippet
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start++
+		if start >= center {
+			break
+		}
+	}
+	for end < len(runes) && end > center && !unicode.IsSpace(runes[end]) {
+		end--
+	}
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// indexWord returns the byte offset of the first occurrence of word in text
+// that is not part of a longer word, or -1.
+func indexWord(text string, word string) int {
+	if word == "" {
+		return -1
+	}
+
+	offset := 0
+	for {
+		index := strings.Index(text[offset:], word)
+		if index < 0 {
+			return -1
+		}
+		index += offset
+
+		before, _ := utf8.DecodeLastRuneInString(text[:index])
+		after, _ := utf8.DecodeRuneInString(text[index+len(word):])
+		if (index == 0 || !isWordRune(before)) && (index+len(word) == len(text) || !isWordRune(after)) {
+			return index
+		}
+		offset = index + len(word)
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r)
+}
+
+func isNotWordRune(r rune) bool {
+	return !isWordRune(r)
+}
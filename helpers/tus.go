@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	TusVersion    = "1.0.0"
+	TusExtensions = "creation,expiration,termination"
+)
+
+// ParseTusMetadata decodes an Upload-Metadata header: comma separated pairs of
+// a key and an optional base64 encoded value.
+func ParseTusMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, fmt.Errorf("invalid upload metadata: malformed pair %q", strings.TrimSpace(pair))
+		}
+
+		key := parts[0]
+		if _, exists := metadata[key]; exists {
+			return nil, fmt.Errorf("invalid upload metadata: duplicate key %q", key)
+		}
+
+		value := ""
+		if len(parts) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid upload metadata: value of %q is not base64", key)
+			}
+			value = string(decoded)
+		}
+		metadata[key] = value
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,237 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"mime"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Reasons reported for rejected files
+const (
+	RejectionTypeMismatch   = "type_mismatch"
+	RejectionTypeNotAllowed = "type_not_allowed"
+	RejectionEncryptedPDF   = "encrypted_pdf"
+	RejectionMacroEnabled   = "macro_enabled"
+	RejectionMalware        = "malware_detected"
+)
+
+// Only the start of a text file is checked, binary formats are recognised by
+// their first bytes
+const textSniffSize = 8 * 1024
+
+type FileRejection struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type FileValidationOptions struct {
+	// AllowedTypes lists extensions without the dot, empty allows every type
+	AllowedTypes         []string
+	RejectEncryptedPDFs  bool
+	RejectMacroDocuments bool
+}
+
+var (
+	signaturePDF = []byte("%PDF-")
+	signatureZip = [][]byte{[]byte("PK\x03\x04"), []byte("PK\x05\x06")}
+	signatureOLE = []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1")
+	// The VBA storage name as stored in an OLE directory (UTF-16LE)
+	oleVBAProjectName = []byte("_\x00V\x00B\x00A\x00_\x00P\x00R\x00O\x00J\x00E\x00C\x00T\x00")
+)
+
+var macroExtensions = map[string]bool{
+	"docm": true, "dotm": true, "xlsm": true, "xltm": true, "xlam": true,
+	"pptm": true, "potm": true, "ppam": true, "ppsm": true,
+}
+
+// fileSignatures maps an extension to the content it must start with. Text
+// formats have no signature and are checked separately.
+var fileSignatures = map[string]func(data []byte) bool{
+	"pdf":  func(data []byte) bool { return bytes.HasPrefix(data, signaturePDF) },
+	"docx": isZipData, "xlsx": isZipData, "pptx": isZipData,
+	"docm": isZipData, "dotm": isZipData, "xlsm": isZipData, "xltm": isZipData,
+	"xlam": isZipData, "pptm": isZipData, "potm": isZipData, "ppam": isZipData,
+	"ppsm": isZipData, "odt": isZipData, "ods": isZipData, "odp": isZipData,
+	"epub": isZipData, "zip": isZipData,
+	"doc": isOLEData, "xls": isOLEData, "ppt": isOLEData,
+	"png":  func(data []byte) bool { return bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) },
+	"jpg":  func(data []byte) bool { return bytes.HasPrefix(data, []byte("\xff\xd8\xff")) },
+	"jpeg": func(data []byte) bool { return bytes.HasPrefix(data, []byte("\xff\xd8\xff")) },
+	"gif": func(data []byte) bool {
+		return bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a"))
+	},
+	"webp": func(data []byte) bool {
+		return len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && string(data[8:12]) == "WEBP"
+	},
+}
+
+var textExtensions = map[string]bool{
+	"txt": true, "md": true, "markdown": true, "csv": true, "tsv": true,
+	"json": true, "xml": true, "html": true, "htm": true, "yaml": true, "yml": true,
+}
+
+// extensionMimeTypes lists the types a client may declare for an extension.
+var extensionMimeTypes = map[string][]string{
+	"pdf":  {"application/pdf"},
+	"doc":  {"application/msword"},
+	"docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	"xls":  {"application/vnd.ms-excel"},
+	"xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	"ppt":  {"application/vnd.ms-powerpoint"},
+	"pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	"txt":  {"text/plain"},
+	"md":   {"text/markdown", "text/x-markdown", "text/plain"},
+	"csv":  {"text/csv", "application/csv", "text/plain"},
+	"json": {"application/json"},
+	"html": {"text/html"},
+	"htm":  {"text/html"},
+	"xml":  {"application/xml", "text/xml"},
+	"png":  {"image/png"},
+	"jpg":  {"image/jpeg"},
+	"jpeg": {"image/jpeg"},
+	"gif":  {"image/gif"},
+	"webp": {"image/webp"},
+}
+
+// DeclaredMimeTypeMatches reports whether a client supplied type is one that
+// belongs to the extension of filename.
+func DeclaredMimeTypeMatches(filename string, mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range extensionMimeTypes[FileExtension(filename)] {
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// FileExtension returns the lowercase extension of filename without the dot.
+func FileExtension(filename string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ValidateFileContent checks the content of an upload against its extension
+// and the given options. Every problem found is reported, an empty result
+// means the file is accepted. Extensions without a known signature are only
+// checked against the allowlist.
+func ValidateFileContent(filename string, data []byte, options FileValidationOptions) []FileRejection {
+	rejections := []FileRejection{}
+	extension := FileExtension(filename)
+
+	if !IsFileTypeAllowed(options.AllowedTypes, filename) {
+		rejections = append(rejections, FileRejection{
+			Code:    RejectionTypeNotAllowed,
+			Message: fmt.Sprintf("files of type %q are not allowed in this space", extension),
+		})
+	}
+
+	if matches, known := matchesSignature(extension, data); known && !matches {
+		rejections = append(rejections, FileRejection{
+			Code:    RejectionTypeMismatch,
+			Message: fmt.Sprintf("file content does not match the %q extension", extension),
+		})
+		// The checks below rely on the content being what the extension says
+		return rejections
+	}
+
+	if options.RejectEncryptedPDFs && extension == "pdf" && bytes.Contains(data, []byte("/Encrypt")) {
+		rejections = append(rejections, FileRejection{
+			Code:    RejectionEncryptedPDF,
+			Message: "encrypted PDF files are not allowed",
+		})
+	}
+
+	if options.RejectMacroDocuments && hasMacros(extension, data) {
+		rejections = append(rejections, FileRejection{
+			Code:    RejectionMacroEnabled,
+			Message: "office files with macros are not allowed",
+		})
+	}
+
+	return rejections
+}
+
+func matchesSignature(extension string, data []byte) (matches bool, known bool) {
+	if signature, ok := fileSignatures[extension]; ok {
+		return signature(data), true
+	}
+	if textExtensions[extension] {
+		return isTextData(data), true
+	}
+	return false, false
+}
+
+func isZipData(data []byte) bool {
+	for _, signature := range signatureZip {
+		if bytes.HasPrefix(data, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOLEData(data []byte) bool {
+	return bytes.HasPrefix(data, signatureOLE)
+}
+
+// isTextData accepts UTF-8 without NUL bytes. A multi-byte character cut off
+// at the end of the sniffed range is not an error.
+func isTextData(data []byte) bool {
+	sample := data
+	if len(sample) > textSniffSize {
+		sample = sample[:textSniffSize]
+		for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+			sample = sample[:len(sample)-1]
+		}
+	}
+	return utf8.Valid(sample) && bytes.IndexByte(sample, 0) < 0
+}
+
+// hasMacros detects macro-enabled Office files by extension, by a VBA project
+// part inside OOXML packages and by the VBA storage of legacy OLE files.
+func hasMacros(extension string, data []byte) bool {
+	if macroExtensions[extension] {
+		return true
+	}
+
+	switch {
+	case isOLEData(data):
+		return bytes.Contains(data, oleVBAProjectName)
+	case isZipData(data) && zipContainerExtensions["."+extension]:
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return false
+		}
+		for _, file := range reader.File {
+			if strings.EqualFold(path.Base(file.Name), "vbaProject.bin") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsFileTypeAllowed checks the extension of filename against an allowlist of
+// extensions. An empty allowlist allows every type.
+func IsFileTypeAllowed(allowedTypes []string, filename string) bool {
+	if len(allowedTypes) == 0 {
+		return true
+	}
+
+	extension := FileExtension(filename)
+	for _, allowed := range allowedTypes {
+		if strings.EqualFold(strings.TrimPrefix(allowed, "."), extension) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/BlenDMinh/dutgrad-server/models"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin must run after AuthMiddleware. Admins are flagged in the
+// database, the flag cannot be changed through the API.
+func RequireAdmin(userService services.UserService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, exists := ctx.Get("user_id")
+		if !exists {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse(http.StatusUnauthorized, "Unauthorized", nil))
+			return
+		}
+
+		user, err := userService.GetById(userID.(uint))
+		if err != nil || !user.IsAdmin {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse(http.StatusForbidden, "Admin access required", nil))
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SpaceMember as permission admits every member of the space, whatever their
+// role allows.
+const SpaceMember = entities.SpacePermissionNone
+
+// SpaceRoleResolver is the part of SpaceService the middleware needs.
+type SpaceRoleResolver interface {
+	GetUserRole(userID uint, spaceID uint) (*entities.SpaceRole, error)
+	GetById(id uint) (*entities.Space, error)
+}
+
+// RequireSpacePermission must run after AuthMiddleware. It rejects callers
+// whose role in the space of the id parameter does not grant permission and
+// stores the role under models.SpaceRoleKey for the handler.
+func RequireSpacePermission(resolver SpaceRoleResolver, permission entities.SpacePermission) gin.HandlerFunc {
+	return requireSpaceRole(resolver, permission, false)
+}
+
+// RequireSpaceReader is RequireSpacePermission for reading routes, it lets
+// every logged in user into a public space while private spaces still
+// require membership.
+func RequireSpaceReader(resolver SpaceRoleResolver) gin.HandlerFunc {
+	return requireSpaceRole(resolver, SpaceMember, true)
+}
+
+func requireSpaceRole(resolver SpaceRoleResolver, permission entities.SpacePermission, allowVisitors bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, exists := ctx.Get("user_id")
+		if !exists {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse(http.StatusUnauthorized, "Unauthorized", nil))
+			return
+		}
+
+		spaceID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+		if err != nil {
+			errMsg := err.Error()
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, models.NewErrorResponse(http.StatusBadRequest, "Invalid ID parameter", &errMsg))
+			return
+		}
+
+		role, err := resolver.GetUserRole(userID.(uint), uint(spaceID))
+		if err != nil || role == nil {
+			if allowVisitors {
+				space, spaceErr := resolver.GetById(uint(spaceID))
+				if spaceErr != nil {
+					ctx.AbortWithStatusJSON(http.StatusNotFound, models.NewErrorResponse(http.StatusNotFound, "Space not found", nil))
+					return
+				}
+				if !space.PrivacyStatus {
+					ctx.Set(models.SpaceRoleKey, (*entities.SpaceRole)(nil))
+					ctx.Next()
+					return
+				}
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse(http.StatusForbidden, "You are not a member of this space", nil))
+			return
+		}
+
+		if !role.HasPermission(permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse(http.StatusForbidden, "Your role in this space does not allow this action", nil))
+			return
+		}
+
+		ctx.Set(models.SpaceRoleKey, role)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,5 @@
+package models
+
+// SpaceRoleKey is the context key of the role the space middlewares resolved.
+// The value is nil for visitors of a public space.
+const SpaceRoleKey = "space_role"
@@ -1,21 +1,146 @@
 package dtos
 
-import "time"
+import (
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+)
 
 type DocumentUploadRequest struct {
 	SpaceID     uint   `form:"space_id" binding:"required"`
 	Description string `form:"description"`
 }
 
+// DocumentUploadOptions holds the user supplied attributes of a new document.
+type DocumentUploadOptions struct {
+	Description string
+	FolderID    *uint
+	Tags        []string
+	// Replace turns an upload whose content already exists in the space into
+	// a new version of that document instead of a conflict.
+	Replace    bool
+	Visibility entities.DocumentVisibility
+}
+
+type DocumentFromURLRequest struct {
+	URL                  string   `json:"url" binding:"required"`
+	Description          string   `json:"description"`
+	FolderID             *uint    `json:"folder_id"`
+	Tags                 []string `json:"tags"`
+	RefreshIntervalHours int      `json:"refresh_interval_hours"`
+	Replace              bool     `json:"replace"`
+	Visibility           string   `json:"visibility"`
+}
+
+type DocumentListQuery struct {
+	FolderID         string     `form:"folder_id"`
+	Recursive        bool       `form:"recursive"`
+	Tags             []string   `form:"tag"`
+	Title            string     `form:"title"`
+	Author           string     `form:"author"`
+	Language         string     `form:"language"`
+	MinPages         int        `form:"min_pages" binding:"min=0"`
+	MaxPages         int        `form:"max_pages" binding:"min=0"`
+	Q                string     `form:"q"`
+	MimeType         string     `form:"mime_type"`
+	UploadedBy       uint       `form:"uploaded_by"`
+	CreatedFrom      *time.Time `form:"created_from" time_format:"2006-01-02"`
+	CreatedTo        *time.Time `form:"created_to" time_format:"2006-01-02"`
+	MinSize          int64      `form:"min_size" binding:"min=0"`
+	MaxSize          int64      `form:"max_size" binding:"min=0"`
+	ProcessingStatus *int       `form:"processing_status"`
+	Sort             string     `form:"sort" binding:"omitempty,oneof=name size created_at updated_at"`
+	Order            string     `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// UpdateDocumentRequest holds the fields of a document that can be edited
+// directly, everything else has a dedicated endpoint.
+type UpdateDocumentRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type PatchDocumentRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UpdateDocumentVisibilityRequest struct {
+	Visibility string `json:"visibility" binding:"required"`
+}
+
+type MoveDocumentRequest struct {
+	FolderID *uint `json:"folder_id"`
+}
+
+type TransferDocumentRequest struct {
+	TargetSpaceID uint  `json:"target_space_id" binding:"required"`
+	FolderID      *uint `json:"folder_id"`
+}
+
+type BatchTransferDocumentsRequest struct {
+	DocumentIDs   []uint `json:"document_ids" binding:"required,min=1,max=100"`
+	TargetSpaceID uint   `json:"target_space_id" binding:"required"`
+	FolderID      *uint  `json:"folder_id"`
+}
+
+type UpdateDocumentTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type CreateFolderRequest struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+type RenameFolderRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type MoveFolderRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
 type DocumentResponse struct {
-	ID               uint      `json:"id"`
-	Name             string    `json:"name"`
-	Description      string    `json:"description"`
-	SpaceID          uint      `json:"space_id"`
-	MimeType         string    `json:"mime_type"`
-	URL              string    `json:"url"`
-	ProcessingStatus string    `json:"processing_status"`
-	SizeKb           int       `json:"size_kb"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID               uint                      `json:"id"`
+	Name             string                    `json:"name"`
+	Description      string                    `json:"description"`
+	SpaceID          uint                      `json:"space_id"`
+	MimeType         string                    `json:"mime_type"`
+	URL              string                    `json:"url"`
+	ProcessingStatus string                    `json:"processing_status"`
+	SizeKb           int                       `json:"size_kb"`
+	Metadata         entities.DocumentMetadata `json:"metadata"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	UpdatedAt        time.Time                 `json:"updated_at"`
+}
+
+type BulkUploadAcceptedEntry struct {
+	Path     string             `json:"path"`
+	Document *entities.Document `json:"document"`
+}
+
+type BulkUploadRejectedEntry struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type BulkUploadReport struct {
+	Accepted []BulkUploadAcceptedEntry `json:"accepted"`
+	Rejected []BulkUploadRejectedEntry `json:"rejected"`
+}
+
+type DocumentTransferAcceptedEntry struct {
+	DocumentID uint               `json:"document_id"`
+	Document   *entities.Document `json:"document"`
+}
+
+type DocumentTransferRejectedEntry struct {
+	DocumentID uint   `json:"document_id"`
+	Reason     string `json:"reason"`
+}
+
+type DocumentTransferReport struct {
+	Accepted []DocumentTransferAcceptedEntry `json:"accepted"`
+	Rejected []DocumentTransferRejectedEntry `json:"rejected"`
 }
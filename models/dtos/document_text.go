@@ -0,0 +1,18 @@
+package dtos
+
+type DocumentSearchHit struct {
+	DocumentID   uint    `json:"document_id"`
+	DocumentName string  `json:"document_name"`
+	MimeType     string  `json:"mime_type"`
+	ChunkIndex   int     `json:"chunk_index"`
+	Snippet      string  `json:"snippet"`
+	Rank         float64 `json:"rank"`
+}
+
+// DocumentTextChunk is a chunk of extracted text, HTML escaped with the
+// matches of the query wrapped in <mark> elements.
+type DocumentTextChunk struct {
+	ChunkIndex int    `json:"chunk_index"`
+	Content    string `json:"content"`
+	MatchCount int    `json:"match_count"`
+}
@@ -0,0 +1,31 @@
+package dtos
+
+import "time"
+
+// ConsistencyIssue is a single mismatch between S3, the database and the RAG
+// index. Depending on the kind, either Key or DocumentID identifies it.
+type ConsistencyIssue struct {
+	Key        string `json:"key,omitempty"`
+	DocumentID uint   `json:"document_id,omitempty"`
+	SpaceID    uint   `json:"space_id,omitempty"`
+	Version    int    `json:"version,omitempty"`
+	Repaired   bool   `json:"repaired"`
+	Error      string `json:"error,omitempty"`
+}
+
+type ConsistencyReport struct {
+	Repair              bool      `json:"repair"`
+	StartedAt           time.Time `json:"started_at"`
+	FinishedAt          time.Time `json:"finished_at"`
+	CheckedObjects      int       `json:"checked_objects"`
+	CheckedDocuments    int       `json:"checked_documents"`
+	CheckedIndexEntries int       `json:"checked_index_entries"`
+	// OrphanedObjects are stored files no document version or upload refers to
+	OrphanedObjects []ConsistencyIssue `json:"orphaned_objects"`
+	// MissingObjects are document versions whose file is gone, they cannot be repaired
+	MissingObjects []ConsistencyIssue `json:"missing_objects"`
+	// OrphanedIndexEntries are indexed documents that were deleted or moved
+	OrphanedIndexEntries []ConsistencyIssue `json:"orphaned_index_entries"`
+	// MissingIndexEntries are documents the chat cannot retrieve from
+	MissingIndexEntries []ConsistencyIssue `json:"missing_index_entries"`
+}
@@ -10,23 +10,39 @@ type GetInvitationLinkRequest struct {
 	SpaceRoleID uint `json:"space_role_id" binding:"required"`
 }
 
+type CreateInvitationLinkRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	SpaceRoleID uint       `json:"space_role_id" binding:"required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	MaxUses     *int       `json:"max_uses"`
+}
+
+type SpaceInvitationLinkResponse struct {
+	entities.SpaceInvitationLink
+	InvitationLink string `json:"invitation_link"`
+	Active         bool   `json:"active"`
+}
+
 type ApiChatRequest struct {
 	QuerySessionID uint   `json:"query_session_id"`
 	Query          string `json:"query" binding:"required"`
+	FolderID       *uint  `json:"folder_id"`
 }
 
 type UserSpaceDTO struct {
-	ID              uint               `json:"id"`
-	Name            string             `json:"name"`
-	Description     string             `json:"description"`
-	PrivacyStatus   bool               `json:"privacy_status"`
-	DocumentLimit   int                `json:"document_limit"`
-	FileSizeLimitKb int                `json:"file_size_limit_kb"`
-	ApiCallLimit    int                `json:"api_call_limit"`
-	CreatedAt       time.Time          `json:"created_at"`
-	UpdatedAt       time.Time          `json:"updated_at"`
-	Role            entities.SpaceRole `json:"role"`
-	UserCount       int                `json:"user_count"`
+	ID                uint               `json:"id"`
+	Name              string             `json:"name"`
+	Description       string             `json:"description"`
+	PrivacyStatus     bool               `json:"privacy_status"`
+	DocumentLimit     int                `json:"document_limit"`
+	FileSizeLimitKb   int                `json:"file_size_limit_kb"`
+	ApiCallLimit      int                `json:"api_call_limit"`
+	StorageLimitBytes int64              `json:"storage_limit_bytes"`
+	StorageUsedBytes  int64              `json:"storage_used_bytes"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	Role              entities.SpaceRole `json:"role"`
+	UserCount         int                `json:"user_count"`
 }
 
 type SpaceInvitationRequest struct {
@@ -52,3 +68,63 @@ type SpaceUsage struct {
 	SpaceID                uint  `json:"space_id"`
 	ChatAPICallsUsageDaily int64 `json:"chat_api_calls_usage_daily"`
 }
+
+// SpaceRoleRequest names the permissions of a custom role, see
+// entities.ParseSpacePermissions. Omitted fields are left unchanged on update.
+type SpaceRoleRequest struct {
+	Name        *string  `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type SpaceRoleResponse struct {
+	entities.SpaceRole
+	Permissions []string `json:"permissions"`
+}
+
+func NewSpaceRoleResponse(role entities.SpaceRole) SpaceRoleResponse {
+	return SpaceRoleResponse{
+		SpaceRole:   role,
+		Permissions: role.Permission.Names(),
+	}
+}
+
+type OwnershipTransferRequest struct {
+	ToUserID      uint `json:"to_user_id" binding:"required"`
+	KeepOwnership bool `json:"keep_ownership"`
+}
+
+type JoinRequestRequest struct {
+	Message string `json:"message"`
+}
+
+type ApproveJoinRequestRequest struct {
+	SpaceRoleID uint `json:"space_role_id"`
+}
+
+type CloneSpaceRequest struct {
+	Name             string  `json:"name"`
+	Description      *string `json:"description"`
+	IncludeDocuments bool    `json:"include_documents"`
+	IncludeMembers   bool    `json:"include_members"`
+	IncludeAPIKeys   bool    `json:"include_api_keys"`
+}
+
+type SaveSpaceTemplateRequest struct {
+	Name             string `json:"name" binding:"required"`
+	Description      string `json:"description"`
+	IncludeDocuments bool   `json:"include_documents"`
+}
+
+type SkippedDocument struct {
+	DocumentID uint   `json:"document_id,omitempty"`
+	Name       string `json:"name"`
+	Reason     string `json:"reason"`
+}
+
+// SpaceCloneResult is the space created by a clone or from a template. The
+// copied documents are indexed by the reindex job in the background.
+type SpaceCloneResult struct {
+	Space            *entities.Space      `json:"space"`
+	ReindexJob       *entities.ReindexJob `json:"reindex_job,omitempty"`
+	SkippedDocuments []SkippedDocument    `json:"skipped_documents"`
+}
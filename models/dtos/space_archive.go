@@ -0,0 +1,112 @@
+package dtos
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+)
+
+// SpaceArchiveVersion is the version of the manifest written by exports.
+// Imports only accept archives with this version.
+const SpaceArchiveVersion = 1
+
+// SpaceArchiveManifest describes a space in an export archive. Ids are only
+// meaningful inside the archive, they are remapped on import.
+type SpaceArchiveManifest struct {
+	Version    int                    `json:"version"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Space      SpaceArchiveSettings   `json:"space"`
+	Roles      []SpaceArchiveRole     `json:"roles"`
+	Members    []SpaceArchiveMember   `json:"members"`
+	Folders    []SpaceArchiveFolder   `json:"folders"`
+	Documents  []SpaceArchiveDocument `json:"documents"`
+	Sessions   []SpaceArchiveSession  `json:"sessions,omitempty"`
+}
+
+type SpaceArchiveSettings struct {
+	Name              string                `json:"name"`
+	Description       string                `json:"description"`
+	PrivacyStatus     bool                  `json:"privacy_status"`
+	AllowJoinRequests bool                  `json:"allow_join_requests"`
+	SystemPrompt      string                `json:"system_prompt"`
+	DocumentLimit     int                   `json:"document_limit"`
+	FileSizeLimitKb   int                   `json:"file_size_limit_kb"`
+	ApiCallLimit      int                   `json:"api_call_limit"`
+	AllowedFileTypes  entities.FileTypeList `json:"allowed_file_types"`
+	StorageLimitBytes int64                 `json:"storage_limit_bytes"`
+}
+
+// SpaceArchiveRole is a custom role of the space. Members refer to built-in
+// roles by their fixed ids.
+type SpaceArchiveRole struct {
+	ID         uint                     `json:"id"`
+	Name       string                   `json:"name"`
+	Permission entities.SpacePermission `json:"permission"`
+}
+
+// SpaceArchiveMember is matched to an account by email on import.
+type SpaceArchiveMember struct {
+	Email  string `json:"email"`
+	RoleID uint   `json:"role_id"`
+}
+
+type SpaceArchiveFolder struct {
+	ID       uint   `json:"id"`
+	ParentID *uint  `json:"parent_id"`
+	Name     string `json:"name"`
+}
+
+// SpaceArchiveDocument points to its file in the archive. Documents sharing
+// content share the file.
+type SpaceArchiveDocument struct {
+	Name          string                      `json:"name"`
+	Description   string                      `json:"description"`
+	MimeType      string                      `json:"mime_type"`
+	Size          int64                       `json:"size"`
+	ContentHash   string                      `json:"content_hash"`
+	PrivacyStatus entities.DocumentVisibility `json:"privacy_status"`
+	FolderID      *uint                       `json:"folder_id"`
+	Tags          entities.DocumentTags       `json:"tags"`
+	File          string                      `json:"file"`
+	S3URL         string                      `json:"-"`
+}
+
+type SpaceArchiveSession struct {
+	UserEmail string                `json:"user_email,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	Messages  []SpaceArchiveMessage `json:"messages"`
+}
+
+type SpaceArchiveMessage struct {
+	Message   json.RawMessage `json:"message"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type SpaceExportOptions struct {
+	IncludeMembers  bool
+	IncludeSessions bool
+}
+
+type SpaceImportOptions struct {
+	IncludeSessions bool
+	// Trusted imports run from the command line: members are added directly
+	// instead of invited and the sessions of every user are restored.
+	Trusted bool
+}
+
+type SkippedMember struct {
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
+
+// SpaceImportReport is the space created from an archive. Documents are sent
+// to the RAG server while they are imported.
+type SpaceImportReport struct {
+	Space            *entities.Space   `json:"space"`
+	AddedMembers     int               `json:"added_members"`
+	InvitedMembers   int               `json:"invited_members"`
+	ImportedSessions int               `json:"imported_sessions"`
+	SkippedMembers   []SkippedMember   `json:"skipped_members"`
+	SkippedDocuments []SkippedDocument `json:"skipped_documents"`
+}
@@ -0,0 +1,9 @@
+package dtos
+
+import "github.com/BlenDMinh/dutgrad-server/databases/entities"
+
+type TrashListing struct {
+	Documents []entities.Document         `json:"documents"`
+	Spaces    []entities.Space            `json:"spaces"`
+	Sessions  []entities.UserQuerySession `json:"sessions"`
+}
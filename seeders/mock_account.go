@@ -94,7 +94,7 @@ func (s *MockAccountSeeder) Seed() error {
 		documents = append(documents, entities.Document{
 			SpaceID:       userSpaces[0].ID,
 			Name:          "Own Test Document " + fmt.Sprint(i+1),
-			PrivacyStatus: false,
+			PrivacyStatus: entities.DocumentVisibilityMembers,
 			S3URL:         "https://example.com/test-document-" + fmt.Sprint(i+1) + ".pdf",
 		})
 	}
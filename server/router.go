@@ -5,6 +5,7 @@ import (
 
 	"github.com/BlenDMinh/dutgrad-server/configs"
 	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/middlewares"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -15,21 +16,40 @@ func GetRouter(
 	authController *controllers.AuthController,
 	oauthController *controllers.OAuthController,
 	documentController *controllers.DocumentController,
+	documentFolderController *controllers.DocumentFolderController,
+	resumableUploadController *controllers.ResumableUploadController,
+	reindexController *controllers.ReindexController,
 	spaceController *controllers.SpaceController,
 	spaceInvitationController *controllers.SpaceInvitationController,
 	spaceInvitationLinkController *controllers.SpaceInvitationLinkController,
 	userQuerySessionController *controllers.UserQuerySessionController,
 	userQueryController *controllers.UserQueryController,
 	spaceApiKeyController *controllers.SpaceApiKeyController,
+	trashController *controllers.TrashController,
+	adminController *controllers.AdminController,
+	spaceOwnershipTransferController *controllers.SpaceOwnershipTransferController,
+	spaceJoinRequestController *controllers.SpaceJoinRequestController,
+	spaceTemplateController *controllers.SpaceTemplateController,
+	spaceArchiveController *controllers.SpaceArchiveController,
 	chatRateLimiter gin.HandlerFunc,
+	requireAdmin gin.HandlerFunc,
+	requireSpaceReader gin.HandlerFunc,
+	requireSpacePermission func(permission entities.SpacePermission) gin.HandlerFunc,
 ) *gin.Engine {
 	env := configs.GetEnv()
 	router := gin.New()
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     env.AllowOrigins,
-		AllowMethods:     []string{"GET", "PUT", "PATCH", "POST", "DELETE", "OPTIONS", "HEAD"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowOrigins: env.AllowOrigins,
+		AllowMethods: []string{"GET", "PUT", "PATCH", "POST", "DELETE", "OPTIONS", "HEAD"},
+		AllowHeaders: []string{
+			"Origin", "Content-Type", "Authorization",
+			"Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset",
+		},
+		ExposeHeaders: []string{
+			"Content-Length", "Location",
+			"Tus-Resumable", "Tus-Version", "Tus-Extension",
+			"Upload-Offset", "Upload-Length", "Upload-Expires", "Upload-Document-Id",
+		},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -37,6 +57,15 @@ func GetRouter(
 	router.Use(gin.Recovery())
 	router.HandleMethodNotAllowed = true
 
+	spaceReader := requireSpaceReader
+	spaceMember := requireSpacePermission(middlewares.SpaceMember)
+	canManageMembers := requireSpacePermission(entities.SpacePermissionManageMembers)
+	canManageSettings := requireSpacePermission(entities.SpacePermissionManageSettings)
+	canUpload := requireSpacePermission(entities.SpacePermissionUploadDocuments)
+	canDelete := requireSpacePermission(entities.SpacePermissionDeleteDocuments)
+	canManageAPIKeys := requireSpacePermission(entities.SpacePermissionManageAPIKeys)
+	canExport := requireSpacePermission(entities.SpacePermissionExport)
+
 	v1 := router.Group("/v1")
 	{
 		v1.GET("", func(c *gin.Context) {
@@ -52,6 +81,7 @@ func GetRouter(
 			userGroup.GET("/tier", middlewares.AuthMiddleware(), userController.GetUserTier)
 			userGroup.GET("/auth-method", middlewares.AuthMiddleware(), userController.GetUserAuthMethod)
 			userGroup.PATCH("/password", middlewares.AuthMiddleware(), userController.UpdatePassword)
+			userGroup.DELETE("/me", middlewares.AuthMiddleware(), userController.DeleteAccount)
 			userController.RegisterCRUD(userGroup)
 		}
 
@@ -86,18 +116,51 @@ func GetRouter(
 
 		documentGroup := v1.Group("/documents")
 		{
-			documentGroup.GET("", documentController.Retrieve)
-			documentGroup.GET("/:id", documentController.RetrieveOne)
+			documentGroup.GET("", middlewares.AuthMiddleware(), documentController.Retrieve)
+			documentGroup.GET("/:id", middlewares.AuthMiddleware(), documentController.RetrieveOne)
+			documentGroup.GET("/:id/download", middlewares.AuthMiddleware(), documentController.DownloadDocument)
+			documentGroup.GET("/:id/preview", middlewares.AuthMiddleware(), documentController.PreviewDocument)
+			documentGroup.GET("/:id/text", middlewares.AuthMiddleware(), documentController.GetDocumentText)
+			documentGroup.GET("/:id/versions", middlewares.AuthMiddleware(), documentController.GetDocumentVersions)
+			documentGroup.GET("/:id/versions/:versionId/download", middlewares.AuthMiddleware(), documentController.DownloadDocumentVersion)
 
 			documentGroup.HEAD("/count/me", middlewares.AuthMiddleware(), documentController.GetUserDocumentCount)
 
 			documentGroup.POST("/upload", middlewares.AuthMiddleware(), documentController.UploadDocument)
+			documentGroup.POST("/move", middlewares.AuthMiddleware(), documentController.MoveDocuments)
+			documentGroup.POST("/copy", middlewares.AuthMiddleware(), documentController.CopyDocuments)
+			documentGroup.POST("/:id/move", middlewares.AuthMiddleware(), documentController.MoveDocumentToSpace)
+			documentGroup.POST("/:id/copy", middlewares.AuthMiddleware(), documentController.CopyDocumentToSpace)
+			documentGroup.POST("/:id/refetch", middlewares.AuthMiddleware(), documentController.RefetchDocument)
+			documentGroup.POST("/:id/reindex", middlewares.AuthMiddleware(), reindexController.ReindexDocument)
+			documentGroup.POST("/:id/versions/:versionId/rollback", middlewares.AuthMiddleware(), documentController.RollbackDocumentVersion)
+
+			documentGroup.PUT("/:id", middlewares.AuthMiddleware(), documentController.Update)
+			documentGroup.PUT("/:id/file", middlewares.AuthMiddleware(), documentController.ReplaceDocumentFile)
+			documentGroup.PUT("/:id/folder", middlewares.AuthMiddleware(), documentController.MoveDocument)
+			documentGroup.PUT("/:id/tags", middlewares.AuthMiddleware(), documentController.UpdateTags)
+			documentGroup.PUT("/:id/visibility", middlewares.AuthMiddleware(), documentController.UpdateVisibility)
+
+			documentGroup.PATCH("/:id", middlewares.AuthMiddleware(), documentController.Patch)
 
-			documentGroup.PUT("/:id", documentController.Update)
+			documentGroup.DELETE("/:id", middlewares.AuthMiddleware(), documentController.DeleteDocument)
+		}
 
-			documentGroup.PATCH("/:id", documentController.Patch)
+		uploadGroup := v1.Group("/uploads")
+		{
+			uploadGroup.OPTIONS("", resumableUploadController.Options)
 
-			documentGroup.DELETE("/:id", middlewares.AuthMiddleware(), documentController.DeleteDocument)
+			uploadGroup.HEAD("/:id", middlewares.AuthMiddleware(), resumableUploadController.GetUploadOffset)
+
+			uploadGroup.PATCH("/:id", middlewares.AuthMiddleware(), resumableUploadController.PatchUpload)
+
+			uploadGroup.DELETE("/:id", middlewares.AuthMiddleware(), resumableUploadController.DeleteUpload)
+		}
+
+		reindexJobGroup := v1.Group("/reindex-jobs")
+		reindexJobGroup.Use(middlewares.AuthMiddleware())
+		{
+			reindexJobGroup.GET("/:id", reindexController.GetJob)
 		}
 
 		spaceGroup := v1.Group("/spaces")
@@ -105,6 +168,7 @@ func GetRouter(
 			spaceGroup.GET("", spaceController.Retrieve)
 			spaceGroup.GET("/roles", spaceController.GetSpaceRoles)
 			spaceGroup.GET("/public", spaceController.GetPublicSpaces)
+			spaceGroup.GET("/discoverable", spaceController.GetDiscoverableSpaces)
 			spaceGroup.GET("/popular", spaceController.GetPopularSpaces)
 			spaceGroup.GET("/user/:id", userController.GetUserSpaces)
 			spaceGroup.GET("/me", middlewares.AuthMiddleware(), userController.GetMySpaces)
@@ -113,30 +177,65 @@ func GetRouter(
 
 			spaceGroup.POST("/join", middlewares.AuthMiddleware(), spaceController.JoinSpace)
 			spaceGroup.POST("", middlewares.AuthMiddleware(), spaceController.CreateSpace)
+			spaceGroup.POST("/import", middlewares.AuthMiddleware(), spaceArchiveController.ImportSpace)
 
 			detailGroup := spaceGroup.Group("/:id")
 			detailGroup.Use(middlewares.AuthMiddleware())
 			{
-				detailGroup.GET("", spaceController.RetrieveOne)
-				detailGroup.PUT("", spaceController.Update)
-				detailGroup.PATCH("", spaceController.Patch)
-				detailGroup.DELETE("", spaceController.Delete)
-
-				detailGroup.GET("/members", spaceController.GetMembers)
-				detailGroup.GET("/members/count", spaceController.CountSpaceMembers)
-				detailGroup.GET("/invitations", spaceController.GetInvitations)
+				detailGroup.GET("", spaceReader, spaceController.RetrieveOne)
+				detailGroup.PUT("", canManageSettings, spaceController.Update)
+				detailGroup.PATCH("", canManageSettings, spaceController.Patch)
+				detailGroup.DELETE("", canManageSettings, spaceController.DeleteSpace)
+
+				detailGroup.GET("/members", spaceMember, spaceController.GetMembers)
+				detailGroup.GET("/members/count", spaceReader, spaceController.CountSpaceMembers)
+				detailGroup.GET("/invitations", canManageMembers, spaceController.GetInvitations)
 				detailGroup.GET("/user-role", spaceController.GetUserRole)
-				detailGroup.GET("/documents", documentController.GetBySpaceID)
-
-				detailGroup.PUT("/invitation-link", spaceController.GetInvitationLink)
-
-				detailGroup.POST("/invitations", spaceController.InviteUserToSpace)
+				detailGroup.GET("/documents", spaceReader, documentController.GetBySpaceID)
+				detailGroup.GET("/search", spaceReader, documentController.SearchText)
+				detailGroup.GET("/tags", spaceReader, documentController.GetTagsBySpaceID)
+				detailGroup.GET("/folders", spaceReader, documentFolderController.GetBySpaceID)
+				detailGroup.GET("/roles", spaceMember, spaceController.GetRolesOfSpace)
+				detailGroup.GET("/ownership-transfers", spaceMember, spaceOwnershipTransferController.GetSpaceTransfers)
+				detailGroup.GET("/join-requests", canManageMembers, spaceJoinRequestController.GetSpaceRequests)
+				detailGroup.GET("/invitation-links", canManageMembers, spaceController.GetInvitationLinks)
+				detailGroup.GET("/invitation-links/:linkId/uses", canManageMembers, spaceController.GetInvitationLinkUses)
+				detailGroup.GET("/trash", canDelete, trashController.GetSpaceTrash)
+				detailGroup.GET("/export", canExport, spaceArchiveController.ExportSpace)
+
+				detailGroup.PUT("/invitation-link", canManageMembers, spaceController.GetInvitationLink)
+				detailGroup.PUT("/join-requests/:requestId/approve", canManageMembers, spaceJoinRequestController.ApproveRequest)
+				detailGroup.PUT("/join-requests/:requestId/reject", canManageMembers, spaceJoinRequestController.RejectRequest)
+
+				detailGroup.POST("/documents/zip", canUpload, documentController.UploadZipArchive)
+				detailGroup.POST("/documents/from-url", canUpload, documentController.UploadFromURL)
+				detailGroup.POST("/uploads", canUpload, resumableUploadController.CreateUpload)
+				detailGroup.POST("/reindex", canManageSettings, reindexController.ReindexSpace)
+				detailGroup.POST("/clone", canManageSettings, spaceTemplateController.CloneSpace)
+				detailGroup.POST("/templates", canManageSettings, spaceTemplateController.SaveTemplate)
+				detailGroup.POST("/folders", canUpload, documentFolderController.CreateFolder)
+				detailGroup.POST("/invitations", canManageMembers, spaceController.InviteUserToSpace)
+				detailGroup.POST("/invitations/:invitationId/resend", canManageMembers, spaceController.ResendInvitation)
+				detailGroup.POST("/invitation-links", canManageMembers, spaceController.CreateInvitationLink)
+				detailGroup.POST("/roles", canManageSettings, spaceController.CreateRole)
+				detailGroup.POST("/ownership-transfers", spaceMember, spaceOwnershipTransferController.ProposeTransfer)
+				detailGroup.POST("/leave", spaceMember, spaceController.LeaveSpace)
 				detailGroup.POST("/join-public", spaceController.JoinPublicSpace)
+				detailGroup.POST("/join-requests", spaceJoinRequestController.SubmitRequest)
+
+				detailGroup.PATCH("/folders/:folderId", canUpload, documentFolderController.RenameFolder)
+				detailGroup.PUT("/folders/:folderId/parent", canUpload, documentFolderController.MoveFolder)
+				detailGroup.DELETE("/folders/:folderId", canDelete, documentFolderController.DeleteFolder)
 
-				detailGroup.PATCH("/members/:memberId/role", middlewares.AuthMiddleware(), spaceController.UpdateUserRole)
+				detailGroup.PATCH("/members/:memberId/role", canManageMembers, spaceController.UpdateUserRole)
+				detailGroup.PATCH("/roles/:roleId", canManageSettings, spaceController.UpdateRole)
 
-				detailGroup.DELETE("/members/:memberId", middlewares.AuthMiddleware(), spaceController.RemoveMember)
+				detailGroup.DELETE("/members/:memberId", canManageMembers, spaceController.RemoveMember)
+				detailGroup.DELETE("/invitations/:invitationId", canManageMembers, spaceController.RevokeInvitation)
+				detailGroup.DELETE("/invitation-links/:linkId", canManageMembers, spaceController.RevokeInvitationLink)
+				detailGroup.DELETE("/roles/:roleId", canManageSettings, spaceController.DeleteRole)
 				apiKeyGroup := detailGroup.Group("/api-keys")
+				apiKeyGroup.Use(canManageAPIKeys)
 				{
 					apiKeyGroup.GET("", spaceApiKeyController.List)
 					apiKeyGroup.GET("/:keyId", spaceApiKeyController.GetOne)
@@ -148,22 +247,63 @@ func GetRouter(
 			}
 			spaceGroup.POST("/:id/chat", middlewares.RequireApiKey(), spaceController.Chat)
 		}
+		trashGroup := v1.Group("/trash")
+		trashGroup.Use(middlewares.AuthMiddleware())
+		{
+			trashGroup.GET("/me", trashController.GetMyTrash)
+
+			trashGroup.POST("/documents/:id/restore", trashController.RestoreDocument)
+			trashGroup.POST("/spaces/:id/restore", trashController.RestoreSpace)
+			trashGroup.POST("/sessions/:id/restore", trashController.RestoreSession)
+		}
+
 		spaceInvitationGroup := v1.Group("/space-invitations")
+		spaceInvitationGroup.Use(middlewares.AuthMiddleware())
 		{
-			spaceInvitationGroup.GET("/count", middlewares.AuthMiddleware(), spaceInvitationController.GetInvitationCount)
-			spaceInvitationGroup.GET("", spaceInvitationController.Retrieve)
+			spaceInvitationGroup.GET("/count", spaceInvitationController.GetInvitationCount)
+			spaceInvitationGroup.GET("", requireAdmin, spaceInvitationController.Retrieve)
 			spaceInvitationGroup.GET("/:id", spaceInvitationController.RetrieveOne)
 
-			spaceInvitationGroup.PUT("/:id", spaceInvitationController.Update)
-			spaceInvitationGroup.PUT("/:id/accept", middlewares.AuthMiddleware(), spaceInvitationController.AcceptInvitation)
-			spaceInvitationGroup.PUT("/:id/reject", middlewares.AuthMiddleware(), spaceInvitationController.RejectInvitation)
+			spaceInvitationGroup.PUT("/:id", requireAdmin, spaceInvitationController.Update)
+			spaceInvitationGroup.PUT("/:id/accept", spaceInvitationController.AcceptInvitation)
+			spaceInvitationGroup.PUT("/:id/reject", spaceInvitationController.RejectInvitation)
 
-			spaceInvitationGroup.PATCH("/:id", spaceInvitationController.Patch)
+			spaceInvitationGroup.PATCH("/:id", requireAdmin, spaceInvitationController.Patch)
 
 			spaceInvitationGroup.DELETE("/:id", spaceInvitationController.Delete)
 		}
 
+		ownershipTransferGroup := v1.Group("/ownership-transfers")
+		ownershipTransferGroup.Use(middlewares.AuthMiddleware())
+		{
+			ownershipTransferGroup.GET("/me", spaceOwnershipTransferController.GetMyTransfers)
+
+			ownershipTransferGroup.PUT("/:id/accept", spaceOwnershipTransferController.AcceptTransfer)
+			ownershipTransferGroup.PUT("/:id/decline", spaceOwnershipTransferController.DeclineTransfer)
+
+			ownershipTransferGroup.DELETE("/:id", spaceOwnershipTransferController.CancelTransfer)
+		}
+
+		joinRequestGroup := v1.Group("/join-requests")
+		joinRequestGroup.Use(middlewares.AuthMiddleware())
+		{
+			joinRequestGroup.GET("/me", spaceJoinRequestController.GetMyRequests)
+
+			joinRequestGroup.DELETE("/:id", spaceJoinRequestController.CancelRequest)
+		}
+
+		spaceTemplateGroup := v1.Group("/space-templates")
+		spaceTemplateGroup.Use(middlewares.AuthMiddleware())
+		{
+			spaceTemplateGroup.GET("", spaceTemplateController.GetMyTemplates)
+			spaceTemplateGroup.GET("/:id", spaceTemplateController.GetTemplate)
+
+			spaceTemplateGroup.DELETE("/:id", spaceTemplateController.DeleteTemplate)
+		}
+
+		// Links are managed through the space, the generic routes are for admins
 		spaceInvitationLinkGroup := v1.Group("/space-invitation-links")
+		spaceInvitationLinkGroup.Use(middlewares.AuthMiddleware(), requireAdmin)
 		{
 			spaceInvitationLinkController.RegisterCRUD(spaceInvitationLinkGroup)
 		}
@@ -183,6 +323,12 @@ func GetRouter(
 			userQuerySessionGroup.DELETE("/:id/history", userQuerySessionController.ClearChatHistory)
 		}
 
+		adminGroup := v1.Group("/admin")
+		adminGroup.Use(middlewares.AuthMiddleware(), requireAdmin)
+		{
+			adminGroup.POST("/reconcile", adminController.Reconcile)
+		}
+
 		userQueryGroup := v1.Group("/user-query")
 		userQueryGroup.Use(middlewares.AuthMiddleware())
 		{
@@ -2,14 +2,17 @@ package server
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/BlenDMinh/dutgrad-server/configs"
 	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
 	"github.com/BlenDMinh/dutgrad-server/middlewares"
 	"github.com/BlenDMinh/dutgrad-server/services"
 	"github.com/BlenDMinh/dutgrad-server/services/oauth"
 	"github.com/BlenDMinh/dutgrad-server/services/oauth/providers"
+	"github.com/gin-gonic/gin"
 )
 
 func Init() {
@@ -20,11 +23,26 @@ func Init() {
 	spaceInvitationRepo := repositories.NewSpaceInvitationRepository()
 	spaceInvitationLinkRepo := repositories.NewSpaceInvitationLinkRepository()
 	documentRepo := repositories.NewDocumentRepository()
+	documentVersionRepo := repositories.NewDocumentVersionRepository()
+	documentFolderRepo := repositories.NewDocumentFolderRepository()
+	blobRepo := repositories.NewBlobRepository()
+	documentTextRepo := repositories.NewDocumentTextRepository()
+	spaceRepo := repositories.NewSpaceRepository()
+	ownershipTransferRepo := repositories.NewSpaceOwnershipTransferRepository()
+	joinRequestRepo := repositories.NewSpaceJoinRequestRepository()
+	spaceRoleRepo := repositories.NewSpaceRoleRepository()
+	spaceTemplateRepo := repositories.NewSpaceTemplateRepository()
+	spaceApiKeyRepo := repositories.NewSpaceApiKeyRepository()
+	userQuerySessionRepo := repositories.NewUserQuerySessionRepository()
+	reindexJobRepo := repositories.NewReindexJobRepository()
+	resumableUploadRepo := repositories.NewResumableUploadRepository()
 
 	// External service initialization
 	ragServerService := services.NewRAGServerService()
+	objectStorage := services.NewS3Storage()
 	// redisService := services.NewRedisService()
 	memoryStorage := services.NewInMemoryStorage()
+	mailer := services.NewMailer()
 	mfaService := services.NewMFAService(
 		memoryStorage,
 		userRepo,
@@ -35,19 +53,87 @@ func Init() {
 	// Service initialization
 	userService := services.NewUserService()
 	authService := services.NewAuthService()
-	documentService := services.NewDocumentService(ragServerService)
+	documentService := services.NewDocumentService(
+		documentRepo,
+		documentVersionRepo,
+		documentFolderRepo,
+		blobRepo,
+		spaceRepo,
+		userRepo,
+		documentTextRepo,
+		ragServerService,
+		objectStorage,
+		services.NewScanner(),
+	)
+	documentFolderService := services.NewDocumentFolderService(documentFolderRepo, documentRepo, documentService)
+	documentTextService := services.NewDocumentTextService(documentTextRepo, spaceRepo, documentFolderRepo, documentRepo)
+	resumableUploadService := services.NewResumableUploadService(
+		resumableUploadRepo,
+		documentFolderRepo,
+		spaceRepo,
+		documentService,
+		userService,
+		objectStorage,
+	)
+	reindexService := services.NewReindexService(reindexJobRepo, documentRepo, documentService)
 	spaceService := services.NewSpaceService(
 		spaceInvitationLinkRepo,
 		ragServerService,
 		userRepo,
 		spaceInvitationRepo,
 		documentRepo,
+		documentService,
+		mailer,
 	)
 	spaceInvitationService := services.NewSpaceInvitationService()
 	spaceInvitationLinkService := services.NewSpaceInvitationLinkService()
 	userQuerySessionService := services.NewUserQuerySessionService()
 	userQueryService := services.NewUserQueryService()
 	spaceApiKeyService := services.NewSpaceApiKeyService()
+	reconcilerService := services.NewReconcilerService(
+		documentRepo,
+		documentVersionRepo,
+		blobRepo,
+		resumableUploadRepo,
+		documentService,
+		ragServerService,
+		objectStorage,
+	)
+	spaceOwnershipTransferService := services.NewSpaceOwnershipTransferService(ownershipTransferRepo, spaceRepo)
+	spaceJoinRequestService := services.NewSpaceJoinRequestService(joinRequestRepo, spaceRepo, spaceRoleRepo, userRepo, mailer)
+	spaceCloneService := services.NewSpaceCloneService(
+		spaceTemplateRepo,
+		spaceRepo,
+		spaceRoleRepo,
+		documentFolderRepo,
+		documentRepo,
+		spaceApiKeyRepo,
+		blobRepo,
+		spaceService,
+		documentService,
+		reindexService,
+		objectStorage,
+	)
+	spaceArchiveService := services.NewSpaceArchiveService(
+		spaceRepo,
+		spaceRoleRepo,
+		documentFolderRepo,
+		documentRepo,
+		userRepo,
+		userQuerySessionRepo,
+		spaceService,
+		documentService,
+		objectStorage,
+	)
+	trashService := services.NewTrashService(
+		documentRepo,
+		spaceRepo,
+		userQuerySessionRepo,
+		documentService,
+		spaceService,
+		userQuerySessionService,
+		reindexService,
+	)
 
 	// Controller initialization
 	userController := controllers.NewUserController(userService)
@@ -66,18 +152,38 @@ func Init() {
 		memoryStorage,
 		mfaService,
 	)
-	documentController := controllers.NewDocumentController(documentService, spaceService)
-	spaceController := controllers.NewSpaceController(spaceService)
+	documentController := controllers.NewDocumentController(documentService, spaceService, documentTextService)
+	documentFolderController := controllers.NewDocumentFolderController(documentFolderService)
+	resumableUploadController := controllers.NewResumableUploadController(resumableUploadService)
+	reindexController := controllers.NewReindexController(reindexService, documentService, spaceService)
+	spaceController := controllers.NewSpaceController(spaceService, documentTextService, spaceCloneService)
 	spaceInvitationController := controllers.NewSpaceInvitationController(spaceInvitationService)
 	spaceInvitationLinkController := controllers.NewSpaceInvitationLinkController(spaceInvitationLinkService)
 	userQuerySessionController := controllers.NewUserQuerySessionController(userQuerySessionService)
-	userQueryController := controllers.NewUserQueryController(userQueryService)
+	userQueryController := controllers.NewUserQueryController(userQueryService, documentTextService)
 	spaceApiKeyController := controllers.NewSpaceApiKeyController(spaceApiKeyService)
+	trashController := controllers.NewTrashController(trashService)
+	adminController := controllers.NewAdminController(reconcilerService)
+	spaceOwnershipTransferController := controllers.NewSpaceOwnershipTransferController(spaceOwnershipTransferService)
+	spaceJoinRequestController := controllers.NewSpaceJoinRequestController(spaceJoinRequestService)
+	spaceTemplateController := controllers.NewSpaceTemplateController(spaceCloneService)
+	spaceArchiveController := controllers.NewSpaceArchiveController(spaceArchiveService, spaceService)
 
 	config := configs.GetEnv()
 
+	documentService.StartURLRefreshRoutine(time.Duration(config.URLIngest.RefreshCheckMinutes) * time.Minute)
+	reindexService.FailInterruptedJobs()
+	resumableUploadService.StartCleanupRoutine(time.Duration(config.ResumableUpload.CleanupIntervalMinutes) * time.Minute)
+	trashService.StartPurgeRoutine(time.Duration(config.Trash.PurgeIntervalMinutes) * time.Minute)
+	spaceService.StartStorageReconcileRoutine(time.Duration(config.Storage.ReconcileIntervalMinutes) * time.Minute)
+
 	// Middleware initialization
 	chatRateLimiter := middlewares.ChatRateLimiter(userService)
+	requireAdmin := middlewares.RequireAdmin(userService)
+	requireSpaceReader := middlewares.RequireSpaceReader(spaceService)
+	requireSpacePermission := func(permission entities.SpacePermission) gin.HandlerFunc {
+		return middlewares.RequireSpacePermission(spaceService, permission)
+	}
 
 	// Router initialization
 	r := GetRouter(
@@ -85,13 +191,25 @@ func Init() {
 		authController,
 		oauthController,
 		documentController,
+		documentFolderController,
+		resumableUploadController,
+		reindexController,
 		spaceController,
 		spaceInvitationController,
 		spaceInvitationLinkController,
 		userQuerySessionController,
 		userQueryController,
 		spaceApiKeyController,
+		trashController,
+		adminController,
+		spaceOwnershipTransferController,
+		spaceJoinRequestController,
+		spaceTemplateController,
+		spaceArchiveController,
 		chatRateLimiter,
+		requireAdmin,
+		requireSpaceReader,
+		requireSpacePermission,
 	)
 
 	r.Run(":" + strconv.Itoa(config.Port))
@@ -67,6 +67,11 @@ func (s *AuthService) RegisterUser(dto *dtos.RegisterDTO) (*entities.User, strin
 		return nil, "", nil, err
 	}
 
+	if err := claimEmailInvitations(tx, &user); err != nil {
+		tx.Rollback()
+		return nil, "", nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, "", nil, err
 	}
@@ -79,6 +84,20 @@ func (s *AuthService) RegisterUser(dto *dtos.RegisterDTO) (*entities.User, strin
 	return &user, token, expiresAt, nil
 }
 
+// claimEmailInvitations hands the invitations sent to the address of a new
+// account over to its user, turning them into regular invitations.
+func claimEmailInvitations(tx *gorm.DB, user *entities.User) error {
+	if user.Email == nil {
+		return nil
+	}
+	return tx.Model(&entities.SpaceInvitation{}).
+		Where("invited_user_id IS NULL AND LOWER(invited_email) = LOWER(?) AND status = ?", *user.Email, entities.InvitationStatusPending).
+		Updates(map[string]interface{}{
+			"invited_user_id": user.ID,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
 func (s *AuthService) LoginUser(email, password string) (*entities.User, string, *time.Time, error) {
 	db := databases.GetDB()
 
@@ -144,6 +163,11 @@ func (s *AuthService) ExternalAuth(dto *dtos.ExternalAuthDTO) (*entities.User, s
 			tx.Rollback()
 			return nil, "", nil, false, err
 		}
+
+		if err := claimEmailInvitations(tx, &user); err != nil {
+			tx.Rollback()
+			return nil, "", nil, false, err
+		}
 	}
 
 	var cred entities.UserAuthCredential
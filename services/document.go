@@ -1,57 +1,393 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
 	"mime/multipart"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/BlenDMinh/dutgrad-server/configs"
-	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
 	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"gorm.io/gorm"
 )
 
 type DocumentService interface {
 	ICrudService[entities.Document, uint]
 	GetDocumentsBySpaceID(spaceID uint) ([]entities.Document, error)
 	CheckDocumentLimits(spaceID uint, fileSize int64) error
-	UploadDocument(fileHeader *multipart.FileHeader, spaceID uint, mimeType string, description string) (*entities.Document, error)
+	CheckFileSizeLimit(spaceID uint, fileSize int64) error
+	ListDocuments(spaceID uint, role *entities.SpaceRole, query dtos.DocumentListQuery, page int, pageSize int) (*helpers.PaginationResult, error)
+	GetVisibleDocuments(userID uint, page int, pageSize int) ([]entities.Document, int64, error)
+	SetDocumentVisibility(documentID uint, visibility entities.DocumentVisibility) (*entities.Document, error)
+	GetTagsBySpaceID(spaceID uint) ([]string, error)
+	UploadDocument(fileHeader *multipart.FileHeader, spaceID uint, uploaderID uint, mimeType string, options dtos.DocumentUploadOptions) (*entities.Document, error)
+	UploadDocumentFile(uploadFile *helpers.UploadFile, spaceID uint, uploaderID uint, mimeType string, options dtos.DocumentUploadOptions) (*entities.Document, error)
+	UploadZipArchive(fileHeader *multipart.FileHeader, spaceID uint, uploaderID uint, options dtos.DocumentUploadOptions) (*dtos.BulkUploadReport, error)
+	MoveDocumentToFolder(documentID uint, folderID *uint) (*entities.Document, error)
+	MoveDocumentToSpace(documentID uint, targetSpaceID uint, folderID *uint) (*entities.Document, error)
+	CopyDocumentToSpace(documentID uint, targetSpaceID uint, folderID *uint, userID uint) (*entities.Document, error)
+	AddStoredDocument(document *entities.Document) (*entities.Document, error)
+	SetDocumentTags(documentID uint, tags []string) (*entities.Document, error)
+	SyncDocumentMetadata(document *entities.Document) error
+	ReplaceDocumentFile(documentID uint, uploadFile *helpers.UploadFile, uploaderID uint, mimeType string) (*entities.Document, error)
+	GetDocumentVersions(documentID uint) ([]entities.DocumentVersion, error)
+	GetDocumentVersion(documentID uint, versionID uint) (*entities.DocumentVersion, error)
+	RollbackDocument(documentID uint, versionID uint, userID uint) (*entities.Document, error)
 	CountUserDocuments(userID uint) (int64, error)
-	DeleteDocument(documentID uint) error
+	DeleteDocument(documentID uint, deletedBy *uint) error
+	GetDeletedDocuments(spaceID *uint, deletedBy *uint) ([]entities.Document, error)
+	RestoreDocument(documentID uint) (*entities.Document, error)
+	PurgeDocument(documentID uint) error
+	ReindexDocument(document *entities.Document) error
+	GetDocumentContent(document *entities.Document) (io.ReadCloser, error)
+	GetPresignedURL(document *entities.Document, inline bool) (string, error)
+	IngestFromURL(rawURL string, spaceID uint, uploaderID uint, options dtos.DocumentUploadOptions, refreshIntervalHours int) (*entities.Document, error)
+	RefetchDocument(documentID uint, uploaderID *uint) (*entities.Document, error)
+	RefreshURLDocuments()
+	StartURLRefreshRoutine(interval time.Duration)
 }
 
+const (
+	defaultPresignExpiry = 5 * time.Minute
+
+	defaultMaxArchiveSizeMb    = 200
+	defaultMaxArchiveEntries   = 100
+	defaultMaxArchiveTotalMb   = 1024
+	defaultMaxCompressionRatio = 100
+)
+
 type documentServiceImpl struct {
 	CrudService[entities.Document, uint]
 	repo             repositories.DocumentRepository
+	versionRepo      repositories.DocumentVersionRepository
+	folderRepo       repositories.DocumentFolderRepository
+	blobRepo         repositories.BlobRepository
+	spaceRepo        repositories.SpaceRepository
+	userRepo         repositories.UserRepository
+	textRepo         repositories.DocumentTextRepository
 	ragServerService *RAGServerService
+	storage          ObjectStorage
+	scanner          Scanner
+}
+
+type DuplicateDocumentError struct {
+	DocumentID uint
+}
+
+func (e *DuplicateDocumentError) Error() string {
+	return fmt.Sprintf("document already exists: identical content is stored as document %d", e.DocumentID)
 }
 
 func NewDocumentService(
+	repo repositories.DocumentRepository,
+	versionRepo repositories.DocumentVersionRepository,
+	folderRepo repositories.DocumentFolderRepository,
+	blobRepo repositories.BlobRepository,
+	spaceRepo repositories.SpaceRepository,
+	userRepo repositories.UserRepository,
+	textRepo repositories.DocumentTextRepository,
 	ragServerService *RAGServerService,
+	storage ObjectStorage,
+	scanner Scanner,
 ) DocumentService {
-	crudService := NewCrudService(repositories.NewDocumentRepository())
-	repo := crudService.repo.(repositories.DocumentRepository)
 	return &documentServiceImpl{
-		CrudService:      *crudService,
+		CrudService:      *NewCrudService[entities.Document, uint](repo),
 		repo:             repo,
+		versionRepo:      versionRepo,
+		folderRepo:       folderRepo,
+		blobRepo:         blobRepo,
+		spaceRepo:        spaceRepo,
+		userRepo:         userRepo,
+		textRepo:         textRepo,
 		ragServerService: ragServerService,
+		storage:          storage,
+		scanner:          scanner,
+	}
+}
+
+var documentLocks sync.Map
+
+// lockDocument serializes file replacements of a single document so that the
+// stored current version and the RAG index never point at different blobs.
+func lockDocument(documentID uint) func() {
+	value, _ := documentLocks.LoadOrStore(documentID, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+func GetDocumentViewURL(documentID uint) string {
+	env := configs.GetEnv()
+	return fmt.Sprintf("%s/documents/view?id=%d", env.WebClientURL, documentID)
+}
+
+// UpdateByID and PatchByID keep the storage fields of a document, which only
+// change through a new version, and the fields with dedicated endpoints.
+func (s *documentServiceImpl) UpdateByID(id uint, model *entities.Document) (*entities.Document, error) {
+	existing, err := s.repo.GetById(id)
+	if err != nil {
+		return nil, err
 	}
+
+	model.SpaceID = existing.SpaceID
+	model.MimeType = existing.MimeType
+	model.Size = existing.Size
+	model.S3URL = existing.S3URL
+	model.ContentHash = existing.ContentHash
+	model.Metadata = existing.Metadata
+	model.CurrentVersion = existing.CurrentVersion
+	model.FolderID = existing.FolderID
+	model.Tags = existing.Tags
+	model.SourceURL = existing.SourceURL
+	model.LastFetchedAt = existing.LastFetchedAt
+	model.PrivacyStatus = existing.PrivacyStatus
+	model.DocumentScan = existing.DocumentScan
+	model.RefreshIntervalHours = existing.RefreshIntervalHours
+	model.UploadedBy = existing.UploadedBy
+	model.DeletedBy = existing.DeletedBy
+	return s.CrudService.UpdateByID(id, model)
+}
+
+func (s *documentServiceImpl) PatchByID(id uint, patchData *entities.Document) (*entities.Document, error) {
+	patchData.SpaceID = 0
+	patchData.MimeType = ""
+	patchData.Size = 0
+	patchData.S3URL = ""
+	patchData.ContentHash = ""
+	patchData.Metadata = entities.DocumentMetadata{}
+	patchData.CurrentVersion = 0
+	patchData.FolderID = nil
+	patchData.Tags = nil
+	patchData.SourceURL = ""
+	patchData.LastFetchedAt = nil
+	patchData.PrivacyStatus = ""
+	patchData.DocumentScan = entities.DocumentScan{}
+	patchData.RefreshIntervalHours = 0
+	patchData.UploadedBy = nil
+	patchData.DeletedBy = nil
+	return s.CrudService.PatchByID(id, patchData)
 }
 
 func (s *documentServiceImpl) GetDocumentsBySpaceID(spaceID uint) ([]entities.Document, error) {
 	return s.repo.GetBySpaceID(spaceID)
 }
 
-func (s *documentServiceImpl) CheckDocumentLimits(spaceID uint, fileSize int64) error {
-	db := databases.GetDB()
+// ListDocuments returns a page of the documents of a space that a member with
+// role can see. A nil role stands for a visitor of a public space.
+func (s *documentServiceImpl) ListDocuments(spaceID uint, role *entities.SpaceRole, query dtos.DocumentListQuery, page int, pageSize int) (*helpers.PaginationResult, error) {
+	filter := repositories.DocumentFilter{
+		SpaceID:      spaceID,
+		Visibilities: entities.VisibleDocumentVisibilities(role),
+	}
+
+	switch query.FolderID {
+	case "":
+	case "root":
+		filter.Unfiled = true
+	default:
+		folderID, err := strconv.ParseUint(query.FolderID, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid folder_id: %v", err)
+		}
+
+		id := uint(folderID)
+		if err := s.validateFolder(spaceID, &id); err != nil {
+			return nil, err
+		}
+
+		filter.FolderIDs = []uint{id}
+		if query.Recursive {
+			if filter.FolderIDs, err = s.folderRepo.GetDescendantIDs(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tags, err := normalizeTags(query.Tags)
+	if err != nil {
+		return nil, err
+	}
+	filter.Tags = tags
+	filter.Title = strings.TrimSpace(query.Title)
+	filter.Author = strings.TrimSpace(query.Author)
+	filter.Language = strings.ToLower(strings.TrimSpace(query.Language))
+	filter.MinPages = query.MinPages
+	filter.MaxPages = query.MaxPages
+	filter.Query = strings.TrimSpace(query.Q)
+	filter.MimeType = strings.ToLower(strings.TrimSpace(query.MimeType))
+	if query.UploadedBy != 0 {
+		filter.UploadedBy = &query.UploadedBy
+	}
+	filter.CreatedFrom = query.CreatedFrom
+	if query.CreatedTo != nil {
+		// The end date is inclusive
+		createdTo := query.CreatedTo.AddDate(0, 0, 1)
+		filter.CreatedTo = &createdTo
+	}
+	filter.MinSize = query.MinSize
+	filter.MaxSize = query.MaxSize
+	filter.ProcessingStatus = query.ProcessingStatus
+	filter.SortBy = query.Sort
+	filter.SortDesc = query.Order == "desc"
+
+	documents, total, err := s.repo.GetPageByFilter(filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := helpers.CreatePaginationResult(documents, page, pageSize, total)
+	return &result, nil
+}
+
+func (s *documentServiceImpl) GetVisibleDocuments(userID uint, page int, pageSize int) ([]entities.Document, int64, error) {
+	return s.repo.GetVisibleToUser(userID, page, pageSize)
+}
+
+func (s *documentServiceImpl) SetDocumentVisibility(documentID uint, visibility entities.DocumentVisibility) (*entities.Document, error) {
+	if !visibility.IsValid() {
+		return nil, fmt.Errorf("invalid visibility: %q", visibility)
+	}
+
+	if err := s.repo.UpdateVisibility(documentID, visibility); err != nil {
+		return nil, err
+	}
+
+	return s.GetById(documentID)
+}
+
+func (s *documentServiceImpl) GetTagsBySpaceID(spaceID uint) ([]string, error) {
+	return s.repo.GetTagsBySpaceID(spaceID)
+}
+
+func (s *documentServiceImpl) MoveDocumentToFolder(documentID uint, folderID *uint) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	document, err := s.GetById(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateFolder(document.SpaceID, folderID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateFolder(documentID, folderID); err != nil {
+		return nil, err
+	}
+	document.FolderID = folderID
+
+	return document, s.SyncDocumentMetadata(document)
+}
+
+func (s *documentServiceImpl) SetDocumentTags(documentID uint, tags []string) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	document, err := s.GetById(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTags(documentID, normalized); err != nil {
+		return nil, err
+	}
+	document.Tags = normalized
+
+	return document, s.SyncDocumentMetadata(document)
+}
+
+// SyncDocumentMetadata pushes the folder path and tags of a document to the
+// RAG server without re-ingesting its content.
+func (s *documentServiceImpl) SyncDocumentMetadata(document *entities.Document) error {
+	metadata, err := s.ragMetadata(document)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ragServerService.UpdateDocumentMetadata(document.ID, document.SpaceID, metadata); err != nil {
+		return fmt.Errorf("failed to update document metadata in RAG server: %v", err)
+	}
+	return nil
+}
+
+func (s *documentServiceImpl) ragMetadata(document *entities.Document) (RAGDocumentMetadata, error) {
+	metadata := RAGDocumentMetadata{Tags: document.Tags}
+	if document.FolderID == nil {
+		metadata.FolderPath = "/"
+		return metadata, nil
+	}
+
+	folderPath, err := s.folderRepo.GetPath(*document.FolderID)
+	if err != nil {
+		return metadata, fmt.Errorf("failed to get folder path: %v", err)
+	}
+	metadata.FolderPath = folderPath
+	return metadata, nil
+}
+
+func (s *documentServiceImpl) validateFolder(spaceID uint, folderID *uint) error {
+	if folderID == nil {
+		return nil
+	}
+
+	folder, err := s.folderRepo.GetById(*folderID)
+	if err != nil || folder.SpaceID != spaceID {
+		return errors.New("folder not found in this space")
+	}
+	return nil
+}
+
+const (
+	maxDocumentTags   = 20
+	maxDocumentTagLen = 64
+)
 
-	var space entities.Space
-	if err := db.First(&space, spaceID).Error; err != nil {
+// normalizeTags trims, lowercases and deduplicates tags so that filtering is
+// case insensitive.
+func normalizeTags(tags []string) (entities.DocumentTags, error) {
+	normalized := entities.DocumentTags{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxDocumentTagLen {
+			return nil, fmt.Errorf("invalid tag: %q is longer than %d characters", tag, maxDocumentTagLen)
+		}
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > maxDocumentTags {
+		return nil, fmt.Errorf("invalid tag: a document can have at most %d tags", maxDocumentTags)
+	}
+	return normalized, nil
+}
+
+func (s *documentServiceImpl) CheckDocumentLimits(spaceID uint, fileSize int64) error {
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
 		return fmt.Errorf("failed to find space: %v", err)
 	}
 
-	var count int64
-	if err := db.Model(&entities.Document{}).Where("space_id = ?", spaceID).Count(&count).Error; err != nil {
+	count, err := s.repo.CountBySpaceID(spaceID)
+	if err != nil {
 		return fmt.Errorf("failed to count documents: %v", err)
 	}
 
@@ -59,6 +395,56 @@ func (s *documentServiceImpl) CheckDocumentLimits(spaceID uint, fileSize int64)
 		return fmt.Errorf("document limit reached: this space can only have %d documents", space.DocumentLimit)
 	}
 
+	if err := checkFileSizeLimit(space, fileSize); err != nil {
+		return err
+	}
+
+	return s.checkStorageQuota(space, fileSize)
+}
+
+// checkStorageQuota fails when adding bytes to a space would exceed the quota
+// of the space or the quota of the tier of its owner. Zero means no quota.
+func (s *documentServiceImpl) checkStorageQuota(space *entities.Space, bytes int64) error {
+	if bytes <= 0 {
+		return nil
+	}
+
+	if space.StorageLimitBytes > 0 && space.StorageUsedBytes+bytes > space.StorageLimitBytes {
+		return fmt.Errorf("storage quota exceeded: this space can only store %d bytes", space.StorageLimitBytes)
+	}
+
+	ownerID, err := s.spaceRepo.GetOwnerID(space.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find space owner: %v", err)
+	}
+
+	// Owners without a tier are only limited by the quota of the space
+	tier, err := s.userRepo.GetUserTier(ownerID)
+	if err != nil || tier.StorageLimitBytes <= 0 {
+		return nil
+	}
+
+	used, err := s.userRepo.GetStorageUsage(ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to get storage usage: %v", err)
+	}
+
+	if used+bytes > tier.StorageLimitBytes {
+		return fmt.Errorf("storage quota exceeded: the tier of the space owner can only store %d bytes", tier.StorageLimitBytes)
+	}
+	return nil
+}
+
+func (s *documentServiceImpl) CheckFileSizeLimit(spaceID uint, fileSize int64) error {
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return fmt.Errorf("failed to find space: %v", err)
+	}
+
+	return checkFileSizeLimit(space, fileSize)
+}
+
+func checkFileSizeLimit(space *entities.Space, fileSize int64) error {
 	fileSizeKB := fileSize / 1024
 	if fileSizeKB > int64(space.FileSizeLimitKb) {
 		return fmt.Errorf("file size exceeds the limit of %d KB for this space", space.FileSizeLimitKb)
@@ -67,52 +453,491 @@ func (s *documentServiceImpl) CheckDocumentLimits(spaceID uint, fileSize int64)
 	return nil
 }
 
-func (s *documentServiceImpl) UploadDocument(fileHeader *multipart.FileHeader, spaceID uint, mimeType string, description string) (*entities.Document, error) {
-	if err := s.CheckDocumentLimits(spaceID, fileHeader.Size); err != nil {
+func (s *documentServiceImpl) UploadDocument(fileHeader *multipart.FileHeader, spaceID uint, uploaderID uint, mimeType string, options dtos.DocumentUploadOptions) (*entities.Document, error) {
+	return s.UploadDocumentFile(helpers.NewUploadFileFromHeader(fileHeader), spaceID, uploaderID, mimeType, options)
+}
+
+func (s *documentServiceImpl) UploadDocumentFile(uploadFile *helpers.UploadFile, spaceID uint, uploaderID uint, mimeType string, options dtos.DocumentUploadOptions) (*entities.Document, error) {
+	document := &entities.Document{
+		SpaceID:       spaceID,
+		Description:   options.Description,
+		FolderID:      options.FolderID,
+		Tags:          options.Tags,
+		PrivacyStatus: options.Visibility,
+	}
+	return s.createDocument(document, uploadFile, &uploaderID, mimeType, options.Replace)
+}
+
+// createDocument stores the file as the first version of document and feeds
+// it to the RAG server. Only the metadata fields of document are expected.
+// Content that already exists in the space is rejected unless replace is set,
+// in which case it becomes a new version of the existing document.
+func (s *documentServiceImpl) createDocument(document *entities.Document, uploadFile *helpers.UploadFile, uploadedBy *uint, mimeType string, replace bool) (*entities.Document, error) {
+	contentHash, err := uploadFile.ContentHash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash file: %v", err)
+	}
+
+	existing, err := s.repo.GetBySpaceAndContentHash(document.SpaceID, contentHash)
+	if err == nil {
+		if !replace {
+			return nil, &DuplicateDocumentError{DocumentID: existing.ID}
+		}
+		return s.replaceExisting(existing.ID, uploadFile, uploadedBy, mimeType)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 
+	if err := s.CheckDocumentLimits(document.SpaceID, uploadFile.Size); err != nil {
+		return nil, err
+	}
+
+	scan, err := s.validateUpload(document.SpaceID, uploadFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if document.PrivacyStatus == "" {
+		document.PrivacyStatus = entities.DocumentVisibilityMembers
+	}
+	if !document.PrivacyStatus.IsValid() {
+		return nil, fmt.Errorf("invalid visibility: %q", document.PrivacyStatus)
+	}
+
+	if err := s.validateFolder(document.SpaceID, document.FolderID); err != nil {
+		return nil, err
+	}
+
+	tags, err := normalizeTags(document.Tags)
+	if err != nil {
+		return nil, err
+	}
+	document.Tags = tags
+
+	metadata, err := s.ragMetadata(document)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType, err = resolveMimeType(uploadFile, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	s3URL, err := s.storeBlob(uploadFile, contentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	document.Name = uploadFile.Filename
+	document.MimeType = mimeType
+	document.Size = uploadFile.Size
+	document.S3URL = s3URL
+	document.ContentHash = contentHash
+	document.Metadata = extractMetadata(uploadFile, mimeType)
+	document.DocumentScan = scan
+	document.UploadedBy = uploadedBy
+
+	version := &entities.DocumentVersion{
+		Name:         document.Name,
+		MimeType:     document.MimeType,
+		Size:         document.Size,
+		S3URL:        document.S3URL,
+		ContentHash:  document.ContentHash,
+		Metadata:     document.Metadata,
+		DocumentScan: document.DocumentScan,
+		UploadedBy:   uploadedBy,
+	}
+
+	document, err = s.repo.CreateWithVersion(document, version)
+	if err != nil {
+		s.releaseBlob(contentHash)
+		return nil, err
+	}
+
+	err = s.ragServerService.UploadDocument(uploadFile, document.SpaceID, document.ID, GetDocumentViewURL(document.ID), document.Description, metadata)
+	if err != nil {
+		s.repo.Purge(document.ID)
+		s.releaseBlob(contentHash)
+		return nil, err
+	}
+
+	s.indexText(document.ID, uploadFile, mimeType)
+	return document, nil
+}
+
+func (s *documentServiceImpl) UploadZipArchive(fileHeader *multipart.FileHeader, spaceID uint, uploaderID uint, options dtos.DocumentUploadOptions) (*dtos.BulkUploadReport, error) {
+	config := configs.GetEnv().BulkUpload
+
+	maxArchiveSize := int64(valueOrDefault(config.MaxArchiveSizeMb, defaultMaxArchiveSizeMb)) * 1024 * 1024
+	if fileHeader.Size > maxArchiveSize {
+		return nil, fmt.Errorf("archive size exceeds the limit of %d MB", maxArchiveSize/1024/1024)
+	}
+
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find space: %v", err)
+	}
+
 	file, err := fileHeader.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	size := fileHeader.Size
+	// CheckDocumentLimits compares whole kilobytes, so allow the remainder
+	maxEntrySize := int64(space.FileSizeLimitKb+1)*1024 - 1
+
+	limits := helpers.ZipLimits{
+		MaxEntries:          valueOrDefault(config.MaxEntries, defaultMaxArchiveEntries),
+		MaxEntrySize:        maxEntrySize,
+		MaxTotalSize:        int64(valueOrDefault(config.MaxTotalSizeMb, defaultMaxArchiveTotalMb)) * 1024 * 1024,
+		MaxCompressionRatio: uint64(valueOrDefault(config.MaxCompressionRatio, defaultMaxCompressionRatio)),
+	}
+
+	report := &dtos.BulkUploadReport{
+		Accepted: []dtos.BulkUploadAcceptedEntry{},
+		Rejected: []dtos.BulkUploadRejectedEntry{},
+	}
 
-	s3URL, err := UploadFileToS3(fileHeader.Filename, file)
+	err = helpers.ReadZipEntries(file, fileHeader.Size, limits, func(entry helpers.ZipEntry) {
+		if entry.Reason != "" {
+			report.Rejected = append(report.Rejected, dtos.BulkUploadRejectedEntry{Path: entry.Path, Reason: entry.Reason})
+			return
+		}
+
+		document, err := s.UploadDocumentFile(helpers.NewUploadFileFromBytes(entry.Name, entry.Data), spaceID, uploaderID, "", options)
+		if err != nil {
+			report.Rejected = append(report.Rejected, dtos.BulkUploadRejectedEntry{Path: entry.Path, Reason: err.Error()})
+			return
+		}
+
+		report.Accepted = append(report.Accepted, dtos.BulkUploadAcceptedEntry{Path: entry.Path, Document: document})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	document := &entities.Document{
-		SpaceID:     spaceID,
-		Name:        fileHeader.Filename,
-		Description: description,
-		MimeType:    mimeType,
-		Size:        size,
-		S3URL:       s3URL,
+	return report, nil
+}
+
+func valueOrDefault(value int, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
 	}
+	return value
+}
+
+func (s *documentServiceImpl) ReplaceDocumentFile(documentID uint, uploadFile *helpers.UploadFile, uploaderID uint, mimeType string) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
 
-	document, err = s.repo.Create(document)
+	document, err := s.GetById(documentID)
 	if err != nil {
 		return nil, err
 	}
 
-	env := configs.GetEnv()
+	return s.replaceDocumentFile(document, uploadFile, &uploaderID, mimeType)
+}
 
-	filePath := fmt.Sprintf("%s/documents/view?id=%d", env.WebClientURL, document.ID)
+func (s *documentServiceImpl) replaceExisting(documentID uint, uploadFile *helpers.UploadFile, uploadedBy *uint, mimeType string) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
 
-	err = s.ragServerService.UploadDocument(fileHeader, spaceID, document.ID, filePath, description)
+	document, err := s.GetById(documentID)
 	if err != nil {
-		s.repo.Delete(document.ID)
 		return nil, err
 	}
 
-	return document, nil
+	return s.replaceDocumentFile(document, uploadFile, uploadedBy, mimeType)
+}
+
+// replaceDocumentFile expects the caller to hold the document lock. Like on
+// upload, content that another document of the space holds is rejected.
+func (s *documentServiceImpl) replaceDocumentFile(document *entities.Document, uploadFile *helpers.UploadFile, uploadedBy *uint, mimeType string) (*entities.Document, error) {
+	contentHash, err := uploadFile.ContentHash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash file: %v", err)
+	}
+
+	existing, err := s.repo.GetBySpaceAndContentHash(document.SpaceID, contentHash)
+	if err == nil && existing.ID != document.ID {
+		return nil, &DuplicateDocumentError{DocumentID: existing.ID}
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := s.CheckFileSizeLimit(document.SpaceID, uploadFile.Size); err != nil {
+		return nil, err
+	}
+
+	scan, err := s.validateUpload(document.SpaceID, uploadFile)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType, err = resolveMimeType(uploadFile, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	s3URL, err := s.storeBlob(uploadFile, contentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &entities.DocumentVersion{
+		Name:         uploadFile.Filename,
+		MimeType:     mimeType,
+		Size:         uploadFile.Size,
+		S3URL:        s3URL,
+		ContentHash:  contentHash,
+		Metadata:     extractMetadata(uploadFile, mimeType),
+		DocumentScan: scan,
+		UploadedBy:   uploadedBy,
+	}
+
+	updated, err := s.applyVersion(document, version, uploadFile)
+	if err != nil {
+		s.releaseBlob(contentHash)
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *documentServiceImpl) GetDocumentVersions(documentID uint) ([]entities.DocumentVersion, error) {
+	return s.versionRepo.GetByDocumentID(documentID)
+}
+
+func (s *documentServiceImpl) GetDocumentVersion(documentID uint, versionID uint) (*entities.DocumentVersion, error) {
+	return s.versionRepo.GetByDocumentAndVersionID(documentID, versionID)
+}
+
+func (s *documentServiceImpl) RollbackDocument(documentID uint, versionID uint, userID uint) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	document, err := s.GetById(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.versionRepo.GetByDocumentAndVersionID(documentID, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("version not found: %v", err)
+	}
+
+	if target.Version == document.CurrentVersion {
+		return nil, fmt.Errorf("version %d is already the current version", target.Version)
+	}
+
+	data, err := s.storage.Download(target.S3URL)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &entities.DocumentVersion{
+		Name:         target.Name,
+		MimeType:     target.MimeType,
+		Size:         target.Size,
+		S3URL:        target.S3URL,
+		ContentHash:  target.ContentHash,
+		Metadata:     target.Metadata,
+		DocumentScan: target.DocumentScan,
+		UploadedBy:   &userID,
+	}
+
+	// The new version is another reference to the blob of the target
+	if target.ContentHash != "" {
+		if _, err := s.blobRepo.Acquire(target.ContentHash, target.S3URL, target.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.applyVersion(document, version, helpers.NewUploadFileFromBytes(target.Name, data))
+	if err != nil {
+		if target.ContentHash != "" {
+			s.releaseBlob(target.ContentHash)
+		}
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// applyVersion re-ingests the document with the content of the new version and
+// only then records the version as current. If either step fails, the RAG
+// entry of the previous version is restored.
+func (s *documentServiceImpl) applyVersion(document *entities.Document, version *entities.DocumentVersion, uploadFile *helpers.UploadFile) (*entities.Document, error) {
+	space, err := s.spaceRepo.GetById(document.SpaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find space: %v", err)
+	}
+
+	if err := s.checkStorageQuota(space, version.Size-document.Size); err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.ragMetadata(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ragServerService.RemoveDocument(document.ID, document.SpaceID); err != nil {
+		return nil, fmt.Errorf("failed to remove previous version from RAG server: %v", err)
+	}
+
+	err = s.ragServerService.UploadDocument(uploadFile, document.SpaceID, document.ID, GetDocumentViewURL(document.ID), document.Description, metadata)
+	if err != nil {
+		s.restoreIndex(document)
+		return nil, fmt.Errorf("failed to upload new version to RAG server: %v", err)
+	}
+
+	updated, err := s.repo.AddVersion(document.ID, version)
+	if err != nil {
+		s.restoreIndex(document)
+		return nil, err
+	}
+
+	s.indexText(updated.ID, uploadFile, updated.MimeType)
+	return updated, nil
+}
+
+func (s *documentServiceImpl) restoreIndex(document *entities.Document) {
+	if err := s.ReindexDocument(document); err != nil {
+		log.Printf("Failed to restore document %d in RAG server: %v", document.ID, err)
+	}
+}
+
+// ReindexDocument sends the stored file of the current version to the RAG
+// server again, replacing whatever is indexed for the document.
+func (s *documentServiceImpl) ReindexDocument(document *entities.Document) error {
+	data, err := s.storage.Download(document.S3URL)
+	if err != nil {
+		return err
+	}
+
+	if err := indexDocumentText(s.textRepo, document.ID, document.MimeType, data); err != nil {
+		log.Printf("Failed to index text of document %d: %v", document.ID, err)
+	}
+
+	metadata, err := s.ragMetadata(document)
+	if err != nil {
+		return err
+	}
+
+	// The index may already be gone, e.g. after the vector store was wiped
+	_ = s.ragServerService.RemoveDocument(document.ID, document.SpaceID)
+
+	return s.ragServerService.UploadDocument(
+		helpers.NewUploadFileFromBytes(document.Name, data),
+		document.SpaceID,
+		document.ID,
+		GetDocumentViewURL(document.ID),
+		document.Description,
+		metadata,
+	)
+}
+
+// resolveMimeType detects the type from the file. The declared type is only
+// kept when it belongs to the extension, e.g. text/markdown for a .md file
+// that is detected as plain text.
+func resolveMimeType(uploadFile *helpers.UploadFile, mimeType string) (string, error) {
+	if mimeType != "" && helpers.DeclaredMimeTypeMatches(uploadFile.Filename, mimeType) {
+		return mimeType, nil
+	}
+	return helpers.GetUploadFileMimeType(uploadFile)
+}
+
+// extractMetadata never fails an upload, a file that cannot be parsed is
+// stored without metadata.
+func extractMetadata(uploadFile *helpers.UploadFile, mimeType string) entities.DocumentMetadata {
+	file, err := uploadFile.Open()
+	if err != nil {
+		log.Printf("Failed to extract metadata of %s: %v", uploadFile.Filename, err)
+		return entities.DocumentMetadata{}
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		log.Printf("Failed to extract metadata of %s: %v", uploadFile.Filename, err)
+		return entities.DocumentMetadata{}
+	}
+
+	metadata, err := helpers.ExtractFileMetadata(uploadFile.Filename, mimeType, data)
+	if err != nil {
+		log.Printf("Failed to extract metadata of %s: %v", uploadFile.Filename, err)
+	}
+	return entities.DocumentMetadata(metadata)
 }
 
-func (s *documentServiceImpl) DeleteDocument(documentID uint) error {
+// indexText never fails an upload, a document whose text cannot be indexed is
+// only missing from keyword search until it is reindexed.
+func (s *documentServiceImpl) indexText(documentID uint, uploadFile *helpers.UploadFile, mimeType string) {
+	file, err := uploadFile.Open()
+	if err == nil {
+		defer file.Close()
+		var data []byte
+		if data, err = io.ReadAll(file); err == nil {
+			err = indexDocumentText(s.textRepo, documentID, mimeType, data)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to index text of document %d: %v", documentID, err)
+	}
+}
+
+// storeBlob takes a reference on the blob for contentHash and uploads the file
+// unless an identical object is already stored.
+func (s *documentServiceImpl) storeBlob(uploadFile *helpers.UploadFile, contentHash string) (string, error) {
+	blob, err := s.blobRepo.Acquire(contentHash, s.storage.GetURL(contentHash), uploadFile.Size)
+	if err != nil {
+		return "", fmt.Errorf("failed to store blob: %v", err)
+	}
+
+	if blob.RefCount > 1 {
+		exists, err := s.storage.Exists(contentHash)
+		if err == nil && exists {
+			return blob.S3URL, nil
+		}
+	}
+
+	file, err := uploadFile.Open()
+	if err != nil {
+		s.releaseBlob(contentHash)
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := s.storage.Upload(contentHash, file); err != nil {
+		s.releaseBlob(contentHash)
+		return "", err
+	}
+
+	return blob.S3URL, nil
+}
+
+// releaseBlob drops a reference and deletes the stored object once no
+// document version uses it anymore.
+func (s *documentServiceImpl) releaseBlob(contentHash string) {
+	err := s.blobRepo.Release(contentHash, func(blob *entities.Blob) error {
+		return s.storage.Delete(blob.S3URL)
+	})
+	if err != nil {
+		log.Printf("Failed to release blob %s: %v", contentHash, err)
+	}
+}
+
+// DeleteDocument moves a document to the trash. It leaves the RAG index right
+// away, the stored files are kept until the document is purged.
+func (s *documentServiceImpl) DeleteDocument(documentID uint, deletedBy *uint) error {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
 	document, err := s.GetById(documentID)
 	if err != nil {
 		return err
@@ -123,15 +948,160 @@ func (s *documentServiceImpl) DeleteDocument(documentID uint) error {
 		return fmt.Errorf("failed to remove document from RAG server: %v", err)
 	}
 
-	config := configs.GetEnv()
-	err = helpers.DeleteFromS3(config.AWS.S3.Bucket, document.S3URL)
+	if err := s.repo.SoftDelete(documentID, deletedBy); err != nil {
+		s.restoreIndex(document)
+		return err
+	}
+	return nil
+}
+
+func (s *documentServiceImpl) GetDeletedDocuments(spaceID *uint, deletedBy *uint) ([]entities.Document, error) {
+	return s.repo.GetDeleted(spaceID, deletedBy)
+}
+
+// RestoreDocument takes a document out of the trash and indexes it again. The
+// space has to accept it like a new upload: within its limits and without an
+// identical document having been added in the meantime.
+func (s *documentServiceImpl) RestoreDocument(documentID uint) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	document, err := s.repo.GetDeletedById(documentID)
 	if err != nil {
-		return fmt.Errorf("failed to delete file from S3: %v", err)
+		return nil, fmt.Errorf("document not found in trash: %v", err)
 	}
 
-	return s.repo.Delete(documentID)
+	if document.ContentHash != "" {
+		existing, err := s.repo.GetBySpaceAndContentHash(document.SpaceID, document.ContentHash)
+		if err == nil {
+			return nil, &DuplicateDocumentError{DocumentID: existing.ID}
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if err := s.CheckDocumentLimits(document.SpaceID, document.Size); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Restore(documentID); err != nil {
+		return nil, err
+	}
+	document.DeletedAt = gorm.DeletedAt{}
+	document.DeletedBy = nil
+
+	if err := s.ReindexDocument(document); err != nil {
+		if err := s.repo.SoftDelete(documentID, nil); err != nil {
+			log.Printf("Failed to move document %d back to trash: %v", documentID, err)
+		}
+		return nil, fmt.Errorf("failed to index restored document: %v", err)
+	}
+
+	return document, nil
+}
+
+// PurgeDocument deletes a document with its versions and releases the stored
+// files without touching the RAG server, e.g. for documents in the trash or
+// when a whole space is removed. Files are released after the rows are gone,
+// so a failure can only leave an orphaned object behind, never a version
+// pointing at a missing file.
+func (s *documentServiceImpl) PurgeDocument(documentID uint) error {
+	document, err := s.GetById(documentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		document, err = s.repo.GetDeletedById(documentID)
+	}
+	if err != nil {
+		return err
+	}
+
+	versions, err := s.versionRepo.GetByDocumentID(documentID)
+	if err != nil {
+		return fmt.Errorf("failed to get document versions: %v", err)
+	}
+
+	if err := s.repo.Purge(documentID); err != nil {
+		return err
+	}
+
+	// Files uploaded before deduplication have no hash and belong to this document only
+	legacyURLs := []string{}
+	for _, version := range versions {
+		if version.ContentHash != "" {
+			s.releaseBlob(version.ContentHash)
+		} else if !slices.Contains(legacyURLs, version.S3URL) {
+			legacyURLs = append(legacyURLs, version.S3URL)
+		}
+	}
+	if document.ContentHash == "" && !slices.Contains(legacyURLs, document.S3URL) {
+		legacyURLs = append(legacyURLs, document.S3URL)
+	}
+
+	for _, s3URL := range legacyURLs {
+		if err := s.storage.Delete(s3URL); err != nil {
+			log.Printf("Failed to delete file %s of document %d: %v", s3URL, documentID, err)
+		}
+	}
+
+	return nil
 }
 
 func (s *documentServiceImpl) CountUserDocuments(userID uint) (int64, error) {
 	return s.repo.CountUserDocuments(userID)
 }
+
+func (s *documentServiceImpl) GetDocumentContent(document *entities.Document) (io.ReadCloser, error) {
+	return s.storage.Get(document.S3URL)
+}
+
+func (s *documentServiceImpl) GetPresignedURL(document *entities.Document, inline bool) (string, error) {
+	config := configs.GetEnv()
+
+	expiry := defaultPresignExpiry
+	if config.AWS.S3.PresignExpirySeconds > 0 {
+		expiry = time.Duration(config.AWS.S3.PresignExpirySeconds) * time.Second
+	}
+
+	return s.storage.GetPresignedURL(
+		document.S3URL,
+		expiry,
+		GetDocumentContentType(document),
+		GetDocumentContentDisposition(document, inline),
+	)
+}
+
+func GetDocumentContentType(document *entities.Document) string {
+	if document.MimeType == "" {
+		return "application/octet-stream"
+	}
+	return document.MimeType
+}
+
+// inlineMimeTypes are the types browsers render without running scripts of
+// the file. Anything else, HTML and SVG included, is always downloaded.
+var inlineMimeTypes = []string{
+	"application/pdf",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"text/plain",
+}
+
+func canPreviewInline(document *entities.Document) bool {
+	mediaType, _, err := mime.ParseMediaType(document.MimeType)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(inlineMimeTypes, mediaType)
+}
+
+// GetDocumentContentDisposition only honors inline for the types in
+// inlineMimeTypes.
+func GetDocumentContentDisposition(document *entities.Document, inline bool) string {
+	disposition := "attachment"
+	if inline && canPreviewInline(document) {
+		disposition = "inline"
+	}
+	return mime.FormatMediaType(disposition, map[string]string{"filename": document.Name})
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+)
+
+type DocumentFolderService interface {
+	ICrudService[entities.DocumentFolder, uint]
+	GetFoldersBySpaceID(spaceID uint) ([]entities.DocumentFolder, error)
+	CreateFolder(spaceID uint, parentID *uint, name string) (*entities.DocumentFolder, error)
+	RenameFolder(folderID uint, name string) (*entities.DocumentFolder, error)
+	MoveFolder(folderID uint, parentID *uint) (*entities.DocumentFolder, error)
+	DeleteFolder(folderID uint, recursive bool, deletedBy uint) error
+}
+
+type documentFolderServiceImpl struct {
+	CrudService[entities.DocumentFolder, uint]
+	repo            repositories.DocumentFolderRepository
+	documentRepo    repositories.DocumentRepository
+	documentService DocumentService
+}
+
+func NewDocumentFolderService(
+	repo repositories.DocumentFolderRepository,
+	documentRepo repositories.DocumentRepository,
+	documentService DocumentService,
+) DocumentFolderService {
+	return &documentFolderServiceImpl{
+		CrudService:     *NewCrudService[entities.DocumentFolder, uint](repo),
+		repo:            repo,
+		documentRepo:    documentRepo,
+		documentService: documentService,
+	}
+}
+
+func (s *documentFolderServiceImpl) GetFoldersBySpaceID(spaceID uint) ([]entities.DocumentFolder, error) {
+	return s.repo.GetBySpaceID(spaceID)
+}
+
+func (s *documentFolderServiceImpl) CreateFolder(spaceID uint, parentID *uint, name string) (*entities.DocumentFolder, error) {
+	name, err := s.validateName(spaceID, parentID, name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if _, err := s.getFolderInSpace(*parentID, spaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.Create(&entities.DocumentFolder{
+		SpaceID:  spaceID,
+		ParentID: parentID,
+		Name:     name,
+	})
+}
+
+func (s *documentFolderServiceImpl) RenameFolder(folderID uint, name string) (*entities.DocumentFolder, error) {
+	folder, err := s.repo.GetById(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	folder.Name, err = s.validateName(folder.SpaceID, folder.ParentID, name, folder.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err = s.repo.Update(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	s.syncFolderDocuments(folder.ID)
+	return folder, nil
+}
+
+func (s *documentFolderServiceImpl) MoveFolder(folderID uint, parentID *uint) (*entities.DocumentFolder, error) {
+	folder, err := s.repo.GetById(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if _, err := s.getFolderInSpace(*parentID, folder.SpaceID); err != nil {
+			return nil, err
+		}
+
+		descendantIDs, err := s.repo.GetDescendantIDs(folder.ID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(descendantIDs, *parentID) {
+			return nil, errors.New("invalid parent: a folder cannot be moved into itself or its subfolders")
+		}
+	}
+
+	if _, err := s.validateName(folder.SpaceID, parentID, folder.Name, folder.ID); err != nil {
+		return nil, err
+	}
+
+	folder.ParentID = parentID
+	folder, err = s.repo.Update(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	s.syncFolderDocuments(folder.ID)
+	return folder, nil
+}
+
+// DeleteFolder removes an empty folder. With recursive set, every document in
+// the folder tree is moved to the trash first and restored at the top level.
+func (s *documentFolderServiceImpl) DeleteFolder(folderID uint, recursive bool, deletedBy uint) error {
+	folder, err := s.repo.GetById(folderID)
+	if err != nil {
+		return err
+	}
+
+	if !recursive {
+		count, err := s.repo.CountChildren(folder.ID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("folder is not empty")
+		}
+		return s.repo.Delete(folder.ID)
+	}
+
+	folderIDs, err := s.repo.GetDescendantIDs(folder.ID)
+	if err != nil {
+		return err
+	}
+
+	documents, err := s.documentRepo.GetByFilter(repositories.DocumentFilter{
+		SpaceID:   folder.SpaceID,
+		FolderIDs: folderIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, document := range documents {
+		if err := s.documentService.DeleteDocument(document.ID, &deletedBy); err != nil {
+			return fmt.Errorf("failed to delete document %d: %v", document.ID, err)
+		}
+	}
+
+	return s.repo.Delete(folder.ID)
+}
+
+func (s *documentFolderServiceImpl) validateName(spaceID uint, parentID *uint, name string, excludeID uint) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", errors.New("invalid folder name")
+	}
+	if len(name) > 255 {
+		return "", errors.New("invalid folder name: too long")
+	}
+
+	exists, err := s.repo.ExistsByName(spaceID, parentID, name, excludeID)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", errors.New("a folder with this name already exists")
+	}
+	return name, nil
+}
+
+func (s *documentFolderServiceImpl) getFolderInSpace(folderID uint, spaceID uint) (*entities.DocumentFolder, error) {
+	folder, err := s.repo.GetById(folderID)
+	if err != nil || folder.SpaceID != spaceID {
+		return nil, errors.New("folder not found in this space")
+	}
+	return folder, nil
+}
+
+// syncFolderDocuments refreshes the folder path of every document below the
+// folder in the RAG server after a rename or move.
+func (s *documentFolderServiceImpl) syncFolderDocuments(folderID uint) {
+	folder, err := s.repo.GetById(folderID)
+	if err != nil {
+		log.Printf("Failed to sync documents of folder %d: %v", folderID, err)
+		return
+	}
+
+	folderIDs, err := s.repo.GetDescendantIDs(folderID)
+	if err != nil {
+		log.Printf("Failed to sync documents of folder %d: %v", folderID, err)
+		return
+	}
+
+	documents, err := s.documentRepo.GetByFilter(repositories.DocumentFilter{
+		SpaceID:   folder.SpaceID,
+		FolderIDs: folderIDs,
+	})
+	if err != nil {
+		log.Printf("Failed to sync documents of folder %d: %v", folderID, err)
+		return
+	}
+
+	for i := range documents {
+		if err := s.documentService.SyncDocumentMetadata(&documents[i]); err != nil {
+			log.Printf("Failed to sync document %d: %v", documents[i].ID, err)
+		}
+	}
+}
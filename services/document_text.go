@@ -0,0 +1,184 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+)
+
+const (
+	documentTextChunkSize = 2000
+	searchSnippetRadius   = 120
+	keywordAnswerPassages = 3
+)
+
+type DocumentTextService interface {
+	SearchSpace(spaceID uint, role *entities.SpaceRole, query string, page int, pageSize int) (*helpers.PaginationResult, error)
+	GetDocumentText(documentID uint, query string, page int, pageSize int) (*helpers.PaginationResult, error)
+	KeywordAnswer(spaceID uint, question string, options ChatOptions) (string, error)
+}
+
+type documentTextServiceImpl struct {
+	repo         repositories.DocumentTextRepository
+	spaceRepo    repositories.SpaceRepository
+	folderRepo   repositories.DocumentFolderRepository
+	documentRepo repositories.DocumentRepository
+}
+
+func NewDocumentTextService(
+	repo repositories.DocumentTextRepository,
+	spaceRepo repositories.SpaceRepository,
+	folderRepo repositories.DocumentFolderRepository,
+	documentRepo repositories.DocumentRepository,
+) DocumentTextService {
+	return &documentTextServiceImpl{
+		repo:         repo,
+		spaceRepo:    spaceRepo,
+		folderRepo:   folderRepo,
+		documentRepo: documentRepo,
+	}
+}
+
+// SearchSpace finds the documents of a space whose text contains every word
+// of query, limited to what role can see.
+func (s *documentTextServiceImpl) SearchSpace(spaceID uint, role *entities.SpaceRole, query string, page int, pageSize int) (*helpers.PaginationResult, error) {
+	terms := helpers.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("invalid search query: no words to search for")
+	}
+
+	matches, total, err := s.repo.SearchSpace(spaceID, terms, entities.VisibleDocumentVisibilities(role), page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %v", err)
+	}
+
+	hits := make([]dtos.DocumentSearchHit, len(matches))
+	for i, match := range matches {
+		hits[i] = dtos.DocumentSearchHit{
+			DocumentID:   match.DocumentID,
+			DocumentName: match.DocumentName,
+			MimeType:     match.MimeType,
+			ChunkIndex:   match.ChunkIndex,
+			Snippet:      helpers.HighlightMatches(helpers.Snippet(match.Content, terms, searchSnippetRadius), terms),
+			Rank:         match.Rank,
+		}
+	}
+
+	result := helpers.CreatePaginationResult(hits, page, pageSize, total)
+	return &result, nil
+}
+
+// GetDocumentText pages through the extracted text of a document, with the
+// words of query highlighted.
+func (s *documentTextServiceImpl) GetDocumentText(documentID uint, query string, page int, pageSize int) (*helpers.PaginationResult, error) {
+	chunks, total, err := s.repo.GetPageByDocumentID(documentID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document text: %v", err)
+	}
+
+	terms := helpers.SearchTerms(query)
+	preview := make([]dtos.DocumentTextChunk, len(chunks))
+	for i, chunk := range chunks {
+		content := helpers.HighlightMatches(chunk.Content, terms)
+		preview[i] = dtos.DocumentTextChunk{
+			ChunkIndex: chunk.ChunkIndex,
+			Content:    content,
+			// The content is escaped, so every tag is a highlight
+			MatchCount: strings.Count(content, "<mark>"),
+		}
+	}
+
+	result := helpers.CreatePaginationResult(preview, page, pageSize, total)
+	return &result, nil
+}
+
+// KeywordAnswer is the degraded answer used while the RAG server is
+// unreachable: the passages sharing the most words with the question, from
+// the documents the chat may retrieve from for the user of options, within
+// the folder of options and its subfolders when one is given.
+func (s *documentTextServiceImpl) KeywordAnswer(spaceID uint, question string, options ChatOptions) (string, error) {
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get space: %v", err)
+	}
+
+	documentIDs, err := retrievableDocumentIDs(space, options.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	if options.FolderID != nil {
+		documentIDs, err = s.filterByFolder(spaceID, *options.FolderID, documentIDs)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	terms := helpers.SearchTerms(question)
+	matches, err := s.repo.SearchDocuments(documentIDs, terms, keywordAnswerPassages)
+	if err != nil {
+		return "", fmt.Errorf("failed to search documents: %v", err)
+	}
+
+	if len(matches) == 0 {
+		return "The assistant is temporarily unavailable and no document in this space matches the words of your question.", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("The assistant is temporarily unavailable. These passages from your documents match the words of your question:\n")
+	for _, match := range matches {
+		sb.WriteString(fmt.Sprintf("\n- %s: %s", match.DocumentName, helpers.Snippet(match.Content, terms, searchSnippetRadius)))
+	}
+	return sb.String(), nil
+}
+
+// filterByFolder keeps the documents of documentIDs that are in the folder or
+// one of its subfolders.
+func (s *documentTextServiceImpl) filterByFolder(spaceID uint, folderID uint, documentIDs []uint) ([]uint, error) {
+	folder, err := s.folderRepo.GetById(folderID)
+	if err != nil || folder.SpaceID != spaceID {
+		return nil, fmt.Errorf("folder not found in this space")
+	}
+
+	folderIDs, err := s.folderRepo.GetDescendantIDs(folder.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subfolders: %v", err)
+	}
+
+	documents, err := s.documentRepo.GetByFilter(repositories.DocumentFilter{SpaceID: spaceID, FolderIDs: folderIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder documents: %v", err)
+	}
+
+	inFolder := make(map[uint]bool, len(documents))
+	for _, document := range documents {
+		inFolder[document.ID] = true
+	}
+
+	filtered := []uint{}
+	for _, id := range documentIDs {
+		if inFolder[id] {
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered, nil
+}
+
+// indexDocumentText stores the extracted text of a document, replacing the
+// text of any previous version. Files without extractable text clear the
+// index so that the text of an earlier version is no longer found.
+func indexDocumentText(repo repositories.DocumentTextRepository, documentID uint, mimeType string, data []byte) error {
+	text, extractErr := helpers.ExtractFileText(mimeType, data)
+
+	if err := repo.ReplaceForDocument(documentID, helpers.ChunkText(text, documentTextChunkSize)); err != nil {
+		return fmt.Errorf("failed to store document text: %v", err)
+	}
+	if extractErr != nil {
+		return fmt.Errorf("failed to extract document text: %v", extractErr)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"gorm.io/gorm"
+)
+
+// MoveDocumentToSpace moves a document with its version history to another
+// space. The stored files are kept as they are, only the index entry moves.
+func (s *documentServiceImpl) MoveDocumentToSpace(documentID uint, targetSpaceID uint, folderID *uint) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	document, err := s.GetById(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkTransfer(document, document.ContentHash, targetSpaceID, folderID); err != nil {
+		return nil, err
+	}
+
+	sourceSpaceID, sourceFolderID := document.SpaceID, document.FolderID
+	if err := s.ragServerService.RemoveDocument(document.ID, sourceSpaceID); err != nil {
+		return nil, fmt.Errorf("failed to remove document from RAG server: %v", err)
+	}
+
+	if err := s.repo.UpdateSpace(document.ID, targetSpaceID, folderID); err != nil {
+		s.restoreIndex(document)
+		return nil, err
+	}
+	document.SpaceID = targetSpaceID
+	document.FolderID = folderID
+
+	if err := s.ReindexDocument(document); err != nil {
+		// Put the document back so that it stays searchable where it was
+		document.SpaceID = sourceSpaceID
+		document.FolderID = sourceFolderID
+		if err := s.repo.UpdateSpace(document.ID, sourceSpaceID, sourceFolderID); err != nil {
+			log.Printf("Failed to move document %d back to space %d: %v", document.ID, sourceSpaceID, err)
+		}
+		s.restoreIndex(document)
+		return nil, fmt.Errorf("failed to index document in the target space: %v", err)
+	}
+
+	return document, nil
+}
+
+// CopyDocumentToSpace creates a new document in the target space from the
+// current version of a document. The copy shares the stored blob and starts
+// with a history of its own; URL refresh settings are not copied.
+func (s *documentServiceImpl) CopyDocumentToSpace(documentID uint, targetSpaceID uint, folderID *uint, userID uint) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	source, err := s.GetById(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.storage.Download(source.S3URL)
+	if err != nil {
+		return nil, err
+	}
+	uploadFile := helpers.NewUploadFileFromBytes(source.Name, data)
+
+	// Files uploaded before deduplication are hashed now and stored as a blob
+	contentHash := source.ContentHash
+	if contentHash == "" {
+		contentHash, err = uploadFile.ContentHash()
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash file: %v", err)
+		}
+	}
+
+	if err := s.checkTransfer(source, contentHash, targetSpaceID, folderID); err != nil {
+		return nil, err
+	}
+
+	s3URL, err := s.storeBlob(uploadFile, contentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &entities.Document{
+		SpaceID:       targetSpaceID,
+		Name:          source.Name,
+		Description:   source.Description,
+		MimeType:      source.MimeType,
+		Size:          source.Size,
+		S3URL:         s3URL,
+		ContentHash:   contentHash,
+		PrivacyStatus: source.PrivacyStatus,
+		FolderID:      folderID,
+		Tags:          source.Tags,
+		Metadata:      source.Metadata,
+		DocumentScan:  source.DocumentScan,
+		UploadedBy:    &userID,
+	}
+
+	version := &entities.DocumentVersion{
+		Name:         document.Name,
+		MimeType:     document.MimeType,
+		Size:         document.Size,
+		S3URL:        document.S3URL,
+		ContentHash:  document.ContentHash,
+		Metadata:     document.Metadata,
+		DocumentScan: document.DocumentScan,
+		UploadedBy:   &userID,
+	}
+
+	document, err = s.repo.CreateWithVersion(document, version)
+	if err != nil {
+		s.releaseBlob(contentHash)
+		return nil, err
+	}
+
+	metadata, err := s.ragMetadata(document)
+	if err == nil {
+		err = s.ragServerService.UploadDocument(uploadFile, document.SpaceID, document.ID, GetDocumentViewURL(document.ID), document.Description, metadata)
+	}
+	if err != nil {
+		s.repo.Purge(document.ID)
+		s.releaseBlob(contentHash)
+		return nil, err
+	}
+
+	if err := indexDocumentText(s.textRepo, document.ID, document.MimeType, data); err != nil {
+		log.Printf("Failed to index text of document %d: %v", document.ID, err)
+	}
+	return document, nil
+}
+
+// AddStoredDocument creates a document from a file that is already stored as
+// a blob, e.g. when a space is cloned. The limits of the target space apply
+// and the document is not indexed, a reindex job of the space picks it up.
+func (s *documentServiceImpl) AddStoredDocument(document *entities.Document) (*entities.Document, error) {
+	if document.ContentHash == "" {
+		return nil, errors.New("document is not stored as a blob")
+	}
+
+	if err := s.CheckDocumentLimits(document.SpaceID, document.Size); err != nil {
+		return nil, err
+	}
+	if err := s.checkAllowedType(document.SpaceID, document.Name); err != nil {
+		return nil, err
+	}
+	if err := s.validateFolder(document.SpaceID, document.FolderID); err != nil {
+		return nil, err
+	}
+
+	blob, err := s.blobRepo.Acquire(document.ContentHash, document.S3URL, document.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store blob: %v", err)
+	}
+	document.ID = 0
+	document.S3URL = blob.S3URL
+
+	version := &entities.DocumentVersion{
+		Name:         document.Name,
+		MimeType:     document.MimeType,
+		Size:         document.Size,
+		S3URL:        document.S3URL,
+		ContentHash:  document.ContentHash,
+		Metadata:     document.Metadata,
+		DocumentScan: document.DocumentScan,
+		UploadedBy:   document.UploadedBy,
+	}
+
+	document, err = s.repo.CreateWithVersion(document, version)
+	if err != nil {
+		s.releaseBlob(blob.ContentHash)
+		return nil, err
+	}
+	return document, nil
+}
+
+// checkTransfer applies the checks of a regular upload to the target space:
+// identical content, the document limit, the file size limit, the allowed
+// file types and the folder. The content itself was validated on upload.
+func (s *documentServiceImpl) checkTransfer(document *entities.Document, contentHash string, targetSpaceID uint, folderID *uint) error {
+	if document.SpaceID == targetSpaceID {
+		return errors.New("invalid target space: document is already in this space")
+	}
+
+	if contentHash != "" {
+		existing, err := s.repo.GetBySpaceAndContentHash(targetSpaceID, contentHash)
+		if err == nil {
+			return &DuplicateDocumentError{DocumentID: existing.ID}
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	if err := s.CheckDocumentLimits(targetSpaceID, document.Size); err != nil {
+		return err
+	}
+
+	if err := s.checkAllowedType(targetSpaceID, document.Name); err != nil {
+		return err
+	}
+
+	return s.validateFolder(targetSpaceID, folderID)
+}
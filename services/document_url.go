@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+)
+
+const (
+	defaultURLFetchTimeout     = 30 * time.Second
+	defaultURLMaxRedirects     = 5
+	defaultURLUserAgent        = "DUTGrad-Fetcher/1.0"
+	defaultURLRefreshCheckTime = 15 * time.Minute
+)
+
+var unsafeFilenamePattern = regexp.MustCompile(`[^\pL\pN._-]+`)
+
+func (s *documentServiceImpl) IngestFromURL(rawURL string, spaceID uint, uploaderID uint, options dtos.DocumentUploadOptions, refreshIntervalHours int) (*entities.Document, error) {
+	if refreshIntervalHours < 0 {
+		return nil, errors.New("invalid refresh interval: must not be negative")
+	}
+
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find space: %v", err)
+	}
+
+	fetched, err := helpers.FetchURL(rawURL, urlFetchLimits(space))
+	if err != nil {
+		return nil, err
+	}
+
+	uploadFile, mimeType, err := toUploadFile(fetched)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	document := &entities.Document{
+		SpaceID:              spaceID,
+		Description:          options.Description,
+		FolderID:             options.FolderID,
+		Tags:                 options.Tags,
+		PrivacyStatus:        options.Visibility,
+		SourceURL:            rawURL,
+		RefreshIntervalHours: refreshIntervalHours,
+		LastFetchedAt:        &now,
+	}
+	return s.createDocument(document, uploadFile, &uploaderID, mimeType, options.Replace)
+}
+
+// RefetchDocument downloads the source of a URL document again and records a
+// new version when the content changed. A nil uploaderID marks a scheduled refresh.
+func (s *documentServiceImpl) RefetchDocument(documentID uint, uploaderID *uint) (*entities.Document, error) {
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	document, err := s.GetById(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if document.SourceURL == "" {
+		return nil, errors.New("document was not imported from a URL")
+	}
+
+	space, err := s.spaceRepo.GetById(document.SpaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find space: %v", err)
+	}
+
+	fetched, err := helpers.FetchURL(document.SourceURL, urlFetchLimits(space))
+	if err != nil {
+		return nil, err
+	}
+
+	uploadFile, mimeType, err := toUploadFile(fetched)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := s.isContentChanged(document, uploadFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		document, err = s.replaceDocumentFile(document, uploadFile, uploaderID, mimeType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := s.repo.UpdateLastFetchedAt(document.ID, now); err != nil {
+		return nil, err
+	}
+	document.LastFetchedAt = &now
+
+	return document, nil
+}
+
+func (s *documentServiceImpl) isContentChanged(document *entities.Document, uploadFile *helpers.UploadFile) (bool, error) {
+	if document.Size != uploadFile.Size {
+		return true, nil
+	}
+
+	if document.ContentHash != "" {
+		contentHash, err := uploadFile.ContentHash()
+		if err != nil {
+			return false, err
+		}
+		return contentHash != document.ContentHash, nil
+	}
+
+	current, err := s.storage.Download(document.S3URL)
+	if err != nil {
+		return false, err
+	}
+
+	file, err := uploadFile.Open()
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var buffer bytes.Buffer
+	if _, err := buffer.ReadFrom(file); err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(current, buffer.Bytes()), nil
+}
+
+func (s *documentServiceImpl) RefreshURLDocuments() {
+	documents, err := s.repo.GetDueForRefresh(time.Now())
+	if err != nil {
+		log.Printf("Failed to list documents due for refresh: %v", err)
+		return
+	}
+
+	for _, document := range documents {
+		if _, err := s.RefetchDocument(document.ID, nil); err != nil {
+			log.Printf("Failed to refresh document %d from %s: %v", document.ID, document.SourceURL, err)
+		}
+	}
+}
+
+func (s *documentServiceImpl) StartURLRefreshRoutine(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultURLRefreshCheckTime
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.RefreshURLDocuments()
+		}
+	}()
+}
+
+func urlFetchLimits(space *entities.Space) helpers.FetchLimits {
+	config := configs.GetEnv().URLIngest
+
+	timeout := time.Duration(config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultURLFetchTimeout
+	}
+
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = defaultURLUserAgent
+	}
+
+	return helpers.FetchLimits{
+		// Same whole-kilobyte allowance as checkFileSizeLimit
+		MaxSize:      int64(space.FileSizeLimitKb+1)*1024 - 1,
+		Timeout:      timeout,
+		MaxRedirects: valueOrDefault(config.MaxRedirects, defaultURLMaxRedirects),
+		UserAgent:    userAgent,
+	}
+}
+
+// toUploadFile turns fetched content into the file handed to the normal upload
+// path. Web pages are stored as Markdown so the RAG server indexes clean text.
+func toUploadFile(fetched *helpers.FetchedContent) (*helpers.UploadFile, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(fetched.ContentType)
+
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		title, markdown, err := helpers.HTMLToMarkdown(fetched.Data, fetched.URL)
+		if err != nil {
+			return nil, "", err
+		}
+
+		name := strings.TrimSuffix(fetched.Filename, path.Ext(fetched.Filename))
+		if title != "" {
+			name = title
+		}
+		return helpers.NewUploadFileFromBytes(sanitizeFilename(name)+".md", []byte(markdown)), "text/markdown", nil
+	}
+
+	filename := sanitizeFilename(fetched.Filename)
+	if path.Ext(filename) == "" {
+		if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+			filename += extensions[0]
+		}
+	}
+
+	return helpers.NewUploadFileFromBytes(filename, fetched.Data), fetched.ContentType, nil
+}
+
+func sanitizeFilename(name string) string {
+	name = strings.Trim(unsafeFilenamePattern.ReplaceAllString(strings.TrimSpace(name), "_"), "_.")
+	if runes := []rune(name); len(runes) > 200 {
+		name = string(runes[:200])
+	}
+	if name == "" {
+		name = "document"
+	}
+	return name
+}
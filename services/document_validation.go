@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+)
+
+// UploadRejectedError lists every reason a file was refused.
+type UploadRejectedError struct {
+	Reasons []helpers.FileRejection
+}
+
+func (e *UploadRejectedError) Error() string {
+	messages := make([]string, len(e.Reasons))
+	for i, reason := range e.Reasons {
+		messages[i] = reason.Message
+	}
+	return "upload rejected: " + strings.Join(messages, "; ")
+}
+
+// validateUpload checks the content of a file before it is stored in a space
+// and scans it for malware. The returned scan is recorded with the version.
+func (s *documentServiceImpl) validateUpload(spaceID uint, uploadFile *helpers.UploadFile) (entities.DocumentScan, error) {
+	scan := entities.DocumentScan{ScanStatus: entities.DocumentScanNotScanned}
+
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return scan, fmt.Errorf("failed to find space: %v", err)
+	}
+
+	file, err := uploadFile.Open()
+	if err != nil {
+		return scan, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return scan, err
+	}
+
+	config := configs.GetEnv().UploadValidation
+	rejections := helpers.ValidateFileContent(uploadFile.Filename, data, helpers.FileValidationOptions{
+		AllowedTypes:         space.AllowedFileTypes,
+		RejectEncryptedPDFs:  config.RejectEncryptedPDFs,
+		RejectMacroDocuments: config.RejectMacroDocuments,
+	})
+	if len(rejections) > 0 {
+		return scan, &UploadRejectedError{Reasons: rejections}
+	}
+
+	result, err := s.scanner.Scan(bytes.NewReader(data))
+	if err != nil {
+		return scan, err
+	}
+	if result == nil {
+		return scan, nil
+	}
+
+	if !result.Clean {
+		return scan, &UploadRejectedError{Reasons: []helpers.FileRejection{{
+			Code:    helpers.RejectionMalware,
+			Message: fmt.Sprintf("malware detected: %s", result.Signature),
+		}}}
+	}
+
+	now := time.Now()
+	scan.ScanStatus = entities.DocumentScanClean
+	scan.ScanEngine = s.scanner.Name()
+	scan.ScannedAt = &now
+	return scan, nil
+}
+
+// checkAllowedType applies the allowlist of a space to a document that is
+// moved or copied there.
+func (s *documentServiceImpl) checkAllowedType(spaceID uint, filename string) error {
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return fmt.Errorf("failed to find space: %v", err)
+	}
+
+	if !helpers.IsFileTypeAllowed(space.AllowedFileTypes, filename) {
+		return &UploadRejectedError{Reasons: []helpers.FileRejection{{
+			Code:    helpers.RejectionTypeNotAllowed,
+			Message: fmt.Sprintf("files of type %q are not allowed in this space", helpers.FileExtension(filename)),
+		}}}
+	}
+	return nil
+}
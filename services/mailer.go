@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+)
+
+const defaultMailFrom = "no-reply@dutgrad.local"
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outbound emails such as space invitations.
+type Mailer interface {
+	Name() string
+	Send(mail *Mail) error
+}
+
+// NewMailer returns the mailer selected in the configuration, or a mailer
+// that only logs the messages when none is configured.
+func NewMailer() Mailer {
+	config := configs.GetEnv().Mail
+	from := config.From
+	if from == "" {
+		from = defaultMailFrom
+	}
+
+	switch config.Type {
+	case "smtp":
+		return NewSMTPMailer(config.SMTP.Host, config.SMTP.Port, config.SMTP.Username, config.SMTP.Password, from)
+	case "file":
+		return NewFileMailer(config.Directory, from)
+	}
+	return logMailer{from: from}
+}
+
+type logMailer struct {
+	from string
+}
+
+func (logMailer) Name() string {
+	return "log"
+}
+
+func (m logMailer) Send(mail *Mail) error {
+	log.Printf("Mail from %s to %s: %s\n%s", m.from, mail.To, mail.Subject, mail.Body)
+	return nil
+}
+
+type smtpMailer struct {
+	address string
+	auth    smtp.Auth
+	from    string
+}
+
+// NewSMTPMailer sends through an SMTP relay, authenticating with PLAIN when
+// a username is given.
+func NewSMTPMailer(host string, port int, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{
+		address: host + ":" + strconv.Itoa(port),
+		auth:    auth,
+		from:    from,
+	}
+}
+
+func (m *smtpMailer) Name() string {
+	return "smtp"
+}
+
+func (m *smtpMailer) Send(mail *Mail) error {
+	message := buildMailMessage(m.from, mail, time.Now())
+	if err := smtp.SendMail(m.address, m.auth, m.from, []string{mail.To}, message); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+type fileMailer struct {
+	directory string
+	from      string
+}
+
+// NewFileMailer writes every message as an .eml file into the directory, which
+// is handy during development.
+func NewFileMailer(directory, from string) Mailer {
+	if directory == "" {
+		directory = filepath.Join(os.TempDir(), "dutgrad-mail")
+	}
+	return &fileMailer{directory: directory, from: from}
+}
+
+func (m *fileMailer) Name() string {
+	return "file"
+}
+
+func (m *fileMailer) Send(mail *Mail) error {
+	if err := os.MkdirAll(m.directory, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), sanitizeMailFileName(mail.To))
+	if err := os.WriteFile(filepath.Join(m.directory, name), buildMailMessage(m.from, mail, now), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
+
+func buildMailMessage(from string, mail *Mail, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", stripHeaderBreaks(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", stripHeaderBreaks(mail.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", stripHeaderBreaks(mail.Subject)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+// stripHeaderBreaks keeps user supplied values from adding headers.
+func stripHeaderBreaks(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+func sanitizeMailFileName(address string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, address)
+}
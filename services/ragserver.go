@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -13,15 +14,42 @@ import (
 	"github.com/BlenDMinh/dutgrad-server/configs"
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
 	"github.com/BlenDMinh/dutgrad-server/helpers"
 )
 
+// ErrRAGServerUnavailable is returned by Chat when the RAG server cannot be
+// reached or fails on its side, as opposed to rejecting the request.
+var ErrRAGServerUnavailable = errors.New("RAG server is unavailable")
+
 type RAGServerService struct {
 	BaseURL           string
 	UploadDocumentURL string
 	ChatURL           string
 	RemoveDocURL      string
 	RemoveSpaceURL    string
+	UpdateDocMetaURL  string
+	ListDocumentsURL  string
+}
+
+// RAGDocumentMetadata is indexed alongside the document content so that
+// retrieval can be scoped to a folder or filtered by tags.
+type RAGDocumentMetadata struct {
+	FolderPath string
+	Tags       []string
+}
+
+// RAGIndexedDocument is a document the RAG server holds an index entry for.
+type RAGIndexedDocument struct {
+	DocID   uint `json:"docId"`
+	SpaceID uint `json:"spaceId"`
+}
+
+type ChatOptions struct {
+	FolderID *uint
+	// UserID is the member asking, nil for API key access which is limited to
+	// what viewers can see
+	UserID *uint
 }
 
 func NewRAGServerService() *RAGServerService {
@@ -32,17 +60,19 @@ func NewRAGServerService() *RAGServerService {
 		ChatURL:           config.RAGServer.ChatURL,
 		RemoveDocURL:      config.RAGServer.RemoveDocURL,
 		RemoveSpaceURL:    config.RAGServer.RemoveSpaceURL,
+		UpdateDocMetaURL:  config.RAGServer.UpdateDocMetaURL,
+		ListDocumentsURL:  config.RAGServer.ListDocumentsURL,
 	}
 }
 
-func (s *RAGServerService) UploadDocument(fileHeader *multipart.FileHeader, spaceID uint, docId uint, filePath string, desc string) error {
-	file, err := fileHeader.Open()
+func (s *RAGServerService) UploadDocument(uploadFile *helpers.UploadFile, spaceID uint, docId uint, filePath string, desc string, metadata RAGDocumentMetadata) error {
+	file, err := uploadFile.Open()
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	mimeType, err := helpers.GetMimeType(fileHeader)
+	mimeType, err := helpers.DetectMimeType(uploadFile.Filename, file)
 	if err != nil {
 		return err
 	}
@@ -51,7 +81,7 @@ func (s *RAGServerService) UploadDocument(fileHeader *multipart.FileHeader, spac
 	writer := multipart.NewWriter(body)
 
 	h := make(textproto.MIMEHeader)
-	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, fileHeader.Filename))
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, uploadFile.Filename))
 	h.Set("Content-Type", mimeType)
 
 	part, err := writer.CreatePart(h)
@@ -75,6 +105,16 @@ func (s *RAGServerService) UploadDocument(fileHeader *multipart.FileHeader, spac
 	if err = writer.WriteField("desc", desc); err != nil {
 		return err
 	}
+	if err = writer.WriteField("folderPath", metadata.FolderPath); err != nil {
+		return err
+	}
+	tags, err := json.Marshal(nonNilTags(metadata.Tags))
+	if err != nil {
+		return err
+	}
+	if err = writer.WriteField("tags", string(tags)); err != nil {
+		return err
+	}
 
 	if err = writer.Close(); err != nil {
 		return err
@@ -110,7 +150,7 @@ func (s *RAGServerService) UploadDocument(fileHeader *multipart.FileHeader, spac
 	return nil
 }
 
-func (s *RAGServerService) Chat(sessionID uint, spaceID uint, message string) (string, error) {
+func (s *RAGServerService) Chat(sessionID uint, spaceID uint, message string, options ChatOptions) (string, error) {
 	url := fmt.Sprintf("%s%s", s.BaseURL, s.ChatURL)
 
 	var space entities.Space
@@ -125,6 +165,25 @@ func (s *RAGServerService) Chat(sessionID uint, spaceID uint, message string) (s
 		"system_prompt": space.SystemPrompt,
 	}
 
+	documentIDs, err := retrievableDocumentIDs(&space, options.UserID)
+	if err != nil {
+		return "", err
+	}
+	reqBody["document_ids"] = documentIDs
+
+	if options.FolderID != nil {
+		folderRepo := repositories.NewDocumentFolderRepository()
+		folder, err := folderRepo.GetById(*options.FolderID)
+		if err != nil || folder.SpaceID != spaceID {
+			return "", fmt.Errorf("folder not found in this space")
+		}
+		folderPath, err := folderRepo.GetPath(folder.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get folder path: %v", err)
+		}
+		reqBody["folder_path"] = folderPath
+	}
+
 	body, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", err
@@ -146,10 +205,14 @@ func (s *RAGServerService) Chat(sessionID uint, spaceID uint, message string) (s
 	}
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %v", ErrRAGServerUnavailable, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("%w: status: %d, response: %s", ErrRAGServerUnavailable, resp.StatusCode, string(respBody))
+	}
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("failed to chat, status: %d, response: %s", resp.StatusCode, string(respBody))
@@ -251,3 +314,115 @@ func (s *RAGServerService) RemoveSpace(spaceID uint) error {
 
 	return nil
 }
+
+func (s *RAGServerService) UpdateDocumentMetadata(docId uint, spaceID uint, metadata RAGDocumentMetadata) error {
+	url := fmt.Sprintf("%s%s", s.BaseURL, s.UpdateDocMetaURL)
+
+	reqBody := map[string]interface{}{
+		"docId":      docId,
+		"spaceId":    spaceID,
+		"folderPath": metadata.FolderPath,
+		"tags":       nonNilTags(metadata.Tags),
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+
+	httpClient := &http.Client{
+		Transport: tr,
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update document metadata, status: %d, response: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// ListDocuments returns every document the RAG server has indexed. The
+// endpoint answers with {"documents": [{"docId": 1, "spaceId": 2}, ...]}.
+func (s *RAGServerService) ListDocuments() ([]RAGIndexedDocument, error) {
+	if s.ListDocumentsURL == "" {
+		return nil, fmt.Errorf("failed to list documents: rag_server.list_documents_url is not configured")
+	}
+
+	url := fmt.Sprintf("%s%s", s.BaseURL, s.ListDocumentsURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+
+	httpClient := &http.Client{
+		Transport: tr,
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list documents, status: %d, response: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response struct {
+		Documents []RAGIndexedDocument `json:"documents"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode document list: %v", err)
+	}
+
+	return response.Documents, nil
+}
+
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// retrievableDocumentIDs returns the documents of space the chat may answer
+// from for the given user, based on their role and the document visibility.
+// Members whose role does not allow chatting are rejected, visitors of a
+// public space get what viewers get, like on the document routes.
+func retrievableDocumentIDs(space *entities.Space, userID *uint) ([]uint, error) {
+	var role *entities.SpaceRole
+	if userID != nil {
+		var err error
+		role, err = repositories.NewSpaceRepository().GetUserRole(*userID, space.ID)
+		if err != nil && space.PrivacyStatus {
+			return nil, fmt.Errorf("user is not a member of this space")
+		}
+		if role != nil && !role.HasPermission(entities.SpacePermissionChat) {
+			return nil, fmt.Errorf("user is not allowed to chat in this space")
+		}
+	}
+
+	return repositories.NewDocumentRepository().GetIDsByVisibility(space.ID, entities.RetrievableDocumentVisibilities(role))
+}
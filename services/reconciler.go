@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+)
+
+// reconcileGracePeriod leaves recent objects and documents alone, they may
+// belong to an upload that is still in progress.
+const reconcileGracePeriod = time.Hour
+
+type ReconcilerService interface {
+	// Reconcile compares S3, the database and the RAG index. With repair set,
+	// orphans are deleted and missing index entries are indexed again; missing
+	// objects are only reported.
+	Reconcile(repair bool) (*dtos.ConsistencyReport, error)
+}
+
+type reconcilerServiceImpl struct {
+	documentRepo     repositories.DocumentRepository
+	versionRepo      repositories.DocumentVersionRepository
+	blobRepo         repositories.BlobRepository
+	uploadRepo       repositories.ResumableUploadRepository
+	documentService  DocumentService
+	ragServerService *RAGServerService
+	storage          ObjectStorage
+}
+
+func NewReconcilerService(
+	documentRepo repositories.DocumentRepository,
+	versionRepo repositories.DocumentVersionRepository,
+	blobRepo repositories.BlobRepository,
+	uploadRepo repositories.ResumableUploadRepository,
+	documentService DocumentService,
+	ragServerService *RAGServerService,
+	storage ObjectStorage,
+) ReconcilerService {
+	return &reconcilerServiceImpl{
+		documentRepo:     documentRepo,
+		versionRepo:      versionRepo,
+		blobRepo:         blobRepo,
+		uploadRepo:       uploadRepo,
+		documentService:  documentService,
+		ragServerService: ragServerService,
+		storage:          storage,
+	}
+}
+
+func (s *reconcilerServiceImpl) Reconcile(repair bool) (*dtos.ConsistencyReport, error) {
+	report := &dtos.ConsistencyReport{
+		Repair:               repair,
+		StartedAt:            time.Now(),
+		OrphanedObjects:      []dtos.ConsistencyIssue{},
+		MissingObjects:       []dtos.ConsistencyIssue{},
+		OrphanedIndexEntries: []dtos.ConsistencyIssue{},
+		MissingIndexEntries:  []dtos.ConsistencyIssue{},
+	}
+	cutoff := report.StartedAt.Add(-reconcileGracePeriod)
+
+	if err := s.reconcileObjects(report, cutoff); err != nil {
+		return nil, err
+	}
+	if err := s.reconcileIndex(report, cutoff); err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func (s *reconcilerServiceImpl) reconcileObjects(report *dtos.ConsistencyReport, cutoff time.Time) error {
+	objects, err := s.storage.List()
+	if err != nil {
+		return err
+	}
+	report.CheckedObjects = len(objects)
+
+	stored := map[string]bool{}
+	for _, object := range objects {
+		stored[object.Key] = true
+	}
+
+	versions, err := s.versionRepo.GetAllFileReferences()
+	if err != nil {
+		return fmt.Errorf("failed to get document versions: %v", err)
+	}
+	blobURLs, err := s.blobRepo.GetAllS3URLs()
+	if err != nil {
+		return fmt.Errorf("failed to get blobs: %v", err)
+	}
+	uploadIDs, err := s.uploadRepo.GetAllIDs()
+	if err != nil {
+		return fmt.Errorf("failed to get uploads: %v", err)
+	}
+
+	referenced := map[string]bool{}
+	for _, version := range versions {
+		key := helpers.GetS3Key(version.S3URL)
+		referenced[key] = true
+		if !stored[key] {
+			report.MissingObjects = append(report.MissingObjects, dtos.ConsistencyIssue{
+				Key:        key,
+				DocumentID: version.DocumentID,
+				Version:    version.Version,
+			})
+		}
+	}
+	for _, url := range blobURLs {
+		referenced[helpers.GetS3Key(url)] = true
+	}
+	uploads := map[uint]bool{}
+	for _, id := range uploadIDs {
+		uploads[id] = true
+	}
+
+	for _, object := range objects {
+		if referenced[object.Key] || object.LastModified.After(cutoff) {
+			continue
+		}
+
+		var uploadID uint
+		var index int
+		if _, err := fmt.Sscanf(object.Key, "upload-%d-chunk-%d", &uploadID, &index); err == nil && uploads[uploadID] {
+			continue
+		}
+
+		issue := dtos.ConsistencyIssue{Key: object.Key}
+		if report.Repair {
+			if err := s.storage.Delete(object.Key); err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Repaired = true
+			}
+		}
+		report.OrphanedObjects = append(report.OrphanedObjects, issue)
+	}
+
+	return nil
+}
+
+func (s *reconcilerServiceImpl) reconcileIndex(report *dtos.ConsistencyReport, cutoff time.Time) error {
+	indexed, err := s.ragServerService.ListDocuments()
+	if err != nil {
+		return err
+	}
+	report.CheckedIndexEntries = len(indexed)
+
+	documents, err := s.documentRepo.GetIndexable()
+	if err != nil {
+		return fmt.Errorf("failed to get documents: %v", err)
+	}
+	report.CheckedDocuments = len(documents)
+
+	// A document moved to another space is orphaned under its old space and
+	// missing under the new one
+	type entry struct{ documentID, spaceID uint }
+	expected := map[entry]bool{}
+	for _, document := range documents {
+		expected[entry{document.ID, document.SpaceID}] = true
+	}
+	present := map[entry]bool{}
+	for _, document := range indexed {
+		present[entry{document.DocID, document.SpaceID}] = true
+	}
+
+	for _, document := range indexed {
+		if expected[entry{document.DocID, document.SpaceID}] {
+			continue
+		}
+
+		issue := dtos.ConsistencyIssue{DocumentID: document.DocID, SpaceID: document.SpaceID}
+		if report.Repair {
+			if err := s.ragServerService.RemoveDocument(document.DocID, document.SpaceID); err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Repaired = true
+			}
+		}
+		report.OrphanedIndexEntries = append(report.OrphanedIndexEntries, issue)
+	}
+
+	for i := range documents {
+		document := &documents[i]
+		if present[entry{document.ID, document.SpaceID}] || document.UpdatedAt.After(cutoff) {
+			continue
+		}
+
+		issue := dtos.ConsistencyIssue{DocumentID: document.ID, SpaceID: document.SpaceID}
+		if report.Repair {
+			if err := s.documentService.ReindexDocument(document); err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Repaired = true
+			}
+		}
+		report.MissingIndexEntries = append(report.MissingIndexEntries, issue)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"gorm.io/gorm"
+)
+
+type ReindexService interface {
+	ICrudService[entities.ReindexJob, uint]
+	CreateJob(spaceID *uint, documentID *uint, createdBy *uint) (*entities.ReindexJob, error)
+	StartJob(spaceID *uint, documentID *uint, createdBy *uint) (*entities.ReindexJob, error)
+	RunJob(jobID uint, progress func(job *entities.ReindexJob)) (*entities.ReindexJob, error)
+	FailInterruptedJobs()
+}
+
+type reindexServiceImpl struct {
+	CrudService[entities.ReindexJob, uint]
+	repo            repositories.ReindexJobRepository
+	documentRepo    repositories.DocumentRepository
+	documentService DocumentService
+}
+
+func NewReindexService(
+	repo repositories.ReindexJobRepository,
+	documentRepo repositories.DocumentRepository,
+	documentService DocumentService,
+) ReindexService {
+	return &reindexServiceImpl{
+		CrudService:     *NewCrudService[entities.ReindexJob, uint](repo),
+		repo:            repo,
+		documentRepo:    documentRepo,
+		documentService: documentService,
+	}
+}
+
+// CreateJob records a pending job. Only one job per scope can be active, a
+// second request for the same scope fails.
+func (s *reindexServiceImpl) CreateJob(spaceID *uint, documentID *uint, createdBy *uint) (*entities.ReindexJob, error) {
+	active, err := s.repo.GetActive(spaceID, documentID)
+	if err == nil {
+		return nil, fmt.Errorf("reindex already in progress: job %d", active.ID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return s.repo.Create(&entities.ReindexJob{
+		SpaceID:    spaceID,
+		DocumentID: documentID,
+		Status:     entities.ReindexJobPending,
+		CreatedBy:  createdBy,
+	})
+}
+
+// StartJob creates a job and runs it in the background. Its progress can be
+// followed through the stored job.
+func (s *reindexServiceImpl) StartJob(spaceID *uint, documentID *uint, createdBy *uint) (*entities.ReindexJob, error) {
+	job, err := s.CreateJob(spaceID, documentID, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if _, err := s.RunJob(job.ID, nil); err != nil {
+			log.Printf("Reindex job %d failed: %v", job.ID, err)
+		}
+	}()
+
+	return job, nil
+}
+
+// RunJob sends every document of the job to the RAG server one after another.
+// A document that fails is counted and skipped; progress is stored after each
+// document and reported to the optional callback.
+func (s *reindexServiceImpl) RunJob(jobID uint, progress func(job *entities.ReindexJob)) (*entities.ReindexJob, error) {
+	job, err := s.repo.GetById(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var documentIDs []uint
+	if job.DocumentID != nil {
+		documentIDs = []uint{*job.DocumentID}
+	} else {
+		documentIDs, err = s.documentRepo.GetIDs(job.SpaceID)
+		if err != nil {
+			s.finishJob(job, fmt.Sprintf("failed to list documents: %v", err))
+			return job, err
+		}
+	}
+
+	now := time.Now()
+	job.Status = entities.ReindexJobRunning
+	job.Total = len(documentIDs)
+	job.StartedAt = &now
+	err = s.repo.UpdateFields(job.ID, map[string]interface{}{
+		"status":     job.Status,
+		"total":      job.Total,
+		"started_at": job.StartedAt,
+	})
+	if err != nil {
+		return job, err
+	}
+
+	for _, documentID := range documentIDs {
+		if err := s.reindexDocument(documentID); err != nil {
+			job.Failed++
+			job.LastError = fmt.Sprintf("document %d: %v", documentID, err)
+			log.Printf("Reindex job %d: failed to reindex document %d: %v", job.ID, documentID, err)
+		}
+		job.Processed++
+
+		err := s.repo.UpdateFields(job.ID, map[string]interface{}{
+			"processed":  job.Processed,
+			"failed":     job.Failed,
+			"last_error": job.LastError,
+		})
+		if err != nil {
+			log.Printf("Reindex job %d: failed to store progress: %v", job.ID, err)
+		}
+
+		if progress != nil {
+			progress(job)
+		}
+	}
+
+	s.finishJob(job, job.LastError)
+	return job, nil
+}
+
+func (s *reindexServiceImpl) reindexDocument(documentID uint) error {
+	document, err := s.documentService.GetById(documentID)
+	if err != nil {
+		// Deleted while the job was running
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	unlock := lockDocument(documentID)
+	defer unlock()
+
+	return s.documentService.ReindexDocument(document)
+}
+
+// finishJob marks the job completed, or failed when not a single document
+// could be indexed.
+func (s *reindexServiceImpl) finishJob(job *entities.ReindexJob, lastError string) {
+	now := time.Now()
+	job.Status = entities.ReindexJobCompleted
+	if lastError != "" && job.Failed == job.Total {
+		job.Status = entities.ReindexJobFailed
+	}
+	job.LastError = lastError
+	job.FinishedAt = &now
+
+	err := s.repo.UpdateFields(job.ID, map[string]interface{}{
+		"status":      job.Status,
+		"last_error":  job.LastError,
+		"finished_at": job.FinishedAt,
+	})
+	if err != nil {
+		log.Printf("Reindex job %d: failed to store result: %v", job.ID, err)
+	}
+}
+
+// FailInterruptedJobs runs at startup: jobs only live in the process that
+// started them, so active jobs left in the database will never finish.
+func (s *reindexServiceImpl) FailInterruptedJobs() {
+	count, err := s.repo.FailActiveJobs("interrupted by server restart")
+	if err != nil {
+		log.Printf("Failed to clean up interrupted reindex jobs: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Marked %d interrupted reindex jobs as failed", count)
+	}
+}
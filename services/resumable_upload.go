@@ -0,0 +1,350 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUploadExpiry          = 24 * time.Hour
+	defaultUploadMaxChunkSizeMb  = 16
+	defaultUploadCleanupInterval = 30 * time.Minute
+)
+
+var uploadLocks sync.Map
+
+type ResumableUploadService interface {
+	ICrudService[entities.ResumableUpload, uint]
+	CreateUpload(spaceID uint, userID uint, length int64, filename string, mimeType string, options dtos.DocumentUploadOptions) (*entities.ResumableUpload, error)
+	WriteChunk(uploadID uint, offset int64, body io.Reader) (*entities.ResumableUpload, *entities.Document, error)
+	TerminateUpload(uploadID uint) error
+	CleanupExpiredUploads()
+	StartCleanupRoutine(interval time.Duration)
+}
+
+type resumableUploadServiceImpl struct {
+	CrudService[entities.ResumableUpload, uint]
+	repo            repositories.ResumableUploadRepository
+	folderRepo      repositories.DocumentFolderRepository
+	spaceRepo       repositories.SpaceRepository
+	documentService DocumentService
+	userService     UserService
+	storage         ObjectStorage
+}
+
+func NewResumableUploadService(
+	repo repositories.ResumableUploadRepository,
+	folderRepo repositories.DocumentFolderRepository,
+	spaceRepo repositories.SpaceRepository,
+	documentService DocumentService,
+	userService UserService,
+	storage ObjectStorage,
+) ResumableUploadService {
+	return &resumableUploadServiceImpl{
+		CrudService:     *NewCrudService[entities.ResumableUpload, uint](repo),
+		repo:            repo,
+		folderRepo:      folderRepo,
+		spaceRepo:       spaceRepo,
+		documentService: documentService,
+		userService:     userService,
+		storage:         storage,
+	}
+}
+
+// lockUpload serializes chunk writes of a single upload so that chunks are
+// numbered and stored in the order of their offsets.
+func lockUpload(uploadID uint) func() {
+	value, _ := uploadLocks.LoadOrStore(uploadID, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+func (s *resumableUploadServiceImpl) CreateUpload(spaceID uint, userID uint, length int64, filename string, mimeType string, options dtos.DocumentUploadOptions) (*entities.ResumableUpload, error) {
+	if filename == "" {
+		return nil, errors.New("invalid upload metadata: filename is required")
+	}
+	if length <= 0 {
+		return nil, errors.New("invalid upload length")
+	}
+
+	// Limits are checked up front so that users do not send a file that
+	// would be rejected once it is complete
+	if err := s.documentService.CheckDocumentLimits(spaceID, length); err != nil {
+		return nil, err
+	}
+	if err := s.checkTierFileSizeLimit(userID, length); err != nil {
+		return nil, err
+	}
+
+	if options.FolderID != nil {
+		folder, err := s.folderRepo.GetById(*options.FolderID)
+		if err != nil || folder.SpaceID != spaceID {
+			return nil, errors.New("folder not found in this space")
+		}
+	}
+
+	tags, err := normalizeTags(options.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Visibility != "" && !options.Visibility.IsValid() {
+		return nil, fmt.Errorf("invalid visibility: %q", options.Visibility)
+	}
+
+	return s.repo.Create(&entities.ResumableUpload{
+		SpaceID:     spaceID,
+		UserID:      userID,
+		Filename:    filename,
+		MimeType:    mimeType,
+		Description: options.Description,
+		FolderID:    options.FolderID,
+		Tags:        tags,
+		Replace:     options.Replace,
+		Visibility:  options.Visibility,
+		Length:      length,
+		ExpiresAt:   time.Now().Add(uploadExpiry()),
+	})
+}
+
+func (s *resumableUploadServiceImpl) checkTierFileSizeLimit(userID uint, fileSize int64) error {
+	tier, err := s.userService.GetUserTier(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user tier: %v", err)
+	}
+
+	if tier != nil && tier.FileSizeLimitKb > 0 && fileSize/1024 > int64(tier.FileSizeLimitKb) {
+		return fmt.Errorf("file size exceeds the limit of %d KB for your tier", tier.FileSizeLimitKb)
+	}
+	return nil
+}
+
+// WriteChunk stores the bytes of body that start at offset. Whatever arrived
+// before a dropped connection is kept, so the client can resume from the
+// returned offset. The upload becomes a document once the last byte is stored.
+func (s *resumableUploadServiceImpl) WriteChunk(uploadID uint, offset int64, body io.Reader) (*entities.ResumableUpload, *entities.Document, error) {
+	unlock := lockUpload(uploadID)
+	defer unlock()
+
+	upload, err := s.repo.GetById(uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if upload.DocumentID != nil {
+		// A retry of the final chunk whose response got lost
+		if offset == upload.Length {
+			return upload, nil, nil
+		}
+		return upload, nil, errors.New("upload is already complete")
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return upload, nil, errors.New("upload has expired")
+	}
+	if offset != upload.Offset {
+		return upload, nil, fmt.Errorf("offset mismatch: upload is at offset %d", upload.Offset)
+	}
+
+	remaining := upload.Length - upload.Offset
+	limit := min(remaining, uploadMaxChunkSize())
+
+	data, readErr := io.ReadAll(io.LimitReader(body, limit+1))
+	if int64(len(data)) > limit {
+		if limit == remaining {
+			return upload, nil, errors.New("upload exceeds the declared length")
+		}
+		// Larger requests are cut at the chunk size, the client continues
+		// from the returned offset
+		data = data[:limit]
+	}
+
+	if len(data) > 0 {
+		if err := s.storeUploadChunk(upload, data); err != nil {
+			return upload, nil, err
+		}
+
+		expiresAt := time.Now().Add(uploadExpiry())
+		if err := s.repo.AdvanceOffset(upload.ID, upload.Offset, int64(len(data)), expiresAt); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return upload, nil, errors.New("offset mismatch: upload was modified concurrently")
+			}
+			return upload, nil, err
+		}
+
+		upload.Offset += int64(len(data))
+		upload.ChunkCount++
+		upload.ExpiresAt = expiresAt
+	}
+
+	if readErr != nil {
+		log.Printf("Upload %d interrupted at offset %d: %v", upload.ID, upload.Offset, readErr)
+		return upload, nil, nil
+	}
+
+	if upload.Offset < upload.Length {
+		return upload, nil, nil
+	}
+
+	document, err := s.completeUpload(upload)
+	if err != nil {
+		return upload, nil, err
+	}
+	return upload, document, nil
+}
+
+// completeUpload joins the chunks and hands the file to the regular upload
+// flow. On failure the chunks are kept, so sending an empty chunk at the final
+// offset retries the completion. The role of the uploader is checked again, it
+// may have changed since the upload was created.
+func (s *resumableUploadServiceImpl) completeUpload(upload *entities.ResumableUpload) (*entities.Document, error) {
+	role, err := s.spaceRepo.GetUserRole(upload.UserID, upload.SpaceID)
+	if err != nil || role == nil || !role.HasPermission(entities.SpacePermissionUploadDocuments) {
+		return nil, errors.New("not allowed to upload documents to this space")
+	}
+
+	tempFile, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	var written int64
+	for index := 0; index < upload.ChunkCount; index++ {
+		chunk, err := s.storage.Get(s.storage.GetURL(uploadChunkKey(upload.ID, index)))
+		if err != nil {
+			return nil, err
+		}
+		n, err := io.Copy(tempFile, chunk)
+		chunk.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to assemble upload: %v", err)
+		}
+		written += n
+	}
+
+	if written != upload.Length {
+		return nil, fmt.Errorf("failed to assemble upload: expected %d bytes, got %d", upload.Length, written)
+	}
+
+	uploadFile, err := helpers.NewUploadFileFromPath(upload.Filename, tempFile.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	options := dtos.DocumentUploadOptions{
+		Description: upload.Description,
+		FolderID:    upload.FolderID,
+		Tags:        upload.Tags,
+		Replace:     upload.Replace,
+		Visibility:  upload.Visibility,
+	}
+
+	document, err := s.documentService.UploadDocumentFile(uploadFile, upload.SpaceID, upload.UserID, upload.MimeType, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetDocumentID(upload.ID, document.ID); err != nil {
+		log.Printf("Failed to mark upload %d as complete: %v", upload.ID, err)
+	}
+	upload.DocumentID = &document.ID
+
+	s.deleteUploadChunks(upload)
+	return document, nil
+}
+
+func (s *resumableUploadServiceImpl) TerminateUpload(uploadID uint) error {
+	unlock := lockUpload(uploadID)
+	defer unlock()
+
+	upload, err := s.repo.GetById(uploadID)
+	if err != nil {
+		return err
+	}
+
+	s.deleteUploadChunks(upload)
+	return s.repo.Delete(upload.ID)
+}
+
+// CleanupExpiredUploads removes uploads that were not resumed in time along
+// with their stored chunks. Completed uploads are kept until they expire so
+// that clients can still look up the created document.
+func (s *resumableUploadServiceImpl) CleanupExpiredUploads() {
+	uploads, err := s.repo.GetExpired(time.Now())
+	if err != nil {
+		log.Printf("Failed to get expired uploads: %v", err)
+		return
+	}
+
+	for _, upload := range uploads {
+		if err := s.TerminateUpload(upload.ID); err != nil {
+			log.Printf("Failed to remove expired upload %d: %v", upload.ID, err)
+		}
+		uploadLocks.Delete(upload.ID)
+	}
+}
+
+func (s *resumableUploadServiceImpl) StartCleanupRoutine(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultUploadCleanupInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.CleanupExpiredUploads()
+		}
+	}()
+}
+
+func uploadChunkKey(uploadID uint, index int) string {
+	return fmt.Sprintf("upload-%d-chunk-%06d", uploadID, index)
+}
+
+func (s *resumableUploadServiceImpl) storeUploadChunk(upload *entities.ResumableUpload, data []byte) error {
+	file, err := helpers.NewUploadFileFromBytes(upload.Filename, data).Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = s.storage.Upload(uploadChunkKey(upload.ID, upload.ChunkCount), file)
+	return err
+}
+
+func (s *resumableUploadServiceImpl) deleteUploadChunks(upload *entities.ResumableUpload) {
+	for index := 0; index < upload.ChunkCount; index++ {
+		if err := s.storage.Delete(s.storage.GetURL(uploadChunkKey(upload.ID, index))); err != nil {
+			log.Printf("Failed to delete chunk %d of upload %d: %v", index, upload.ID, err)
+		}
+	}
+}
+
+func uploadExpiry() time.Duration {
+	hours := configs.GetEnv().ResumableUpload.ExpiryHours
+	if hours <= 0 {
+		return defaultUploadExpiry
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func uploadMaxChunkSize() int64 {
+	sizeMb := valueOrDefault(configs.GetEnv().ResumableUpload.MaxChunkSizeMb, defaultUploadMaxChunkSizeMb)
+	return int64(sizeMb) * 1024 * 1024
+}
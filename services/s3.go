@@ -1,7 +1,9 @@
 package services
 
 import (
+	"io"
 	"mime/multipart"
+	"time"
 
 	"github.com/BlenDMinh/dutgrad-server/configs"
 	"github.com/BlenDMinh/dutgrad-server/helpers"
@@ -14,3 +16,59 @@ func UploadFileToS3(filename string, file multipart.File) (string, error) {
 
 	return helpers.UploadToS3(config.AWS.S3.Bucket, key, file)
 }
+
+// ObjectStorage holds the stored files of documents. Objects are created under
+// a key and referred to by the URL recorded on blobs and versions afterwards.
+type ObjectStorage interface {
+	GetURL(key string) string
+	Upload(key string, file multipart.File) (string, error)
+	Exists(key string) (bool, error)
+	Get(url string) (io.ReadCloser, error)
+	Download(url string) ([]byte, error)
+	Delete(url string) error
+	List() ([]helpers.S3Object, error)
+	GetPresignedURL(url string, expiry time.Duration, contentType string, contentDisposition string) (string, error)
+}
+
+type s3Storage struct{}
+
+// NewS3Storage stores objects in the bucket from the configuration.
+func NewS3Storage() ObjectStorage {
+	return s3Storage{}
+}
+
+func (s3Storage) bucket() string {
+	return configs.GetEnv().AWS.S3.Bucket
+}
+
+func (s s3Storage) GetURL(key string) string {
+	return helpers.GetS3URL(s.bucket(), key)
+}
+
+func (s s3Storage) Upload(key string, file multipart.File) (string, error) {
+	return helpers.UploadToS3(s.bucket(), key, file)
+}
+
+func (s s3Storage) Exists(key string) (bool, error) {
+	return helpers.S3ObjectExists(s.bucket(), key)
+}
+
+func (s s3Storage) Get(url string) (io.ReadCloser, error) {
+	return helpers.GetS3Object(s.bucket(), helpers.GetS3Key(url))
+}
+
+func (s s3Storage) Download(url string) ([]byte, error) {
+	return helpers.DownloadFromS3(s.bucket(), helpers.GetS3Key(url))
+}
+
+func (s s3Storage) Delete(url string) error {
+	return helpers.DeleteFromS3(s.bucket(), url)
+}
+
+func (s s3Storage) List() ([]helpers.S3Object, error) {
+	return helpers.ListS3Objects(s.bucket())
+}
+
+func (s s3Storage) GetPresignedURL(url string, expiry time.Duration, contentType string, contentDisposition string) (string, error) {
+	return helpers.GetPresignedS3URL(s.bucket(), helpers.GetS3Key(url), expiry, contentType, contentDisposition)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+)
+
+const (
+	defaultScannerTimeout = 60 * time.Second
+	clamdChunkSize        = 64 * 1024
+)
+
+type ScanResult struct {
+	Clean bool
+	// Signature names the detected threat when the file is not clean
+	Signature string
+}
+
+// Scanner checks uploaded files for malware before they are stored. A nil
+// result means the file was not scanned.
+type Scanner interface {
+	Name() string
+	Scan(data io.Reader) (*ScanResult, error)
+}
+
+// NewScanner returns the scanner selected in the configuration, or a scanner
+// that accepts every file when none is configured.
+func NewScanner() Scanner {
+	config := configs.GetEnv().UploadValidation.Scanner
+	switch config.Type {
+	case "clamd":
+		timeout := defaultScannerTimeout
+		if config.TimeoutSeconds > 0 {
+			timeout = time.Duration(config.TimeoutSeconds) * time.Second
+		}
+		return NewClamdScanner(config.Address, timeout)
+	}
+	return noopScanner{}
+}
+
+type noopScanner struct{}
+
+func (noopScanner) Name() string {
+	return "none"
+}
+
+func (noopScanner) Scan(data io.Reader) (*ScanResult, error) {
+	return nil, nil
+}
+
+type clamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner talks to a clamd daemon. The address is either
+// unix:/path/to/socket or tcp:host:port, a bare host:port means TCP.
+func NewClamdScanner(address string, timeout time.Duration) Scanner {
+	network := "tcp"
+	if rest, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", rest
+	} else if rest, ok := strings.CutPrefix(address, "tcp:"); ok {
+		address = rest
+	}
+	return &clamdScanner{network: network, address: address, timeout: timeout}
+}
+
+func (s *clamdScanner) Name() string {
+	return "clamd"
+}
+
+// Scan streams data with the INSTREAM command: chunks prefixed with their
+// length in network byte order, terminated by an empty chunk.
+func (s *clamdScanner) Scan(data io.Reader) (*ScanResult, error) {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan file: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	writer := bufio.NewWriter(conn)
+	if _, err := writer.WriteString("zINSTREAM\x00"); err != nil {
+		return nil, fmt.Errorf("failed to scan file: %v", err)
+	}
+
+	buffer := make([]byte, clamdChunkSize)
+	header := make([]byte, 4)
+	for {
+		n, readErr := data.Read(buffer)
+		if n > 0 {
+			binary.BigEndian.PutUint32(header, uint32(n))
+			writer.Write(header)
+			if _, err := writer.Write(buffer[:n]); err != nil {
+				return nil, fmt.Errorf("failed to scan file: %v", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to scan file: %v", readErr)
+		}
+	}
+
+	binary.BigEndian.PutUint32(header, 0)
+	writer.Write(header)
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to scan file: %v", err)
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan file: %v", err)
+	}
+	return parseClamdReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// parseClamdReply reads "stream: OK", "stream: <signature> FOUND" or
+// "<message> ERROR".
+func parseClamdReply(reply string) (*ScanResult, error) {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return &ScanResult{Clean: true}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return &ScanResult{Signature: strings.TrimSuffix(result, " FOUND")}, nil
+	}
+	return nil, fmt.Errorf("failed to scan file: clamd replied %q", reply)
+}
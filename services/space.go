@@ -3,7 +3,13 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/BlenDMinh/dutgrad-server/configs"
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
@@ -11,14 +17,30 @@ import (
 	"github.com/BlenDMinh/dutgrad-server/models/dtos"
 )
 
+const (
+	defaultStorageReconcileInterval = 6 * time.Hour
+	defaultInvitationExpiryDays     = 14
+)
+
 type SpaceService interface {
 	ICrudService[entities.Space, uint]
 	GetPublicSpaces(page int, pageSize int) (*helpers.PaginationResult, error)
+	GetDiscoverableSpaces(page int, pageSize int) (*helpers.PaginationResult, error)
 	GetMembers(spaceId uint) ([]entities.SpaceUser, error)
 	GetInvitations(spaceId uint) ([]entities.SpaceInvitation, error)
-	GetOrCreateSpaceInvitationLink(spaceID, spaceRoleID uint) (*entities.SpaceInvitationLink, error)
+	GetOrCreateSpaceInvitationLink(spaceID, spaceRoleID, grantedBy uint) (*entities.SpaceInvitationLink, error)
+	CreateInvitationLink(link *entities.SpaceInvitationLink) (*entities.SpaceInvitationLink, error)
+	GetInvitationLinks(spaceID uint) ([]entities.SpaceInvitationLink, error)
+	RevokeInvitationLink(spaceID, linkID, revokedBy uint) error
+	GetInvitationLinkUses(spaceID, linkID uint) ([]entities.SpaceInvitationLinkUse, error)
 	CreateInvitation(invitation *entities.SpaceInvitation) (*entities.SpaceInvitation, error)
+	ResendInvitation(spaceID, invitationID uint) (*entities.SpaceInvitation, error)
+	RevokeInvitation(spaceID, invitationID uint) error
 	GetSpaceRoles() ([]entities.SpaceRole, error)
+	GetRolesOfSpace(spaceID uint) ([]entities.SpaceRole, error)
+	CreateCustomRole(spaceID, createdBy uint, name string, permission entities.SpacePermission) (*entities.SpaceRole, error)
+	UpdateCustomRole(spaceID, roleID, updatedBy uint, name *string, permission *entities.SpacePermission) (*entities.SpaceRole, error)
+	DeleteCustomRole(spaceID, roleID uint) error
 	JoinSpaceWithToken(token string, userID uint) (uint, error)
 	JoinPublicSpace(spaceID uint, userID uint) error
 	GetUserRole(userID, spaceID uint) (*entities.SpaceRole, error)
@@ -30,19 +52,29 @@ type SpaceService interface {
 	CreateSpace(space *entities.Space, userID uint) (*entities.Space, error)
 	UpdateMemberRole(spaceID, memberID, roleID, updatedBy uint) error
 	RemoveMember(spaceID, memberID, requestingUserID uint) error
+	LeaveSpace(spaceID, userID uint) error
 	Delete(id uint) error
+	DeleteSpace(id uint, deletedBy uint) error
+	GetDeletedSpaces(userID uint) ([]entities.Space, error)
+	RestoreSpace(id uint) (*entities.Space, error)
+	PurgeSpace(id uint) error
 	GetSpaceUsage(spaceID uint) (*dtos.SpaceUsage, error)
 	IsAPIRateLimited(spaceID uint) bool
+	ReconcileStorageUsage()
+	StartStorageReconcileRoutine(interval time.Duration)
 }
 
 type spaceServiceImpl struct {
 	CrudService[entities.Space, uint]
 	repo                      repositories.SpaceRepository
+	roleRepo                  repositories.SpaceRoleRepository
 	invitationLinkRepo        repositories.SpaceInvitationLinkRepository
 	ragServerService          *RAGServerService
 	userRepository            repositories.UserRepository
 	spaceInvitationRepository repositories.SpaceInvitationRepository
 	documentRepository        repositories.DocumentRepository
+	documentService           DocumentService
+	mailer                    Mailer
 }
 
 func NewSpaceService(
@@ -51,6 +83,8 @@ func NewSpaceService(
 	userRepository repositories.UserRepository,
 	spaceInvitationRepository repositories.SpaceInvitationRepository,
 	documentRepository repositories.DocumentRepository,
+	documentService DocumentService,
+	mailer Mailer,
 ) SpaceService {
 	crudService := NewCrudService(repositories.NewSpaceRepository())
 	repo := crudService.repo.(repositories.SpaceRepository)
@@ -60,9 +94,12 @@ func NewSpaceService(
 		invitationLinkRepo:        invitationLinkRepo,
 		ragServerService:          ragServerService,
 		repo:                      repo,
+		roleRepo:                  repositories.NewSpaceRoleRepository(),
 		userRepository:            userRepository,
 		spaceInvitationRepository: spaceInvitationRepository,
 		documentRepository:        documentRepository,
+		documentService:           documentService,
+		mailer:                    mailer,
 	}
 }
 
@@ -81,6 +118,22 @@ func (s *spaceServiceImpl) GetPublicSpaces(page int, pageSize int) (*helpers.Pag
 	return &result, nil
 }
 
+// GetDiscoverableSpaces lists the private spaces that accept join requests.
+func (s *spaceServiceImpl) GetDiscoverableSpaces(page int, pageSize int) (*helpers.PaginationResult, error) {
+	spaces, err := s.repo.FindDiscoverableSpaces(page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountDiscoverableSpaces()
+	if err != nil {
+		return nil, err
+	}
+
+	result := helpers.CreatePaginationResult(spaces, page, pageSize, count)
+	return &result, nil
+}
+
 func (s *spaceServiceImpl) GetMembers(spaceId uint) ([]entities.SpaceUser, error) {
 	return s.repo.GetMembers(spaceId)
 }
@@ -89,78 +142,344 @@ func (s *spaceServiceImpl) GetInvitations(spaceId uint) ([]entities.SpaceInvitat
 	return s.repo.GetInvitations(spaceId)
 }
 
-func (s *spaceServiceImpl) GetOrCreateSpaceInvitationLink(spaceID, spaceRoleID uint) (*entities.SpaceInvitationLink, error) {
+// GetOrCreateSpaceInvitationLink returns the default link of the space. A
+// default link with another role is revoked and replaced, so that its tokens
+// stop granting the old role.
+func (s *spaceServiceImpl) GetOrCreateSpaceInvitationLink(spaceID, spaceRoleID, grantedBy uint) (*entities.SpaceInvitationLink, error) {
+	if err := s.validateGrantedRole(spaceID, spaceRoleID, grantedBy); err != nil {
+		return nil, err
+	}
+
 	repo := s.invitationLinkRepo
-	invitationLink, _ := repo.GetBySpaceID(spaceID)
-	if invitationLink == nil {
-		invitationLink = &entities.SpaceInvitationLink{
-			SpaceID:     spaceID,
-			SpaceRoleID: spaceRoleID,
+	invitationLink, _ := repo.GetDefaultBySpaceID(spaceID)
+	if invitationLink != nil {
+		if invitationLink.SpaceRoleID == spaceRoleID && invitationLink.UnusableReason(time.Now()) == "" {
+			return invitationLink, nil
+		}
+		if err := repo.Revoke(invitationLink.ID, grantedBy); err != nil {
+			return nil, fmt.Errorf("failed to revoke the previous link: %v", err)
+		}
+	}
+
+	return repo.Create(&entities.SpaceInvitationLink{
+		SpaceID:     spaceID,
+		SpaceRoleID: spaceRoleID,
+		Name:        entities.DefaultInvitationLinkName,
+		CreatedBy:   &grantedBy,
+	})
+}
+
+func (s *spaceServiceImpl) CreateInvitationLink(link *entities.SpaceInvitationLink) (*entities.SpaceInvitationLink, error) {
+	link.Name = strings.TrimSpace(link.Name)
+	if link.Name == "" || len(link.Name) > 255 {
+		return nil, errors.New("invalid link: the name must be between 1 and 255 characters")
+	}
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("invalid link: the expiry must be in the future")
+	}
+	if link.MaxUses != nil && *link.MaxUses <= 0 {
+		return nil, errors.New("invalid link: the maximum number of uses must be positive")
+	}
+	if link.CreatedBy == nil {
+		return nil, errors.New("invalid link: the creator is required")
+	}
+	if err := s.validateGrantedRole(link.SpaceID, link.SpaceRoleID, *link.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	link.ID = 0
+	link.UseCount = 0
+	link.RevokedAt = nil
+	link.RevokedBy = nil
+	return s.invitationLinkRepo.Create(link)
+}
+
+func (s *spaceServiceImpl) GetInvitationLinks(spaceID uint) ([]entities.SpaceInvitationLink, error) {
+	return s.invitationLinkRepo.GetBySpaceID(spaceID)
+}
+
+func (s *spaceServiceImpl) RevokeInvitationLink(spaceID, linkID, revokedBy uint) error {
+	link, err := s.getInvitationLink(spaceID, linkID)
+	if err != nil {
+		return err
+	}
+	if link.RevokedAt != nil {
+		return nil
+	}
+	return s.invitationLinkRepo.Revoke(link.ID, revokedBy)
+}
+
+func (s *spaceServiceImpl) GetInvitationLinkUses(spaceID, linkID uint) ([]entities.SpaceInvitationLinkUse, error) {
+	link, err := s.getInvitationLink(spaceID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	return s.invitationLinkRepo.GetUses(link.ID)
+}
+
+func (s *spaceServiceImpl) getInvitationLink(spaceID, linkID uint) (*entities.SpaceInvitationLink, error) {
+	link, err := s.invitationLinkRepo.GetById(linkID)
+	if err != nil || link.SpaceID != spaceID {
+		return nil, errors.New("invitation link not found")
+	}
+	return link, nil
+}
+
+// CreateInvitation invites a user, or an email address that has no account
+// yet, and emails the invitee. A failed email leaves the invitation in place,
+// it can be sent again with ResendInvitation.
+func (s *spaceServiceImpl) CreateInvitation(invitation *entities.SpaceInvitation) (*entities.SpaceInvitation, error) {
+	if err := s.validateGrantedRole(invitation.SpaceID, invitation.SpaceRoleID, invitation.InviterID); err != nil {
+		return nil, err
+	}
+
+	if invitation.InvitedUserID == nil {
+		if invitation.InvitedEmail == nil {
+			return nil, errors.New("invalid invitation: an invitee is required")
+		}
+		address, err := mail.ParseAddress(strings.TrimSpace(*invitation.InvitedEmail))
+		if err != nil {
+			return nil, errors.New("invalid invitation: invalid email address")
 		}
-		invitationLink, err := repo.Create(invitationLink)
+		email := strings.ToLower(address.Address)
+		invitation.InvitedEmail = &email
+
+		if user, err := s.userRepository.GetByEmail(email); err == nil {
+			invitation.InvitedUserID = &user.ID
+			invitation.InvitedEmail = nil
+		}
+	} else {
+		invitation.InvitedEmail = nil
+	}
 
+	if invitation.InvitedUserID != nil {
+		isMember, err := s.repo.IsMemberOfSpace(*invitation.InvitedUserID, invitation.SpaceID)
 		if err != nil {
 			return nil, err
 		}
-		return invitationLink, nil
+		if isMember {
+			return nil, errors.New("user is already a member of this space")
+		}
+	}
+
+	invitation.ID = 0
+	invitation.Status = entities.InvitationStatusPending
+	invitation.ExpiresAt = invitationExpiry(time.Now())
+	invitation.LastSentAt = nil
+	invitation.SendCount = 0
+	if _, err := s.repo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+
+	if err := s.sendInvitation(invitation); err != nil {
+		log.Printf("Failed to email invitation %d: %v", invitation.ID, err)
 	}
-	if invitationLink.SpaceRoleID != spaceRoleID {
-		invitationLink.SpaceRoleID = spaceRoleID
-		invitationLink, err := repo.Update(invitationLink)
+	return invitation, nil
+}
+
+// ResendInvitation emails a pending invitation again and restarts its expiry.
+func (s *spaceServiceImpl) ResendInvitation(spaceID, invitationID uint) (*entities.SpaceInvitation, error) {
+	invitation, err := s.getInvitation(spaceID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != entities.InvitationStatusPending {
+		return nil, errors.New("invitation is no longer pending")
+	}
+
+	invitation.ExpiresAt = invitationExpiry(time.Now())
+	if err := s.sendInvitation(invitation); err != nil {
+		return nil, err
+	}
+	return s.spaceInvitationRepository.GetById(invitation.ID)
+}
+
+// RevokeInvitation withdraws a pending invitation of the space.
+func (s *spaceServiceImpl) RevokeInvitation(spaceID, invitationID uint) error {
+	invitation, err := s.getInvitation(spaceID, invitationID)
+	if err != nil {
+		return err
+	}
+	return s.spaceInvitationRepository.Delete(invitation.ID)
+}
+
+func (s *spaceServiceImpl) getInvitation(spaceID, invitationID uint) (*entities.SpaceInvitation, error) {
+	invitation, err := s.spaceInvitationRepository.GetById(invitationID)
+	if err != nil || invitation.SpaceID != spaceID {
+		return nil, errors.New("invitation not found")
+	}
+	return invitation, nil
+}
+
+// sendInvitation emails the invitee and records the delivery. People without
+// an account are pointed at the registration page, their invitation shows up
+// once they sign up with the invited address.
+func (s *spaceServiceImpl) sendInvitation(invitation *entities.SpaceInvitation) error {
+	space, err := s.repo.GetById(invitation.SpaceID)
+	if err != nil {
+		return err
+	}
+
+	inviterName := "Someone"
+	if inviter, err := s.userRepository.GetById(invitation.InviterID); err == nil {
+		inviterName = inviter.Username
+	}
+
+	webClientURL := configs.GetEnv().WebClientURL
+	var to, link string
+	if invitation.InvitedUserID != nil {
+		user, err := s.userRepository.GetById(*invitation.InvitedUserID)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if user.Email == nil {
+			return errors.New("the invited user has no email address")
 		}
+		to, link = *user.Email, webClientURL+"/invitations"
+	} else {
+		to, link = *invitation.InvitedEmail, webClientURL+"/register?email="+url.QueryEscape(*invitation.InvitedEmail)
+	}
+
+	body := fmt.Sprintf("%s invited you to join the space \"%s\".\n\n", inviterName, space.Name)
+	if invitation.Message != "" {
+		body += invitation.Message + "\n\n"
+	}
+	body += "Open the invitation: " + link + "\n"
+	if invitation.ExpiresAt != nil {
+		body += "The invitation expires on " + invitation.ExpiresAt.Format("2006-01-02 15:04 MST") + ".\n"
+	}
 
-		return invitationLink, nil
+	err = s.mailer.Send(&Mail{
+		To:      to,
+		Subject: fmt.Sprintf("You have been invited to %s", space.Name),
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send invitation email: %v", err)
 	}
-	return invitationLink, nil
+	return s.spaceInvitationRepository.MarkSent(invitation.ID, time.Now(), invitation.ExpiresAt)
 }
 
-func (s *spaceServiceImpl) CreateInvitation(invitation *entities.SpaceInvitation) (*entities.SpaceInvitation, error) {
-	return s.repo.CreateInvitation(invitation)
+func invitationExpiry(now time.Time) *time.Time {
+	days := configs.GetEnv().Invitation.ExpiryDays
+	if days <= 0 {
+		days = defaultInvitationExpiryDays
+	}
+	expiresAt := now.AddDate(0, 0, days)
+	return &expiresAt
 }
 
 func (s *spaceServiceImpl) GetSpaceRoles() ([]entities.SpaceRole, error) {
 	return s.repo.GetAllRoles()
 }
 
-func (s *spaceServiceImpl) JoinSpaceWithToken(token string, userID uint) (uint, error) {
-	payload, err := helpers.VerifyTokenForPayload(token)
+func (s *spaceServiceImpl) GetRolesOfSpace(spaceID uint) ([]entities.SpaceRole, error) {
+	return s.roleRepo.GetBySpaceID(spaceID)
+}
+
+func (s *spaceServiceImpl) CreateCustomRole(spaceID, createdBy uint, name string, permission entities.SpacePermission) (*entities.SpaceRole, error) {
+	name = strings.TrimSpace(name)
+	if err := s.validateCustomRole(spaceID, 0, createdBy, name, permission); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.Create(&entities.SpaceRole{
+		SpaceID:    &spaceID,
+		Name:       name,
+		Permission: permission,
+	})
+}
+
+func (s *spaceServiceImpl) UpdateCustomRole(spaceID, roleID, updatedBy uint, name *string, permission *entities.SpacePermission) (*entities.SpaceRole, error) {
+	role, err := s.getCustomRole(spaceID, roleID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if payload == nil {
-		return 0, fmt.Errorf("invalid token")
+	if name != nil {
+		role.Name = strings.TrimSpace(*name)
+	}
+	if permission != nil {
+		role.Permission = *permission
+	}
+	if err := s.validateCustomRole(spaceID, role.ID, updatedBy, role.Name, role.Permission); err != nil {
+		return nil, err
 	}
 
-	parsePayload := *payload
+	return s.roleRepo.Update(role)
+}
 
-	db := databases.GetDB()
-	var spaceUser entities.SpaceUser
-	err = db.Where("user_id = ? AND space_id = ?", userID, parsePayload["space_id"]).First(&spaceUser).Error
-	if err == nil {
-		return 0, fmt.Errorf("user is already a member of this space")
+// DeleteCustomRole refuses to delete a role that members, pending invitations
+// or the invitation link still grant, they would be left without a role.
+func (s *spaceServiceImpl) DeleteCustomRole(spaceID, roleID uint) error {
+	role, err := s.getCustomRole(spaceID, roleID)
+	if err != nil {
+		return err
 	}
 
-	spaceRoleIDFloat := parsePayload["space_role_id"].(float64)
-	spaceRoleID := uint(spaceRoleIDFloat)
+	inUse, err := s.roleRepo.IsInUse(role.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check role usage: %v", err)
+	}
+	if inUse {
+		return errors.New("role is still assigned to members, invitations or the invitation link")
+	}
 
-	spaceIDFloat := parsePayload["space_id"].(float64)
-	spaceID := uint(spaceIDFloat)
+	return s.roleRepo.Delete(role.ID)
+}
 
-	newSpaceUser := entities.SpaceUser{
-		UserID:      userID,
-		SpaceID:     spaceID,
-		SpaceRoleID: &spaceRoleID,
+// getCustomRole only returns custom roles of the space, built-in roles cannot
+// be changed.
+func (s *spaceServiceImpl) getCustomRole(spaceID, roleID uint) (*entities.SpaceRole, error) {
+	role, err := s.roleRepo.GetById(roleID)
+	if err != nil || !role.IsCustom() || *role.SpaceID != spaceID {
+		return nil, errors.New("role not found in the space")
 	}
+	return role, nil
+}
 
-	err = db.Create(&newSpaceUser).Error
+// validateCustomRole also keeps members from creating or widening a role
+// beyond their own permissions, which would let them grant themselves more.
+func (s *spaceServiceImpl) validateCustomRole(spaceID, roleID, actorID uint, name string, permission entities.SpacePermission) error {
+	if name == "" || len(name) > 255 {
+		return errors.New("invalid role name: it must be between 1 and 255 characters")
+	}
+	if !permission.IsValid() {
+		return fmt.Errorf("invalid permission: unknown bits in %d", permission)
+	}
+
+	actor, err := s.GetUserRole(actorID, spaceID)
+	if err != nil {
+		return err
+	}
+	if !actor.HasPermission(permission) {
+		return errors.New("invalid permission: the role grants permissions you do not have")
+	}
+
+	exists, err := s.roleRepo.NameExists(spaceID, name, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to check role name: %v", err)
+	}
+	if exists {
+		return errors.New("role name already exists in the space")
+	}
+	return nil
+}
+
+// JoinSpaceWithToken checks the link the token names against the database,
+// the signature alone only proves the link existed at some point.
+func (s *spaceServiceImpl) JoinSpaceWithToken(token string, userID uint) (uint, error) {
+	linkID, err := helpers.VerifyInvitationLinkToken(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid token")
+	}
+
+	link, err := s.invitationLinkRepo.Redeem(linkID, userID)
 	if err != nil {
 		return 0, err
 	}
 
-	return spaceID, nil
+	return link.SpaceID, nil
 }
 
 func (s *spaceServiceImpl) GetUserRole(userID, spaceID uint) (*entities.SpaceRole, error) {
@@ -247,17 +566,64 @@ func (s *spaceServiceImpl) CreateSpace(space *entities.Space, userID uint) (*ent
 }
 
 func (s *spaceServiceImpl) UpdateMemberRole(spaceID, memberID, roleID, updatedBy uint) error {
+	if err := s.validateGrantedRole(spaceID, roleID, updatedBy); err != nil {
+		return err
+	}
+
+	memberRole, err := s.repo.GetUserRole(memberID, spaceID)
+	if err != nil || memberRole == nil {
+		return errors.New("member not found in the space")
+	}
+	// Only co-owners demote an owner, the repository keeps the last one
+	if memberRole.IsOwner() && !s.isOwner(updatedBy, spaceID) {
+		return errors.New("cannot change the role of a space owner")
+	}
+
 	return s.repo.UpdateMemberRole(spaceID, memberID, roleID, updatedBy)
 }
 
+func (s *spaceServiceImpl) validateGrantedRole(spaceID, roleID, grantedBy uint) error {
+	return validateGrantedRole(s.roleRepo, s.repo, spaceID, roleID, grantedBy)
+}
+
+// validateGrantedRole rejects roles that cannot be handed out by an invitation,
+// a join request or a role change: the owner role, custom roles of other
+// spaces and roles granting more than the member handing them out is allowed to do.
+func validateGrantedRole(
+	roleRepo repositories.SpaceRoleRepository,
+	spaceRepo repositories.SpaceRepository,
+	spaceID, roleID, grantedBy uint,
+) error {
+	role, err := roleRepo.GetById(roleID)
+	if err != nil {
+		return fmt.Errorf("invalid role: role %d does not exist", roleID)
+	}
+	if role.IsCustom() {
+		if *role.SpaceID != spaceID {
+			return fmt.Errorf("invalid role: role %d belongs to another space", roleID)
+		}
+	} else if !role.IsEditor() && !role.IsViewer() {
+		return errors.New("invalid role: ownership cannot be granted")
+	}
+
+	granter, err := spaceRepo.GetUserRole(grantedBy, spaceID)
+	if err != nil {
+		return fmt.Errorf("user is not a member of this space or %v", err)
+	}
+	if granter == nil || !granter.HasPermission(role.Permission) {
+		return errors.New("invalid role: it grants permissions you do not have")
+	}
+	return nil
+}
+
 func (s *spaceServiceImpl) RemoveMember(spaceID, memberID, requestingUserID uint) error {
 	requestingUserRole, err := s.GetUserRole(requestingUserID, spaceID)
 	if err != nil {
 		return err
 	}
 
-	if !requestingUserRole.IsOwner() {
-		return errors.New("only space owners can remove members")
+	if !requestingUserRole.HasPermission(entities.SpacePermissionManageMembers) {
+		return errors.New("only members allowed to manage members can remove members")
 	}
 
 	if memberID == requestingUserID {
@@ -275,7 +641,7 @@ func (s *spaceServiceImpl) RemoveMember(spaceID, memberID, requestingUserID uint
 			return err
 		}
 
-		if memberRole.ID == uint(entities.SpaceRoleOwner) {
+		if memberRole.IsOwner() && !requestingUserRole.IsOwner() {
 			return errors.New("cannot remove a space owner")
 		}
 
@@ -285,25 +651,83 @@ func (s *spaceServiceImpl) RemoveMember(spaceID, memberID, requestingUserID uint
 	return s.spaceInvitationRepository.CancelInvitation(spaceID, memberID)
 }
 
+// LeaveSpace removes the user from the space. The last owner has to transfer
+// ownership first.
+func (s *spaceServiceImpl) LeaveSpace(spaceID, userID uint) error {
+	return s.repo.RemoveMember(spaceID, userID)
+}
+
+func (s *spaceServiceImpl) isOwner(userID, spaceID uint) bool {
+	role, err := s.repo.GetUserRole(userID, spaceID)
+	return err == nil && role != nil && role.IsOwner()
+}
+
 func (s *spaceServiceImpl) Delete(id uint) error {
-	documents, err := s.documentRepository.GetBySpaceID(id)
+	return s.trashSpace(id, nil)
+}
+
+// DeleteSpace moves a space to the trash on behalf of one of its owners. The
+// route only asks for manage_settings, which custom roles may grant too.
+func (s *spaceServiceImpl) DeleteSpace(id uint, deletedBy uint) error {
+	if !s.isOwner(deletedBy, id) {
+		return errors.New("only the owner can delete this space")
+	}
+	return s.trashSpace(id, &deletedBy)
+}
+
+// trashSpace moves a space to the trash. Its documents stay as they are but
+// leave the RAG index until the space is restored.
+func (s *spaceServiceImpl) trashSpace(id uint, deletedBy *uint) error {
+	if _, err := s.repo.GetById(id); err != nil {
+		return err
+	}
+
+	err := s.ragServerService.RemoveSpace(id)
+	if err != nil {
+		return fmt.Errorf("failed to remove space from RAG server: %v", err)
+	}
+
+	return s.repo.SoftDelete(id, deletedBy)
+}
+
+func (s *spaceServiceImpl) GetDeletedSpaces(userID uint) ([]entities.Space, error) {
+	return s.repo.GetDeletedOwnedBy(userID)
+}
+
+// RestoreSpace takes a space out of the trash. Its documents have to be
+// indexed again afterwards.
+func (s *spaceServiceImpl) RestoreSpace(id uint) (*entities.Space, error) {
+	if _, err := s.repo.GetDeletedById(id); err != nil {
+		return nil, fmt.Errorf("space not found in trash: %v", err)
+	}
+
+	if err := s.repo.Restore(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetById(id)
+}
+
+// PurgeSpace deletes a space for good with every document it ever held,
+// including the documents in its trash.
+func (s *spaceServiceImpl) PurgeSpace(id uint) error {
+	documentIDs, err := s.documentRepository.GetAllIDsBySpaceID(id)
 	if err != nil {
 		return fmt.Errorf("failed to get documents in space: %v", err)
 	}
 
-	for _, doc := range documents {
-		err := s.documentRepository.Delete(doc.ID)
+	for _, documentID := range documentIDs {
+		err := s.documentService.PurgeDocument(documentID)
 		if err != nil {
-			return fmt.Errorf("failed to delete document %d: %v", doc.ID, err)
+			return fmt.Errorf("failed to delete document %d: %v", documentID, err)
 		}
 	}
 
-	err = s.ragServerService.RemoveSpace(id)
-	if err != nil {
-		return fmt.Errorf("failed to remove space from RAG server: %v", err)
+	// The index was already dropped when the space was moved to the trash
+	if err := s.ragServerService.RemoveSpace(id); err != nil {
+		log.Printf("Failed to remove space %d from RAG server: %v", id, err)
 	}
 
-	return s.repo.Delete(id)
+	return s.repo.Purge(id)
 }
 
 func (s *spaceServiceImpl) GetSpaceUsage(spaceID uint) (*dtos.SpaceUsage, error) {
@@ -334,3 +758,31 @@ func (s *spaceServiceImpl) IsAPIRateLimited(spaceID uint) bool {
 
 	return usage.ChatAPICallsUsageDaily >= int64(space.ApiCallLimit)
 }
+
+// ReconcileStorageUsage corrects the storage counters of spaces that drifted
+// from the sizes of their documents, e.g. after a failed request.
+func (s *spaceServiceImpl) ReconcileStorageUsage() {
+	fixed, err := s.repo.ReconcileStorageUsage()
+	if err != nil {
+		log.Printf("Failed to reconcile storage usage: %v", err)
+		return
+	}
+	if fixed > 0 {
+		log.Printf("Reconciled storage usage of %d spaces", fixed)
+	}
+}
+
+func (s *spaceServiceImpl) StartStorageReconcileRoutine(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultStorageReconcileInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.ReconcileStorageUsage()
+		}
+	}()
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+)
+
+const (
+	spaceArchiveManifestName    = "manifest.json"
+	maxSpaceArchiveManifestSize = 16 * 1024 * 1024
+	defaultMaxImportSizeMb      = 1024
+)
+
+// SpaceArchiveService moves spaces between deployments. An archive is a ZIP
+// file with a manifest describing the space and the files of its documents.
+type SpaceArchiveService interface {
+	PrepareExport(spaceID uint, options dtos.SpaceExportOptions) (*dtos.SpaceArchiveManifest, error)
+	WriteExport(manifest *dtos.SpaceArchiveManifest, w io.Writer) error
+	ImportSpace(r io.ReaderAt, size int64, userID uint, options dtos.SpaceImportOptions) (*dtos.SpaceImportReport, error)
+}
+
+type spaceArchiveServiceImpl struct {
+	spaceRepo       repositories.SpaceRepository
+	roleRepo        repositories.SpaceRoleRepository
+	folderRepo      repositories.DocumentFolderRepository
+	documentRepo    repositories.DocumentRepository
+	userRepo        repositories.UserRepository
+	sessionRepo     repositories.UserQuerySessionRepository
+	spaceService    SpaceService
+	documentService DocumentService
+	storage         ObjectStorage
+}
+
+func NewSpaceArchiveService(
+	spaceRepo repositories.SpaceRepository,
+	roleRepo repositories.SpaceRoleRepository,
+	folderRepo repositories.DocumentFolderRepository,
+	documentRepo repositories.DocumentRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.UserQuerySessionRepository,
+	spaceService SpaceService,
+	documentService DocumentService,
+	storage ObjectStorage,
+) SpaceArchiveService {
+	return &spaceArchiveServiceImpl{
+		spaceRepo:       spaceRepo,
+		roleRepo:        roleRepo,
+		folderRepo:      folderRepo,
+		documentRepo:    documentRepo,
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		spaceService:    spaceService,
+		documentService: documentService,
+		storage:         storage,
+	}
+}
+
+// PrepareExport collects everything the archive of a space holds, so that
+// errors surface before the archive is streamed.
+func (s *spaceArchiveServiceImpl) PrepareExport(spaceID uint, options dtos.SpaceExportOptions) (*dtos.SpaceArchiveManifest, error) {
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return nil, errors.New("space not found")
+	}
+
+	manifest := &dtos.SpaceArchiveManifest{
+		Version:    dtos.SpaceArchiveVersion,
+		ExportedAt: time.Now(),
+		Space: dtos.SpaceArchiveSettings{
+			Name:              space.Name,
+			Description:       space.Description,
+			PrivacyStatus:     space.PrivacyStatus,
+			AllowJoinRequests: space.AllowJoinRequests,
+			SystemPrompt:      space.SystemPrompt,
+			DocumentLimit:     space.DocumentLimit,
+			FileSizeLimitKb:   space.FileSizeLimitKb,
+			ApiCallLimit:      space.ApiCallLimit,
+			AllowedFileTypes:  space.AllowedFileTypes,
+			StorageLimitBytes: space.StorageLimitBytes,
+		},
+		Roles:     []dtos.SpaceArchiveRole{},
+		Members:   []dtos.SpaceArchiveMember{},
+		Folders:   []dtos.SpaceArchiveFolder{},
+		Documents: []dtos.SpaceArchiveDocument{},
+	}
+
+	roles, err := s.roleRepo.GetBySpaceID(spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export roles: %v", err)
+	}
+	for _, role := range roles {
+		if role.IsCustom() {
+			manifest.Roles = append(manifest.Roles, dtos.SpaceArchiveRole{ID: role.ID, Name: role.Name, Permission: role.Permission})
+		}
+	}
+
+	if options.IncludeMembers {
+		members, err := s.spaceRepo.GetMembers(spaceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export members: %v", err)
+		}
+		for _, member := range members {
+			if member.User.Email == nil || member.SpaceRoleID == nil {
+				continue
+			}
+			manifest.Members = append(manifest.Members, dtos.SpaceArchiveMember{Email: *member.User.Email, RoleID: *member.SpaceRoleID})
+		}
+	}
+
+	folders, err := s.folderRepo.GetBySpaceID(spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export folders: %v", err)
+	}
+	for _, folder := range folders {
+		manifest.Folders = append(manifest.Folders, dtos.SpaceArchiveFolder{ID: folder.ID, ParentID: folder.ParentID, Name: folder.Name})
+	}
+
+	documentIDs, err := s.documentRepo.GetIDs(&spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export documents: %v", err)
+	}
+	for _, documentID := range documentIDs {
+		document, err := s.documentRepo.GetById(documentID)
+		if err != nil {
+			continue
+		}
+
+		// Documents with the same content share their file in the archive
+		file := fmt.Sprintf("files/document-%d", document.ID)
+		if document.ContentHash != "" {
+			file = "files/" + document.ContentHash
+		}
+		manifest.Documents = append(manifest.Documents, dtos.SpaceArchiveDocument{
+			Name:          document.Name,
+			Description:   document.Description,
+			MimeType:      document.MimeType,
+			Size:          document.Size,
+			ContentHash:   document.ContentHash,
+			PrivacyStatus: document.PrivacyStatus,
+			FolderID:      document.FolderID,
+			Tags:          document.Tags,
+			File:          file,
+			S3URL:         document.S3URL,
+		})
+	}
+
+	if options.IncludeSessions {
+		sessions, err := s.sessionRepo.GetBySpaceIDWithHistory(spaceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export sessions: %v", err)
+		}
+		manifest.Sessions = []dtos.SpaceArchiveSession{}
+		for _, session := range sessions {
+			exported := dtos.SpaceArchiveSession{CreatedAt: session.CreatedAt, Messages: []dtos.SpaceArchiveMessage{}}
+			if session.User != nil && session.User.Email != nil {
+				exported.UserEmail = *session.User.Email
+			}
+			for _, history := range session.ChatHistories {
+				exported.Messages = append(exported.Messages, dtos.SpaceArchiveMessage{
+					Message:   json.RawMessage(history.Message),
+					CreatedAt: history.CreatedAt,
+				})
+			}
+			manifest.Sessions = append(manifest.Sessions, exported)
+		}
+	}
+
+	return manifest, nil
+}
+
+// WriteExport writes the archive of a prepared export, the manifest first and
+// then the file of every document.
+func (s *spaceArchiveServiceImpl) WriteExport(manifest *dtos.SpaceArchiveManifest, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	entry, err := archive.Create(spaceArchiveManifestName)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	written := map[string]bool{}
+	for _, document := range manifest.Documents {
+		if written[document.File] {
+			continue
+		}
+		written[document.File] = true
+
+		if err := s.writeArchiveFile(archive, document.File, document.S3URL); err != nil {
+			return fmt.Errorf("failed to export %s: %v", document.Name, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+func (s *spaceArchiveServiceImpl) writeArchiveFile(archive *zip.Writer, name string, s3URL string) error {
+	content, err := s.storage.Get(s3URL)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, content)
+	return err
+}
+
+// ImportSpace creates a space owned by the user from an archive. The space
+// counts towards the space limit of the user and every document is uploaded
+// again, going through the limits of the space and the RAG server.
+func (s *spaceArchiveServiceImpl) ImportSpace(r io.ReaderAt, size int64, userID uint, options dtos.SpaceImportOptions) (*dtos.SpaceImportReport, error) {
+	maxSize := int64(valueOrDefault(configs.GetEnv().SpaceArchive.MaxImportSizeMb, defaultMaxImportSizeMb)) * 1024 * 1024
+	if size > maxSize {
+		return nil, fmt.Errorf("invalid archive: the size exceeds the limit of %d MB", maxSize/1024/1024)
+	}
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %v", err)
+	}
+	manifest, err := readSpaceArchiveManifest(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	space, err := s.spaceService.CreateSpace(&entities.Space{
+		Name:              manifest.Space.Name,
+		Description:       manifest.Space.Description,
+		PrivacyStatus:     manifest.Space.PrivacyStatus,
+		AllowJoinRequests: manifest.Space.AllowJoinRequests,
+		SystemPrompt:      manifest.Space.SystemPrompt,
+		DocumentLimit:     manifest.Space.DocumentLimit,
+		FileSizeLimitKb:   manifest.Space.FileSizeLimitKb,
+		ApiCallLimit:      manifest.Space.ApiCallLimit,
+		AllowedFileTypes:  manifest.Space.AllowedFileTypes,
+		StorageLimitBytes: manifest.Space.StorageLimitBytes,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dtos.SpaceImportReport{
+		Space:            space,
+		SkippedMembers:   []dtos.SkippedMember{},
+		SkippedDocuments: []dtos.SkippedDocument{},
+	}
+
+	roleIDs, folderIDs, err := s.importStructure(manifest, space.ID, userID)
+	if err != nil {
+		if purgeErr := s.spaceService.PurgeSpace(space.ID); purgeErr != nil {
+			log.Printf("Failed to remove space %d after a failed import: %v", space.ID, purgeErr)
+		}
+		return nil, err
+	}
+
+	s.importMembers(manifest, space.ID, userID, roleIDs, options, report)
+	s.importDocuments(archive, manifest, space, userID, folderIDs, report)
+	if options.IncludeSessions {
+		s.importSessions(manifest, space.ID, userID, options, report)
+	}
+	return report, nil
+}
+
+func readSpaceArchiveManifest(archive *zip.Reader) (*dtos.SpaceArchiveManifest, error) {
+	data, err := helpers.ReadZipFile(archive, spaceArchiveManifestName, maxSpaceArchiveManifestSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %v", err)
+	}
+
+	var manifest dtos.SpaceArchiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid archive: failed to read the manifest: %v", err)
+	}
+	if manifest.Version != dtos.SpaceArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d, expected %d", manifest.Version, dtos.SpaceArchiveVersion)
+	}
+
+	manifest.Space.Name = strings.TrimSpace(manifest.Space.Name)
+	if manifest.Space.Name == "" || len(manifest.Space.Name) > 255 {
+		return nil, errors.New("invalid archive: the space name must be between 1 and 255 characters")
+	}
+	return &manifest, nil
+}
+
+// importStructure creates the custom roles and the folder tree of the
+// archive and returns the new ids by the ids in the archive.
+func (s *spaceArchiveServiceImpl) importStructure(manifest *dtos.SpaceArchiveManifest, spaceID, userID uint) (map[uint]uint, map[uint]uint, error) {
+	roleIDs := map[uint]uint{}
+	for _, role := range manifest.Roles {
+		created, err := s.spaceService.CreateCustomRole(spaceID, userID, role.Name, role.Permission)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to import roles: %v", err)
+		}
+		roleIDs[role.ID] = created.ID
+	}
+
+	folderIDs := map[uint]uint{}
+	folders := manifest.Folders
+	for len(folders) > 0 {
+		remaining := []dtos.SpaceArchiveFolder{}
+		for _, folder := range folders {
+			var parentID *uint
+			if folder.ParentID != nil {
+				mapped, ok := folderIDs[*folder.ParentID]
+				if !ok {
+					remaining = append(remaining, folder)
+					continue
+				}
+				parentID = &mapped
+			}
+
+			created, err := s.folderRepo.Create(&entities.DocumentFolder{SpaceID: spaceID, ParentID: parentID, Name: folder.Name})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to import folders: %v", err)
+			}
+			folderIDs[folder.ID] = created.ID
+		}
+
+		// Folders whose parent is missing from the archive cannot be placed
+		if len(remaining) == len(folders) {
+			break
+		}
+		folders = remaining
+	}
+	return roleIDs, folderIDs, nil
+}
+
+// importMembers adds the members of the archive found by email. Only trusted
+// imports add them directly, other imports invite them so that nobody joins a
+// space without agreeing to it.
+func (s *spaceArchiveServiceImpl) importMembers(manifest *dtos.SpaceArchiveManifest, spaceID, userID uint, roleIDs map[uint]uint, options dtos.SpaceImportOptions, report *dtos.SpaceImportReport) {
+	members := []entities.SpaceUser{}
+	added := map[uint]bool{userID: true}
+	for _, member := range manifest.Members {
+		roleID, ok := roleIDs[member.RoleID]
+		if !ok {
+			switch member.RoleID {
+			case entities.SpaceRoleOwner, entities.SpaceRoleEditor, entities.SpaceRoleViewer:
+				roleID = member.RoleID
+			default:
+				roleID = entities.SpaceRoleViewer
+			}
+		}
+
+		if !options.Trusted {
+			// Ownership is only handed over through an ownership transfer
+			if roleID == entities.SpaceRoleOwner {
+				roleID = entities.SpaceRoleEditor
+			}
+			email := member.Email
+			_, err := s.spaceService.CreateInvitation(&entities.SpaceInvitation{
+				SpaceID:      spaceID,
+				SpaceRoleID:  roleID,
+				InviterID:    userID,
+				InvitedEmail: &email,
+			})
+			if err != nil {
+				if !strings.Contains(err.Error(), "already a member") {
+					report.SkippedMembers = append(report.SkippedMembers, dtos.SkippedMember{Email: member.Email, Reason: err.Error()})
+				}
+				continue
+			}
+			report.InvitedMembers++
+			continue
+		}
+
+		user, err := s.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(member.Email)))
+		if err != nil {
+			report.SkippedMembers = append(report.SkippedMembers, dtos.SkippedMember{Email: member.Email, Reason: "no account with this email"})
+			continue
+		}
+		if added[user.ID] {
+			continue
+		}
+		added[user.ID] = true
+		members = append(members, entities.SpaceUser{UserID: user.ID, SpaceID: spaceID, SpaceRoleID: &roleID})
+	}
+
+	if len(members) == 0 {
+		return
+	}
+	if err := s.spaceRepo.AddMembers(members); err != nil {
+		for _, member := range manifest.Members {
+			report.SkippedMembers = append(report.SkippedMembers, dtos.SkippedMember{Email: member.Email, Reason: err.Error()})
+		}
+		return
+	}
+	report.AddedMembers = len(members)
+}
+
+// importDocuments uploads the file of every document of the archive, which
+// checks the limits of the space and indexes the document.
+func (s *spaceArchiveServiceImpl) importDocuments(archive *zip.Reader, manifest *dtos.SpaceArchiveManifest, space *entities.Space, userID uint, folderIDs map[uint]uint, report *dtos.SpaceImportReport) {
+	// CheckDocumentLimits compares whole kilobytes, so allow the remainder
+	maxFileSize := int64(space.FileSizeLimitKb+1)*1024 - 1
+
+	for _, document := range manifest.Documents {
+		skip := func(reason string) {
+			report.SkippedDocuments = append(report.SkippedDocuments, dtos.SkippedDocument{Name: document.Name, Reason: reason})
+		}
+
+		data, err := helpers.ReadZipFile(archive, document.File, maxFileSize)
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		uploadFile := helpers.NewUploadFileFromBytes(document.Name, data)
+		if document.ContentHash != "" {
+			contentHash, err := uploadFile.ContentHash()
+			if err != nil || contentHash != document.ContentHash {
+				skip("the file does not match its content hash")
+				continue
+			}
+		}
+
+		var folderID *uint
+		if document.FolderID != nil {
+			if mapped, ok := folderIDs[*document.FolderID]; ok {
+				folderID = &mapped
+			}
+		}
+
+		_, err = s.documentService.UploadDocumentFile(uploadFile, space.ID, userID, "", dtos.DocumentUploadOptions{
+			Description: document.Description,
+			FolderID:    folderID,
+			Tags:        document.Tags,
+			Visibility:  document.PrivacyStatus,
+		})
+		if err != nil {
+			skip(err.Error())
+		}
+	}
+}
+
+// importSessions restores the chat sessions of the archive. Other imports
+// only restore the sessions of the importing user, sessions are private.
+func (s *spaceArchiveServiceImpl) importSessions(manifest *dtos.SpaceArchiveManifest, spaceID, userID uint, options dtos.SpaceImportOptions, report *dtos.SpaceImportReport) {
+	importer, err := s.userRepo.GetById(userID)
+	if err != nil {
+		return
+	}
+
+	for _, session := range manifest.Sessions {
+		var ownerID *uint
+		email := strings.ToLower(strings.TrimSpace(session.UserEmail))
+		if importer.Email != nil && email == strings.ToLower(*importer.Email) {
+			ownerID = &importer.ID
+		} else if !options.Trusted {
+			continue
+		} else if email != "" {
+			if user, err := s.userRepo.GetByEmail(email); err == nil {
+				ownerID = &user.ID
+			}
+		}
+
+		restored := &entities.UserQuerySession{
+			UserID:        ownerID,
+			SpaceID:       spaceID,
+			CreatedAt:     session.CreatedAt,
+			ChatHistories: []entities.ChatHistory{},
+		}
+		for _, message := range session.Messages {
+			restored.ChatHistories = append(restored.ChatHistories, entities.ChatHistory{
+				Message:   []byte(message.Message),
+				CreatedAt: message.CreatedAt,
+			})
+		}
+
+		if _, err := s.sessionRepo.Create(restored); err != nil {
+			log.Printf("Failed to import a session into space %d: %v", spaceID, err)
+			continue
+		}
+		report.ImportedSessions++
+	}
+}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+)
+
+type SpaceCloneService interface {
+	CloneSpace(spaceID, userID uint, req *dtos.CloneSpaceRequest) (*dtos.SpaceCloneResult, error)
+	SaveTemplate(spaceID, userID uint, req *dtos.SaveSpaceTemplateRequest) (*entities.SpaceTemplate, []dtos.SkippedDocument, error)
+	GetTemplates(userID uint) ([]entities.SpaceTemplate, error)
+	GetTemplate(templateID, userID uint) (*entities.SpaceTemplate, error)
+	DeleteTemplate(templateID, userID uint) error
+	CreateSpaceFromTemplate(templateID, userID uint, space *entities.Space) (*dtos.SpaceCloneResult, error)
+}
+
+type spaceCloneServiceImpl struct {
+	templateRepo    repositories.SpaceTemplateRepository
+	spaceRepo       repositories.SpaceRepository
+	roleRepo        repositories.SpaceRoleRepository
+	folderRepo      repositories.DocumentFolderRepository
+	documentRepo    repositories.DocumentRepository
+	apiKeyRepo      repositories.SpaceApiKeyRepository
+	blobRepo        repositories.BlobRepository
+	spaceService    SpaceService
+	documentService DocumentService
+	reindexService  ReindexService
+	storage         ObjectStorage
+}
+
+func NewSpaceCloneService(
+	templateRepo repositories.SpaceTemplateRepository,
+	spaceRepo repositories.SpaceRepository,
+	roleRepo repositories.SpaceRoleRepository,
+	folderRepo repositories.DocumentFolderRepository,
+	documentRepo repositories.DocumentRepository,
+	apiKeyRepo repositories.SpaceApiKeyRepository,
+	blobRepo repositories.BlobRepository,
+	spaceService SpaceService,
+	documentService DocumentService,
+	reindexService ReindexService,
+	storage ObjectStorage,
+) SpaceCloneService {
+	return &spaceCloneServiceImpl{
+		templateRepo:    templateRepo,
+		spaceRepo:       spaceRepo,
+		roleRepo:        roleRepo,
+		folderRepo:      folderRepo,
+		documentRepo:    documentRepo,
+		apiKeyRepo:      apiKeyRepo,
+		blobRepo:        blobRepo,
+		spaceService:    spaceService,
+		documentService: documentService,
+		reindexService:  reindexService,
+		storage:         storage,
+	}
+}
+
+// CloneSpace creates a space with the settings and custom roles of another
+// one, and optionally its documents, members and API keys. Members are
+// invited rather than added, so nobody joins the copy without agreeing to it
+// and owners come back as editors. The new space
+// counts towards the space limit of the user and every copied document goes
+// through the limits of the new space; documents that do not fit are skipped
+// and reported.
+func (s *spaceCloneServiceImpl) CloneSpace(spaceID, userID uint, req *dtos.CloneSpaceRequest) (*dtos.SpaceCloneResult, error) {
+	role, err := s.spaceRepo.GetUserRole(userID, spaceID)
+	if err != nil || role == nil || !role.HasPermission(entities.SpacePermissionManageSettings) {
+		return nil, errors.New("not allowed to clone this space")
+	}
+	if req.IncludeMembers && !role.HasPermission(entities.SpacePermissionManageMembers) {
+		return nil, errors.New("not allowed to clone the members of this space")
+	}
+	if req.IncludeAPIKeys && !role.HasPermission(entities.SpacePermissionManageAPIKeys) {
+		return nil, errors.New("not allowed to clone the API keys of this space")
+	}
+
+	source, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return nil, errors.New("space not found")
+	}
+
+	space := &entities.Space{
+		Name:              strings.TrimSpace(req.Name),
+		Description:       source.Description,
+		PrivacyStatus:     source.PrivacyStatus,
+		AllowJoinRequests: source.AllowJoinRequests,
+		SystemPrompt:      source.SystemPrompt,
+		DocumentLimit:     source.DocumentLimit,
+		FileSizeLimitKb:   source.FileSizeLimitKb,
+		ApiCallLimit:      source.ApiCallLimit,
+		AllowedFileTypes:  source.AllowedFileTypes,
+		StorageLimitBytes: source.StorageLimitBytes,
+	}
+	if space.Name == "" {
+		space.Name = source.Name + " (copy)"
+	}
+	if req.Description != nil {
+		space.Description = *req.Description
+	}
+
+	space, err = s.spaceService.CreateSpace(space, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Members and API keys are part of the request, a failure there removes
+	// the half-made space instead of returning it
+	if err := s.cloneSettings(source.ID, space.ID, userID, req); err != nil {
+		if purgeErr := s.spaceService.PurgeSpace(space.ID); purgeErr != nil {
+			log.Printf("Failed to remove space %d after a failed clone: %v", space.ID, purgeErr)
+		}
+		return nil, err
+	}
+
+	result := &dtos.SpaceCloneResult{Space: space, SkippedDocuments: []dtos.SkippedDocument{}}
+	if req.IncludeDocuments {
+		copied := s.cloneDocuments(source.ID, space.ID, userID, result)
+		s.startIndexing(result, copied, userID)
+	}
+	return result, nil
+}
+
+func (s *spaceCloneServiceImpl) cloneSettings(sourceID, spaceID, userID uint, req *dtos.CloneSpaceRequest) error {
+	roleIDs, err := s.cloneCustomRoles(sourceID, spaceID)
+	if err != nil {
+		return fmt.Errorf("failed to copy roles: %v", err)
+	}
+
+	if req.IncludeMembers {
+		members, err := s.spaceRepo.GetMembers(sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to copy members: %v", err)
+		}
+
+		for _, member := range members {
+			if member.UserID == userID || member.SpaceRoleID == nil {
+				continue
+			}
+			roleID := *member.SpaceRoleID
+			if mapped, ok := roleIDs[roleID]; ok {
+				roleID = mapped
+			}
+			// Ownership is only handed over through an ownership transfer
+			if roleID == entities.SpaceRoleOwner {
+				roleID = entities.SpaceRoleEditor
+			}
+			memberID := member.UserID
+			_, err := s.spaceService.CreateInvitation(&entities.SpaceInvitation{
+				SpaceID:       spaceID,
+				SpaceRoleID:   roleID,
+				InviterID:     userID,
+				InvitedUserID: &memberID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to invite members: %v", err)
+			}
+		}
+	}
+
+	if req.IncludeAPIKeys {
+		keys, err := s.apiKeyRepo.GetAllBySpaceID(sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to copy API keys: %v", err)
+		}
+		// The copies are new keys, the tokens of the source keys stay bound to it
+		for _, key := range keys {
+			_, err := s.apiKeyRepo.Create(&entities.SpaceAPIKey{
+				Name:        key.Name,
+				Description: key.Description,
+				SpaceID:     spaceID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to copy API keys: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// cloneCustomRoles copies the custom roles of a space and returns the ids of
+// the copies by the ids of the originals.
+func (s *spaceCloneServiceImpl) cloneCustomRoles(sourceID, spaceID uint) (map[uint]uint, error) {
+	roles, err := s.roleRepo.GetBySpaceID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIDs := map[uint]uint{}
+	for _, role := range roles {
+		if !role.IsCustom() {
+			continue
+		}
+		created, err := s.roleRepo.Create(&entities.SpaceRole{
+			SpaceID:    &spaceID,
+			Name:       role.Name,
+			Permission: role.Permission,
+		})
+		if err != nil {
+			return nil, err
+		}
+		roleIDs[role.ID] = created.ID
+	}
+	return roleIDs, nil
+}
+
+// cloneFolders copies the folder tree of a space, parents before their
+// children, and returns the ids of the copies by the ids of the originals.
+func (s *spaceCloneServiceImpl) cloneFolders(sourceID, spaceID uint) (map[uint]uint, error) {
+	folders, err := s.folderRepo.GetBySpaceID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	folderIDs := map[uint]uint{}
+	for len(folders) > 0 {
+		remaining := []entities.DocumentFolder{}
+		for _, folder := range folders {
+			var parentID *uint
+			if folder.ParentID != nil {
+				mapped, ok := folderIDs[*folder.ParentID]
+				if !ok {
+					remaining = append(remaining, folder)
+					continue
+				}
+				parentID = &mapped
+			}
+
+			created, err := s.folderRepo.Create(&entities.DocumentFolder{
+				SpaceID:  spaceID,
+				ParentID: parentID,
+				Name:     folder.Name,
+			})
+			if err != nil {
+				return nil, err
+			}
+			folderIDs[folder.ID] = created.ID
+		}
+
+		// Folders whose parent is gone cannot be placed
+		if len(remaining) == len(folders) {
+			break
+		}
+		folders = remaining
+	}
+	return folderIDs, nil
+}
+
+// cloneDocuments copies the documents of a space into its clone and returns
+// how many were copied. The copies share the stored files of the originals.
+func (s *spaceCloneServiceImpl) cloneDocuments(sourceID, spaceID, userID uint, result *dtos.SpaceCloneResult) int {
+	folderIDs, err := s.cloneFolders(sourceID, spaceID)
+	if err != nil {
+		log.Printf("Failed to copy the folders of space %d: %v", sourceID, err)
+	}
+
+	documentIDs, err := s.documentRepo.GetIDs(&sourceID)
+	if err != nil {
+		result.SkippedDocuments = append(result.SkippedDocuments, dtos.SkippedDocument{
+			Reason: fmt.Sprintf("failed to list documents: %v", err),
+		})
+		return 0
+	}
+
+	copied := 0
+	for _, documentID := range documentIDs {
+		document, err := s.documentRepo.GetById(documentID)
+		if err != nil {
+			continue
+		}
+
+		var folderID *uint
+		if document.FolderID != nil {
+			if mapped, ok := folderIDs[*document.FolderID]; ok {
+				folderID = &mapped
+			}
+		}
+
+		// Files stored before deduplication have no blob to share yet and are
+		// copied the long way, which also indexes them right away
+		if document.ContentHash == "" {
+			_, err = s.documentService.CopyDocumentToSpace(document.ID, spaceID, folderID, userID)
+		} else {
+			_, err = s.documentService.AddStoredDocument(&entities.Document{
+				SpaceID:       spaceID,
+				Name:          document.Name,
+				Description:   document.Description,
+				MimeType:      document.MimeType,
+				Size:          document.Size,
+				S3URL:         document.S3URL,
+				ContentHash:   document.ContentHash,
+				PrivacyStatus: document.PrivacyStatus,
+				FolderID:      folderID,
+				Tags:          document.Tags,
+				Metadata:      document.Metadata,
+				DocumentScan:  document.DocumentScan,
+				UploadedBy:    &userID,
+			})
+		}
+		if err != nil {
+			result.SkippedDocuments = append(result.SkippedDocuments, dtos.SkippedDocument{
+				DocumentID: document.ID,
+				Name:       document.Name,
+				Reason:     err.Error(),
+			})
+			continue
+		}
+		copied++
+	}
+	return copied
+}
+
+// startIndexing sends the copied documents to the RAG server in a background
+// job, whose progress the client can follow.
+func (s *spaceCloneServiceImpl) startIndexing(result *dtos.SpaceCloneResult, copied int, userID uint) {
+	if copied == 0 {
+		return
+	}
+	job, err := s.reindexService.StartJob(&result.Space.ID, nil, &userID)
+	if err != nil {
+		log.Printf("Failed to start indexing space %d: %v", result.Space.ID, err)
+		return
+	}
+	result.ReindexJob = job
+}
+
+// SaveTemplate saves the settings of a space, and optionally its documents,
+// as a template of the user. Template documents keep their files alive even
+// when the documents they were saved from are deleted.
+func (s *spaceCloneServiceImpl) SaveTemplate(spaceID, userID uint, req *dtos.SaveSpaceTemplateRequest) (*entities.SpaceTemplate, []dtos.SkippedDocument, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 255 {
+		return nil, nil, errors.New("invalid template: the name must be between 1 and 255 characters")
+	}
+
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return nil, nil, errors.New("space not found")
+	}
+
+	template := &entities.SpaceTemplate{
+		UserID:            userID,
+		Name:              name,
+		Description:       req.Description,
+		SpaceName:         space.Name,
+		SpaceDescription:  space.Description,
+		PrivacyStatus:     space.PrivacyStatus,
+		AllowJoinRequests: space.AllowJoinRequests,
+		SystemPrompt:      space.SystemPrompt,
+		DocumentLimit:     space.DocumentLimit,
+		FileSizeLimitKb:   space.FileSizeLimitKb,
+		ApiCallLimit:      space.ApiCallLimit,
+		AllowedFileTypes:  space.AllowedFileTypes,
+		StorageLimitBytes: space.StorageLimitBytes,
+		SourceSpaceID:     &space.ID,
+		Documents:         []entities.SpaceTemplateDocument{},
+	}
+
+	skipped := []dtos.SkippedDocument{}
+	if req.IncludeDocuments {
+		documentIDs, err := s.documentRepo.GetIDs(&spaceID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list documents: %v", err)
+		}
+
+		for _, documentID := range documentIDs {
+			document, err := s.documentRepo.GetById(documentID)
+			if err != nil {
+				continue
+			}
+			if document.ContentHash == "" {
+				skipped = append(skipped, dtos.SkippedDocument{
+					DocumentID: document.ID,
+					Name:       document.Name,
+					Reason:     "the file was stored before deduplication, upload it again to include it",
+				})
+				continue
+			}
+
+			blob, err := s.blobRepo.Acquire(document.ContentHash, document.S3URL, document.Size)
+			if err != nil {
+				s.releaseTemplateDocuments(template)
+				return nil, nil, fmt.Errorf("failed to store blob: %v", err)
+			}
+			template.Documents = append(template.Documents, entities.SpaceTemplateDocument{
+				Name:          document.Name,
+				Description:   document.Description,
+				MimeType:      document.MimeType,
+				Size:          document.Size,
+				S3URL:         blob.S3URL,
+				ContentHash:   blob.ContentHash,
+				PrivacyStatus: document.PrivacyStatus,
+				Tags:          document.Tags,
+				Metadata:      document.Metadata,
+			})
+		}
+	}
+
+	if _, err := s.templateRepo.Create(template); err != nil {
+		s.releaseTemplateDocuments(template)
+		return nil, nil, err
+	}
+	return template, skipped, nil
+}
+
+func (s *spaceCloneServiceImpl) GetTemplates(userID uint) ([]entities.SpaceTemplate, error) {
+	return s.templateRepo.GetByUserID(userID)
+}
+
+// GetTemplate returns a template of the user. Templates of other users do not
+// exist as far as the user can tell.
+func (s *spaceCloneServiceImpl) GetTemplate(templateID, userID uint) (*entities.SpaceTemplate, error) {
+	template, err := s.templateRepo.GetWithDocuments(templateID)
+	if err != nil || template.UserID != userID {
+		return nil, errors.New("template not found")
+	}
+	return template, nil
+}
+
+func (s *spaceCloneServiceImpl) DeleteTemplate(templateID, userID uint) error {
+	template, err := s.GetTemplate(templateID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.templateRepo.Delete(template.ID); err != nil {
+		return err
+	}
+	s.releaseTemplateDocuments(template)
+	return nil
+}
+
+// CreateSpaceFromTemplate creates a space with the settings and documents of
+// a template. The name and description of space replace those of the
+// template when they are set.
+func (s *spaceCloneServiceImpl) CreateSpaceFromTemplate(templateID, userID uint, space *entities.Space) (*dtos.SpaceCloneResult, error) {
+	template, err := s.GetTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	created := template.NewSpace()
+	if space != nil {
+		if name := strings.TrimSpace(space.Name); name != "" {
+			created.Name = name
+		}
+		if space.Description != "" {
+			created.Description = space.Description
+		}
+	}
+
+	created, err = s.spaceService.CreateSpace(created, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dtos.SpaceCloneResult{Space: created, SkippedDocuments: []dtos.SkippedDocument{}}
+	copied := 0
+	for _, document := range template.Documents {
+		if _, err := s.documentService.AddStoredDocument(document.NewDocument(created.ID, userID)); err != nil {
+			result.SkippedDocuments = append(result.SkippedDocuments, dtos.SkippedDocument{
+				Name:   document.Name,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		copied++
+	}
+
+	s.startIndexing(result, copied, userID)
+	return result, nil
+}
+
+// releaseTemplateDocuments gives up the references of the template on the
+// blobs of its documents, deleting files nothing refers to anymore.
+func (s *spaceCloneServiceImpl) releaseTemplateDocuments(template *entities.SpaceTemplate) {
+	for _, document := range template.Documents {
+		err := s.blobRepo.Release(document.ContentHash, func(blob *entities.Blob) error {
+			return s.storage.Delete(blob.S3URL)
+		})
+		if err != nil {
+			log.Printf("Failed to release blob %s: %v", document.ContentHash, err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"errors"
+
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
 )
@@ -11,11 +13,14 @@ type SpaceInvitationService interface {
 	RejectInvitation(invitationId uint, userId uint) error
 	CancelInvitation(spaceID uint, invitedUserID uint) error
 	CountInvitationByUserID(userID uint) (int64, error)
+	GetInvitationForUser(invitationID uint, userID uint) (*entities.SpaceInvitation, error)
+	DeleteInvitation(invitationID uint, userID uint) error
 }
 
 type spaceInvitationServiceImpl struct {
 	CrudService[entities.SpaceInvitation, uint]
-	repo repositories.SpaceInvitationRepository
+	repo      repositories.SpaceInvitationRepository
+	spaceRepo repositories.SpaceRepository
 }
 
 func NewSpaceInvitationService() SpaceInvitationService {
@@ -24,7 +29,41 @@ func NewSpaceInvitationService() SpaceInvitationService {
 	return &spaceInvitationServiceImpl{
 		CrudService: *crudService,
 		repo:        repo,
+		spaceRepo:   repositories.NewSpaceRepository(),
+	}
+}
+
+// GetInvitationForUser returns the invitation to the invited user, the inviter
+// and the members allowed to manage the members of the space. Anyone else is told it does not exist.
+func (s *spaceInvitationServiceImpl) GetInvitationForUser(invitationID uint, userID uint) (*entities.SpaceInvitation, error) {
+	invitation, err := s.repo.GetById(invitationID)
+	if err != nil {
+		return nil, errors.New("invitation not found")
 	}
+
+	if invitation.IsInvited(userID) || invitation.InviterID == userID || s.canManageMembers(userID, invitation.SpaceID) {
+		return invitation, nil
+	}
+	return nil, errors.New("invitation not found")
+}
+
+// DeleteInvitation withdraws an invitation, which only the inviter and the
+// members allowed to manage the members of the space may do. The invited user rejects it instead.
+func (s *spaceInvitationServiceImpl) DeleteInvitation(invitationID uint, userID uint) error {
+	invitation, err := s.GetInvitationForUser(invitationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if invitation.InviterID != userID && !s.canManageMembers(userID, invitation.SpaceID) {
+		return errors.New("not allowed to cancel this invitation")
+	}
+	return s.repo.Delete(invitation.ID)
+}
+
+func (s *spaceInvitationServiceImpl) canManageMembers(userID uint, spaceID uint) bool {
+	role, err := s.spaceRepo.GetUserRole(userID, spaceID)
+	return err == nil && role != nil && role.HasPermission(entities.SpacePermissionManageMembers)
 }
 
 func (s *spaceInvitationServiceImpl) AcceptInvitation(invitationId uint, userId uint) (uint, error) {
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+)
+
+const (
+	defaultJoinRequestMaxPending        = 5
+	defaultJoinRequestMaxPerDay         = 10
+	defaultJoinRequestRejectionCooldown = 24 * time.Hour
+	maxJoinRequestMessageLength         = 1000
+)
+
+type SpaceJoinRequestService interface {
+	SubmitRequest(spaceID, userID uint, message string) (*entities.SpaceJoinRequest, error)
+	GetSpaceRequests(spaceID uint, status string) ([]entities.SpaceJoinRequest, error)
+	GetMyRequests(userID uint) ([]entities.SpaceJoinRequest, error)
+	ApproveRequest(spaceID, requestID, reviewerID, roleID uint) (*entities.SpaceJoinRequest, error)
+	RejectRequest(spaceID, requestID, reviewerID uint) error
+	CancelRequest(requestID, userID uint) error
+}
+
+type spaceJoinRequestServiceImpl struct {
+	repo      repositories.SpaceJoinRequestRepository
+	spaceRepo repositories.SpaceRepository
+	roleRepo  repositories.SpaceRoleRepository
+	userRepo  repositories.UserRepository
+	mailer    Mailer
+}
+
+func NewSpaceJoinRequestService(
+	repo repositories.SpaceJoinRequestRepository,
+	spaceRepo repositories.SpaceRepository,
+	roleRepo repositories.SpaceRoleRepository,
+	userRepo repositories.UserRepository,
+	mailer Mailer,
+) SpaceJoinRequestService {
+	return &spaceJoinRequestServiceImpl{
+		repo:      repo,
+		spaceRepo: spaceRepo,
+		roleRepo:  roleRepo,
+		userRepo:  userRepo,
+		mailer:    mailer,
+	}
+}
+
+// SubmitRequest asks to join a private space that accepts requests. The
+// members allowed to manage members are notified by email.
+func (s *spaceJoinRequestServiceImpl) SubmitRequest(spaceID, userID uint, message string) (*entities.SpaceJoinRequest, error) {
+	message = strings.TrimSpace(message)
+	if len(message) > maxJoinRequestMessageLength {
+		return nil, fmt.Errorf("invalid request: the message must be at most %d characters", maxJoinRequestMessageLength)
+	}
+
+	space, err := s.spaceRepo.GetById(spaceID)
+	if err != nil {
+		return nil, errors.New("space not found")
+	}
+	if !space.PrivacyStatus {
+		return nil, errors.New("space is public, join it directly")
+	}
+	if !space.AllowJoinRequests {
+		return nil, errors.New("space does not accept join requests")
+	}
+
+	isMember, err := s.spaceRepo.IsMemberOfSpace(userID, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return nil, errors.New("user is already a member of this space")
+	}
+
+	pending, err := s.repo.HasPending(spaceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending requests: %v", err)
+	}
+	if pending {
+		return nil, errors.New("a join request to this space is already pending")
+	}
+
+	if err := s.checkRateLimit(spaceID, userID); err != nil {
+		return nil, err
+	}
+
+	request, err := s.repo.Create(&entities.SpaceJoinRequest{
+		SpaceID: spaceID,
+		UserID:  userID,
+		Message: message,
+		Status:  entities.JoinRequestPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyManagers(space, request)
+	return request, nil
+}
+
+// checkRateLimit keeps a user from flooding owners with requests: the number
+// of open and recent requests is capped, and a rejected user has to wait
+// before asking the same space again.
+func (s *spaceJoinRequestServiceImpl) checkRateLimit(spaceID, userID uint) error {
+	config := configs.GetEnv().JoinRequest
+	maxPending := config.MaxPending
+	if maxPending <= 0 {
+		maxPending = defaultJoinRequestMaxPending
+	}
+	maxPerDay := config.MaxPerDay
+	if maxPerDay <= 0 {
+		maxPerDay = defaultJoinRequestMaxPerDay
+	}
+	cooldown := defaultJoinRequestRejectionCooldown
+	if config.RejectionCooldownHours > 0 {
+		cooldown = time.Duration(config.RejectionCooldownHours) * time.Hour
+	}
+
+	pending, err := s.repo.CountPendingByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if pending >= int64(maxPending) {
+		return errors.New("too many pending join requests, wait for an answer first")
+	}
+
+	now := time.Now()
+	recent, err := s.repo.CountCreatedSince(userID, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent >= int64(maxPerDay) {
+		return errors.New("too many join requests, try again later")
+	}
+
+	rejectedAt, err := s.repo.GetLastRejectedAt(spaceID, userID)
+	if err != nil {
+		return err
+	}
+	if rejectedAt != nil && now.Before(rejectedAt.Add(cooldown)) {
+		return errors.New("too many join requests: your last request to this space was rejected recently, try again later")
+	}
+	return nil
+}
+
+func (s *spaceJoinRequestServiceImpl) GetSpaceRequests(spaceID uint, status string) ([]entities.SpaceJoinRequest, error) {
+	switch status {
+	case "", entities.JoinRequestPending, entities.JoinRequestApproved, entities.JoinRequestRejected, entities.JoinRequestCancelled:
+	default:
+		return nil, fmt.Errorf("invalid request: unknown status %q", status)
+	}
+	return s.repo.GetBySpaceID(spaceID, status)
+}
+
+func (s *spaceJoinRequestServiceImpl) GetMyRequests(userID uint) ([]entities.SpaceJoinRequest, error) {
+	return s.repo.GetByUserID(userID)
+}
+
+// ApproveRequest adds the requester with the role, the viewer role when none
+// is given. The role is checked like the role of an invitation.
+func (s *spaceJoinRequestServiceImpl) ApproveRequest(spaceID, requestID, reviewerID, roleID uint) (*entities.SpaceJoinRequest, error) {
+	if _, err := s.getRequest(spaceID, requestID); err != nil {
+		return nil, err
+	}
+	if roleID == 0 {
+		roleID = entities.SpaceRoleViewer
+	}
+	if err := validateGrantedRole(s.roleRepo, s.spaceRepo, spaceID, roleID, reviewerID); err != nil {
+		return nil, err
+	}
+
+	request, err := s.repo.Approve(requestID, roleID, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyRequester(request)
+	return request, nil
+}
+
+func (s *spaceJoinRequestServiceImpl) RejectRequest(spaceID, requestID, reviewerID uint) error {
+	request, err := s.getRequest(spaceID, requestID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Close(requestID, entities.JoinRequestRejected, reviewerID); err != nil {
+		return err
+	}
+
+	request.Status = entities.JoinRequestRejected
+	s.notifyRequester(request)
+	return nil
+}
+
+// CancelRequest withdraws the user's own request.
+func (s *spaceJoinRequestServiceImpl) CancelRequest(requestID, userID uint) error {
+	request, err := s.repo.GetById(requestID)
+	if err != nil || request.UserID != userID {
+		return errors.New("join request not found")
+	}
+	return s.repo.Close(requestID, entities.JoinRequestCancelled, userID)
+}
+
+func (s *spaceJoinRequestServiceImpl) getRequest(spaceID, requestID uint) (*entities.SpaceJoinRequest, error) {
+	request, err := s.repo.GetById(requestID)
+	if err != nil || request.SpaceID != spaceID {
+		return nil, errors.New("join request not found")
+	}
+	return request, nil
+}
+
+// notifyManagers emails the members allowed to manage members about a new
+// request. Failures are only logged, the request stays visible in the space.
+func (s *spaceJoinRequestServiceImpl) notifyManagers(space *entities.Space, request *entities.SpaceJoinRequest) {
+	members, err := s.spaceRepo.GetMembers(space.ID)
+	if err != nil {
+		log.Printf("Failed to notify about join request %d: %v", request.ID, err)
+		return
+	}
+
+	requesterName := "Someone"
+	if requester, err := s.userRepo.GetById(request.UserID); err == nil {
+		requesterName = requester.Username
+	}
+
+	body := fmt.Sprintf("%s asked to join the space \"%s\".\n\n", requesterName, space.Name)
+	if request.Message != "" {
+		body += request.Message + "\n\n"
+	}
+	body += fmt.Sprintf("Review the request: %s/spaces/%d/join-requests\n", configs.GetEnv().WebClientURL, space.ID)
+
+	for _, member := range members {
+		if member.SpaceRoleID == nil || !member.SpaceRole.HasPermission(entities.SpacePermissionManageMembers) {
+			continue
+		}
+		if member.User.Email == nil {
+			continue
+		}
+		err := s.mailer.Send(&Mail{
+			To:      *member.User.Email,
+			Subject: fmt.Sprintf("New request to join %s", space.Name),
+			Body:    body,
+		})
+		if err != nil {
+			log.Printf("Failed to notify user %d about join request %d: %v", member.UserID, request.ID, err)
+		}
+	}
+}
+
+// notifyRequester emails the requester the decision on the request.
+func (s *spaceJoinRequestServiceImpl) notifyRequester(request *entities.SpaceJoinRequest) {
+	requester, err := s.userRepo.GetById(request.UserID)
+	if err != nil || requester.Email == nil {
+		return
+	}
+	space, err := s.spaceRepo.GetById(request.SpaceID)
+	if err != nil {
+		return
+	}
+
+	subject := fmt.Sprintf("Your request to join %s was rejected", space.Name)
+	body := fmt.Sprintf("Your request to join the space \"%s\" was rejected.\n", space.Name)
+	if request.Status == entities.JoinRequestApproved {
+		subject = fmt.Sprintf("Your request to join %s was approved", space.Name)
+		body = fmt.Sprintf("Your request to join the space \"%s\" was approved.\n\nOpen the space: %s/spaces/%d\n",
+			space.Name, configs.GetEnv().WebClientURL, space.ID)
+	}
+
+	if err := s.mailer.Send(&Mail{To: *requester.Email, Subject: subject, Body: body}); err != nil {
+		log.Printf("Failed to notify user %d about join request %d: %v", request.UserID, request.ID, err)
+	}
+}
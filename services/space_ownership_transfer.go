@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+)
+
+type SpaceOwnershipTransferService interface {
+	ProposeTransfer(spaceID, fromUserID, toUserID uint, keepOwnership bool) (*entities.SpaceOwnershipTransfer, error)
+	GetPendingTransfers(spaceID uint, userID uint) ([]entities.SpaceOwnershipTransfer, error)
+	GetMyTransfers(userID uint) ([]entities.SpaceOwnershipTransfer, error)
+	AcceptTransfer(transferID uint, userID uint) (*entities.SpaceOwnershipTransfer, error)
+	DeclineTransfer(transferID uint, userID uint) error
+	CancelTransfer(transferID uint, userID uint) error
+}
+
+type spaceOwnershipTransferServiceImpl struct {
+	repo      repositories.SpaceOwnershipTransferRepository
+	spaceRepo repositories.SpaceRepository
+}
+
+func NewSpaceOwnershipTransferService(
+	repo repositories.SpaceOwnershipTransferRepository,
+	spaceRepo repositories.SpaceRepository,
+) SpaceOwnershipTransferService {
+	return &spaceOwnershipTransferServiceImpl{
+		repo:      repo,
+		spaceRepo: spaceRepo,
+	}
+}
+
+// ProposeTransfer is the first step of a transfer, the target member has to
+// accept it before anything changes.
+func (s *spaceOwnershipTransferServiceImpl) ProposeTransfer(spaceID, fromUserID, toUserID uint, keepOwnership bool) (*entities.SpaceOwnershipTransfer, error) {
+	if err := s.requireOwner(spaceID, fromUserID); err != nil {
+		return nil, err
+	}
+	if toUserID == fromUserID {
+		return nil, errors.New("invalid target: you cannot transfer ownership to yourself")
+	}
+
+	role, err := s.spaceRepo.GetUserRole(toUserID, spaceID)
+	if err != nil {
+		return nil, errors.New("invalid target: the user is not a member of this space")
+	}
+	if role != nil && role.IsOwner() {
+		return nil, errors.New("invalid target: the user is already an owner of this space")
+	}
+
+	pending, err := s.repo.HasPending(spaceID, toUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending transfers: %v", err)
+	}
+	if pending {
+		return nil, errors.New("a transfer to this member is already pending")
+	}
+
+	return s.repo.Create(&entities.SpaceOwnershipTransfer{
+		SpaceID:       spaceID,
+		FromUserID:    fromUserID,
+		ToUserID:      toUserID,
+		KeepOwnership: keepOwnership,
+		Status:        entities.OwnershipTransferPending,
+	})
+}
+
+func (s *spaceOwnershipTransferServiceImpl) GetPendingTransfers(spaceID uint, userID uint) ([]entities.SpaceOwnershipTransfer, error) {
+	if err := s.requireOwner(spaceID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPendingBySpaceID(spaceID)
+}
+
+func (s *spaceOwnershipTransferServiceImpl) GetMyTransfers(userID uint) ([]entities.SpaceOwnershipTransfer, error) {
+	return s.repo.GetPendingForUser(userID)
+}
+
+func (s *spaceOwnershipTransferServiceImpl) AcceptTransfer(transferID uint, userID uint) (*entities.SpaceOwnershipTransfer, error) {
+	if _, err := s.getTransferTo(transferID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.Accept(transferID)
+}
+
+func (s *spaceOwnershipTransferServiceImpl) DeclineTransfer(transferID uint, userID uint) error {
+	if _, err := s.getTransferTo(transferID, userID); err != nil {
+		return err
+	}
+	return s.repo.SetStatus(transferID, entities.OwnershipTransferDeclined)
+}
+
+// CancelTransfer withdraws a proposal, which the proposer and the other
+// owners of the space may do.
+func (s *spaceOwnershipTransferServiceImpl) CancelTransfer(transferID uint, userID uint) error {
+	transfer, err := s.repo.GetById(transferID)
+	if err != nil {
+		return errors.New("transfer not found")
+	}
+	if transfer.FromUserID != userID {
+		if err := s.requireOwner(transfer.SpaceID, userID); err != nil {
+			return errors.New("transfer not found")
+		}
+	}
+	return s.repo.SetStatus(transferID, entities.OwnershipTransferCancelled)
+}
+
+// getTransferTo hides transfers addressed to someone else.
+func (s *spaceOwnershipTransferServiceImpl) getTransferTo(transferID uint, userID uint) (*entities.SpaceOwnershipTransfer, error) {
+	transfer, err := s.repo.GetById(transferID)
+	if err != nil || transfer.ToUserID != userID {
+		return nil, errors.New("transfer not found")
+	}
+	return transfer, nil
+}
+
+func (s *spaceOwnershipTransferServiceImpl) requireOwner(spaceID uint, userID uint) error {
+	isOwner, err := s.spaceRepo.IsOwner(userID, spaceID)
+	if err != nil {
+		return fmt.Errorf("failed to check ownership: %v", err)
+	}
+	if !isOwner {
+		return errors.New("only owners of the space can transfer ownership")
+	}
+	return nil
+}
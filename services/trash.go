@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+)
+
+const (
+	defaultTrashRetentionDays = 30
+	defaultTrashPurgeInterval = time.Hour
+)
+
+type TrashService interface {
+	GetUserTrash(userID uint) (*dtos.TrashListing, error)
+	GetSpaceTrash(spaceID uint) ([]entities.Document, error)
+	RestoreDocument(documentID uint, userID uint) (*entities.Document, error)
+	RestoreSpace(spaceID uint, userID uint) (*entities.Space, *entities.ReindexJob, error)
+	RestoreSession(sessionID uint, userID uint) (*entities.UserQuerySession, error)
+	PurgeExpired()
+	StartPurgeRoutine(interval time.Duration)
+}
+
+type trashServiceImpl struct {
+	documentRepo    repositories.DocumentRepository
+	spaceRepo       repositories.SpaceRepository
+	sessionRepo     repositories.UserQuerySessionRepository
+	documentService DocumentService
+	spaceService    SpaceService
+	sessionService  UserQuerySessionService
+	reindexService  ReindexService
+}
+
+func NewTrashService(
+	documentRepo repositories.DocumentRepository,
+	spaceRepo repositories.SpaceRepository,
+	sessionRepo repositories.UserQuerySessionRepository,
+	documentService DocumentService,
+	spaceService SpaceService,
+	sessionService UserQuerySessionService,
+	reindexService ReindexService,
+) TrashService {
+	return &trashServiceImpl{
+		documentRepo:    documentRepo,
+		spaceRepo:       spaceRepo,
+		sessionRepo:     sessionRepo,
+		documentService: documentService,
+		spaceService:    spaceService,
+		sessionService:  sessionService,
+		reindexService:  reindexService,
+	}
+}
+
+// GetUserTrash lists what a user deleted: documents they moved to the trash,
+// spaces they own and their chat sessions.
+func (s *trashServiceImpl) GetUserTrash(userID uint) (*dtos.TrashListing, error) {
+	documents, err := s.documentService.GetDeletedDocuments(nil, &userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted documents: %v", err)
+	}
+
+	spaces, err := s.spaceService.GetDeletedSpaces(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted spaces: %v", err)
+	}
+
+	sessions, err := s.sessionService.GetDeletedSessions(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted sessions: %v", err)
+	}
+
+	return &dtos.TrashListing{
+		Documents: documents,
+		Spaces:    spaces,
+		Sessions:  sessions,
+	}, nil
+}
+
+func (s *trashServiceImpl) GetSpaceTrash(spaceID uint) ([]entities.Document, error) {
+	return s.documentService.GetDeletedDocuments(&spaceID, nil)
+}
+
+// RestoreDocument is open to members allowed to delete documents in the space
+// the document was deleted from.
+func (s *trashServiceImpl) RestoreDocument(documentID uint, userID uint) (*entities.Document, error) {
+	document, err := s.documentRepo.GetDeletedById(documentID)
+	if err != nil {
+		return nil, errors.New("document not found in trash")
+	}
+
+	role, err := s.spaceService.GetUserRole(userID, document.SpaceID)
+	if err != nil {
+		return nil, errors.New("document not found in trash")
+	}
+	if !role.HasPermission(entities.SpacePermissionDeleteDocuments) {
+		return nil, errors.New("not allowed to restore this document")
+	}
+
+	return s.documentService.RestoreDocument(documentID)
+}
+
+// RestoreSpace brings a space back for its owner and starts a reindex job,
+// since the index of the space was dropped when it was deleted.
+func (s *trashServiceImpl) RestoreSpace(spaceID uint, userID uint) (*entities.Space, *entities.ReindexJob, error) {
+	isOwner, err := s.spaceRepo.IsOwner(userID, spaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isOwner {
+		return nil, nil, errors.New("space not found in trash")
+	}
+
+	space, err := s.spaceService.RestoreSpace(spaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	job, err := s.reindexService.StartJob(&spaceID, nil, &userID)
+	if err != nil {
+		return space, nil, fmt.Errorf("space restored but reindex failed to start: %v", err)
+	}
+
+	return space, job, nil
+}
+
+func (s *trashServiceImpl) RestoreSession(sessionID uint, userID uint) (*entities.UserQuerySession, error) {
+	return s.sessionService.RestoreSession(sessionID, userID)
+}
+
+// PurgeExpired deletes everything that stayed in the trash longer than the
+// retention period, together with the stored files.
+func (s *trashServiceImpl) PurgeExpired() {
+	config := configs.GetEnv().Trash
+	retention := time.Duration(valueOrDefault(config.RetentionDays, defaultTrashRetentionDays)) * 24 * time.Hour
+	cutoff := time.Now().Add(-retention)
+
+	documentIDs, err := s.documentRepo.GetIDsDeletedBefore(cutoff)
+	if err != nil {
+		log.Printf("Failed to get expired documents in trash: %v", err)
+	}
+	for _, documentID := range documentIDs {
+		if err := s.documentService.PurgeDocument(documentID); err != nil {
+			log.Printf("Failed to purge document %d: %v", documentID, err)
+		}
+	}
+
+	spaceIDs, err := s.spaceRepo.GetIDsDeletedBefore(cutoff)
+	if err != nil {
+		log.Printf("Failed to get expired spaces in trash: %v", err)
+	}
+	for _, spaceID := range spaceIDs {
+		if err := s.spaceService.PurgeSpace(spaceID); err != nil {
+			log.Printf("Failed to purge space %d: %v", spaceID, err)
+		}
+	}
+
+	sessionIDs, err := s.sessionRepo.GetIDsDeletedBefore(cutoff)
+	if err != nil {
+		log.Printf("Failed to get expired sessions in trash: %v", err)
+	}
+	for _, sessionID := range sessionIDs {
+		if err := s.sessionService.PurgeSession(sessionID); err != nil {
+			log.Printf("Failed to purge session %d: %v", sessionID, err)
+		}
+	}
+}
+
+func (s *trashServiceImpl) StartPurgeRoutine(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultTrashPurgeInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.PurgeExpired()
+		}
+	}()
+}
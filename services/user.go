@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/BlenDMinh/dutgrad-server/databases"
 	"github.com/BlenDMinh/dutgrad-server/databases/entities"
@@ -22,7 +23,8 @@ type UserService interface {
 
 type userServiceImpl struct {
 	CrudService[entities.User, uint]
-	repo repositories.UserRepository
+	repo      repositories.UserRepository
+	spaceRepo repositories.SpaceRepository
 }
 
 func NewUserService() UserService {
@@ -32,9 +34,29 @@ func NewUserService() UserService {
 	return &userServiceImpl{
 		CrudService: *crudService,
 		repo:        repo,
+		spaceRepo:   repositories.NewSpaceRepository(),
 	}
 }
 
+// Delete refuses to delete the last owner of a space, the space would be left
+// without anyone to manage it. Ownership has to be transferred, or the space
+// deleted, first.
+func (s *userServiceImpl) Delete(id uint) error {
+	spaces, err := s.spaceRepo.GetSpacesWhereLastOwner(id)
+	if err != nil {
+		return fmt.Errorf("failed to check owned spaces: %v", err)
+	}
+	if len(spaces) > 0 {
+		names := make([]string, 0, len(spaces))
+		for _, space := range spaces {
+			names = append(names, fmt.Sprintf("%q", space.Name))
+		}
+		return fmt.Errorf("you are the last owner of %s, transfer ownership or delete them first", strings.Join(names, ", "))
+	}
+
+	return s.repo.Delete(id)
+}
+
 func (s *userServiceImpl) GetSpacesByUserId(userId uint) ([]dtos.UserSpaceDTO, error) {
 	return s.repo.GetSpacesByUserId(userId)
 }
@@ -14,6 +14,9 @@ type UserQuerySessionService interface {
 	GetTempMessageByID(id uint) (*string, error)
 	GetChatHistoryBySessionID(sessionID uint, userID uint) ([]map[string]interface{}, error)
 	ClearChatHistoryBySessionID(sessionID uint, userID uint) error
+	GetDeletedSessions(userID uint) ([]entities.UserQuerySession, error)
+	RestoreSession(sessionID uint, userID uint) (*entities.UserQuerySession, error)
+	PurgeSession(sessionID uint) error
 }
 
 type UserQuerySessionServiceImpl struct {
@@ -57,5 +60,26 @@ func (s *UserQuerySessionServiceImpl) ClearChatHistoryBySessionID(sessionID uint
 		return fmt.Errorf("unauthorized: you can only clear chat history for your own sessions")
 	}
 
-	return s.repo.ClearChatHistory(sessionID)
+	// The history is kept in the trash until the session is purged
+	return s.repo.Delete(sessionID)
+}
+
+func (s *UserQuerySessionServiceImpl) GetDeletedSessions(userID uint) ([]entities.UserQuerySession, error) {
+	return s.repo.GetDeletedByUserID(userID)
+}
+
+func (s *UserQuerySessionServiceImpl) RestoreSession(sessionID uint, userID uint) (*entities.UserQuerySession, error) {
+	session, err := s.repo.GetDeletedById(sessionID)
+	if err != nil || session.UserID == nil || *session.UserID != userID {
+		return nil, fmt.Errorf("session not found in trash")
+	}
+
+	if err := s.repo.Restore(sessionID); err != nil {
+		return nil, err
+	}
+	return s.GetById(sessionID)
+}
+
+func (s *UserQuerySessionServiceImpl) PurgeSession(sessionID uint) error {
+	return s.repo.Purge(sessionID)
 }
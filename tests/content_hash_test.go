@@ -0,0 +1,26 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadFileContentHash(t *testing.T) {
+	t.Run("✅ Cùng nội dung cho cùng mã băm", func(t *testing.T) {
+		first, err := helpers.NewUploadFileFromBytes("a.txt", []byte("hello")).ContentHash()
+		assert.NoError(t, err)
+		second, err := helpers.NewUploadFileFromBytes("b.txt", []byte("hello")).ContentHash()
+		assert.NoError(t, err)
+
+		assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", first)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("✅ Nội dung khác cho mã băm khác", func(t *testing.T) {
+		first, _ := helpers.NewUploadFileFromBytes("a.txt", []byte("hello")).ContentHash()
+		second, _ := helpers.NewUploadFileFromBytes("a.txt", []byte("hello!")).ContentHash()
+		assert.NotEqual(t, first, second)
+	})
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var testDatabaseCount atomic.Int64
+
+// setupTestDatabase points the repositories at a fresh in-memory SQLite
+// database with the tables of documents and their spaces.
+func setupTestDatabase(t *testing.T) *gorm.DB {
+	dsn := fmt.Sprintf("file:test%d?mode=memory&cache=shared", testDatabaseCount.Add(1))
+	configs.GetEnv().MasterDBs = []configs.MasterDBConfig{{Driver: "sqlite", DSN: dsn}}
+	databases.Init()
+	t.Cleanup(databases.Close)
+
+	db := databases.GetDB()
+	registerPostgresFunctions(t, db)
+	err := db.AutoMigrate(
+		&entities.User{},
+		&entities.Space{},
+		&entities.SpaceRole{},
+		&entities.SpaceUser{},
+		&entities.DocumentFolder{},
+		&entities.Document{},
+		&entities.DocumentVersion{},
+		&entities.Blob{},
+	)
+	assert.NoError(t, err)
+	return db
+}
+
+// registerPostgresFunctions adds the Postgres functions used by the
+// repositories that SQLite lacks. Functions live on a connection, so the pool
+// is limited to the one they are registered on.
+func registerPostgresFunctions(t *testing.T, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	conn, err := sqlDB.Conn(context.Background())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		return driverConn.(*sqlite3.SQLiteConn).RegisterFunc("greatest", func(a, b int64) int64 {
+			return max(a, b)
+		}, true)
+	})
+	assert.NoError(t, err)
+}
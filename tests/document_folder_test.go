@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupDocumentFolderRouter(fixture *documentServiceFixture) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	service := services.NewDocumentFolderService(&fakeStoreFolderRepo{store: fixture.store}, fixture.repo, fixture.service)
+	controller := controllers.NewDocumentFolderController(service)
+
+	spaces := r.Group("/spaces", func(ctx *gin.Context) {
+		ctx.Set("user_id", uint(testEditorID))
+	})
+	spaces.DELETE("/:id/folders/:folderId", controller.DeleteFolder)
+	return r
+}
+
+func (f *documentServiceFixture) addFolder(spaceID uint, parentID *uint, name string) uint {
+	id := f.store.newID()
+	f.store.folders[id] = &entities.DocumentFolder{ID: id, SpaceID: spaceID, ParentID: parentID, Name: name}
+	return id
+}
+
+func (f *documentServiceFixture) uploadToFolder(t *testing.T, spaceID uint, folderID uint, name string, content string) *entities.Document {
+	document, err := f.service.UploadDocumentFile(helpers.NewUploadFileFromBytes(name, []byte(content)), spaceID, testEditorID, "", dtos.DocumentUploadOptions{FolderID: &folderID})
+	assert.NoError(t, err)
+	return document
+}
+
+func deleteFolder(router *gin.Engine, spaceID uint, folderID uint) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/spaces/%d/folders/%d?recursive=true", spaceID, folderID), nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeleteFolderRecursive(t *testing.T) {
+	t.Run("✅ Xóa thư mục cùng tài liệu trong các thư mục con", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		router := setupDocumentFolderRouter(fixture)
+
+		lectures := fixture.addFolder(testPrivateSpaceID, nil, "Lectures")
+		week1 := fixture.addFolder(testPrivateSpaceID, &lectures, "Week 1")
+		outline := fixture.uploadToFolder(t, testPrivateSpaceID, lectures, "outline.txt", "course outline")
+		notes := fixture.uploadToFolder(t, testPrivateSpaceID, week1, "notes.txt", "week one notes")
+		syllabus := fixture.upload(t, testPrivateSpaceID, "syllabus.txt", "grading policy")
+
+		w := deleteFolder(router, testPrivateSpaceID, lectures)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.NotContains(t, fixture.store.folders, lectures)
+		assert.NotContains(t, fixture.store.folders, week1)
+		for _, document := range []*entities.Document{outline, notes} {
+			assert.True(t, fixture.store.documents[document.ID].DeletedAt.Valid)
+			assert.Equal(t, uint(testEditorID), *fixture.store.documents[document.ID].DeletedBy)
+			assert.False(t, fixture.rag.isIndexed(document.ID))
+		}
+
+		// Documents outside the folder keep their index and their storage
+		assert.False(t, fixture.store.documents[syllabus.ID].DeletedAt.Valid)
+		assert.True(t, fixture.rag.isIndexed(syllabus.ID))
+		assert.Equal(t, syllabus.Size, fixture.store.spaces[testPrivateSpaceID].StorageUsedBytes)
+	})
+
+	t.Run("❌ Không xóa thư mục của không gian khác", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		router := setupDocumentFolderRouter(fixture)
+
+		other := fixture.addFolder(testPublicSpaceID, nil, "Exercises")
+		document := fixture.uploadToFolder(t, testPublicSpaceID, other, "exercise.txt", "exercise one")
+
+		w := deleteFolder(router, testPrivateSpaceID, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		assert.Contains(t, fixture.store.folders, other)
+		assert.False(t, fixture.store.documents[document.ID].DeletedAt.Valid)
+		assert.True(t, fixture.rag.isIndexed(document.ID))
+		assert.Equal(t, document.Size, fixture.store.spaces[testPublicSpaceID].StorageUsedBytes)
+	})
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+const englishSample = "The course covers the basics of calculus and the applications of derivatives. " +
+	"It is designed for students that want to understand how the theory is used in practice, " +
+	"with weekly exercises and a final project on the topic of optimization."
+
+const vietnameseSample = "Môn học này giới thiệu các khái niệm cơ bản của giải tích và những ứng dụng của đạo hàm. " +
+	"Sinh viên được hướng dẫn cách sử dụng lý thuyết trong thực tế, với các bài tập hàng tuần và một đồ án cuối kỳ " +
+	"cho người học có nhu cầu tìm hiểu sâu hơn về tối ưu hóa."
+
+func createTestPDF(t *testing.T) []byte {
+	var content bytes.Buffer
+	writer := zlib.NewWriter(&content)
+	writer.Write([]byte("BT /F1 12 Tf 72 712 Td (Hello calculus students) Tj ET\nBT (Week \\(1\\) notes) Tj ET"))
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 5 0 R >> endobj\n")
+	pdf.WriteString("4 0 obj << /Type /Page /Parent 2 0 R >> endobj\n")
+	pdf.WriteString(fmt.Sprintf("5 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", content.Len()))
+	pdf.Write(content.Bytes())
+	pdf.WriteString("\nendstream endobj\n")
+	pdf.WriteString("6 0 obj << /Title (Calculus \\(Part 1\\)) /Author <FEFF004E0067007500791EC5006E> >> endobj\n")
+	pdf.WriteString("trailer << /Root 1 0 R /Info 6 0 R >>\n%%EOF")
+	return pdf.Bytes()
+}
+
+func TestExtractFileMetadata(t *testing.T) {
+	t.Run("✅ Trích xuất siêu dữ liệu PDF", func(t *testing.T) {
+		metadata, err := helpers.ExtractFileMetadata("calculus.pdf", "application/pdf", createTestPDF(t))
+		assert.NoError(t, err)
+		assert.Equal(t, "Calculus (Part 1)", metadata.Title)
+		assert.Equal(t, "Nguyễn", metadata.Author)
+		assert.Equal(t, 2, metadata.PageCount)
+		assert.Equal(t, 6, metadata.WordCount)
+	})
+
+	t.Run("✅ Trích xuất siêu dữ liệu DOCX", func(t *testing.T) {
+		data := createTestZip(t, []zipTestFile{
+			{Name: "docProps/core.xml", Content: []byte(`<?xml version="1.0"?><cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Syllabus</dc:title><dc:creator>Trần Văn A</dc:creator></cp:coreProperties>`)},
+			{Name: "docProps/app.xml", Content: []byte(`<?xml version="1.0"?><Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"><Pages>3</Pages></Properties>`)},
+			{Name: "word/document.xml", Content: []byte(`<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>` + englishSample + `</w:t></w:r></w:p></w:body></w:document>`)},
+		})
+
+		metadata, err := helpers.ExtractFileMetadata("syllabus.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", data)
+		assert.NoError(t, err)
+		assert.Equal(t, "Syllabus", metadata.Title)
+		assert.Equal(t, "Trần Văn A", metadata.Author)
+		assert.Equal(t, 3, metadata.PageCount)
+		assert.Equal(t, helpers.CountWords(englishSample), metadata.WordCount)
+		assert.Equal(t, "en", metadata.Language)
+	})
+
+	t.Run("✅ Đếm số slide và số sheet", func(t *testing.T) {
+		pptx := createTestZip(t, []zipTestFile{
+			{Name: "ppt/slides/slide1.xml", Content: []byte(`<p:sld xmlns:p="p" xmlns:a="a"><a:t>One</a:t></p:sld>`)},
+			{Name: "ppt/slides/slide2.xml", Content: []byte(`<p:sld xmlns:p="p" xmlns:a="a"><a:t>Two</a:t></p:sld>`)},
+			{Name: "ppt/slides/_rels/slide1.xml.rels", Content: []byte(`<Relationships/>`)},
+		})
+		metadata, err := helpers.ExtractFileMetadata("deck.pptx", "application/vnd.openxmlformats-officedocument.presentationml.presentation", pptx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, metadata.SlideCount)
+		assert.Equal(t, 2, metadata.WordCount)
+
+		xlsx := createTestZip(t, []zipTestFile{
+			{Name: "xl/workbook.xml", Content: []byte(`<workbook xmlns="x"><sheets><sheet name="A"/><sheet name="B"/><sheet name="C"/></sheets></workbook>`)},
+		})
+		metadata, err = helpers.ExtractFileMetadata("grades.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", xlsx)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, metadata.SheetCount)
+	})
+
+	t.Run("✅ Tệp văn bản, Markdown và CSV", func(t *testing.T) {
+		metadata, err := helpers.ExtractFileMetadata("notes.md", "text/markdown", []byte("# Ghi chú\n\n"+vietnameseSample))
+		assert.NoError(t, err)
+		assert.Equal(t, "Ghi chú", metadata.Title)
+		assert.Equal(t, "vi", metadata.Language)
+
+		metadata, err = helpers.ExtractFileMetadata("readme.txt", "text/plain", []byte("short text"))
+		assert.NoError(t, err)
+		assert.Equal(t, "readme", metadata.Title)
+		assert.Equal(t, 2, metadata.WordCount)
+		assert.Empty(t, metadata.Language)
+
+		metadata, err = helpers.ExtractFileMetadata("scores.csv", "text/csv", []byte("name,score\nAn,9\nBình,8\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, metadata.RowCount)
+	})
+
+	t.Run("❌ Tệp hỏng không làm hỏng quá trình tải lên", func(t *testing.T) {
+		metadata, err := helpers.ExtractFileMetadata("broken.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", []byte("not a zip"))
+		assert.Error(t, err)
+		assert.Equal(t, "broken", metadata.Title)
+
+		metadata, err = helpers.ExtractFileMetadata("image.png", "image/png", []byte(strings.Repeat("x", 10)))
+		assert.NoError(t, err)
+		assert.Empty(t, metadata.Title)
+	})
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePreviewDocumentService struct {
+	services.DocumentService
+	documents map[uint]*entities.Document
+}
+
+func (s *fakePreviewDocumentService) GetById(id uint) (*entities.Document, error) {
+	document, ok := s.documents[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return document, nil
+}
+
+func (s *fakePreviewDocumentService) GetDocumentContent(document *entities.Document) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("<script>alert(1)</script>")), nil
+}
+
+// fakePreviewSpaceService knows the spaces of the preview documents, so that
+// visitors of the public one are told apart from strangers to the private one.
+type fakePreviewSpaceService struct {
+	fakeUpdateSpaceService
+}
+
+func (s *fakePreviewSpaceService) GetById(id uint) (*entities.Space, error) {
+	switch id {
+	case testPrivateSpaceID:
+		return &entities.Space{ID: id, PrivacyStatus: true}, nil
+	case testPublicSpaceID:
+		return &entities.Space{ID: id, PrivacyStatus: false}, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func setupDocumentPreviewRouter(userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	service := &fakePreviewDocumentService{
+		documents: map[uint]*entities.Document{
+			1: {ID: 1, SpaceID: testPrivateSpaceID, Name: "lecture.pdf", MimeType: "application/pdf", PrivacyStatus: entities.DocumentVisibilityMembers},
+			2: {ID: 2, SpaceID: testPrivateSpaceID, Name: "page.html", MimeType: "text/html; charset=utf-8", PrivacyStatus: entities.DocumentVisibilityMembers},
+			3: {ID: 3, SpaceID: testPublicSpaceID, Name: "syllabus.pdf", MimeType: "application/pdf", PrivacyStatus: entities.DocumentVisibilityMembers},
+			4: {ID: 4, SpaceID: testPublicSpaceID, Name: "answers.pdf", MimeType: "application/pdf", PrivacyStatus: entities.DocumentVisibilityEditors},
+		},
+	}
+	controller := controllers.NewDocumentController(service, &fakePreviewSpaceService{}, nil)
+
+	documents := r.Group("/documents", func(ctx *gin.Context) {
+		ctx.Set("user_id", userID)
+	})
+	documents.GET("/:id/preview", controller.PreviewDocument)
+	return r
+}
+
+func TestDocumentContentDisposition(t *testing.T) {
+	disposition := func(mimeType string, inline bool) string {
+		return services.GetDocumentContentDisposition(&entities.Document{Name: "file", MimeType: mimeType}, inline)
+	}
+
+	t.Run("✅ Xem trực tiếp PDF, ảnh và văn bản thuần", func(t *testing.T) {
+		assert.Equal(t, "inline; filename=file", disposition("application/pdf", true))
+		assert.Equal(t, "inline; filename=file", disposition("image/png", true))
+		assert.Equal(t, "inline; filename=file", disposition("text/plain; charset=utf-8", true))
+		assert.Equal(t, "attachment; filename=file", disposition("application/pdf", false))
+	})
+
+	t.Run("❌ Các loại khác luôn được tải xuống", func(t *testing.T) {
+		assert.Equal(t, "attachment; filename=file", disposition("text/html", true))
+		assert.Equal(t, "attachment; filename=file", disposition("image/svg+xml", true))
+		assert.Equal(t, "attachment; filename=file", disposition("application/xhtml+xml", true))
+		assert.Equal(t, "attachment; filename=file", disposition("", true))
+	})
+}
+
+func TestDocumentPreviewHeaders(t *testing.T) {
+	router := setupDocumentPreviewRouter(testEditorID)
+
+	preview := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/documents/"+id+"/preview?mode=stream", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("✅ Xem trước PDF trong trình duyệt với sandbox", func(t *testing.T) {
+		w := preview("1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), "inline"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("❌ HTML không được hiển thị trực tiếp", func(t *testing.T) {
+		w := preview("2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"))
+	})
+}
+
+func TestDocumentPreviewVisitors(t *testing.T) {
+	router := setupDocumentPreviewRouter(testNonMemberID)
+
+	preview := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/documents/"+id+"/preview?mode=stream", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("✅ Khách của không gian công khai xem được tài liệu như người xem", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, preview("3").Code)
+	})
+
+	t.Run("❌ Khách không xem được tài liệu dành cho biên tập viên", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, preview("4").Code)
+	})
+
+	t.Run("❌ Người ngoài không xem được tài liệu của không gian riêng tư", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, preview("1").Code)
+	})
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func bindDocumentListQuery(rawQuery string) (dtos.DocumentListQuery, error) {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/v1/spaces/1/documents?"+rawQuery, nil)
+
+	var query dtos.DocumentListQuery
+	err := ctx.ShouldBindQuery(&query)
+	return query, err
+}
+
+func TestDocumentListQuery(t *testing.T) {
+	t.Run("✅ Đọc đầy đủ bộ lọc và sắp xếp", func(t *testing.T) {
+		query, err := bindDocumentListQuery("q=giải+tích&mime_type=application/pdf&uploaded_by=7" +
+			"&created_from=2025-03-01&created_to=2025-03-31&min_size=1024&max_size=2048" +
+			"&processing_status=0&sort=size&order=desc")
+		assert.NoError(t, err)
+
+		assert.Equal(t, "giải tích", query.Q)
+		assert.Equal(t, "application/pdf", query.MimeType)
+		assert.Equal(t, uint(7), query.UploadedBy)
+		assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), query.CreatedFrom.UTC())
+		assert.Equal(t, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), query.CreatedTo.UTC())
+		assert.Equal(t, int64(1024), query.MinSize)
+		assert.Equal(t, int64(2048), query.MaxSize)
+		if assert.NotNil(t, query.ProcessingStatus) {
+			assert.Equal(t, 0, *query.ProcessingStatus)
+		}
+		assert.Equal(t, "size", query.Sort)
+		assert.Equal(t, "desc", query.Order)
+	})
+
+	t.Run("✅ Không có bộ lọc", func(t *testing.T) {
+		query, err := bindDocumentListQuery("")
+		assert.NoError(t, err)
+		assert.Nil(t, query.ProcessingStatus)
+		assert.Nil(t, query.CreatedFrom)
+		assert.Empty(t, query.Sort)
+	})
+
+	t.Run("❌ Tham số không hợp lệ", func(t *testing.T) {
+		_, err := bindDocumentListQuery("sort=s3_url")
+		assert.Error(t, err)
+
+		_, err = bindDocumentListQuery("order=sideways")
+		assert.Error(t, err)
+
+		_, err = bindDocumentListQuery("min_size=-1")
+		assert.Error(t, err)
+
+		_, err = bindDocumentListQuery("created_from=01/03/2025")
+		assert.Error(t, err)
+	})
+}
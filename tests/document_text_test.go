@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractFileText(t *testing.T) {
+	t.Run("✅ Trích xuất văn bản từ PDF, DOCX và CSV", func(t *testing.T) {
+		text, err := helpers.ExtractFileText("application/pdf", createTestPDF(t))
+		assert.NoError(t, err)
+		assert.Contains(t, text, "Hello calculus students")
+		assert.Contains(t, text, "Week (1) notes")
+
+		docx := createTestZip(t, []zipTestFile{
+			{Name: "word/document.xml", Content: []byte(`<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>` + vietnameseSample + `</w:t></w:r></w:p></w:body></w:document>`)},
+		})
+		text, err = helpers.ExtractFileText("application/vnd.openxmlformats-officedocument.wordprocessingml.document", docx)
+		assert.NoError(t, err)
+		assert.Contains(t, text, "giải tích")
+
+		// Every row is indexed, not only the sample used for metadata
+		csv := "name,score\n" + strings.Repeat("An,9\n", 20000) + "Bình,8\n"
+		text, err = helpers.ExtractFileText("text/csv", []byte(csv))
+		assert.NoError(t, err)
+		assert.Contains(t, text, "Bình 8")
+	})
+
+	t.Run("✅ Loại tệp không hỗ trợ trả về văn bản rỗng", func(t *testing.T) {
+		text, err := helpers.ExtractFileText("image/png", []byte("\x89PNG"))
+		assert.NoError(t, err)
+		assert.Empty(t, text)
+	})
+
+	t.Run("✅ Loại bỏ ký tự NUL và UTF-8 không hợp lệ", func(t *testing.T) {
+		text, err := helpers.ExtractFileText("text/plain", []byte("abc\x00def\xff"))
+		assert.NoError(t, err)
+		assert.Equal(t, "abcdef", text)
+	})
+}
+
+func TestChunkText(t *testing.T) {
+	t.Run("✅ Chia văn bản tại khoảng trắng", func(t *testing.T) {
+		chunks := helpers.ChunkText(vietnameseSample, 50)
+		assert.Greater(t, len(chunks), 1)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 50)
+		}
+		assert.Equal(t, strings.Fields(vietnameseSample), strings.Fields(strings.Join(chunks, " ")))
+	})
+
+	t.Run("✅ Cắt từ quá dài", func(t *testing.T) {
+		chunks := helpers.ChunkText(strings.Repeat("a", 25), 10)
+		assert.Equal(t, []string{"aaaaaaaaaa", "aaaaaaaaaa", "aaaaa"}, chunks)
+	})
+
+	t.Run("❌ Văn bản rỗng không tạo đoạn nào", func(t *testing.T) {
+		assert.Empty(t, helpers.ChunkText("  \n\t ", 10))
+	})
+}
+
+func TestHighlightMatches(t *testing.T) {
+	t.Run("✅ Đánh dấu từ khớp không phân biệt hoa thường", func(t *testing.T) {
+		terms := helpers.SearchTerms("Giải TÍCH")
+		assert.Equal(t, []string{"giải", "tích"}, terms)
+
+		highlighted := helpers.HighlightMatches("Môn Giải tích 1, giải tích 2", terms)
+		assert.Equal(t, "Môn <mark>Giải</mark> <mark>tích</mark> 1, <mark>giải</mark> <mark>tích</mark> 2", highlighted)
+	})
+
+	t.Run("✅ Chỉ khớp nguyên từ", func(t *testing.T) {
+		highlighted := helpers.HighlightMatches("calculus calc", []string{"calc"})
+		assert.Equal(t, "calculus <mark>calc</mark>", highlighted)
+	})
+
+	t.Run("❌ Nội dung HTML trong tài liệu được escape", func(t *testing.T) {
+		highlighted := helpers.HighlightMatches("<script>alert(1)</script>", []string{"alert"})
+		assert.Equal(t, "&lt;script&gt;<mark>alert</mark>(1)&lt;/script&gt;", highlighted)
+	})
+}
+
+func TestSnippet(t *testing.T) {
+	t.Run("✅ Lấy ngữ cảnh quanh từ khớp đầu tiên", func(t *testing.T) {
+		snippet := helpers.Snippet(englishSample, []string{"exercises"}, 30)
+		assert.Contains(t, snippet, "exercises")
+		assert.True(t, strings.HasPrefix(snippet, "…"))
+		assert.True(t, strings.HasSuffix(snippet, "…"))
+		assert.LessOrEqual(t, utf8.RuneCountInString(snippet), 62)
+	})
+
+	t.Run("✅ Không có từ khớp thì lấy phần đầu", func(t *testing.T) {
+		snippet := helpers.Snippet(englishSample, []string{"missing"}, 20)
+		assert.True(t, strings.HasPrefix(snippet, "The course"))
+	})
+}
+
+// fakeKeywordTextRepo matches every document it is asked about, so the
+// answer lists the documents the service searched.
+type fakeKeywordTextRepo struct {
+	repositories.DocumentTextRepository
+	names map[uint]string
+}
+
+func (r *fakeKeywordTextRepo) SearchDocuments(documentIDs []uint, terms []string, limit int) ([]repositories.DocumentTextMatch, error) {
+	matches := []repositories.DocumentTextMatch{}
+	for _, id := range documentIDs {
+		matches = append(matches, repositories.DocumentTextMatch{DocumentID: id, DocumentName: r.names[id], Content: "routing tables"})
+	}
+	return matches, nil
+}
+
+func TestKeywordAnswer(t *testing.T) {
+	db := setupTestDatabase(t)
+
+	spaceID := uint(testPublicSpaceID)
+	assert.NoError(t, db.Create(&entities.Space{ID: spaceID, Name: "Networks", AllowedFileTypes: entities.FileTypeList{}}).Error)
+	otherSpaceID := uint(testPrivateSpaceID)
+	assert.NoError(t, db.Create(&entities.Space{ID: otherSpaceID, Name: "Other", AllowedFileTypes: entities.FileTypeList{}}).Error)
+
+	lectures := &entities.DocumentFolder{SpaceID: spaceID, Name: "Lectures"}
+	assert.NoError(t, db.Create(lectures).Error)
+	week := &entities.DocumentFolder{SpaceID: spaceID, ParentID: &lectures.ID, Name: "Week 1"}
+	assert.NoError(t, db.Create(week).Error)
+	labs := &entities.DocumentFolder{SpaceID: spaceID, Name: "Labs"}
+	assert.NoError(t, db.Create(labs).Error)
+	elsewhere := &entities.DocumentFolder{SpaceID: otherSpaceID, Name: "Elsewhere"}
+	assert.NoError(t, db.Create(elsewhere).Error)
+
+	// Empty tags are stored as JSON bytes, SQLite would return a nil list as text
+	tags := entities.DocumentTags{}
+	names := map[uint]string{}
+	addDocument := func(name string, folderID *uint) {
+		document := &entities.Document{SpaceID: spaceID, Name: name, FolderID: folderID, Tags: tags, PrivacyStatus: entities.DocumentVisibilityMembers}
+		assert.NoError(t, db.Create(document).Error)
+		names[document.ID] = name
+	}
+	addDocument("intro.pdf", &lectures.ID)
+	addDocument("routing.pdf", &week.ID)
+	addDocument("lab1.pdf", &labs.ID)
+	addDocument("syllabus.pdf", nil)
+
+	service := services.NewDocumentTextService(
+		&fakeKeywordTextRepo{names: names},
+		repositories.NewSpaceRepository(),
+		repositories.NewDocumentFolderRepository(),
+		repositories.NewDocumentRepository(),
+	)
+
+	t.Run("✅ Không chọn thư mục thì tìm trong cả không gian", func(t *testing.T) {
+		answer, err := service.KeywordAnswer(spaceID, "routing", services.ChatOptions{})
+		assert.NoError(t, err)
+		for _, name := range []string{"intro.pdf", "routing.pdf", "lab1.pdf", "syllabus.pdf"} {
+			assert.Contains(t, answer, name)
+		}
+	})
+
+	t.Run("✅ Chọn thư mục thì chỉ tìm trong thư mục và thư mục con", func(t *testing.T) {
+		answer, err := service.KeywordAnswer(spaceID, "routing", services.ChatOptions{FolderID: &lectures.ID})
+		assert.NoError(t, err)
+		assert.Contains(t, answer, "intro.pdf")
+		assert.Contains(t, answer, "routing.pdf")
+		assert.NotContains(t, answer, "lab1.pdf")
+		assert.NotContains(t, answer, "syllabus.pdf")
+	})
+
+	t.Run("❌ Thư mục của không gian khác", func(t *testing.T) {
+		_, err := service.KeywordAnswer(spaceID, "routing", services.ChatOptions{FolderID: &elsewhere.ID})
+		assert.ErrorContains(t, err, "folder not found")
+	})
+}
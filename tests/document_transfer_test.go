@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUploaderID = 6
+
+type fakeTransferDocumentService struct {
+	services.DocumentService
+	moved  []uint
+	copied []uint
+}
+
+func (s *fakeTransferDocumentService) GetById(id uint) (*entities.Document, error) {
+	if id != 1 {
+		return nil, errors.New("record not found")
+	}
+	return &entities.Document{ID: 1, SpaceID: testPrivateSpaceID, Name: "lecture.pdf", PrivacyStatus: entities.DocumentVisibilityMembers}, nil
+}
+
+func (s *fakeTransferDocumentService) MoveDocumentToSpace(documentID uint, targetSpaceID uint, folderID *uint) (*entities.Document, error) {
+	s.moved = append(s.moved, documentID)
+	return &entities.Document{ID: documentID, SpaceID: targetSpaceID}, nil
+}
+
+func (s *fakeTransferDocumentService) CopyDocumentToSpace(documentID uint, targetSpaceID uint, folderID *uint, userID uint) (*entities.Document, error) {
+	s.copied = append(s.copied, documentID)
+	return &entities.Document{ID: 2, SpaceID: targetSpaceID}, nil
+}
+
+type fakeTransferSpaceService struct {
+	services.SpaceService
+}
+
+// GetUserRole makes the uploader a member who can add documents to both
+// spaces but not delete them.
+func (s *fakeTransferSpaceService) GetUserRole(userID, spaceID uint) (*entities.SpaceRole, error) {
+	switch userID {
+	case testEditorID:
+		return testSpaceRoles()[entities.SpaceRoleEditor], nil
+	case testUploaderID:
+		return &entities.SpaceRole{ID: testTARoleID, Permission: entities.SpacePermissionUploadDocuments | entities.SpacePermissionChat}, nil
+	}
+	return nil, errors.New("user is not a member of this space")
+}
+
+func setupDocumentTransferRouter(userID uint) (*gin.Engine, *fakeTransferDocumentService) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	service := &fakeTransferDocumentService{}
+	controller := controllers.NewDocumentController(service, &fakeTransferSpaceService{}, nil)
+
+	documents := r.Group("/documents", func(ctx *gin.Context) {
+		ctx.Set("user_id", userID)
+	})
+	documents.POST("/:id/move", controller.MoveDocumentToSpace)
+	documents.POST("/:id/copy", controller.CopyDocumentToSpace)
+	documents.POST("/move", controller.MoveDocuments)
+	return r, service
+}
+
+func sendTransferRequest(router *gin.Engine, path string, body map[string]interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDocumentTransferPermissions(t *testing.T) {
+	t.Run("✅ Biên tập viên chuyển tài liệu sang không gian khác", func(t *testing.T) {
+		router, service := setupDocumentTransferRouter(testEditorID)
+
+		w := sendTransferRequest(router, "/documents/1/move", map[string]interface{}{"target_space_id": testPublicSpaceID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []uint{1}, service.moved)
+	})
+
+	t.Run("✅ Vai trò chỉ được tải lên vẫn sao chép được tài liệu", func(t *testing.T) {
+		router, service := setupDocumentTransferRouter(testUploaderID)
+
+		w := sendTransferRequest(router, "/documents/1/copy", map[string]interface{}{"target_space_id": testPublicSpaceID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []uint{1}, service.copied)
+	})
+
+	t.Run("❌ Vai trò không được xóa tài liệu thì không chuyển được", func(t *testing.T) {
+		router, service := setupDocumentTransferRouter(testUploaderID)
+
+		w := sendTransferRequest(router, "/documents/1/move", map[string]interface{}{"target_space_id": testPublicSpaceID})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, service.moved)
+
+		w = sendTransferRequest(router, "/documents/move", map[string]interface{}{
+			"document_ids":    []uint{1},
+			"target_space_id": testPublicSpaceID,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "not allowed to transfer this document")
+		assert.Empty(t, service.moved)
+	})
+}
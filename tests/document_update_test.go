@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUpdateDocumentRepo struct {
+	repositories.DocumentRepository
+	documents map[uint]*entities.Document
+}
+
+func (r *fakeUpdateDocumentRepo) GetById(id uint) (*entities.Document, error) {
+	document, ok := r.documents[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *document
+	return &copied, nil
+}
+
+func (r *fakeUpdateDocumentRepo) Update(document *entities.Document) (*entities.Document, error) {
+	stored := *document
+	r.documents[document.ID] = &stored
+	return document, nil
+}
+
+type fakeUpdateSpaceService struct {
+	services.SpaceService
+}
+
+func (s *fakeUpdateSpaceService) GetUserRole(userID, spaceID uint) (*entities.SpaceRole, error) {
+	role, ok := testSpaceRoles()[entities.SpaceRoleEditor]
+	if !ok || userID != testEditorID {
+		return nil, errors.New("user is not a member of this space")
+	}
+	return role, nil
+}
+
+func setupDocumentUpdateService() (services.DocumentService, *fakeUpdateDocumentRepo) {
+	uploaderID := uint(testEditorID)
+	repo := &fakeUpdateDocumentRepo{
+		documents: map[uint]*entities.Document{
+			1: {
+				ID:                   1,
+				SpaceID:              testPrivateSpaceID,
+				Name:                 "lecture.pdf",
+				Description:          "Week 1",
+				MimeType:             "application/pdf",
+				S3URL:                "https://bucket.s3.amazonaws.com/abc",
+				ContentHash:          "abc",
+				UploadedBy:           &uploaderID,
+				SourceURL:            "https://example.com/lecture.pdf",
+				RefreshIntervalHours: 24,
+				DocumentScan: entities.DocumentScan{
+					ScanStatus: entities.DocumentScanClean,
+					ScanEngine: "clamd",
+				},
+			},
+		},
+	}
+	service := services.NewDocumentService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	return service, repo
+}
+
+func setupDocumentUpdateRouter(service services.DocumentService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	controller := controllers.NewDocumentController(service, &fakeUpdateSpaceService{}, nil)
+
+	documents := r.Group("/documents", func(ctx *gin.Context) {
+		ctx.Set("user_id", uint(testEditorID))
+	})
+	documents.PUT("/:id", controller.Update)
+	documents.PATCH("/:id", controller.Patch)
+	return r
+}
+
+func TestDocumentUpdateProtectedFields(t *testing.T) {
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		t.Run(fmt.Sprintf("✅ %s chỉ sửa tên và mô tả, không đổi không gian", method), func(t *testing.T) {
+			service, repo := setupDocumentUpdateService()
+			router := setupDocumentUpdateRouter(service)
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"name":        "renamed.pdf",
+				"description": "Week 2",
+				"space_id":    testPublicSpaceID,
+				"s3_url":      "https://bucket.s3.amazonaws.com/other",
+			})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, "/documents/1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			document := repo.documents[1]
+			assert.Equal(t, "renamed.pdf", document.Name)
+			assert.Equal(t, "Week 2", document.Description)
+			assert.Equal(t, uint(testPrivateSpaceID), document.SpaceID)
+			assert.Equal(t, "https://bucket.s3.amazonaws.com/abc", document.S3URL)
+		})
+	}
+
+	t.Run("✅ Dịch vụ giữ nguyên không gian khi cập nhật toàn bộ", func(t *testing.T) {
+		service, repo := setupDocumentUpdateService()
+
+		_, err := service.UpdateByID(1, &entities.Document{Name: "renamed.pdf", SpaceID: testPublicSpaceID})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(testPrivateSpaceID), repo.documents[1].SpaceID)
+	})
+
+	t.Run("✅ Dịch vụ giữ nguyên không gian khi cập nhật một phần", func(t *testing.T) {
+		service, repo := setupDocumentUpdateService()
+
+		_, err := service.PatchByID(1, &entities.Document{SpaceID: testPublicSpaceID})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(testPrivateSpaceID), repo.documents[1].SpaceID)
+		assert.Equal(t, "lecture.pdf", repo.documents[1].Name)
+	})
+
+	t.Run("✅ Giữ nguyên kết quả quét virus", func(t *testing.T) {
+		service, repo := setupDocumentUpdateService()
+		notScanned := entities.DocumentScan{ScanStatus: entities.DocumentScanNotScanned, ScanEngine: "none"}
+
+		_, err := service.UpdateByID(1, &entities.Document{Name: "renamed.pdf", DocumentScan: notScanned})
+		assert.NoError(t, err)
+		assert.Equal(t, entities.DocumentScanClean, repo.documents[1].ScanStatus)
+		assert.Equal(t, "clamd", repo.documents[1].ScanEngine)
+
+		_, err = service.PatchByID(1, &entities.Document{DocumentScan: notScanned})
+		assert.NoError(t, err)
+		assert.Equal(t, entities.DocumentScanClean, repo.documents[1].ScanStatus)
+		assert.Equal(t, "clamd", repo.documents[1].ScanEngine)
+	})
+
+	t.Run("✅ Giữ nguyên người tải lên và chu kỳ làm mới", func(t *testing.T) {
+		service, repo := setupDocumentUpdateService()
+		otherID := uint(testOwnerID)
+
+		_, err := service.UpdateByID(1, &entities.Document{
+			Name:                 "renamed.pdf",
+			UploadedBy:           &otherID,
+			DeletedBy:            &otherID,
+			RefreshIntervalHours: 1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(testEditorID), *repo.documents[1].UploadedBy)
+		assert.Nil(t, repo.documents[1].DeletedBy)
+		assert.Equal(t, 24, repo.documents[1].RefreshIntervalHours)
+
+		_, err = service.PatchByID(1, &entities.Document{
+			UploadedBy:           &otherID,
+			DeletedBy:            &otherID,
+			RefreshIntervalHours: 1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(testEditorID), *repo.documents[1].UploadedBy)
+		assert.Nil(t, repo.documents[1].DeletedBy)
+		assert.Equal(t, 24, repo.documents[1].RefreshIntervalHours)
+	})
+
+	t.Run("❌ Thiếu tên khi cập nhật toàn bộ", func(t *testing.T) {
+		service, repo := setupDocumentUpdateService()
+		router := setupDocumentUpdateRouter(service)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/documents/1", bytes.NewBufferString(`{"space_id": 11}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "lecture.pdf", repo.documents[1].Name)
+	})
+}
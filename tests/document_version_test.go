@@ -0,0 +1,681 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// documentStore keeps documents, their versions and blobs in memory. The fake
+// repositories below share it and update the storage used by spaces the way
+// the database repositories do.
+type documentStore struct {
+	documents map[uint]*entities.Document
+	versions  []entities.DocumentVersion
+	blobs     map[string]*entities.Blob
+	spaces    map[uint]*entities.Space
+	folders   map[uint]*entities.DocumentFolder
+	texts     map[uint][]string
+	nextID    uint
+}
+
+func newDocumentStore() *documentStore {
+	return &documentStore{
+		documents: map[uint]*entities.Document{},
+		blobs:     map[string]*entities.Blob{},
+		spaces: map[uint]*entities.Space{
+			testPrivateSpaceID: {ID: testPrivateSpaceID, Name: "Networks", DocumentLimit: 10, FileSizeLimitKb: 1024},
+			testPublicSpaceID:  {ID: testPublicSpaceID, Name: "Algorithms", DocumentLimit: 10, FileSizeLimitKb: 1024},
+		},
+		folders: map[uint]*entities.DocumentFolder{},
+		texts:   map[uint][]string{},
+		nextID:  1,
+	}
+}
+
+func (s *documentStore) newID() uint {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+func (s *documentStore) addStorageUsage(spaceID uint, delta int64) {
+	s.spaces[spaceID].StorageUsedBytes += delta
+}
+
+type fakeStoreDocumentRepo struct {
+	repositories.DocumentRepository
+	store *documentStore
+}
+
+func (r *fakeStoreDocumentRepo) GetById(id uint) (*entities.Document, error) {
+	document, ok := r.store.documents[id]
+	if !ok || document.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *document
+	return &copied, nil
+}
+
+func (r *fakeStoreDocumentRepo) GetDeletedById(id uint) (*entities.Document, error) {
+	document, ok := r.store.documents[id]
+	if !ok || !document.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *document
+	return &copied, nil
+}
+
+func (r *fakeStoreDocumentRepo) GetBySpaceAndContentHash(spaceID uint, contentHash string) (*entities.Document, error) {
+	for _, document := range r.store.documents {
+		if document.SpaceID == spaceID && document.ContentHash == contentHash && !document.DeletedAt.Valid {
+			copied := *document
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeStoreDocumentRepo) GetByFilter(filter repositories.DocumentFilter) ([]entities.Document, error) {
+	documents := []entities.Document{}
+	for id := uint(1); id < r.store.nextID; id++ {
+		document, ok := r.store.documents[id]
+		if !ok || document.DeletedAt.Valid || document.SpaceID != filter.SpaceID {
+			continue
+		}
+		if len(filter.FolderIDs) > 0 && (document.FolderID == nil || !containsID(filter.FolderIDs, *document.FolderID)) {
+			continue
+		}
+		documents = append(documents, *document)
+	}
+	return documents, nil
+}
+
+func (r *fakeStoreDocumentRepo) GetDeleted(spaceID *uint, deletedBy *uint) ([]entities.Document, error) {
+	documents := []entities.Document{}
+	for id := uint(1); id < r.store.nextID; id++ {
+		document, ok := r.store.documents[id]
+		if !ok || !document.DeletedAt.Valid {
+			continue
+		}
+		if spaceID != nil && document.SpaceID != *spaceID {
+			continue
+		}
+		if deletedBy != nil && (document.DeletedBy == nil || *document.DeletedBy != *deletedBy) {
+			continue
+		}
+		documents = append(documents, *document)
+	}
+	return documents, nil
+}
+
+func (r *fakeStoreDocumentRepo) GetIDs(spaceID *uint) ([]uint, error) {
+	ids := []uint{}
+	for id := uint(1); id < r.store.nextID; id++ {
+		document, ok := r.store.documents[id]
+		if !ok || document.DeletedAt.Valid || (spaceID != nil && document.SpaceID != *spaceID) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *fakeStoreDocumentRepo) GetIndexable() ([]entities.Document, error) {
+	ids, _ := r.GetIDs(nil)
+	documents := []entities.Document{}
+	for _, id := range ids {
+		documents = append(documents, *r.store.documents[id])
+	}
+	return documents, nil
+}
+
+func (r *fakeStoreDocumentRepo) CountBySpaceID(spaceID uint) (int64, error) {
+	var count int64
+	for _, document := range r.store.documents {
+		if document.SpaceID == spaceID && !document.DeletedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeStoreDocumentRepo) CreateWithVersion(document *entities.Document, version *entities.DocumentVersion) (*entities.Document, error) {
+	document.ID = r.store.newID()
+	document.CurrentVersion = 1
+	stored := *document
+	r.store.documents[document.ID] = &stored
+
+	version.ID = r.store.newID()
+	version.DocumentID = document.ID
+	version.Version = 1
+	r.store.versions = append(r.store.versions, *version)
+	r.store.addStorageUsage(document.SpaceID, document.Size)
+	return document, nil
+}
+
+func (r *fakeStoreDocumentRepo) AddVersion(documentID uint, version *entities.DocumentVersion) (*entities.Document, error) {
+	document, ok := r.store.documents[documentID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	latest := 0
+	for _, existing := range r.store.versions {
+		if existing.DocumentID == documentID && existing.Version > latest {
+			latest = existing.Version
+		}
+	}
+	version.ID = r.store.newID()
+	version.DocumentID = documentID
+	version.Version = latest + 1
+	r.store.versions = append(r.store.versions, *version)
+	r.store.addStorageUsage(document.SpaceID, version.Size-document.Size)
+
+	document.Name = version.Name
+	document.MimeType = version.MimeType
+	document.Size = version.Size
+	document.S3URL = version.S3URL
+	document.ContentHash = version.ContentHash
+	document.Metadata = version.Metadata
+	document.DocumentScan = version.DocumentScan
+	document.CurrentVersion = version.Version
+	copied := *document
+	return &copied, nil
+}
+
+func (r *fakeStoreDocumentRepo) SoftDelete(documentID uint, deletedBy *uint) error {
+	document, ok := r.store.documents[documentID]
+	if !ok || document.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	document.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	document.DeletedBy = deletedBy
+	r.store.addStorageUsage(document.SpaceID, -document.Size)
+	return nil
+}
+
+func (r *fakeStoreDocumentRepo) GetIDsDeletedBefore(cutoff time.Time) ([]uint, error) {
+	ids := []uint{}
+	for id, document := range r.store.documents {
+		if document.DeletedAt.Valid && document.DeletedAt.Time.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeStoreDocumentRepo) Restore(documentID uint) error {
+	document, ok := r.store.documents[documentID]
+	if !ok || !document.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	document.DeletedAt = gorm.DeletedAt{}
+	document.DeletedBy = nil
+	r.store.addStorageUsage(document.SpaceID, document.Size)
+	return nil
+}
+
+func (r *fakeStoreDocumentRepo) Purge(documentID uint) error {
+	document, ok := r.store.documents[documentID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.store.documents, documentID)
+
+	versions := []entities.DocumentVersion{}
+	for _, version := range r.store.versions {
+		if version.DocumentID != documentID {
+			versions = append(versions, version)
+		}
+	}
+	r.store.versions = versions
+
+	if !document.DeletedAt.Valid {
+		r.store.addStorageUsage(document.SpaceID, -document.Size)
+	}
+	return nil
+}
+
+type fakeStoreVersionRepo struct {
+	repositories.DocumentVersionRepository
+	store *documentStore
+}
+
+func (r *fakeStoreVersionRepo) GetByDocumentID(documentID uint) ([]entities.DocumentVersion, error) {
+	versions := []entities.DocumentVersion{}
+	for _, version := range r.store.versions {
+		if version.DocumentID == documentID {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+func (r *fakeStoreVersionRepo) GetByDocumentAndVersionID(documentID uint, versionID uint) (*entities.DocumentVersion, error) {
+	for _, version := range r.store.versions {
+		if version.DocumentID == documentID && version.ID == versionID {
+			return &version, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeStoreVersionRepo) GetAllFileReferences() ([]entities.DocumentVersion, error) {
+	return r.store.versions, nil
+}
+
+type fakeStoreBlobRepo struct {
+	repositories.BlobRepository
+	store *documentStore
+}
+
+func (r *fakeStoreBlobRepo) Acquire(contentHash string, s3URL string, size int64) (*entities.Blob, error) {
+	blob, ok := r.store.blobs[contentHash]
+	if !ok {
+		blob = &entities.Blob{ID: r.store.newID(), ContentHash: contentHash, S3URL: s3URL, Size: size}
+		r.store.blobs[contentHash] = blob
+	}
+	blob.RefCount++
+	copied := *blob
+	return &copied, nil
+}
+
+func (r *fakeStoreBlobRepo) Release(contentHash string, onUnreferenced func(blob *entities.Blob) error) error {
+	blob, ok := r.store.blobs[contentHash]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	if blob.RefCount > 1 {
+		blob.RefCount--
+		return nil
+	}
+	if err := onUnreferenced(blob); err != nil {
+		return err
+	}
+	delete(r.store.blobs, contentHash)
+	return nil
+}
+
+func (r *fakeStoreBlobRepo) GetAllS3URLs() ([]string, error) {
+	urls := []string{}
+	for _, blob := range r.store.blobs {
+		urls = append(urls, blob.S3URL)
+	}
+	return urls, nil
+}
+
+type fakeStoreSpaceRepo struct {
+	repositories.SpaceRepository
+	store *documentStore
+}
+
+func (r *fakeStoreSpaceRepo) GetById(id uint) (*entities.Space, error) {
+	space, ok := r.store.spaces[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *space
+	return &copied, nil
+}
+
+func (r *fakeStoreSpaceRepo) GetOwnerID(spaceID uint) (uint, error) {
+	return testOwnerID, nil
+}
+
+func (r *fakeStoreSpaceRepo) GetIDsDeletedBefore(cutoff time.Time) ([]uint, error) {
+	return []uint{}, nil
+}
+
+// fakeStoreUserRepo leaves owners without a tier, spaces are only limited by
+// their own quota.
+type fakeStoreUserRepo struct {
+	repositories.UserRepository
+}
+
+func (r *fakeStoreUserRepo) GetUserTier(userID uint) (*entities.Tier, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeStoreTextRepo struct {
+	repositories.DocumentTextRepository
+	store *documentStore
+}
+
+func (r *fakeStoreTextRepo) ReplaceForDocument(documentID uint, chunks []string) error {
+	r.store.texts[documentID] = chunks
+	return nil
+}
+
+type fakeStoreFolderRepo struct {
+	repositories.DocumentFolderRepository
+	store *documentStore
+}
+
+func (r *fakeStoreFolderRepo) GetById(id uint) (*entities.DocumentFolder, error) {
+	folder, ok := r.store.folders[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *folder
+	return &copied, nil
+}
+
+func (r *fakeStoreFolderRepo) GetPath(folderID uint) (string, error) {
+	path := ""
+	for id := &folderID; id != nil; id = r.store.folders[*id].ParentID {
+		path = "/" + r.store.folders[*id].Name + path
+	}
+	return path, nil
+}
+
+func (r *fakeStoreFolderRepo) GetDescendantIDs(folderID uint) ([]uint, error) {
+	ids := []uint{folderID}
+	for i := 0; i < len(ids); i++ {
+		for id, folder := range r.store.folders {
+			if folder.ParentID != nil && *folder.ParentID == ids[i] {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Delete removes subfolders as well, like the cascade on the parent key.
+func (r *fakeStoreFolderRepo) Delete(id uint) error {
+	ids, _ := r.GetDescendantIDs(id)
+	for _, folderID := range ids {
+		delete(r.store.folders, folderID)
+	}
+	return nil
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeObjectStorage keeps objects in memory under their key. Objects without
+// a modification time are listed as long stored.
+type fakeObjectStorage struct {
+	services.ObjectStorage
+	objects      map[string][]byte
+	lastModified map[string]time.Time
+	deleted      []string
+}
+
+func newFakeObjectStorage() *fakeObjectStorage {
+	return &fakeObjectStorage{objects: map[string][]byte{}, lastModified: map[string]time.Time{}}
+}
+
+func (s *fakeObjectStorage) GetURL(key string) string {
+	return "https://test-bucket.s3.amazonaws.com/" + key
+}
+
+func (s *fakeObjectStorage) Upload(key string, file multipart.File) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	s.objects[key] = data
+	return s.GetURL(key), nil
+}
+
+func (s *fakeObjectStorage) Exists(key string) (bool, error) {
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+func (s *fakeObjectStorage) Get(url string) (io.ReadCloser, error) {
+	data, err := s.Download(url)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *fakeObjectStorage) Download(url string) ([]byte, error) {
+	data, ok := s.objects[helpers.GetS3Key(url)]
+	if !ok {
+		return nil, errors.New("unable to get file from S3, NoSuchKey")
+	}
+	return data, nil
+}
+
+func (s *fakeObjectStorage) Delete(url string) error {
+	key := helpers.GetS3Key(url)
+	delete(s.objects, key)
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func (s *fakeObjectStorage) List() ([]helpers.S3Object, error) {
+	objects := []helpers.S3Object{}
+	for key := range s.objects {
+		objects = append(objects, helpers.S3Object{Key: key, LastModified: s.lastModified[key]})
+	}
+	return objects, nil
+}
+
+// fakeRAGServer records which documents are indexed, by document id.
+type fakeRAGServer struct {
+	server  *httptest.Server
+	mutex   sync.Mutex
+	indexed map[uint]uint
+}
+
+func newFakeRAGServer(t *testing.T) *fakeRAGServer {
+	rag := &fakeRAGServer{indexed: map[uint]uint{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		docID, _ := strconv.ParseUint(r.FormValue("docId"), 10, 32)
+		spaceID, _ := strconv.ParseUint(r.FormValue("spaceId"), 10, 32)
+		rag.mutex.Lock()
+		rag.indexed[uint(docID)] = uint(spaceID)
+		rag.mutex.Unlock()
+	})
+	mux.HandleFunc("DELETE /remove", func(w http.ResponseWriter, r *http.Request) {
+		var body services.RAGIndexedDocument
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		rag.mutex.Lock()
+		delete(rag.indexed, body.DocID)
+		rag.mutex.Unlock()
+	})
+	mux.HandleFunc("DELETE /remove-space", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("PATCH /metadata", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /documents", func(w http.ResponseWriter, r *http.Request) {
+		rag.mutex.Lock()
+		defer rag.mutex.Unlock()
+		documents := []services.RAGIndexedDocument{}
+		for docID, spaceID := range rag.indexed {
+			documents = append(documents, services.RAGIndexedDocument{DocID: docID, SpaceID: spaceID})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"documents": documents})
+	})
+
+	rag.server = httptest.NewServer(mux)
+	t.Cleanup(rag.server.Close)
+	return rag
+}
+
+func (r *fakeRAGServer) service() *services.RAGServerService {
+	return &services.RAGServerService{
+		BaseURL:           r.server.URL,
+		UploadDocumentURL: "/upload",
+		RemoveDocURL:      "/remove",
+		RemoveSpaceURL:    "/remove-space",
+		UpdateDocMetaURL:  "/metadata",
+		ListDocumentsURL:  "/documents",
+	}
+}
+
+func (r *fakeRAGServer) isIndexed(documentID uint) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := r.indexed[documentID]
+	return ok
+}
+
+type documentServiceFixture struct {
+	store   *documentStore
+	storage *fakeObjectStorage
+	rag     *fakeRAGServer
+	repo    *fakeStoreDocumentRepo
+	service services.DocumentService
+}
+
+func setupDocumentServiceFixture(t *testing.T) *documentServiceFixture {
+	store := newDocumentStore()
+	fixture := &documentServiceFixture{
+		store:   store,
+		storage: newFakeObjectStorage(),
+		rag:     newFakeRAGServer(t),
+		repo:    &fakeStoreDocumentRepo{store: store},
+	}
+	fixture.service = services.NewDocumentService(
+		fixture.repo,
+		&fakeStoreVersionRepo{store: store},
+		&fakeStoreFolderRepo{store: store},
+		&fakeStoreBlobRepo{store: store},
+		&fakeStoreSpaceRepo{store: store},
+		&fakeStoreUserRepo{},
+		&fakeStoreTextRepo{store: store},
+		fixture.rag.service(),
+		fixture.storage,
+		services.NewScanner(),
+	)
+	return fixture
+}
+
+func (f *documentServiceFixture) upload(t *testing.T, spaceID uint, name string, content string) *entities.Document {
+	document, err := f.service.UploadDocumentFile(helpers.NewUploadFileFromBytes(name, []byte(content)), spaceID, testEditorID, "", dtos.DocumentUploadOptions{})
+	assert.NoError(t, err)
+	return document
+}
+
+func (f *documentServiceFixture) replace(t *testing.T, documentID uint, name string, content string) *entities.Document {
+	document, err := f.service.ReplaceDocumentFile(documentID, helpers.NewUploadFileFromBytes(name, []byte(content)), testEditorID, "")
+	assert.NoError(t, err)
+	return document
+}
+
+func TestDocumentVersioning(t *testing.T) {
+	t.Run("✅ Thay tệp vẫn giữ tệp cũ khi phiên bản cũ còn tham chiếu", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+
+		original := fixture.upload(t, testPrivateSpaceID, "notes.txt", "first draft")
+		replaced := fixture.replace(t, original.ID, "notes-v2.txt", "second draft")
+
+		assert.Equal(t, 2, replaced.CurrentVersion)
+		assert.NotEqual(t, original.ContentHash, replaced.ContentHash)
+		assert.Contains(t, fixture.storage.objects, original.ContentHash)
+		assert.Contains(t, fixture.storage.objects, replaced.ContentHash)
+		assert.Equal(t, 1, fixture.store.blobs[original.ContentHash].RefCount)
+		assert.Empty(t, fixture.storage.deleted)
+		assert.True(t, fixture.rag.isIndexed(original.ID))
+	})
+
+	t.Run("✅ Khôi phục phiên bản cũ lấy lại tên, kiểu và mã băm", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+
+		original := fixture.upload(t, testPrivateSpaceID, "notes.txt", "first draft")
+		fixture.replace(t, original.ID, "slides.md", "# second draft")
+
+		versions, err := fixture.service.GetDocumentVersions(original.ID)
+		assert.NoError(t, err)
+		assert.Len(t, versions, 2)
+
+		rolledBack, err := fixture.service.RollbackDocument(original.ID, versions[0].ID, testOwnerID)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, rolledBack.CurrentVersion)
+		assert.Equal(t, "notes.txt", rolledBack.Name)
+		assert.Equal(t, original.MimeType, rolledBack.MimeType)
+		assert.Equal(t, original.ContentHash, rolledBack.ContentHash)
+		assert.Equal(t, original.S3URL, rolledBack.S3URL)
+
+		// The new version is one more reference to the blob of the first one
+		assert.Equal(t, 2, fixture.store.blobs[original.ContentHash].RefCount)
+	})
+
+	t.Run("✅ Lần giải phóng cuối cùng mới xóa tệp trên S3", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+
+		first := fixture.upload(t, testPrivateSpaceID, "notes.txt", "shared content")
+		second := fixture.upload(t, testPublicSpaceID, "copy.txt", "shared content")
+		assert.Equal(t, first.ContentHash, second.ContentHash)
+		assert.Equal(t, 2, fixture.store.blobs[first.ContentHash].RefCount)
+
+		assert.NoError(t, fixture.service.PurgeDocument(first.ID))
+		assert.Contains(t, fixture.storage.objects, first.ContentHash)
+		assert.Empty(t, fixture.storage.deleted)
+
+		assert.NoError(t, fixture.service.PurgeDocument(second.ID))
+		assert.NotContains(t, fixture.storage.objects, first.ContentHash)
+		assert.NotContains(t, fixture.store.blobs, first.ContentHash)
+		assert.Equal(t, []string{first.ContentHash}, fixture.storage.deleted)
+	})
+
+	t.Run("❌ Không khôi phục phiên bản hiện tại", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+
+		original := fixture.upload(t, testPrivateSpaceID, "notes.txt", "first draft")
+		versions, _ := fixture.service.GetDocumentVersions(original.ID)
+
+		_, err := fixture.service.RollbackDocument(original.ID, versions[0].ID, testOwnerID)
+		assert.ErrorContains(t, err, "already the current version")
+		assert.Equal(t, 1, fixture.store.blobs[original.ContentHash].RefCount)
+	})
+
+	t.Run("❌ Không thay bằng nội dung của tài liệu khác trong không gian", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+
+		first := fixture.upload(t, testPrivateSpaceID, "notes.txt", "first draft")
+		second := fixture.upload(t, testPrivateSpaceID, "lab.txt", "lab report")
+
+		_, err := fixture.service.ReplaceDocumentFile(second.ID, helpers.NewUploadFileFromBytes("copy.txt", []byte("first draft")), testEditorID, "")
+		var duplicate *services.DuplicateDocumentError
+		assert.ErrorAs(t, err, &duplicate)
+		assert.Equal(t, first.ID, duplicate.DocumentID)
+
+		unchanged, _ := fixture.service.GetById(second.ID)
+		assert.Equal(t, 1, unchanged.CurrentVersion)
+		assert.Equal(t, 1, fixture.store.blobs[first.ContentHash].RefCount)
+	})
+
+	t.Run("✅ Thay bằng chính nội dung hiện tại vẫn được", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+
+		original := fixture.upload(t, testPrivateSpaceID, "notes.txt", "first draft")
+		replaced := fixture.replace(t, original.ID, "notes-renamed.txt", "first draft")
+		assert.Equal(t, 2, replaced.CurrentVersion)
+	})
+}
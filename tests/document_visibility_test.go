@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentVisibility(t *testing.T) {
+	owner := &entities.SpaceRole{ID: entities.SpaceRoleOwner, Permission: entities.SpacePermissionsOwner}
+	editor := &entities.SpaceRole{ID: entities.SpaceRoleEditor, Permission: entities.SpacePermissionsEditor}
+	viewer := &entities.SpaceRole{ID: entities.SpaceRoleViewer, Permission: entities.SpacePermissionsViewer}
+
+	document := func(visibility entities.DocumentVisibility) entities.Document {
+		return entities.Document{PrivacyStatus: visibility}
+	}
+
+	t.Run("✅ Tài liệu cho mọi thành viên", func(t *testing.T) {
+		doc := document(entities.DocumentVisibilityMembers)
+		assert.True(t, doc.IsVisibleTo(owner))
+		assert.True(t, doc.IsVisibleTo(viewer))
+		assert.True(t, doc.IsVisibleTo(nil))
+	})
+
+	t.Run("✅ Tài liệu chỉ dành cho biên tập viên", func(t *testing.T) {
+		doc := document(entities.DocumentVisibilityEditors)
+		assert.True(t, doc.IsVisibleTo(owner))
+		assert.True(t, doc.IsVisibleTo(editor))
+		assert.False(t, doc.IsVisibleTo(viewer))
+		assert.False(t, doc.IsVisibleTo(nil))
+	})
+
+	t.Run("✅ Tài liệu chỉ để tham khảo không dùng cho chat", func(t *testing.T) {
+		doc := document(entities.DocumentVisibilityReferenceOnly)
+		assert.True(t, doc.IsVisibleTo(viewer))
+		assert.NotContains(t, entities.RetrievableDocumentVisibilities(owner), entities.DocumentVisibilityReferenceOnly)
+		assert.NotContains(t, entities.RetrievableDocumentVisibilities(viewer), entities.DocumentVisibilityEditors)
+		assert.Contains(t, entities.RetrievableDocumentVisibilities(editor), entities.DocumentVisibilityEditors)
+	})
+
+	t.Run("❌ Giá trị không hợp lệ", func(t *testing.T) {
+		assert.False(t, entities.DocumentVisibility("public").IsValid())
+		assert.True(t, entities.DocumentVisibilityEditors.IsValid())
+	})
+}
+
+func TestGetVisibleToUser(t *testing.T) {
+	db := setupTestDatabase(t)
+
+	spaceID := uint(testJoinableSpaceID)
+	uploaderRoleID := uint(testTARoleID + 1)
+	assert.NoError(t, db.Create(&entities.Space{ID: spaceID, Name: "Networks"}).Error)
+	for _, role := range testSpaceRoles() {
+		assert.NoError(t, db.Create(role).Error)
+	}
+	assert.NoError(t, db.Create(&entities.SpaceRole{
+		ID:         uploaderRoleID,
+		SpaceID:    &spaceID,
+		Name:       "Uploader",
+		Permission: entities.SpacePermissionUploadDocuments | entities.SpacePermissionChat,
+	}).Error)
+
+	members := map[uint]uint{
+		testOwnerID:    entities.SpaceRoleOwner,
+		testEditorID:   entities.SpaceRoleEditor,
+		testViewerID:   entities.SpaceRoleViewer,
+		testTAID:       testTARoleID,
+		testUploaderID: uploaderRoleID,
+	}
+	for userID, roleID := range members {
+		roleID := roleID
+		assert.NoError(t, db.Create(&entities.SpaceUser{UserID: userID, SpaceID: spaceID, SpaceRoleID: &roleID}).Error)
+	}
+
+	// Empty tags are stored as JSON bytes, SQLite would return a nil list as text
+	tags := entities.DocumentTags{}
+	assert.NoError(t, db.Create(&entities.Document{SpaceID: spaceID, Name: "syllabus.pdf", Tags: tags, PrivacyStatus: entities.DocumentVisibilityMembers}).Error)
+	assert.NoError(t, db.Create(&entities.Document{SpaceID: spaceID, Name: "answers.pdf", Tags: tags, PrivacyStatus: entities.DocumentVisibilityEditors}).Error)
+
+	repo := repositories.NewDocumentRepository()
+	visibleCount := func(userID uint) int64 {
+		_, total, err := repo.GetVisibleToUser(userID, 1, 10)
+		assert.NoError(t, err)
+		return total
+	}
+
+	t.Run("✅ Vai trò được tải lên thấy tài liệu dành cho biên tập viên", func(t *testing.T) {
+		assert.Equal(t, int64(2), visibleCount(testOwnerID))
+		assert.Equal(t, int64(2), visibleCount(testEditorID))
+		assert.Equal(t, int64(2), visibleCount(testUploaderID))
+	})
+
+	t.Run("❌ Vai trò không được tải lên chỉ thấy tài liệu chung", func(t *testing.T) {
+		assert.Equal(t, int64(1), visibleCount(testViewerID))
+		assert.Equal(t, int64(1), visibleCount(testTAID))
+		assert.Equal(t, int64(0), visibleCount(testNonMemberID))
+	})
+}
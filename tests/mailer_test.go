@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailer(t *testing.T) {
+	t.Run("✅ Ghi thư thành tệp .eml", func(t *testing.T) {
+		dir := t.TempDir()
+		mailer := services.NewFileMailer(dir, "no-reply@dutgrad.test")
+		assert.Equal(t, "file", mailer.Name())
+
+		err := mailer.Send(&services.Mail{
+			To:      "new.student@example.com",
+			Subject: "Lời mời tham gia Giải tích",
+			Body:    "Xin chào\nHãy đăng ký để tham gia.",
+		})
+		assert.NoError(t, err)
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+		assert.Contains(t, filepath.Base(files[0]), "new.student_example.com")
+
+		content, err := os.ReadFile(files[0])
+		assert.NoError(t, err)
+		message := string(content)
+		assert.Contains(t, message, "From: no-reply@dutgrad.test\r\n")
+		assert.Contains(t, message, "To: new.student@example.com\r\n")
+		assert.Contains(t, message, "Subject: =?utf-8?q?")
+		assert.True(t, strings.HasSuffix(message, "\r\n\r\nXin chào\r\nHãy đăng ký để tham gia."))
+	})
+
+	t.Run("❌ Không cho phép chèn thêm header", func(t *testing.T) {
+		dir := t.TempDir()
+		mailer := services.NewFileMailer(dir, "no-reply@dutgrad.test")
+
+		err := mailer.Send(&services.Mail{
+			To:      "victim@example.com\r\nBcc: everyone@example.com",
+			Subject: "Hello\nBcc: everyone@example.com",
+			Body:    "body",
+		})
+		assert.NoError(t, err)
+
+		files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+		assert.Len(t, files, 1)
+		content, _ := os.ReadFile(files[0])
+		headers, _, _ := strings.Cut(string(content), "\r\n\r\n")
+		assert.NotContains(t, headers, "\r\nBcc:")
+	})
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const testActiveUploadID = 7
+
+type fakeReconcileUploadRepo struct {
+	repositories.ResumableUploadRepository
+}
+
+func (r *fakeReconcileUploadRepo) GetAllIDs() ([]uint, error) {
+	return []uint{testActiveUploadID}, nil
+}
+
+func setupReconcilerService(fixture *documentServiceFixture) services.ReconcilerService {
+	return services.NewReconcilerService(
+		fixture.repo,
+		&fakeStoreVersionRepo{store: fixture.store},
+		&fakeStoreBlobRepo{store: fixture.store},
+		&fakeReconcileUploadRepo{},
+		fixture.service,
+		fixture.rag.service(),
+		fixture.storage,
+	)
+}
+
+type reconcileFixture struct {
+	*documentServiceFixture
+	notes  *entities.Document
+	slides *entities.Document
+	moved  *entities.Document
+	draft  *entities.Document
+}
+
+// setupReconcileFixture leaves the stores out of sync in every way the
+// reconciler knows about, next to state it has to leave alone.
+func setupReconcileFixture(t *testing.T) *reconcileFixture {
+	fixture := &reconcileFixture{documentServiceFixture: setupDocumentServiceFixture(t)}
+	fixture.notes = fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+	fixture.slides = fixture.upload(t, testPrivateSpaceID, "slides.txt", "week two slides")
+	fixture.moved = fixture.upload(t, testPrivateSpaceID, "exercises.txt", "week three exercises")
+	fixture.draft = fixture.upload(t, testPrivateSpaceID, "draft.txt", "unfinished draft")
+
+	// Objects nothing refers to, a recent one, chunks of a live and of a
+	// finished upload and a blob acquired before its version was recorded
+	fixture.storage.objects["stray"] = []byte("stray")
+	fixture.storage.objects["recent"] = []byte("recent")
+	fixture.storage.lastModified["recent"] = time.Now()
+	fixture.storage.objects["upload-7-chunk-0"] = []byte("chunk")
+	fixture.storage.objects["upload-8-chunk-0"] = []byte("chunk")
+	fixture.storage.objects["pending"] = []byte("pending")
+	fixture.store.blobs["pending"] = &entities.Blob{ContentHash: "pending", S3URL: fixture.storage.GetURL("pending"), RefCount: 1}
+
+	delete(fixture.storage.objects, helpers.GetS3Key(fixture.slides.S3URL))
+
+	// Moved without reindexing, lost from the index, a recent document still
+	// being indexed and an entry of a document that no longer exists
+	fixture.store.documents[fixture.moved.ID].SpaceID = testPublicSpaceID
+	fixture.store.documents[fixture.draft.ID].UpdatedAt = time.Now()
+	fixture.rag.mutex.Lock()
+	delete(fixture.rag.indexed, fixture.notes.ID)
+	delete(fixture.rag.indexed, fixture.draft.ID)
+	fixture.rag.indexed[999] = testPrivateSpaceID
+	fixture.rag.mutex.Unlock()
+
+	return fixture
+}
+
+func issueKeys(issues []dtos.ConsistencyIssue) []string {
+	keys := []string{}
+	for _, issue := range issues {
+		keys = append(keys, issue.Key)
+	}
+	return keys
+}
+
+func issueEntries(issues []dtos.ConsistencyIssue) [][2]uint {
+	entries := [][2]uint{}
+	for _, issue := range issues {
+		entries = append(entries, [2]uint{issue.DocumentID, issue.SpaceID})
+	}
+	return entries
+}
+
+func TestReconciler(t *testing.T) {
+	t.Run("✅ Phân loại tệp và mục chỉ mục mồ côi khi chạy thử", func(t *testing.T) {
+		fixture := setupReconcileFixture(t)
+
+		report, err := setupReconcilerService(fixture.documentServiceFixture).Reconcile(false)
+		assert.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{"stray", "upload-8-chunk-0"}, issueKeys(report.OrphanedObjects))
+		assert.Equal(t, []dtos.ConsistencyIssue{{
+			Key:        helpers.GetS3Key(fixture.slides.S3URL),
+			DocumentID: fixture.slides.ID,
+			Version:    1,
+		}}, report.MissingObjects)
+		assert.ElementsMatch(t, [][2]uint{
+			{fixture.moved.ID, testPrivateSpaceID},
+			{999, testPrivateSpaceID},
+		}, issueEntries(report.OrphanedIndexEntries))
+		assert.ElementsMatch(t, [][2]uint{
+			{fixture.notes.ID, testPrivateSpaceID},
+			{fixture.moved.ID, testPublicSpaceID},
+		}, issueEntries(report.MissingIndexEntries))
+
+		// A dry run changes nothing
+		for _, issue := range report.OrphanedObjects {
+			assert.False(t, issue.Repaired)
+		}
+		assert.Empty(t, fixture.storage.deleted)
+		assert.False(t, fixture.rag.isIndexed(fixture.notes.ID))
+		assert.True(t, fixture.rag.isIndexed(999))
+	})
+
+	t.Run("✅ Sửa các mục mồ côi và lập chỉ mục lại tài liệu bị thiếu", func(t *testing.T) {
+		fixture := setupReconcileFixture(t)
+
+		report, err := setupReconcilerService(fixture.documentServiceFixture).Reconcile(true)
+		assert.NoError(t, err)
+
+		for _, issues := range [][]dtos.ConsistencyIssue{report.OrphanedObjects, report.OrphanedIndexEntries, report.MissingIndexEntries} {
+			for _, issue := range issues {
+				assert.True(t, issue.Repaired)
+				assert.Empty(t, issue.Error)
+			}
+		}
+
+		assert.ElementsMatch(t, []string{"stray", "upload-8-chunk-0"}, fixture.storage.deleted)
+		for _, key := range []string{"recent", "upload-7-chunk-0", "pending"} {
+			assert.Contains(t, fixture.storage.objects, key)
+		}
+
+		fixture.rag.mutex.Lock()
+		defer fixture.rag.mutex.Unlock()
+		assert.Equal(t, map[uint]uint{
+			fixture.notes.ID:  testPrivateSpaceID,
+			fixture.slides.ID: testPrivateSpaceID,
+			fixture.moved.ID:  testPublicSpaceID,
+		}, fixture.rag.indexed)
+	})
+}
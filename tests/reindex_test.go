@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeReindexJobRepo applies the stored fields to its jobs, so the progress
+// written during a run can be checked afterwards.
+type fakeReindexJobRepo struct {
+	repositories.ReindexJobRepository
+	jobs map[uint]*entities.ReindexJob
+}
+
+func (r *fakeReindexJobRepo) GetActive(spaceID *uint, documentID *uint) (*entities.ReindexJob, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeReindexJobRepo) Create(job *entities.ReindexJob) (*entities.ReindexJob, error) {
+	job.ID = uint(len(r.jobs) + 1)
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return job, nil
+}
+
+func (r *fakeReindexJobRepo) GetById(id uint) (*entities.ReindexJob, error) {
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *fakeReindexJobRepo) UpdateFields(jobID uint, fields map[string]interface{}) error {
+	job := r.jobs[jobID]
+	for field, value := range fields {
+		switch field {
+		case "status":
+			job.Status = value.(string)
+		case "total":
+			job.Total = value.(int)
+		case "processed":
+			job.Processed = value.(int)
+		case "failed":
+			job.Failed = value.(int)
+		case "last_error":
+			job.LastError = value.(string)
+		}
+	}
+	return nil
+}
+
+func setupReindexService(fixture *documentServiceFixture) (services.ReindexService, *fakeReindexJobRepo) {
+	repo := &fakeReindexJobRepo{jobs: map[uint]*entities.ReindexJob{}}
+	return services.NewReindexService(repo, fixture.repo, fixture.service), repo
+}
+
+// dropIndex empties the RAG server, like a vector store that was wiped.
+func (f *documentServiceFixture) dropIndex() {
+	f.rag.mutex.Lock()
+	defer f.rag.mutex.Unlock()
+	f.rag.indexed = map[uint]uint{}
+}
+
+func TestReindexJob(t *testing.T) {
+	spaceID := uint(testPrivateSpaceID)
+
+	t.Run("✅ Ghi nhận tiến độ và bỏ qua tài liệu lỗi", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		service, repo := setupReindexService(fixture)
+
+		notes := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+		broken := fixture.upload(t, testPrivateSpaceID, "slides.txt", "week two slides")
+		exercises := fixture.upload(t, testPrivateSpaceID, "exercises.txt", "week three exercises")
+		other := fixture.upload(t, testPublicSpaceID, "other.txt", "another space")
+		delete(fixture.storage.objects, helpers.GetS3Key(broken.S3URL))
+		fixture.dropIndex()
+
+		job, err := service.CreateJob(&spaceID, nil, nil)
+		assert.NoError(t, err)
+
+		reported := []int{}
+		job, err = service.RunJob(job.ID, func(job *entities.ReindexJob) {
+			reported = append(reported, job.Processed)
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, []int{1, 2, 3}, reported)
+		assert.Equal(t, entities.ReindexJobCompleted, job.Status)
+		assert.Equal(t, 3, job.Total)
+		assert.Equal(t, 3, job.Processed)
+		assert.Equal(t, 1, job.Failed)
+		assert.Contains(t, job.LastError, fmt.Sprintf("document %d:", broken.ID))
+		assert.NotNil(t, job.FinishedAt)
+
+		stored := repo.jobs[job.ID]
+		assert.Equal(t, entities.ReindexJobCompleted, stored.Status)
+		assert.Equal(t, 3, stored.Processed)
+		assert.Equal(t, 1, stored.Failed)
+
+		assert.True(t, fixture.rag.isIndexed(notes.ID))
+		assert.False(t, fixture.rag.isIndexed(broken.ID))
+		assert.True(t, fixture.rag.isIndexed(exercises.ID))
+		assert.False(t, fixture.rag.isIndexed(other.ID))
+	})
+
+	t.Run("✅ Tài liệu bị xóa trong lúc chạy không tính là lỗi", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		service, _ := setupReindexService(fixture)
+
+		notes := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+		job, err := service.CreateJob(nil, &notes.ID, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, fixture.service.PurgeDocument(notes.ID))
+
+		job, err = service.RunJob(job.ID, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.ReindexJobCompleted, job.Status)
+		assert.Equal(t, 1, job.Processed)
+		assert.Equal(t, 0, job.Failed)
+	})
+
+	t.Run("❌ Công việc thất bại khi không tài liệu nào được lập chỉ mục", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		service, repo := setupReindexService(fixture)
+
+		for _, name := range []string{"notes.txt", "slides.txt"} {
+			document := fixture.upload(t, testPrivateSpaceID, name, name+" content")
+			delete(fixture.storage.objects, helpers.GetS3Key(document.S3URL))
+		}
+
+		job, err := service.CreateJob(&spaceID, nil, nil)
+		assert.NoError(t, err)
+		job, err = service.RunJob(job.ID, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, entities.ReindexJobFailed, job.Status)
+		assert.Equal(t, 2, job.Processed)
+		assert.Equal(t, 2, job.Failed)
+		assert.Equal(t, entities.ReindexJobFailed, repo.jobs[job.ID].Status)
+	})
+}
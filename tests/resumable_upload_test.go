@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResumableUploadRepo struct {
+	repositories.ResumableUploadRepository
+	uploads map[uint]*entities.ResumableUpload
+}
+
+func (r *fakeResumableUploadRepo) GetById(id uint) (*entities.ResumableUpload, error) {
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *upload
+	return &copied, nil
+}
+
+func (r *fakeResumableUploadRepo) AdvanceOffset(uploadID uint, offset int64, size int64, expiresAt time.Time) error {
+	upload := r.uploads[uploadID]
+	upload.Offset += size
+	upload.ChunkCount++
+	upload.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *fakeResumableUploadRepo) SetDocumentID(uploadID uint, documentID uint) error {
+	r.uploads[uploadID].DocumentID = &documentID
+	return nil
+}
+
+func setupResumableUploadService(userID uint) (services.ResumableUploadService, *fakeArchiveDocumentService, *fakeObjectStorage) {
+	repo := &fakeResumableUploadRepo{uploads: map[uint]*entities.ResumableUpload{
+		testActiveUploadID: {
+			ID:        testActiveUploadID,
+			SpaceID:   testJoinableSpaceID,
+			UserID:    userID,
+			Filename:  "notes.txt",
+			Length:    int64(len("lecture notes")),
+			ExpiresAt: time.Now().Add(time.Hour),
+		},
+	}}
+	spaceRepo := &fakeJoinRequestSpaceRepo{members: map[uint]uint{
+		testOwnerID:  entities.SpaceRoleOwner,
+		testEditorID: entities.SpaceRoleEditor,
+		testViewerID: entities.SpaceRoleViewer,
+	}}
+	documentService := &fakeArchiveDocumentService{}
+	storage := newFakeObjectStorage()
+
+	service := services.NewResumableUploadService(repo, nil, spaceRepo, documentService, nil, storage)
+	return service, documentService, storage
+}
+
+func TestResumableUploadCompletion(t *testing.T) {
+	t.Run("✅ Tải lên xong thì tạo tài liệu và xóa các phần đã lưu", func(t *testing.T) {
+		service, documentService, storage := setupResumableUploadService(testEditorID)
+
+		_, document, err := service.WriteChunk(testActiveUploadID, 0, strings.NewReader("lecture notes"))
+		assert.NoError(t, err)
+		assert.NotNil(t, document)
+		assert.Len(t, documentService.uploaded, 1)
+		assert.Empty(t, storage.objects)
+	})
+
+	t.Run("❌ Vai trò bị hạ xuống người xem trước khi tải lên xong", func(t *testing.T) {
+		service, documentService, storage := setupResumableUploadService(testViewerID)
+
+		_, document, err := service.WriteChunk(testActiveUploadID, 0, strings.NewReader("lecture notes"))
+		assert.ErrorContains(t, err, "not allowed to upload")
+		assert.Nil(t, document)
+		assert.Empty(t, documentService.uploaded)
+		// The chunks are kept, the upload completes once the role allows it again
+		assert.Len(t, storage.objects, 1)
+	})
+
+	t.Run("❌ Không còn là thành viên của không gian", func(t *testing.T) {
+		service, documentService, _ := setupResumableUploadService(testNonMemberID)
+
+		_, _, err := service.WriteChunk(testActiveUploadID, 0, strings.NewReader("lecture notes"))
+		assert.ErrorContains(t, err, "not allowed to upload")
+		assert.Empty(t, documentService.uploaded)
+	})
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+var testArchiveEmails = map[uint]string{
+	testOwnerID:  "owner@example.com",
+	testEditorID: "editor@example.com",
+	testTAID:     "ta@example.com",
+}
+
+type fakeArchiveSpaceRepo struct {
+	fakeJoinRequestSpaceRepo
+	added []entities.SpaceUser
+}
+
+func (r *fakeArchiveSpaceRepo) GetMembers(spaceID uint) ([]entities.SpaceUser, error) {
+	members := []entities.SpaceUser{}
+	for userID, roleID := range r.members {
+		email := testArchiveEmails[userID]
+		members = append(members, entities.SpaceUser{
+			UserID:      userID,
+			SpaceID:     spaceID,
+			SpaceRoleID: &roleID,
+			User:        entities.User{ID: userID, Email: &email},
+		})
+	}
+	return members, nil
+}
+
+func (r *fakeArchiveSpaceRepo) AddMembers(members []entities.SpaceUser) error {
+	r.added = append(r.added, members...)
+	return nil
+}
+
+type fakeArchiveUserRepo struct {
+	repositories.UserRepository
+}
+
+func (r *fakeArchiveUserRepo) GetById(id uint) (*entities.User, error) {
+	email, ok := testArchiveEmails[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &entities.User{ID: id, Email: &email}, nil
+}
+
+func (r *fakeArchiveUserRepo) GetByEmail(email string) (*entities.User, error) {
+	for id, address := range testArchiveEmails {
+		if address == email {
+			return r.GetById(id)
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+type fakeArchiveSessionRepo struct {
+	repositories.UserQuerySessionRepository
+	created []entities.UserQuerySession
+}
+
+func (r *fakeArchiveSessionRepo) GetBySpaceIDWithHistory(spaceID uint) ([]entities.UserQuerySession, error) {
+	ownerID := uint(testOwnerID)
+	email := testArchiveEmails[testOwnerID]
+	return []entities.UserQuerySession{{
+		ID:      1,
+		UserID:  &ownerID,
+		SpaceID: spaceID,
+		User:    &entities.User{ID: ownerID, Email: &email},
+		ChatHistories: []entities.ChatHistory{
+			{Message: []byte(`{"type":"human","content":"Xin chào"}`)},
+		},
+	}}, nil
+}
+
+func (r *fakeArchiveSessionRepo) Create(session *entities.UserQuerySession) (*entities.UserQuerySession, error) {
+	r.created = append(r.created, *session)
+	return session, nil
+}
+
+type fakeArchiveDocumentRepo struct {
+	repositories.DocumentRepository
+}
+
+func (r *fakeArchiveDocumentRepo) GetIDs(spaceID *uint) ([]uint, error) {
+	return []uint{}, nil
+}
+
+type fakeArchiveSpaceService struct {
+	fakeCloneSpaceService
+	roles       []entities.SpaceRole
+	invitations []entities.SpaceInvitation
+}
+
+func (s *fakeArchiveSpaceService) CreateSpace(space *entities.Space, userID uint) (*entities.Space, error) {
+	if userID == testViewerID {
+		return nil, errors.New("space limit reached: you can only create 0 spaces with your current tier")
+	}
+	return s.fakeCloneSpaceService.CreateSpace(space, userID)
+}
+
+func (s *fakeArchiveSpaceService) CreateCustomRole(spaceID, createdBy uint, name string, permission entities.SpacePermission) (*entities.SpaceRole, error) {
+	role := entities.SpaceRole{ID: uint(200 + len(s.roles)), SpaceID: &spaceID, Name: name, Permission: permission}
+	s.roles = append(s.roles, role)
+	return &role, nil
+}
+
+func (s *fakeArchiveSpaceService) CreateInvitation(invitation *entities.SpaceInvitation) (*entities.SpaceInvitation, error) {
+	s.invitations = append(s.invitations, *invitation)
+	return invitation, nil
+}
+
+type fakeArchiveDocumentService struct {
+	services.DocumentService
+	uploaded []dtos.DocumentUploadOptions
+}
+
+func (s *fakeArchiveDocumentService) UploadDocumentFile(uploadFile *helpers.UploadFile, spaceID uint, uploaderID uint, mimeType string, options dtos.DocumentUploadOptions) (*entities.Document, error) {
+	s.uploaded = append(s.uploaded, options)
+	return &entities.Document{SpaceID: spaceID, Name: uploadFile.Filename}, nil
+}
+
+type spaceArchiveFixture struct {
+	service         services.SpaceArchiveService
+	spaceRepo       *fakeArchiveSpaceRepo
+	sessionRepo     *fakeArchiveSessionRepo
+	spaceService    *fakeArchiveSpaceService
+	documentService *fakeArchiveDocumentService
+}
+
+func setupSpaceArchiveService() *spaceArchiveFixture {
+	fixture := &spaceArchiveFixture{
+		spaceRepo: &fakeArchiveSpaceRepo{fakeJoinRequestSpaceRepo: fakeJoinRequestSpaceRepo{
+			spaces: map[uint]*entities.Space{
+				testJoinableSpaceID: {ID: testJoinableSpaceID, Name: "Networks", PrivacyStatus: true, SystemPrompt: "Answer in Vietnamese", FileSizeLimitKb: 1024},
+			},
+			members: map[uint]uint{
+				testOwnerID:  entities.SpaceRoleOwner,
+				testEditorID: entities.SpaceRoleOwner,
+				testTAID:     testTARoleID,
+			},
+		}},
+		sessionRepo:     &fakeArchiveSessionRepo{},
+		spaceService:    &fakeArchiveSpaceService{},
+		documentService: &fakeArchiveDocumentService{},
+	}
+	fixture.service = services.NewSpaceArchiveService(
+		fixture.spaceRepo,
+		&fakeCloneRoleRepo{},
+		&fakeCloneFolderRepo{},
+		&fakeArchiveDocumentRepo{},
+		&fakeArchiveUserRepo{},
+		fixture.sessionRepo,
+		fixture.spaceService,
+		fixture.documentService,
+		newFakeObjectStorage(),
+	)
+	return fixture
+}
+
+func exportTestSpace(t *testing.T, fixture *spaceArchiveFixture) []byte {
+	manifest, err := fixture.service.PrepareExport(testJoinableSpaceID, dtos.SpaceExportOptions{IncludeMembers: true, IncludeSessions: true})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, fixture.service.WriteExport(manifest, &buf))
+	return buf.Bytes()
+}
+
+func buildTestArchive(t *testing.T, manifest map[string]interface{}, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	entry, _ := archive.Create("manifest.json")
+	assert.NoError(t, json.NewEncoder(entry).Encode(manifest))
+	for name, data := range files {
+		entry, _ := archive.Create(name)
+		entry.Write(data)
+	}
+	assert.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestSpaceArchive(t *testing.T) {
+	t.Run("✅ Xuất rồi nhập lại, vai trò được ánh xạ lại", func(t *testing.T) {
+		fixture := setupSpaceArchiveService()
+		data := exportTestSpace(t, fixture)
+
+		report, err := fixture.service.ImportSpace(bytes.NewReader(data), int64(len(data)), testOwnerID, dtos.SpaceImportOptions{
+			IncludeSessions: true,
+			Trusted:         true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Networks", report.Space.Name)
+		assert.Equal(t, "Answer in Vietnamese", report.Space.SystemPrompt)
+
+		assert.Len(t, fixture.spaceService.roles, 1)
+		// The importer is the owner already, the others are added directly
+		assert.Equal(t, 2, report.AddedMembers)
+		for _, member := range fixture.spaceRepo.added {
+			assert.NotEqual(t, uint(testOwnerID), member.UserID)
+			if member.UserID == testTAID {
+				assert.Equal(t, uint(200), *member.SpaceRoleID)
+			}
+		}
+
+		assert.Equal(t, 1, report.ImportedSessions)
+		assert.Equal(t, uint(testOwnerID), *fixture.sessionRepo.created[0].UserID)
+		assert.JSONEq(t, `{"type":"human","content":"Xin chào"}`, string(fixture.sessionRepo.created[0].ChatHistories[0].Message))
+	})
+
+	t.Run("✅ Nhập qua API thì mời thành viên, không chuyển quyền sở hữu", func(t *testing.T) {
+		fixture := setupSpaceArchiveService()
+		data := exportTestSpace(t, fixture)
+
+		report, err := fixture.service.ImportSpace(bytes.NewReader(data), int64(len(data)), testTAID, dtos.SpaceImportOptions{IncludeSessions: true})
+		assert.NoError(t, err)
+		assert.Empty(t, fixture.spaceRepo.added)
+		assert.Equal(t, 3, report.InvitedMembers)
+		for _, invitation := range fixture.spaceService.invitations {
+			assert.NotEqual(t, uint(entities.SpaceRoleOwner), invitation.SpaceRoleID)
+		}
+
+		// The sessions belong to the owner, not to the importer
+		assert.Equal(t, 0, report.ImportedSessions)
+	})
+
+	t.Run("✅ Tài liệu được tải lên lại và kiểm tra mã băm", func(t *testing.T) {
+		fixture := setupSpaceArchiveService()
+		content := []byte("Bài giảng mạng máy tính")
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+
+		data := buildTestArchive(t, map[string]interface{}{
+			"version": dtos.SpaceArchiveVersion,
+			"space":   map[string]interface{}{"name": "Networks", "file_size_limit_kb": 1024},
+			"folders": []map[string]interface{}{{"id": 7, "name": "Slides"}},
+			"documents": []map[string]interface{}{
+				{"name": "lecture.txt", "content_hash": hash, "file": "files/" + hash, "folder_id": 7, "tags": []string{"week-1"}},
+				{"name": "tampered.txt", "content_hash": hash, "file": "files/tampered"},
+				{"name": "missing.txt", "file": "files/missing"},
+			},
+		}, map[string][]byte{
+			"files/" + hash:  content,
+			"files/tampered": []byte("something else"),
+		})
+
+		report, err := fixture.service.ImportSpace(bytes.NewReader(data), int64(len(data)), testOwnerID, dtos.SpaceImportOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, fixture.documentService.uploaded, 1)
+		assert.Equal(t, uint(300), *fixture.documentService.uploaded[0].FolderID)
+		assert.Equal(t, []string{"week-1"}, fixture.documentService.uploaded[0].Tags)
+
+		assert.Len(t, report.SkippedDocuments, 2)
+		assert.Contains(t, report.SkippedDocuments[0].Reason, "does not match")
+		assert.Contains(t, report.SkippedDocuments[1].Reason, "missing from the archive")
+	})
+
+	t.Run("❌ Từ chối kho lưu trữ không hợp lệ", func(t *testing.T) {
+		fixture := setupSpaceArchiveService()
+
+		_, err := fixture.service.ImportSpace(bytes.NewReader([]byte("not a zip")), 9, testOwnerID, dtos.SpaceImportOptions{})
+		assert.ErrorContains(t, err, "invalid archive")
+
+		data := buildTestArchive(t, map[string]interface{}{"version": 99, "space": map[string]interface{}{"name": "Networks"}}, nil)
+		_, err = fixture.service.ImportSpace(bytes.NewReader(data), int64(len(data)), testOwnerID, dtos.SpaceImportOptions{})
+		assert.ErrorContains(t, err, "unsupported archive version 99")
+
+		data = buildTestArchive(t, map[string]interface{}{"version": dtos.SpaceArchiveVersion, "space": map[string]interface{}{"name": " "}}, nil)
+		_, err = fixture.service.ImportSpace(bytes.NewReader(data), int64(len(data)), testOwnerID, dtos.SpaceImportOptions{})
+		assert.ErrorContains(t, err, "invalid archive")
+	})
+
+	t.Run("❌ Vượt giới hạn không gian của gói", func(t *testing.T) {
+		fixture := setupSpaceArchiveService()
+		data := exportTestSpace(t, fixture)
+
+		_, err := fixture.service.ImportSpace(bytes.NewReader(data), int64(len(data)), testViewerID, dtos.SpaceImportOptions{})
+		assert.ErrorContains(t, err, "space limit reached")
+	})
+}
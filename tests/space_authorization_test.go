@@ -0,0 +1,508 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/configs"
+	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases"
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/middlewares"
+	"github.com/BlenDMinh/dutgrad-server/server"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSpaceRoleResolver struct {
+	roles  map[uint]entities.SpaceRole
+	spaces map[uint]*entities.Space
+}
+
+func (r *fakeSpaceRoleResolver) GetUserRole(userID uint, spaceID uint) (*entities.SpaceRole, error) {
+	role, ok := r.roles[userID]
+	if !ok || r.spaces[spaceID] == nil {
+		return nil, errors.New("user is not a member of this space")
+	}
+	return &role, nil
+}
+
+func (r *fakeSpaceRoleResolver) GetById(id uint) (*entities.Space, error) {
+	space, ok := r.spaces[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return space, nil
+}
+
+const (
+	testOwnerID     = 1
+	testEditorID    = 2
+	testViewerID    = 3
+	testNonMemberID = 4
+	testTAID        = 5
+
+	testPrivateSpaceID = 10
+	testPublicSpaceID  = 11
+
+	testTARoleID = 20
+)
+
+// testGuardReader stands for RequireSpaceReader in the matrices below.
+const testGuardReader = entities.SpacePermission(1 << 31)
+
+func setupSpaceRoleRouter(permission entities.SpacePermission) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	spaceID := uint(testPrivateSpaceID)
+	resolver := &fakeSpaceRoleResolver{
+		roles: map[uint]entities.SpaceRole{
+			testOwnerID:  {ID: entities.SpaceRoleOwner, Permission: entities.SpacePermissionsOwner},
+			testEditorID: {ID: entities.SpaceRoleEditor, Permission: entities.SpacePermissionsEditor},
+			testViewerID: {ID: entities.SpaceRoleViewer, Permission: entities.SpacePermissionsViewer},
+			testTAID: {
+				ID:         testTARoleID,
+				SpaceID:    &spaceID,
+				Name:       "TA",
+				Permission: entities.SpacePermissionUploadDocuments | entities.SpacePermissionViewAnalytics,
+			},
+		},
+		spaces: map[uint]*entities.Space{
+			testPrivateSpaceID: {ID: testPrivateSpaceID, PrivacyStatus: true},
+			testPublicSpaceID:  {ID: testPublicSpaceID, PrivacyStatus: false},
+		},
+	}
+
+	guard := middlewares.RequireSpacePermission(resolver, permission)
+	if permission == testGuardReader {
+		guard = middlewares.RequireSpaceReader(resolver)
+	}
+
+	r := gin.New()
+	r.GET("/spaces/:id", func(ctx *gin.Context) {
+		var userID uint
+		fmt.Sscan(ctx.GetHeader("X-User-ID"), &userID)
+		if userID != 0 {
+			ctx.Set("user_id", userID)
+		}
+	}, guard, func(ctx *gin.Context) {
+		role := controllers.GetSpaceRole(ctx)
+		if role == nil {
+			ctx.JSON(http.StatusOK, gin.H{"role": 0})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"role": role.ID})
+	})
+	return r
+}
+
+func requestSpace(r *gin.Engine, userID uint, spaceID string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/spaces/"+spaceID, nil)
+	if userID != 0 {
+		req.Header.Set("X-User-ID", fmt.Sprint(userID))
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func resultMark(allowed bool) string {
+	if allowed {
+		return "✅"
+	}
+	return "❌"
+}
+
+func TestSpaceRolePermissions(t *testing.T) {
+	t.Run("✅ Vai trò mặc định giữ quyền cũ", func(t *testing.T) {
+		owner := entities.SpaceRole{ID: entities.SpaceRoleOwner, Permission: entities.SpacePermissionsOwner}
+		editor := entities.SpaceRole{ID: entities.SpaceRoleEditor, Permission: entities.SpacePermissionsEditor}
+		viewer := entities.SpaceRole{ID: entities.SpaceRoleViewer, Permission: entities.SpacePermissionsViewer}
+
+		assert.True(t, owner.HasPermission(entities.SpacePermissionAll))
+		assert.True(t, editor.HasPermission(entities.SpacePermissionUploadDocuments|entities.SpacePermissionDeleteDocuments|entities.SpacePermissionChat))
+		assert.False(t, editor.HasPermission(entities.SpacePermissionManageMembers))
+		assert.True(t, viewer.HasPermission(entities.SpacePermissionChat))
+		assert.False(t, viewer.HasPermission(entities.SpacePermissionUploadDocuments))
+
+		// The migration stores these values for the built-in roles
+		assert.Equal(t, entities.SpacePermission(255), entities.SpacePermissionsOwner)
+		assert.Equal(t, entities.SpacePermission(44), entities.SpacePermissionsEditor)
+		assert.Equal(t, entities.SpacePermission(32), entities.SpacePermissionsViewer)
+	})
+
+	t.Run("✅ Quyền được đọc theo tên", func(t *testing.T) {
+		permission, ok := entities.ParseSpacePermissions([]string{"upload_documents", "view_analytics"})
+		assert.True(t, ok)
+		assert.Equal(t, entities.SpacePermissionUploadDocuments|entities.SpacePermissionViewAnalytics, permission)
+		assert.Equal(t, []string{"upload_documents", "view_analytics"}, permission.Names())
+
+		ta := entities.SpaceRole{Permission: permission}
+		assert.True(t, ta.HasPermission(entities.SpacePermissionUploadDocuments))
+		assert.False(t, ta.HasPermission(entities.SpacePermissionUploadDocuments|entities.SpacePermissionDeleteDocuments))
+		assert.True(t, ta.HasPermission(entities.SpacePermissionNone))
+	})
+
+	t.Run("❌ Tên quyền hoặc bit không hợp lệ", func(t *testing.T) {
+		_, ok := entities.ParseSpacePermissions([]string{"chat", "admin"})
+		assert.False(t, ok)
+		assert.False(t, entities.SpacePermission(256).IsValid())
+		assert.True(t, entities.SpacePermissionAll.IsValid())
+	})
+
+	t.Run("✅ Tài liệu dành cho biên tập viên theo quyền tải lên", func(t *testing.T) {
+		ta := &entities.SpaceRole{ID: testTARoleID, Permission: entities.SpacePermissionUploadDocuments}
+		viewer := &entities.SpaceRole{ID: entities.SpaceRoleViewer, Permission: entities.SpacePermissionsViewer}
+		assert.Contains(t, entities.VisibleDocumentVisibilities(ta), entities.DocumentVisibilityEditors)
+		assert.NotContains(t, entities.VisibleDocumentVisibilities(viewer), entities.DocumentVisibilityEditors)
+	})
+}
+
+func TestRequireSpacePermission(t *testing.T) {
+	users := map[string]uint{
+		"owner":      testOwnerID,
+		"editor":     testEditorID,
+		"viewer":     testViewerID,
+		"ta":         testTAID,
+		"non-member": testNonMemberID,
+	}
+
+	// allowed[permission][user] for the private space, members are treated
+	// the same in a public space
+	allowed := map[entities.SpacePermission]map[string]bool{
+		testGuardReader:                         {"owner": true, "editor": true, "viewer": true, "ta": true, "non-member": false},
+		middlewares.SpaceMember:                 {"owner": true, "editor": true, "viewer": true, "ta": true, "non-member": false},
+		entities.SpacePermissionUploadDocuments: {"owner": true, "editor": true, "viewer": false, "ta": true, "non-member": false},
+		entities.SpacePermissionDeleteDocuments: {"owner": true, "editor": true, "viewer": false, "ta": false, "non-member": false},
+		entities.SpacePermissionViewAnalytics:   {"owner": true, "editor": false, "viewer": false, "ta": true, "non-member": false},
+		entities.SpacePermissionManageMembers:   {"owner": true, "editor": false, "viewer": false, "ta": false, "non-member": false},
+		entities.SpacePermissionManageSettings:  {"owner": true, "editor": false, "viewer": false, "ta": false, "non-member": false},
+	}
+
+	for permission, expectations := range allowed {
+		r := setupSpaceRoleRouter(permission)
+		for name, userID := range users {
+			expected := expectations[name]
+
+			t.Run(fmt.Sprintf("%s Quyền %v, người dùng %s, không gian riêng tư", resultMark(expected), permission.Names(), name), func(t *testing.T) {
+				w := requestSpace(r, userID, fmt.Sprint(testPrivateSpaceID))
+				if expected {
+					assert.Equal(t, http.StatusOK, w.Code)
+				} else {
+					assert.Equal(t, http.StatusForbidden, w.Code)
+				}
+			})
+
+			// Only reading routes let non-members into a public space
+			expectedPublic := expected || (permission == testGuardReader && name == "non-member")
+			t.Run(fmt.Sprintf("%s Quyền %v, người dùng %s, không gian công khai", resultMark(expectedPublic), permission.Names(), name), func(t *testing.T) {
+				w := requestSpace(r, userID, fmt.Sprint(testPublicSpaceID))
+				if expectedPublic {
+					assert.Equal(t, http.StatusOK, w.Code)
+				} else {
+					assert.Equal(t, http.StatusForbidden, w.Code)
+				}
+			})
+		}
+	}
+
+	t.Run("✅ Vai trò được lưu vào context", func(t *testing.T) {
+		r := setupSpaceRoleRouter(middlewares.SpaceMember)
+		w := requestSpace(r, testTAID, fmt.Sprint(testPrivateSpaceID))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"role": %d}`, testTARoleID), w.Body.String())
+
+		r = setupSpaceRoleRouter(testGuardReader)
+		w = requestSpace(r, testNonMemberID, fmt.Sprint(testPublicSpaceID))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"role": 0}`, w.Body.String())
+	})
+
+	t.Run("❌ Không gian không tồn tại", func(t *testing.T) {
+		r := setupSpaceRoleRouter(testGuardReader)
+		w := requestSpace(r, testNonMemberID, "99")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		r = setupSpaceRoleRouter(middlewares.SpaceMember)
+		w = requestSpace(r, testOwnerID, "99")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("❌ ID không hợp lệ hoặc chưa đăng nhập", func(t *testing.T) {
+		r := setupSpaceRoleRouter(middlewares.SpaceMember)
+		w := requestSpace(r, testOwnerID, "abc")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = requestSpace(r, 0, fmt.Sprint(testPrivateSpaceID))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+const (
+	guardNone  = "none"
+	guardAdmin = "admin"
+)
+
+func spaceGuard(permission entities.SpacePermission) string {
+	if permission == testGuardReader {
+		return "space:reader"
+	}
+	return fmt.Sprintf("space:%v", permission.Names())
+}
+
+// setupGuardedRouter builds the real router with guards that report
+// themselves instead of running, so that no handler or database is reached.
+func setupGuardedRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	configs.GetEnv().AllowOrigins = []string{"http://localhost"}
+
+	reportGuard := func(guard string) gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			ctx.AbortWithStatusJSON(http.StatusOK, gin.H{"guard": guard})
+		}
+	}
+
+	return server.GetRouter(
+		&controllers.UserController{},
+		&controllers.AuthController{},
+		&controllers.OAuthController{},
+		&controllers.DocumentController{},
+		&controllers.DocumentFolderController{},
+		&controllers.ResumableUploadController{},
+		&controllers.ReindexController{},
+		&controllers.SpaceController{},
+		&controllers.SpaceInvitationController{},
+		&controllers.SpaceInvitationLinkController{},
+		&controllers.UserQuerySessionController{},
+		&controllers.UserQueryController{},
+		&controllers.SpaceApiKeyController{},
+		&controllers.TrashController{},
+		&controllers.AdminController{},
+		&controllers.SpaceOwnershipTransferController{},
+		&controllers.SpaceJoinRequestController{},
+		&controllers.SpaceTemplateController{},
+		&controllers.SpaceArchiveController{},
+		reportGuard("chat-rate-limit"),
+		reportGuard(guardAdmin),
+		reportGuard(spaceGuard(testGuardReader)),
+		func(permission entities.SpacePermission) gin.HandlerFunc {
+			return reportGuard(spaceGuard(permission))
+		},
+	)
+}
+
+func TestSpaceRoutePolicies(t *testing.T) {
+	reader := spaceGuard(testGuardReader)
+	member := spaceGuard(middlewares.SpaceMember)
+	manageMembers := spaceGuard(entities.SpacePermissionManageMembers)
+	manageSettings := spaceGuard(entities.SpacePermissionManageSettings)
+	upload := spaceGuard(entities.SpacePermissionUploadDocuments)
+	deleteDocuments := spaceGuard(entities.SpacePermissionDeleteDocuments)
+	manageAPIKeys := spaceGuard(entities.SpacePermissionManageAPIKeys)
+	export := spaceGuard(entities.SpacePermissionExport)
+
+	// Every route of a space with the guard it must have. Routes without a
+	// guard serve non-members on purpose.
+	policies := map[string]string{
+		"GET /v1/spaces/:id":                                   reader,
+		"PUT /v1/spaces/:id":                                   manageSettings,
+		"PATCH /v1/spaces/:id":                                 manageSettings,
+		"DELETE /v1/spaces/:id":                                manageSettings,
+		"GET /v1/spaces/:id/members":                           member,
+		"GET /v1/spaces/:id/members/count":                     reader,
+		"GET /v1/spaces/:id/invitations":                       manageMembers,
+		"GET /v1/spaces/:id/user-role":                         guardNone,
+		"GET /v1/spaces/:id/documents":                         reader,
+		"GET /v1/spaces/:id/search":                            reader,
+		"GET /v1/spaces/:id/tags":                              reader,
+		"GET /v1/spaces/:id/folders":                           reader,
+		"GET /v1/spaces/:id/roles":                             member,
+		"GET /v1/spaces/:id/ownership-transfers":               member,
+		"GET /v1/spaces/:id/invitation-links":                  manageMembers,
+		"GET /v1/spaces/:id/invitation-links/:linkId/uses":     manageMembers,
+		"GET /v1/spaces/:id/join-requests":                     manageMembers,
+		"GET /v1/spaces/:id/trash":                             deleteDocuments,
+		"GET /v1/spaces/:id/export":                            export,
+		"PUT /v1/spaces/:id/invitation-link":                   manageMembers,
+		"POST /v1/spaces/:id/documents/zip":                    upload,
+		"POST /v1/spaces/:id/documents/from-url":               upload,
+		"POST /v1/spaces/:id/uploads":                          upload,
+		"POST /v1/spaces/:id/reindex":                          manageSettings,
+		"POST /v1/spaces/:id/clone":                            manageSettings,
+		"POST /v1/spaces/:id/templates":                        manageSettings,
+		"POST /v1/spaces/:id/folders":                          upload,
+		"POST /v1/spaces/:id/invitation-links":                 manageMembers,
+		"POST /v1/spaces/:id/invitations":                      manageMembers,
+		"POST /v1/spaces/:id/invitations/:invitationId/resend": manageMembers,
+		"POST /v1/spaces/:id/roles":                            manageSettings,
+		"POST /v1/spaces/:id/ownership-transfers":              member,
+		"POST /v1/spaces/:id/leave":                            member,
+		"POST /v1/spaces/:id/join-public":                      guardNone,
+		"POST /v1/spaces/:id/join-requests":                    guardNone,
+		"PUT /v1/spaces/:id/join-requests/:requestId/approve":  manageMembers,
+		"PUT /v1/spaces/:id/join-requests/:requestId/reject":   manageMembers,
+		"POST /v1/spaces/:id/chat":                             guardNone,
+		"PATCH /v1/spaces/:id/folders/:folderId":               upload,
+		"PUT /v1/spaces/:id/folders/:folderId/parent":          upload,
+		"DELETE /v1/spaces/:id/folders/:folderId":              deleteDocuments,
+		"PATCH /v1/spaces/:id/members/:memberId/role":          manageMembers,
+		"PATCH /v1/spaces/:id/roles/:roleId":                   manageSettings,
+		"DELETE /v1/spaces/:id/members/:memberId":              manageMembers,
+		"DELETE /v1/spaces/:id/invitation-links/:linkId":       manageMembers,
+		"DELETE /v1/spaces/:id/invitations/:invitationId":      manageMembers,
+		"DELETE /v1/spaces/:id/roles/:roleId":                  manageSettings,
+		"GET /v1/spaces/:id/api-keys":                          manageAPIKeys,
+		"GET /v1/spaces/:id/api-keys/:keyId":                   manageAPIKeys,
+		"POST /v1/spaces/:id/api-keys":                         manageAPIKeys,
+		"DELETE /v1/spaces/:id/api-keys/:keyId":                manageAPIKeys,
+		"GET /v1/space-invitations":                            guardAdmin,
+		"PUT /v1/space-invitations/:id":                        guardAdmin,
+		"PATCH /v1/space-invitations/:id":                      guardAdmin,
+		"GET /v1/space-invitation-links":                       guardAdmin,
+		"GET /v1/space-invitation-links/:id":                   guardAdmin,
+		"POST /v1/space-invitation-links":                      guardAdmin,
+		"PUT /v1/space-invitation-links/:id":                   guardAdmin,
+		"PATCH /v1/space-invitation-links/:id":                 guardAdmin,
+		"DELETE /v1/space-invitation-links/:id":                guardAdmin,
+		"GET /v1/space-invitations/count":                      guardNone,
+		"GET /v1/space-invitations/:id":                        guardNone,
+		"PUT /v1/space-invitations/:id/accept":                 guardNone,
+		"PUT /v1/space-invitations/:id/reject":                 guardNone,
+		"DELETE /v1/space-invitations/:id":                     guardNone,
+	}
+
+	r := setupGuardedRouter()
+	token, _, err := helpers.GenerateJWTToken(testOwnerID)
+	assert.NoError(t, err)
+
+	t.Run("✅ Mọi route của không gian đều có chính sách", func(t *testing.T) {
+		for _, route := range r.Routes() {
+			if !strings.HasPrefix(route.Path, "/v1/spaces/:id") && !strings.HasPrefix(route.Path, "/v1/space-invitation") {
+				continue
+			}
+			key := route.Method + " " + route.Path
+			_, ok := policies[key]
+			assert.True(t, ok, "route %s has no policy in the test matrix", key)
+		}
+	})
+
+	for key, guard := range policies {
+		if guard == guardNone {
+			continue
+		}
+
+		t.Run("✅ "+key+" yêu cầu "+guard, func(t *testing.T) {
+			method, path, _ := strings.Cut(key, " ")
+			path = strings.NewReplacer(":id", "10", ":folderId", "20", ":memberId", "30", ":keyId", "40", ":roleId", "50", ":linkId", "60", ":invitationId", "70", ":requestId", "80").Replace(path)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+
+			var response struct {
+				Guard string `json:"guard"`
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, guard, response.Guard)
+		})
+
+		t.Run("❌ "+key+" từ chối người chưa đăng nhập", func(t *testing.T) {
+			method, path, _ := strings.Cut(key, " ")
+			path = strings.NewReplacer(":id", "10", ":folderId", "20", ":memberId", "30", ":keyId", "40", ":roleId", "50", ":linkId", "60", ":invitationId", "70", ":requestId", "80").Replace(path)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, path, nil)
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+// setupSpaceDeletionRouter serves DELETE /spaces/:id the way the real router
+// does, on a database where the TA role grants manage_settings.
+func setupSpaceDeletionRouter(t *testing.T) *gin.Engine {
+	db := setupTestDatabase(t)
+
+	spaceID := uint(testPrivateSpaceID)
+	// SQLite would return a nil list as text
+	assert.NoError(t, db.Create(&entities.Space{
+		ID:               spaceID,
+		Name:             "Networks",
+		PrivacyStatus:    true,
+		AllowedFileTypes: entities.FileTypeList{},
+	}).Error)
+	for _, role := range testSpaceRoles() {
+		if role.IsCustom() {
+			continue
+		}
+		assert.NoError(t, db.Create(role).Error)
+	}
+	assert.NoError(t, db.Create(&entities.SpaceRole{
+		ID:         testTARoleID,
+		SpaceID:    &spaceID,
+		Name:       "TA",
+		Permission: entities.SpacePermissionManageSettings | entities.SpacePermissionChat,
+	}).Error)
+
+	members := map[uint]uint{
+		testOwnerID:  entities.SpaceRoleOwner,
+		testEditorID: entities.SpaceRoleEditor,
+		testTAID:     testTARoleID,
+	}
+	for userID, roleID := range members {
+		roleID := roleID
+		assert.NoError(t, db.Create(&entities.SpaceUser{UserID: userID, SpaceID: spaceID, SpaceRoleID: &roleID}).Error)
+	}
+
+	spaceService := services.NewSpaceService(nil, newFakeRAGServer(t).service(), nil, nil, nil, nil, nil)
+	controller := controllers.NewSpaceController(spaceService, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/spaces/:id", func(ctx *gin.Context) {
+		var userID uint
+		fmt.Sscan(ctx.GetHeader("X-User-ID"), &userID)
+		ctx.Set("user_id", userID)
+	}, middlewares.RequireSpacePermission(spaceService, entities.SpacePermissionManageSettings), controller.DeleteSpace)
+	return r
+}
+
+func TestDeleteSpaceRequiresOwner(t *testing.T) {
+	expected := map[string]struct {
+		userID uint
+		status int
+	}{
+		"owner": {testOwnerID, http.StatusOK},
+		"vai trò tùy chỉnh có manage_settings": {testTAID, http.StatusForbidden},
+		"editor":     {testEditorID, http.StatusForbidden},
+		"non-member": {testNonMemberID, http.StatusForbidden},
+	}
+
+	for name, tc := range expected {
+		t.Run(fmt.Sprintf("%s Xóa không gian, người dùng %s", resultMark(tc.status == http.StatusOK), name), func(t *testing.T) {
+			r := setupSpaceDeletionRouter(t)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/spaces/%d", testPrivateSpaceID), nil)
+			req.Header.Set("X-User-ID", fmt.Sprint(tc.userID))
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+
+			var count int64
+			databases.GetDB().Model(&entities.Space{}).Where("id = ?", testPrivateSpaceID).Count(&count)
+			if tc.status == http.StatusOK {
+				assert.Equal(t, int64(0), count)
+			} else {
+				assert.Equal(t, int64(1), count)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const testClonedSpaceID = 100
+
+type fakeCloneSpaceRepo struct {
+	fakeJoinRequestSpaceRepo
+	added []entities.SpaceUser
+}
+
+func (r *fakeCloneSpaceRepo) AddMembers(members []entities.SpaceUser) error {
+	r.added = append(r.added, members...)
+	return nil
+}
+
+type fakeCloneRoleRepo struct {
+	repositories.SpaceRoleRepository
+	created []entities.SpaceRole
+}
+
+func (r *fakeCloneRoleRepo) GetBySpaceID(spaceID uint) ([]entities.SpaceRole, error) {
+	roles := []entities.SpaceRole{}
+	for _, role := range testSpaceRoles() {
+		roles = append(roles, *role)
+	}
+	return roles, nil
+}
+
+func (r *fakeCloneRoleRepo) Create(role *entities.SpaceRole) (*entities.SpaceRole, error) {
+	role.ID = uint(200 + len(r.created))
+	r.created = append(r.created, *role)
+	return role, nil
+}
+
+type fakeCloneFolderRepo struct {
+	repositories.DocumentFolderRepository
+	created []entities.DocumentFolder
+}
+
+func (r *fakeCloneFolderRepo) GetBySpaceID(spaceID uint) ([]entities.DocumentFolder, error) {
+	parentID := uint(1)
+	// The child comes first to check that parents are copied before it
+	return []entities.DocumentFolder{
+		{ID: 2, SpaceID: spaceID, ParentID: &parentID, Name: "Week 1"},
+		{ID: 1, SpaceID: spaceID, Name: "Slides"},
+	}, nil
+}
+
+func (r *fakeCloneFolderRepo) Create(folder *entities.DocumentFolder) (*entities.DocumentFolder, error) {
+	folder.ID = uint(300 + len(r.created))
+	r.created = append(r.created, *folder)
+	return folder, nil
+}
+
+type fakeCloneDocumentRepo struct {
+	repositories.DocumentRepository
+	documents map[uint]*entities.Document
+}
+
+func (r *fakeCloneDocumentRepo) GetIDs(spaceID *uint) ([]uint, error) {
+	return []uint{1, 2}, nil
+}
+
+func (r *fakeCloneDocumentRepo) GetById(id uint) (*entities.Document, error) {
+	document, ok := r.documents[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return document, nil
+}
+
+type fakeCloneAPIKeyRepo struct {
+	repositories.SpaceApiKeyRepository
+}
+
+type fakeCloneSpaceService struct {
+	services.SpaceService
+	purged      []uint
+	invitations []entities.SpaceInvitation
+}
+
+func (s *fakeCloneSpaceService) CreateInvitation(invitation *entities.SpaceInvitation) (*entities.SpaceInvitation, error) {
+	s.invitations = append(s.invitations, *invitation)
+	return invitation, nil
+}
+
+func (s *fakeCloneSpaceService) CreateSpace(space *entities.Space, userID uint) (*entities.Space, error) {
+	space.ID = testClonedSpaceID
+	return space, nil
+}
+
+func (s *fakeCloneSpaceService) PurgeSpace(id uint) error {
+	s.purged = append(s.purged, id)
+	return nil
+}
+
+type fakeCloneDocumentService struct {
+	services.DocumentService
+	added []entities.Document
+}
+
+func (s *fakeCloneDocumentService) AddStoredDocument(document *entities.Document) (*entities.Document, error) {
+	if len(s.added) >= 1 {
+		return nil, errors.New("document limit reached for this space")
+	}
+	s.added = append(s.added, *document)
+	return document, nil
+}
+
+type fakeCloneReindexService struct {
+	services.ReindexService
+	started []uint
+}
+
+func (s *fakeCloneReindexService) StartJob(spaceID *uint, documentID *uint, createdBy *uint) (*entities.ReindexJob, error) {
+	s.started = append(s.started, *spaceID)
+	return &entities.ReindexJob{ID: 1, SpaceID: spaceID}, nil
+}
+
+type fakeCloneTemplateRepo struct {
+	repositories.SpaceTemplateRepository
+	templates map[uint]*entities.SpaceTemplate
+}
+
+func (r *fakeCloneTemplateRepo) GetWithDocuments(id uint) (*entities.SpaceTemplate, error) {
+	template, ok := r.templates[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return template, nil
+}
+
+type spaceCloneFixture struct {
+	service         services.SpaceCloneService
+	spaceRepo       *fakeCloneSpaceRepo
+	roleRepo        *fakeCloneRoleRepo
+	folderRepo      *fakeCloneFolderRepo
+	spaceService    *fakeCloneSpaceService
+	documentService *fakeCloneDocumentService
+	reindexService  *fakeCloneReindexService
+}
+
+func setupSpaceCloneService() *spaceCloneFixture {
+	folderID := uint(2)
+	fixture := &spaceCloneFixture{
+		spaceRepo: &fakeCloneSpaceRepo{fakeJoinRequestSpaceRepo: fakeJoinRequestSpaceRepo{
+			spaces: map[uint]*entities.Space{
+				testJoinableSpaceID: {ID: testJoinableSpaceID, Name: "Networks", Description: "Fall", PrivacyStatus: true, DocumentLimit: 1},
+			},
+			members: map[uint]uint{
+				testOwnerID:    entities.SpaceRoleOwner,
+				testEditorID:   entities.SpaceRoleEditor,
+				testTAID:       testTARoleID,
+				testUploaderID: entities.SpaceRoleOwner,
+			},
+		}},
+		roleRepo:        &fakeCloneRoleRepo{},
+		folderRepo:      &fakeCloneFolderRepo{},
+		spaceService:    &fakeCloneSpaceService{},
+		documentService: &fakeCloneDocumentService{},
+		reindexService:  &fakeCloneReindexService{},
+	}
+	documentRepo := &fakeCloneDocumentRepo{documents: map[uint]*entities.Document{
+		1: {ID: 1, SpaceID: testJoinableSpaceID, Name: "lecture.pdf", ContentHash: "hash-1", FolderID: &folderID},
+		2: {ID: 2, SpaceID: testJoinableSpaceID, Name: "lab.pdf", ContentHash: "hash-2"},
+	}}
+	templateRepo := &fakeCloneTemplateRepo{templates: map[uint]*entities.SpaceTemplate{
+		1: {ID: 1, UserID: testOwnerID, SpaceName: "Networks", PrivacyStatus: true, Documents: []entities.SpaceTemplateDocument{
+			{Name: "syllabus.pdf", ContentHash: "hash-3"},
+		}},
+	}}
+
+	fixture.service = services.NewSpaceCloneService(
+		templateRepo,
+		fixture.spaceRepo,
+		fixture.roleRepo,
+		fixture.folderRepo,
+		documentRepo,
+		&fakeCloneAPIKeyRepo{},
+		nil,
+		fixture.spaceService,
+		fixture.documentService,
+		fixture.reindexService,
+		newFakeObjectStorage(),
+	)
+	return fixture
+}
+
+func TestSpaceClone(t *testing.T) {
+	t.Run("✅ Nhân bản không gian cùng vai trò, thành viên và tài liệu", func(t *testing.T) {
+		fixture := setupSpaceCloneService()
+
+		result, err := fixture.service.CloneSpace(testJoinableSpaceID, testOwnerID, &dtos.CloneSpaceRequest{
+			IncludeDocuments: true,
+			IncludeMembers:   true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Networks (copy)", result.Space.Name)
+		assert.Equal(t, "Fall", result.Space.Description)
+
+		// Only the custom role is copied, and invited members keep it through
+		// the copy while the second owner is invited as an editor
+		assert.Len(t, fixture.roleRepo.created, 1)
+		assert.Empty(t, fixture.spaceRepo.added)
+		assert.Len(t, fixture.spaceService.invitations, 3)
+		for _, invitation := range fixture.spaceService.invitations {
+			assert.Equal(t, uint(testClonedSpaceID), invitation.SpaceID)
+			assert.Equal(t, uint(testOwnerID), invitation.InviterID)
+			assert.NotEqual(t, uint(testOwnerID), *invitation.InvitedUserID)
+			assert.NotEqual(t, uint(entities.SpaceRoleOwner), invitation.SpaceRoleID)
+			switch *invitation.InvitedUserID {
+			case testTAID:
+				assert.Equal(t, uint(200), invitation.SpaceRoleID)
+			case testUploaderID, testEditorID:
+				assert.Equal(t, uint(entities.SpaceRoleEditor), invitation.SpaceRoleID)
+			}
+		}
+
+		assert.Len(t, fixture.folderRepo.created, 2)
+		assert.Equal(t, "Slides", fixture.folderRepo.created[0].Name)
+		assert.Equal(t, uint(300), *fixture.folderRepo.created[1].ParentID)
+
+		// The document limit of the clone is 1, the second document is skipped
+		assert.Len(t, fixture.documentService.added, 1)
+		assert.Equal(t, uint(301), *fixture.documentService.added[0].FolderID)
+		assert.Len(t, result.SkippedDocuments, 1)
+		assert.Equal(t, "lab.pdf", result.SkippedDocuments[0].Name)
+
+		assert.Equal(t, []uint{testClonedSpaceID}, fixture.reindexService.started)
+		assert.NotNil(t, result.ReindexJob)
+	})
+
+	t.Run("✅ Nhân bản không kèm tài liệu thì không lập chỉ mục", func(t *testing.T) {
+		fixture := setupSpaceCloneService()
+		name := "Networks 2026"
+
+		result, err := fixture.service.CloneSpace(testJoinableSpaceID, testOwnerID, &dtos.CloneSpaceRequest{Name: name})
+		assert.NoError(t, err)
+		assert.Equal(t, name, result.Space.Name)
+		assert.Empty(t, fixture.spaceService.invitations)
+		assert.Empty(t, fixture.documentService.added)
+		assert.Empty(t, fixture.reindexService.started)
+	})
+
+	t.Run("❌ Không đủ quyền nhân bản", func(t *testing.T) {
+		fixture := setupSpaceCloneService()
+
+		_, err := fixture.service.CloneSpace(testJoinableSpaceID, testEditorID, &dtos.CloneSpaceRequest{})
+		assert.ErrorContains(t, err, "not allowed to clone this space")
+
+		_, err = fixture.service.CloneSpace(testJoinableSpaceID, testNonMemberID, &dtos.CloneSpaceRequest{})
+		assert.ErrorContains(t, err, "not allowed to clone this space")
+	})
+
+	t.Run("✅ Tạo không gian từ mẫu của mình", func(t *testing.T) {
+		fixture := setupSpaceCloneService()
+
+		result, err := fixture.service.CreateSpaceFromTemplate(1, testOwnerID, &entities.Space{Name: "Networks 2027"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Networks 2027", result.Space.Name)
+		assert.True(t, result.Space.PrivacyStatus)
+		assert.Len(t, fixture.documentService.added, 1)
+		assert.Equal(t, uint(testClonedSpaceID), fixture.documentService.added[0].SpaceID)
+		assert.Equal(t, uint(testOwnerID), *fixture.documentService.added[0].UploadedBy)
+		assert.Equal(t, []uint{testClonedSpaceID}, fixture.reindexService.started)
+	})
+
+	t.Run("❌ Không dùng được mẫu của người khác", func(t *testing.T) {
+		fixture := setupSpaceCloneService()
+
+		_, err := fixture.service.CreateSpaceFromTemplate(1, testEditorID, nil)
+		assert.ErrorContains(t, err, "template not found")
+
+		_, err = fixture.service.CreateSpaceFromTemplate(99, testOwnerID, nil)
+		assert.ErrorContains(t, err, "template not found")
+	})
+}
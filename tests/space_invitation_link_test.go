@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitationLinkToken(t *testing.T) {
+	t.Run("✅ Token chỉ mang ID của liên kết", func(t *testing.T) {
+		token, err := helpers.GenerateInvitationLinkToken(42, nil)
+		assert.NoError(t, err)
+
+		linkID, err := helpers.VerifyInvitationLinkToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(42), linkID)
+	})
+
+	t.Run("❌ Token đã hết hạn", func(t *testing.T) {
+		expired := time.Now().Add(-time.Minute)
+		token, err := helpers.GenerateInvitationLinkToken(42, &expired)
+		assert.NoError(t, err)
+
+		_, err = helpers.VerifyInvitationLinkToken(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("❌ Token đăng nhập hoặc token cũ không có ID liên kết", func(t *testing.T) {
+		loginToken, _, err := helpers.GenerateJWTToken(1)
+		assert.NoError(t, err)
+		_, err = helpers.VerifyInvitationLinkToken(loginToken)
+		assert.Error(t, err)
+
+		legacyToken, _, err := helpers.GenerateTokenForPayload(map[string]interface{}{"space_id": 1, "space_role_id": 3}, nil)
+		assert.NoError(t, err)
+		_, err = helpers.VerifyInvitationLinkToken(legacyToken)
+		assert.Error(t, err)
+
+		linkToken, err := helpers.GenerateInvitationLinkToken(42, nil)
+		assert.NoError(t, err)
+		_, err = helpers.VerifyJWTToken(linkToken)
+		assert.Error(t, err)
+	})
+}
+
+func TestInvitationLinkUsability(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	maxUses := 2
+
+	t.Run("✅ Liên kết còn hiệu lực", func(t *testing.T) {
+		link := entities.SpaceInvitationLink{ExpiresAt: &future, MaxUses: &maxUses, UseCount: 1}
+		assert.Empty(t, link.UnusableReason(now))
+		assert.Empty(t, entities.SpaceInvitationLink{}.UnusableReason(now))
+	})
+
+	t.Run("❌ Liên kết đã thu hồi, hết hạn hoặc hết lượt", func(t *testing.T) {
+		revoked := entities.SpaceInvitationLink{RevokedAt: &past}
+		assert.Contains(t, revoked.UnusableReason(now), "revoked")
+
+		expired := entities.SpaceInvitationLink{ExpiresAt: &past}
+		assert.Contains(t, expired.UnusableReason(now), "expired")
+
+		usedUp := entities.SpaceInvitationLink{MaxUses: &maxUses, UseCount: 2}
+		assert.Contains(t, usedUp.UnusableReason(now), "maximum number of uses")
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -589,3 +590,31 @@ func TestRejectInvitation(t *testing.T) {
 	}
 	assert.True(t, found)
 }
+
+func TestSpaceInvitationExpiry(t *testing.T) {
+	userID := uint(testViewerID)
+	email := "new.student@example.com"
+	now := time.Now()
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	t.Run("✅ Lời mời qua email chưa gắn với người dùng", func(t *testing.T) {
+		invitation := entities.SpaceInvitation{InvitedEmail: &email, ExpiresAt: &future}
+		assert.True(t, invitation.IsForEmail())
+		assert.False(t, invitation.IsInvited(userID))
+		assert.False(t, invitation.IsExpired(now))
+	})
+
+	t.Run("✅ Lời mời không có hạn không bao giờ hết hạn", func(t *testing.T) {
+		invitation := entities.SpaceInvitation{InvitedUserID: &userID}
+		assert.False(t, invitation.IsForEmail())
+		assert.True(t, invitation.IsInvited(userID))
+		assert.False(t, invitation.IsExpired(now.AddDate(10, 0, 0)))
+	})
+
+	t.Run("❌ Lời mời đã hết hạn", func(t *testing.T) {
+		invitation := entities.SpaceInvitation{InvitedUserID: &userID, ExpiresAt: &past}
+		assert.True(t, invitation.IsExpired(now))
+		assert.True(t, invitation.IsExpired(past))
+	})
+}
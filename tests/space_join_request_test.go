@@ -0,0 +1,289 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const testJoinableSpaceID = 12
+
+type fakeJoinRequestSpaceRepo struct {
+	repositories.SpaceRepository
+	spaces  map[uint]*entities.Space
+	members map[uint]uint
+}
+
+func (r *fakeJoinRequestSpaceRepo) GetById(id uint) (*entities.Space, error) {
+	space, ok := r.spaces[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return space, nil
+}
+
+func (r *fakeJoinRequestSpaceRepo) IsMemberOfSpace(userID uint, spaceID uint) (bool, error) {
+	_, ok := r.members[userID]
+	return ok, nil
+}
+
+func (r *fakeJoinRequestSpaceRepo) GetUserRole(userID, spaceID uint) (*entities.SpaceRole, error) {
+	roleID, ok := r.members[userID]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return testSpaceRoles()[roleID], nil
+}
+
+func (r *fakeJoinRequestSpaceRepo) GetMembers(spaceID uint) ([]entities.SpaceUser, error) {
+	members := []entities.SpaceUser{}
+	for userID, roleID := range r.members {
+		email := "member@example.com"
+		members = append(members, entities.SpaceUser{
+			UserID:      userID,
+			SpaceID:     spaceID,
+			SpaceRoleID: &roleID,
+			SpaceRole:   *testSpaceRoles()[roleID],
+			User:        entities.User{ID: userID, Email: &email},
+		})
+	}
+	return members, nil
+}
+
+type fakeJoinRequestRoleRepo struct {
+	repositories.SpaceRoleRepository
+}
+
+func (r *fakeJoinRequestRoleRepo) GetById(id uint) (*entities.SpaceRole, error) {
+	role, ok := testSpaceRoles()[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return role, nil
+}
+
+type fakeJoinRequestUserRepo struct {
+	repositories.UserRepository
+}
+
+func (r *fakeJoinRequestUserRepo) GetById(id uint) (*entities.User, error) {
+	email := "user@example.com"
+	return &entities.User{ID: id, Username: "user", Email: &email}, nil
+}
+
+type fakeJoinRequestRepo struct {
+	repositories.SpaceJoinRequestRepository
+	requests map[uint]*entities.SpaceJoinRequest
+}
+
+func (r *fakeJoinRequestRepo) Create(request *entities.SpaceJoinRequest) (*entities.SpaceJoinRequest, error) {
+	request.ID = uint(len(r.requests) + 1)
+	request.CreatedAt = time.Now()
+	r.requests[request.ID] = request
+	return request, nil
+}
+
+func (r *fakeJoinRequestRepo) GetById(id uint) (*entities.SpaceJoinRequest, error) {
+	request, ok := r.requests[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return request, nil
+}
+
+func (r *fakeJoinRequestRepo) HasPending(spaceID, userID uint) (bool, error) {
+	for _, request := range r.requests {
+		if request.SpaceID == spaceID && request.UserID == userID && request.Status == entities.JoinRequestPending {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeJoinRequestRepo) CountPendingByUserID(userID uint) (int64, error) {
+	var count int64
+	for _, request := range r.requests {
+		if request.UserID == userID && request.Status == entities.JoinRequestPending {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeJoinRequestRepo) CountCreatedSince(userID uint, since time.Time) (int64, error) {
+	var count int64
+	for _, request := range r.requests {
+		if request.UserID == userID && !request.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeJoinRequestRepo) GetLastRejectedAt(spaceID, userID uint) (*time.Time, error) {
+	var last *time.Time
+	for _, request := range r.requests {
+		if request.SpaceID == spaceID && request.UserID == userID && request.Status == entities.JoinRequestRejected {
+			if last == nil || request.ReviewedAt.After(*last) {
+				last = request.ReviewedAt
+			}
+		}
+	}
+	return last, nil
+}
+
+func (r *fakeJoinRequestRepo) Approve(requestID, roleID, reviewedBy uint) (*entities.SpaceJoinRequest, error) {
+	request := r.requests[requestID]
+	request.Status = entities.JoinRequestApproved
+	request.SpaceRoleID = &roleID
+	request.ReviewedBy = &reviewedBy
+	return request, nil
+}
+
+func (r *fakeJoinRequestRepo) Close(requestID uint, status string, reviewedBy uint) error {
+	request := r.requests[requestID]
+	if request.Status != entities.JoinRequestPending {
+		return errors.New("join request is no longer pending")
+	}
+	now := time.Now()
+	request.Status = status
+	request.ReviewedAt = &now
+	return nil
+}
+
+type recordingMailer struct {
+	sent []services.Mail
+}
+
+func (m *recordingMailer) Name() string {
+	return "recording"
+}
+
+func (m *recordingMailer) Send(mail *services.Mail) error {
+	m.sent = append(m.sent, *mail)
+	return nil
+}
+
+func testSpaceRoles() map[uint]*entities.SpaceRole {
+	spaceID := uint(testJoinableSpaceID)
+	return map[uint]*entities.SpaceRole{
+		entities.SpaceRoleOwner:  {ID: entities.SpaceRoleOwner, Permission: entities.SpacePermissionsOwner},
+		entities.SpaceRoleEditor: {ID: entities.SpaceRoleEditor, Permission: entities.SpacePermissionsEditor},
+		entities.SpaceRoleViewer: {ID: entities.SpaceRoleViewer, Permission: entities.SpacePermissionsViewer},
+		testTARoleID:             {ID: testTARoleID, SpaceID: &spaceID, Permission: entities.SpacePermissionManageMembers | entities.SpacePermissionChat},
+	}
+}
+
+func setupJoinRequestService() (services.SpaceJoinRequestService, *fakeJoinRequestRepo, *recordingMailer) {
+	spaceRepo := &fakeJoinRequestSpaceRepo{
+		spaces: map[uint]*entities.Space{
+			testPrivateSpaceID:  {ID: testPrivateSpaceID, Name: "Private", PrivacyStatus: true},
+			testPublicSpaceID:   {ID: testPublicSpaceID, Name: "Public", PrivacyStatus: false, AllowJoinRequests: true},
+			testJoinableSpaceID: {ID: testJoinableSpaceID, Name: "Joinable", PrivacyStatus: true, AllowJoinRequests: true},
+		},
+		members: map[uint]uint{
+			testOwnerID:  entities.SpaceRoleOwner,
+			testEditorID: entities.SpaceRoleEditor,
+			testTAID:     testTARoleID,
+		},
+	}
+	repo := &fakeJoinRequestRepo{requests: map[uint]*entities.SpaceJoinRequest{}}
+	mailer := &recordingMailer{}
+	service := services.NewSpaceJoinRequestService(repo, spaceRepo, &fakeJoinRequestRoleRepo{}, &fakeJoinRequestUserRepo{}, mailer)
+	return service, repo, mailer
+}
+
+func TestSpaceJoinRequest(t *testing.T) {
+	t.Run("✅ Gửi yêu cầu và chủ sở hữu duyệt với vai trò đã chọn", func(t *testing.T) {
+		service, _, mailer := setupJoinRequestService()
+
+		request, err := service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "  Cho em tham gia với ạ  ")
+		assert.NoError(t, err)
+		assert.Equal(t, entities.JoinRequestPending, request.Status)
+		assert.Equal(t, "Cho em tham gia với ạ", request.Message)
+		// The owner and the TA may manage members, the editor may not
+		assert.Len(t, mailer.sent, 2)
+
+		approved, err := service.ApproveRequest(testJoinableSpaceID, request.ID, testOwnerID, entities.SpaceRoleEditor)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.JoinRequestApproved, approved.Status)
+		assert.Equal(t, uint(entities.SpaceRoleEditor), *approved.SpaceRoleID)
+		assert.Len(t, mailer.sent, 3)
+		assert.Contains(t, mailer.sent[2].Subject, "approved")
+	})
+
+	t.Run("✅ Duyệt không chọn vai trò thì là người xem", func(t *testing.T) {
+		service, _, _ := setupJoinRequestService()
+		request, _ := service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "")
+
+		approved, err := service.ApproveRequest(testJoinableSpaceID, request.ID, testTAID, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(entities.SpaceRoleViewer), *approved.SpaceRoleID)
+	})
+
+	t.Run("❌ Không gian không nhận yêu cầu", func(t *testing.T) {
+		service, _, _ := setupJoinRequestService()
+
+		_, err := service.SubmitRequest(testPrivateSpaceID, testNonMemberID, "")
+		assert.ErrorContains(t, err, "does not accept join requests")
+
+		_, err = service.SubmitRequest(testPublicSpaceID, testNonMemberID, "")
+		assert.ErrorContains(t, err, "space is public")
+
+		_, err = service.SubmitRequest(testJoinableSpaceID, testEditorID, "")
+		assert.ErrorContains(t, err, "already a member")
+	})
+
+	t.Run("❌ Không duyệt với vai trò vượt quyền", func(t *testing.T) {
+		service, _, _ := setupJoinRequestService()
+		request, _ := service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "")
+
+		_, err := service.ApproveRequest(testJoinableSpaceID, request.ID, testTAID, entities.SpaceRoleEditor)
+		assert.ErrorContains(t, err, "invalid role")
+
+		_, err = service.ApproveRequest(testJoinableSpaceID, request.ID, testOwnerID, entities.SpaceRoleOwner)
+		assert.ErrorContains(t, err, "invalid role")
+
+		_, err = service.ApproveRequest(testPrivateSpaceID, request.ID, testOwnerID, entities.SpaceRoleViewer)
+		assert.ErrorContains(t, err, "join request not found")
+	})
+
+	t.Run("❌ Chống spam yêu cầu", func(t *testing.T) {
+		service, _, _ := setupJoinRequestService()
+
+		request, err := service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "")
+		assert.NoError(t, err)
+		_, err = service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "")
+		assert.ErrorContains(t, err, "already pending")
+
+		assert.NoError(t, service.RejectRequest(testJoinableSpaceID, request.ID, testOwnerID))
+		_, err = service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "")
+		assert.ErrorContains(t, err, "rejected recently")
+	})
+
+	t.Run("❌ Giới hạn số yêu cầu mỗi ngày", func(t *testing.T) {
+		service, repo, _ := setupJoinRequestService()
+		for i := 0; i < 10; i++ {
+			repo.Create(&entities.SpaceJoinRequest{SpaceID: 100 + uint(i), UserID: testNonMemberID, Status: entities.JoinRequestCancelled})
+		}
+
+		_, err := service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "")
+		assert.ErrorContains(t, err, "too many join requests")
+	})
+
+	t.Run("✅ Người gửi tự hủy yêu cầu của mình", func(t *testing.T) {
+		service, repo, _ := setupJoinRequestService()
+		request, _ := service.SubmitRequest(testJoinableSpaceID, testNonMemberID, "")
+
+		assert.ErrorContains(t, service.CancelRequest(request.ID, testViewerID), "join request not found")
+		assert.NoError(t, service.CancelRequest(request.ID, testNonMemberID))
+		assert.Equal(t, entities.JoinRequestCancelled, repo.requests[request.ID].Status)
+
+		assert.ErrorContains(t, service.RejectRequest(testJoinableSpaceID, request.ID, testOwnerID), "no longer pending")
+	})
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOwnershipSpaceRepo only implements the methods the transfer service
+// uses, the embedded interface panics on anything else.
+type fakeOwnershipSpaceRepo struct {
+	repositories.SpaceRepository
+	roles map[uint]uint
+}
+
+func (r *fakeOwnershipSpaceRepo) GetUserRole(userID, spaceID uint) (*entities.SpaceRole, error) {
+	roleID, ok := r.roles[userID]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &entities.SpaceRole{ID: roleID}, nil
+}
+
+func (r *fakeOwnershipSpaceRepo) IsOwner(userID uint, spaceID uint) (bool, error) {
+	return r.roles[userID] == entities.SpaceRoleOwner, nil
+}
+
+type fakeOwnershipTransferRepo struct {
+	repositories.SpaceOwnershipTransferRepository
+	transfers map[uint]*entities.SpaceOwnershipTransfer
+	accepted  []uint
+}
+
+func (r *fakeOwnershipTransferRepo) Create(transfer *entities.SpaceOwnershipTransfer) (*entities.SpaceOwnershipTransfer, error) {
+	transfer.ID = uint(len(r.transfers) + 1)
+	r.transfers[transfer.ID] = transfer
+	return transfer, nil
+}
+
+func (r *fakeOwnershipTransferRepo) GetById(id uint) (*entities.SpaceOwnershipTransfer, error) {
+	transfer, ok := r.transfers[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return transfer, nil
+}
+
+func (r *fakeOwnershipTransferRepo) HasPending(spaceID, toUserID uint) (bool, error) {
+	for _, transfer := range r.transfers {
+		if transfer.SpaceID == spaceID && transfer.ToUserID == toUserID && transfer.Status == entities.OwnershipTransferPending {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeOwnershipTransferRepo) Accept(transferID uint) (*entities.SpaceOwnershipTransfer, error) {
+	r.accepted = append(r.accepted, transferID)
+	r.transfers[transferID].Status = entities.OwnershipTransferAccepted
+	return r.transfers[transferID], nil
+}
+
+func (r *fakeOwnershipTransferRepo) SetStatus(transferID uint, status string) error {
+	r.transfers[transferID].Status = status
+	return nil
+}
+
+func setupOwnershipTransferService() (services.SpaceOwnershipTransferService, *fakeOwnershipTransferRepo) {
+	spaceRepo := &fakeOwnershipSpaceRepo{roles: map[uint]uint{
+		testOwnerID:  entities.SpaceRoleOwner,
+		testEditorID: entities.SpaceRoleEditor,
+		testViewerID: entities.SpaceRoleViewer,
+	}}
+	transferRepo := &fakeOwnershipTransferRepo{transfers: map[uint]*entities.SpaceOwnershipTransfer{}}
+	return services.NewSpaceOwnershipTransferService(transferRepo, spaceRepo), transferRepo
+}
+
+func TestSpaceOwnershipTransfer(t *testing.T) {
+	t.Run("✅ Chủ sở hữu đề xuất và thành viên chấp nhận", func(t *testing.T) {
+		service, repo := setupOwnershipTransferService()
+
+		transfer, err := service.ProposeTransfer(testPrivateSpaceID, testOwnerID, testEditorID, true)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.OwnershipTransferPending, transfer.Status)
+		assert.True(t, transfer.KeepOwnership)
+		assert.Empty(t, repo.accepted, "nothing changes before the target accepts")
+
+		accepted, err := service.AcceptTransfer(transfer.ID, testEditorID)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.OwnershipTransferAccepted, accepted.Status)
+		assert.Equal(t, []uint{transfer.ID}, repo.accepted)
+	})
+
+	t.Run("❌ Chỉ chủ sở hữu được đề xuất", func(t *testing.T) {
+		service, _ := setupOwnershipTransferService()
+		_, err := service.ProposeTransfer(testPrivateSpaceID, testEditorID, testViewerID, false)
+		assert.ErrorContains(t, err, "only owners")
+	})
+
+	t.Run("❌ Người nhận không hợp lệ", func(t *testing.T) {
+		service, _ := setupOwnershipTransferService()
+
+		_, err := service.ProposeTransfer(testPrivateSpaceID, testOwnerID, testOwnerID, false)
+		assert.ErrorContains(t, err, "invalid target")
+
+		_, err = service.ProposeTransfer(testPrivateSpaceID, testOwnerID, testNonMemberID, false)
+		assert.ErrorContains(t, err, "not a member")
+	})
+
+	t.Run("❌ Không đề xuất trùng khi còn chờ", func(t *testing.T) {
+		service, _ := setupOwnershipTransferService()
+		_, err := service.ProposeTransfer(testPrivateSpaceID, testOwnerID, testViewerID, false)
+		assert.NoError(t, err)
+
+		_, err = service.ProposeTransfer(testPrivateSpaceID, testOwnerID, testViewerID, true)
+		assert.ErrorContains(t, err, "already pending")
+	})
+
+	t.Run("❌ Chỉ người nhận được chấp nhận hoặc từ chối", func(t *testing.T) {
+		service, repo := setupOwnershipTransferService()
+		transfer, err := service.ProposeTransfer(testPrivateSpaceID, testOwnerID, testEditorID, false)
+		assert.NoError(t, err)
+
+		_, err = service.AcceptTransfer(transfer.ID, testViewerID)
+		assert.ErrorContains(t, err, "transfer not found")
+		assert.ErrorContains(t, service.DeclineTransfer(transfer.ID, testOwnerID), "transfer not found")
+		assert.Empty(t, repo.accepted)
+
+		assert.NoError(t, service.DeclineTransfer(transfer.ID, testEditorID))
+		assert.Equal(t, entities.OwnershipTransferDeclined, repo.transfers[transfer.ID].Status)
+	})
+
+	t.Run("✅ Người đề xuất hủy đề xuất", func(t *testing.T) {
+		service, repo := setupOwnershipTransferService()
+		transfer, err := service.ProposeTransfer(testPrivateSpaceID, testOwnerID, testEditorID, false)
+		assert.NoError(t, err)
+
+		assert.ErrorContains(t, service.CancelTransfer(transfer.ID, testEditorID), "transfer not found")
+		assert.NoError(t, service.CancelTransfer(transfer.ID, testOwnerID))
+		assert.Equal(t, entities.OwnershipTransferCancelled, repo.transfers[transfer.ID].Status)
+	})
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/databases/entities"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/BlenDMinh/dutgrad-server/models/dtos"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupStorageUsageFixture runs the document service on the database
+// repositories, which keep spaces.storage_used_bytes up to date, with files
+// and the RAG server faked.
+func setupStorageUsageFixture(t *testing.T, storageLimitBytes int64) (*documentServiceFixture, *gorm.DB) {
+	db := setupTestDatabase(t)
+	assert.NoError(t, db.Create(&entities.Space{
+		ID:                testPrivateSpaceID,
+		Name:              "Networks",
+		DocumentLimit:     10,
+		FileSizeLimitKb:   1024,
+		StorageLimitBytes: storageLimitBytes,
+		AllowedFileTypes:  entities.FileTypeList{},
+	}).Error)
+	// The column is read only on the entity, the migration defaults it to 0
+	assert.NoError(t, db.Exec("UPDATE spaces SET storage_used_bytes = 0").Error)
+	ownerRoleID := uint(entities.SpaceRoleOwner)
+	assert.NoError(t, db.Create(&entities.SpaceUser{UserID: testOwnerID, SpaceID: testPrivateSpaceID, SpaceRoleID: &ownerRoleID}).Error)
+
+	store := newDocumentStore()
+	fixture := &documentServiceFixture{
+		store:   store,
+		storage: newFakeObjectStorage(),
+		rag:     newFakeRAGServer(t),
+	}
+	fixture.service = services.NewDocumentService(
+		repositories.NewDocumentRepository(),
+		repositories.NewDocumentVersionRepository(),
+		repositories.NewDocumentFolderRepository(),
+		repositories.NewBlobRepository(),
+		repositories.NewSpaceRepository(),
+		&fakeStoreUserRepo{},
+		&fakeStoreTextRepo{store: store},
+		fixture.rag.service(),
+		fixture.storage,
+		services.NewScanner(),
+	)
+	return fixture, db
+}
+
+// assertStorageUsage checks the tracked usage of the space against the
+// expected value and against the sizes of the documents outside the trash.
+func assertStorageUsage(t *testing.T, db *gorm.DB, expected int64) {
+	var space entities.Space
+	assert.NoError(t, db.First(&space, testPrivateSpaceID).Error)
+
+	var total int64
+	assert.NoError(t, db.Model(&entities.Document{}).
+		Where("space_id = ?", testPrivateSpaceID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&total).Error)
+
+	assert.Equal(t, expected, space.StorageUsedBytes)
+	assert.Equal(t, total, space.StorageUsedBytes)
+}
+
+func TestStorageUsage(t *testing.T) {
+	editorID := uint(testEditorID)
+
+	t.Run("✅ Dung lượng đã dùng luôn khớp với các tài liệu", func(t *testing.T) {
+		fixture, db := setupStorageUsageFixture(t, 0)
+
+		notes := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+		assertStorageUsage(t, db, notes.Size)
+
+		replaced := fixture.replace(t, notes.ID, "notes.txt", "week one notes, corrected and extended")
+		assertStorageUsage(t, db, replaced.Size)
+
+		slides := fixture.upload(t, testPrivateSpaceID, "slides.txt", "week two slides")
+		assertStorageUsage(t, db, replaced.Size+slides.Size)
+
+		assert.NoError(t, fixture.service.DeleteDocument(notes.ID, &editorID))
+		assertStorageUsage(t, db, slides.Size)
+
+		_, err := fixture.service.RestoreDocument(notes.ID)
+		assert.NoError(t, err)
+		assertStorageUsage(t, db, replaced.Size+slides.Size)
+
+		// Purging from the trash does not count the document a second time
+		assert.NoError(t, fixture.service.DeleteDocument(notes.ID, &editorID))
+		assert.NoError(t, fixture.service.PurgeDocument(notes.ID))
+		assertStorageUsage(t, db, slides.Size)
+
+		assert.NoError(t, fixture.service.PurgeDocument(slides.ID))
+		assertStorageUsage(t, db, 0)
+	})
+
+	t.Run("❌ Từ chối tải lên vượt quá hạn mức", func(t *testing.T) {
+		fixture, db := setupStorageUsageFixture(t, 20)
+
+		notes := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+
+		_, err := fixture.service.UploadDocumentFile(helpers.NewUploadFileFromBytes("slides.txt", []byte("week two slides")), testPrivateSpaceID, testEditorID, "", dtos.DocumentUploadOptions{})
+		assert.ErrorContains(t, err, "storage quota exceeded")
+		assertStorageUsage(t, db, notes.Size)
+		assert.Len(t, fixture.storage.objects, 1)
+	})
+
+	t.Run("❌ Từ chối thay tệp vượt quá hạn mức", func(t *testing.T) {
+		fixture, db := setupStorageUsageFixture(t, 20)
+
+		notes := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+
+		_, err := fixture.service.ReplaceDocumentFile(notes.ID, helpers.NewUploadFileFromBytes("notes.txt", []byte("week one notes, corrected")), testEditorID, "")
+		assert.ErrorContains(t, err, "storage quota exceeded")
+		assertStorageUsage(t, db, notes.Size)
+
+		current, err := fixture.service.GetById(notes.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, current.CurrentVersion)
+
+		// The blob stored for the rejected version is released again
+		var blobs int64
+		assert.NoError(t, db.Model(&entities.Blob{}).Count(&blobs).Error)
+		assert.Equal(t, int64(1), blobs)
+		assert.Len(t, fixture.storage.objects, 1)
+		assert.True(t, fixture.rag.isIndexed(notes.ID))
+	})
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BlenDMinh/dutgrad-server/controllers"
+	"github.com/BlenDMinh/dutgrad-server/databases/repositories"
+	"github.com/BlenDMinh/dutgrad-server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type fakeTrashSessionRepo struct {
+	repositories.UserQuerySessionRepository
+}
+
+func (r *fakeTrashSessionRepo) GetIDsDeletedBefore(cutoff time.Time) ([]uint, error) {
+	return []uint{}, nil
+}
+
+func setupTrashService(fixture *documentServiceFixture) services.TrashService {
+	return services.NewTrashService(
+		fixture.repo,
+		&fakeStoreSpaceRepo{store: fixture.store},
+		&fakeTrashSessionRepo{},
+		fixture.service,
+		&fakeUpdateSpaceService{},
+		nil,
+		nil,
+	)
+}
+
+func setupTrashRouter(service services.TrashService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	controller := controllers.NewTrashController(service)
+
+	trash := r.Group("/trash", func(ctx *gin.Context) {
+		ctx.Set("user_id", uint(testEditorID))
+	})
+	trash.POST("/documents/:id/restore", controller.RestoreDocument)
+	return r
+}
+
+func restoreDocument(router *gin.Engine, documentID uint) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/trash/documents/%d/restore", documentID), nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDocumentTrash(t *testing.T) {
+	editorID := uint(testEditorID)
+
+	t.Run("✅ Xóa mềm đưa tài liệu vào thùng rác và giữ tệp", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		document := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+
+		assert.NoError(t, fixture.service.DeleteDocument(document.ID, &editorID))
+
+		_, err := fixture.service.GetById(document.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		trash, err := setupTrashService(fixture).GetSpaceTrash(testPrivateSpaceID)
+		assert.NoError(t, err)
+		assert.Len(t, trash, 1)
+		assert.Equal(t, editorID, *trash[0].DeletedBy)
+
+		assert.False(t, fixture.rag.isIndexed(document.ID))
+		assert.Contains(t, fixture.storage.objects, document.ContentHash)
+		assert.Equal(t, int64(0), fixture.store.spaces[testPrivateSpaceID].StorageUsedBytes)
+	})
+
+	t.Run("✅ Khôi phục tài liệu và lập chỉ mục lại", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		router := setupTrashRouter(setupTrashService(fixture))
+		document := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+		assert.NoError(t, fixture.service.DeleteDocument(document.ID, &editorID))
+
+		w := restoreDocument(router, document.ID)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		restored, err := fixture.service.GetById(document.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, restored.DeletedBy)
+		assert.True(t, fixture.rag.isIndexed(document.ID))
+		assert.Equal(t, document.Size, fixture.store.spaces[testPrivateSpaceID].StorageUsedBytes)
+	})
+
+	t.Run("❌ Không khôi phục khi đã có tài liệu mới cùng nội dung", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		router := setupTrashRouter(setupTrashService(fixture))
+		document := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+		assert.NoError(t, fixture.service.DeleteDocument(document.ID, &editorID))
+		newer := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+
+		w := restoreDocument(router, document.ID)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var response struct {
+			Data struct {
+				ExistingDocumentID uint `json:"existing_document_id"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, newer.ID, response.Data.ExistingDocumentID)
+
+		assert.True(t, fixture.store.documents[document.ID].DeletedAt.Valid)
+		assert.Equal(t, newer.Size, fixture.store.spaces[testPrivateSpaceID].StorageUsedBytes)
+	})
+
+	t.Run("✅ Dọn thùng rác giải phóng tệp của tài liệu đã hết hạn", func(t *testing.T) {
+		fixture := setupDocumentServiceFixture(t)
+		expired := fixture.upload(t, testPrivateSpaceID, "notes.txt", "week one notes")
+		replaced := fixture.replace(t, expired.ID, "notes.txt", "week one notes, corrected")
+		recent := fixture.upload(t, testPrivateSpaceID, "slides.txt", "week two slides")
+		assert.NoError(t, fixture.service.DeleteDocument(expired.ID, &editorID))
+		assert.NoError(t, fixture.service.DeleteDocument(recent.ID, &editorID))
+		fixture.store.documents[expired.ID].DeletedAt.Time = time.Now().AddDate(0, 0, -31)
+
+		setupTrashService(fixture).PurgeExpired()
+
+		assert.NotContains(t, fixture.store.documents, expired.ID)
+		assert.NotContains(t, fixture.store.blobs, expired.ContentHash)
+		assert.NotContains(t, fixture.store.blobs, replaced.ContentHash)
+		assert.ElementsMatch(t, []string{expired.ContentHash, replaced.ContentHash}, fixture.storage.deleted)
+
+		assert.True(t, fixture.store.documents[recent.ID].DeletedAt.Valid)
+		assert.Contains(t, fixture.storage.objects, recent.ContentHash)
+		assert.Equal(t, int64(0), fixture.store.spaces[testPrivateSpaceID].StorageUsedBytes)
+	})
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	t.Run("✅ Giải mã siêu dữ liệu tải lên", func(t *testing.T) {
+		metadata, err := helpers.ParseTusMetadata("filename Z2lhaS10aWNoLnBkZg==, filetype YXBwbGljYXRpb24vcGRm,replace")
+		assert.NoError(t, err)
+		assert.Equal(t, "giai-tich.pdf", metadata["filename"])
+		assert.Equal(t, "application/pdf", metadata["filetype"])
+		assert.Contains(t, metadata, "replace")
+		assert.Empty(t, metadata["replace"])
+	})
+
+	t.Run("✅ Tiêu đề trống", func(t *testing.T) {
+		metadata, err := helpers.ParseTusMetadata("")
+		assert.NoError(t, err)
+		assert.Empty(t, metadata)
+	})
+
+	t.Run("❌ Giá trị không phải base64", func(t *testing.T) {
+		_, err := helpers.ParseTusMetadata("filename not-base64!")
+		assert.Error(t, err)
+	})
+
+	t.Run("❌ Khóa bị trùng", func(t *testing.T) {
+		_, err := helpers.ParseTusMetadata("filename YQ==,filename Yg==")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func rejectionCodes(rejections []helpers.FileRejection) []string {
+	codes := []string{}
+	for _, rejection := range rejections {
+		codes = append(codes, rejection.Code)
+	}
+	return codes
+}
+
+func TestValidateFileContent(t *testing.T) {
+	strict := helpers.FileValidationOptions{RejectEncryptedPDFs: true, RejectMacroDocuments: true}
+
+	t.Run("✅ Chấp nhận tệp hợp lệ", func(t *testing.T) {
+		assert.Empty(t, helpers.ValidateFileContent("calculus.pdf", createTestPDF(t), strict))
+		assert.Empty(t, helpers.ValidateFileContent("notes.md", []byte("# Ghi chú\n\n"+vietnameseSample), strict))
+
+		docx := createTestZip(t, []zipTestFile{{Name: "word/document.xml", Content: []byte("<w:document/>")}})
+		assert.Empty(t, helpers.ValidateFileContent("syllabus.docx", docx, strict))
+
+		// Extensions without a known signature are only checked against the allowlist
+		assert.Empty(t, helpers.ValidateFileContent("model.bin", []byte{0x00, 0x01}, strict))
+	})
+
+	t.Run("❌ Nội dung không khớp với phần mở rộng", func(t *testing.T) {
+		rejections := helpers.ValidateFileContent("report.pdf", []byte("MZ\x90\x00 not a pdf"), strict)
+		assert.Equal(t, []string{helpers.RejectionTypeMismatch}, rejectionCodes(rejections))
+
+		rejections = helpers.ValidateFileContent("notes.txt", []byte("text\x00with nul"), strict)
+		assert.Equal(t, []string{helpers.RejectionTypeMismatch}, rejectionCodes(rejections))
+	})
+
+	t.Run("❌ Loại tệp không nằm trong danh sách cho phép", func(t *testing.T) {
+		options := helpers.FileValidationOptions{AllowedTypes: []string{".PDF", "docx"}}
+		assert.Empty(t, helpers.ValidateFileContent("calculus.pdf", createTestPDF(t), options))
+
+		rejections := helpers.ValidateFileContent("notes.md", []byte("# Notes"), options)
+		assert.Equal(t, []string{helpers.RejectionTypeNotAllowed}, rejectionCodes(rejections))
+	})
+
+	t.Run("❌ PDF mã hóa và tệp Office có macro", func(t *testing.T) {
+		encrypted := []byte("%PDF-1.7\ntrailer << /Root 1 0 R /Encrypt 5 0 R >>\n%%EOF")
+		rejections := helpers.ValidateFileContent("secret.pdf", encrypted, strict)
+		assert.Equal(t, []string{helpers.RejectionEncryptedPDF}, rejectionCodes(rejections))
+		assert.Empty(t, helpers.ValidateFileContent("secret.pdf", encrypted, helpers.FileValidationOptions{}))
+
+		macro := createTestZip(t, []zipTestFile{
+			{Name: "word/document.xml", Content: []byte("<w:document/>")},
+			{Name: "word/vbaProject.bin", Content: []byte("vba")},
+		})
+		rejections = helpers.ValidateFileContent("syllabus.docx", macro, strict)
+		assert.Equal(t, []string{helpers.RejectionMacroEnabled}, rejectionCodes(rejections))
+
+		docm := createTestZip(t, []zipTestFile{{Name: "word/document.xml", Content: []byte("<w:document/>")}})
+		rejections = helpers.ValidateFileContent("syllabus.docm", docm, strict)
+		assert.Equal(t, []string{helpers.RejectionMacroEnabled}, rejectionCodes(rejections))
+	})
+}
+
+func TestDeclaredMimeTypeMatches(t *testing.T) {
+	t.Run("✅ Loại MIME thuộc về phần mở rộng", func(t *testing.T) {
+		assert.True(t, helpers.DeclaredMimeTypeMatches("notes.md", "text/markdown"))
+		assert.True(t, helpers.DeclaredMimeTypeMatches("scores.csv", "text/csv; charset=utf-8"))
+	})
+
+	t.Run("❌ Loại MIME do client khai báo không khớp", func(t *testing.T) {
+		assert.False(t, helpers.DeclaredMimeTypeMatches("report.pdf", "text/html"))
+		assert.False(t, helpers.DeclaredMimeTypeMatches("model.bin", "application/pdf"))
+		assert.False(t, helpers.DeclaredMimeTypeMatches("report.pdf", "not a mime type"))
+	})
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/BlenDMinh/dutgrad-server/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type zipTestFile struct {
+	Name    string
+	Content []byte
+}
+
+func createTestZip(t *testing.T, files []zipTestFile) []byte {
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+	for _, file := range files {
+		w, err := writer.Create(file.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(file.Content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readTestZip(t *testing.T, data []byte, limits helpers.ZipLimits) (map[string]helpers.ZipEntry, error) {
+	entries := map[string]helpers.ZipEntry{}
+	err := helpers.ReadZipEntries(bytes.NewReader(data), int64(len(data)), limits, func(entry helpers.ZipEntry) {
+		entries[entry.Path] = entry
+	})
+	return entries, err
+}
+
+var defaultTestZipLimits = helpers.ZipLimits{
+	MaxEntries:          10,
+	MaxEntrySize:        1024,
+	MaxTotalSize:        4096,
+	MaxCompressionRatio: 100,
+}
+
+func TestReadZipEntries(t *testing.T) {
+	t.Run("✅ Giải nén các tệp hợp lệ", func(t *testing.T) {
+		data := createTestZip(t, []zipTestFile{
+			{Name: "lectures/week1.txt", Content: []byte("Week 1 notes")},
+			{Name: "syllabus.md", Content: []byte("# Syllabus")},
+		})
+
+		entries, err := readTestZip(t, data, defaultTestZipLimits)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "week1.txt", entries["lectures/week1.txt"].Name)
+		assert.Equal(t, "Week 1 notes", string(entries["lectures/week1.txt"].Data))
+		assert.Empty(t, entries["syllabus.md"].Reason)
+	})
+
+	t.Run("❌ Chặn zip-slip", func(t *testing.T) {
+		data := createTestZip(t, []zipTestFile{
+			{Name: "../../etc/passwd", Content: []byte("root")},
+			{Name: "/absolute.txt", Content: []byte("abs")},
+		})
+
+		entries, err := readTestZip(t, data, defaultTestZipLimits)
+		assert.NoError(t, err)
+		assert.Equal(t, "path traversal is not allowed", entries["../../etc/passwd"].Reason)
+		assert.Equal(t, "absolute paths are not allowed", entries["/absolute.txt"].Reason)
+		assert.Nil(t, entries["../../etc/passwd"].Data)
+	})
+
+	t.Run("❌ Chặn tệp nén lồng nhau", func(t *testing.T) {
+		inner := createTestZip(t, []zipTestFile{{Name: "inner.txt", Content: []byte("inner")}})
+		data := createTestZip(t, []zipTestFile{
+			{Name: "nested.zip", Content: inner},
+			{Name: "disguised.pdf", Content: inner},
+		})
+
+		entries, err := readTestZip(t, data, defaultTestZipLimits)
+		assert.NoError(t, err)
+		assert.Equal(t, "nested archives are not allowed", entries["nested.zip"].Reason)
+		assert.Equal(t, "nested archives are not allowed", entries["disguised.pdf"].Reason)
+	})
+
+	t.Run("❌ Chặn zip bomb", func(t *testing.T) {
+		data := createTestZip(t, []zipTestFile{
+			{Name: "bomb.txt", Content: []byte(strings.Repeat("A", 1000000))},
+		})
+
+		limits := defaultTestZipLimits
+		limits.MaxEntrySize = 2 * 1024 * 1024
+		entries, err := readTestZip(t, data, limits)
+		assert.NoError(t, err)
+		assert.Equal(t, "compression ratio is suspiciously high", entries["bomb.txt"].Reason)
+	})
+
+	t.Run("❌ Tệp vượt quá kích thước cho phép", func(t *testing.T) {
+		data := createTestZip(t, []zipTestFile{
+			{Name: "large.txt", Content: bytes.Repeat([]byte("0123456789"), 200)},
+		})
+
+		entries, err := readTestZip(t, data, defaultTestZipLimits)
+		assert.NoError(t, err)
+		assert.Equal(t, "file exceeds the maximum allowed size", entries["large.txt"].Reason)
+	})
+
+	t.Run("❌ Quá nhiều tệp trong archive", func(t *testing.T) {
+		files := []zipTestFile{}
+		for i := 0; i < 11; i++ {
+			files = append(files, zipTestFile{Name: strings.Repeat("a", i+1) + ".txt", Content: []byte("x")})
+		}
+
+		_, err := readTestZip(t, createTestZip(t, files), defaultTestZipLimits)
+		assert.Error(t, err)
+	})
+
+	t.Run("❌ Bỏ qua tệp hệ thống", func(t *testing.T) {
+		data := createTestZip(t, []zipTestFile{
+			{Name: "__MACOSX/._notes.txt", Content: []byte("meta")},
+			{Name: ".DS_Store", Content: []byte("meta")},
+		})
+
+		entries, err := readTestZip(t, data, defaultTestZipLimits)
+		assert.NoError(t, err)
+		assert.Equal(t, "hidden or system file", entries["__MACOSX/._notes.txt"].Reason)
+		assert.Equal(t, "hidden or system file", entries[".DS_Store"].Reason)
+	})
+}